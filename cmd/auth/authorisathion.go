@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/api"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/controller"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/handler"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/monitoring"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/utils"
 	"github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/labstack/echo/v4"
 	"github.com/prometheus/client_golang/prometheus"
@@ -58,6 +62,7 @@ func main() {
 		fmt.Printf("fatal error config file: %s \n", err)
 		os.Exit(1)
 	}
+	utils.SecondsToDurations(auth_constants.DurationConfigKeys...)
 	// -------------------- Set up logging -------------------- //
 
 	log := logrus.New()
@@ -81,6 +86,16 @@ func main() {
 
 	log.Infof("log level: %s", log.Level.String())
 
+	// -------------------- Set up token keys -------------------- //
+
+	ephemeral, err := auth_utils.InitKeyRing()
+	if err != nil {
+		log.Fatalf("failed to load token keys: %s", err)
+	}
+	if ephemeral {
+		log.Warn("tokens are signed with an ephemeral key, they stop being accepted after a restart")
+	}
+
 	// -------------------- Set database -------------------- //
 
 	clientOptions := options.Client().ApplyURI(viper.GetString("db.connection_string"))
@@ -104,10 +119,12 @@ func main() {
 	s.Binder = api.NewBinder()
 
 	s.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+	auth_monitoring.RegisterMonitoring(prometheus.DefaultRegisterer)
+	s.GET(auth_constants.JWKSPath, controller.JWKS)
 
 	server := grpc.NewServer(
 		grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor),
-		grpc.UnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),
+		grpc.ChainUnaryInterceptor(grpc_prometheus.UnaryServerInterceptor, controller.RequestIDInterceptor(logrus.NewEntry(log))),
 		grpc.KeepaliveParams(keepalive.ServerParameters{MaxConnectionIdle: 5 * time.Minute}),
 	)
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/utils"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -35,6 +36,7 @@ func main() {
 		fmt.Printf("fatal error config file: %s \n", err)
 		os.Exit(1)
 	}
+	utils.SecondsToDurations(constants.DurationConfigKeys...)
 
 	viper.SetDefault("service.bind.address", defaultAddress)
 	viper.SetDefault("service.bind.port", defaultPort)
@@ -0,0 +1,50 @@
+// Command migrate_messages moves the chat messages embedded into the dialogs to the messages collection.
+// It is safe to run it again if it is interrupted, and it does nothing when there is nothing to move.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/db"
+)
+
+const configPathEnvVar = "CONFIG_PATH"
+
+func main() {
+	viper.AutomaticEnv()
+
+	viper.SetConfigFile(viper.GetString(configPathEnvVar))
+	if err := viper.ReadInConfig(); err != nil {
+		fmt.Printf("fatal error config file: %s \n", err)
+		os.Exit(1)
+	}
+
+	log := logrus.New()
+	log.SetFormatter(&logrus.JSONFormatter{TimestampFormat: time.RFC3339})
+
+	ctx := context.Background()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(viper.GetString("db.connection_string")))
+	if err != nil {
+		log.Fatalf("failed to establish mongo db connection: %s", err)
+	}
+	defer client.Disconnect(ctx)
+
+	if err := client.Ping(ctx, nil); err != nil {
+		log.Fatalf("failed to check (ping) mongo db connection: %s", err)
+	}
+
+	moved, err := db.MigrateEmbeddedMessages(ctx, client.Database(viper.GetString("db.database")))
+	if err != nil {
+		log.Fatalf("failed to migrate messages (%d moved): %s", moved, err)
+	}
+	log.Infof("%d messages are moved", moved)
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/spf13/viper"
+)
+
+// runAccountPurger periodically purges the accounts whose deletion grace period is over.
+func (svc *APIService) runAccountPurger(ctx context.Context) {
+	interval := viper.GetDuration(constants.ViperAccountPurgeIntervalKey)
+	if interval <= 0 {
+		interval = constants.DefaultAccountPurgeInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			svc.purgeDueAccounts(ctx)
+		}
+	}
+}
+
+// purgeDueAccounts claims the due accounts in the auth service, purges their data here and then
+// lets the auth service remove the credentials. Accounts that fail are claimed again next time.
+func (svc *APIService) purgeDueAccounts(ctx context.Context) {
+	userIDs, err := svc.auth.ClaimAccountDeletions(ctx, constants.AccountPurgeBatch)
+	if err != nil {
+		svc.log.Errorf("ClaimAccountDeletions error: %s", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		if err := svc.registry.AccountService.PurgeAccount(ctx, userID); err != nil {
+			svc.log.Errorf("PurgeAccount of %s error: %s", userID, err)
+			continue
+		}
+		if err := svc.auth.PurgeAccount(ctx, userID); err != nil {
+			svc.log.Errorf("PurgeAccount of %s credentials error: %s", userID, err)
+		}
+	}
+}
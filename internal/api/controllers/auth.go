@@ -1,7 +1,7 @@
 package controllers
 
 import (
-	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/cl"
@@ -28,17 +28,17 @@ func (c *AuthController) SignupUser(ctx echo.Context) error {
 		return err
 	}
 
-	token, userID, err := c.rep.SignUp(request.Email, request.Password)
+	tokens, err := c.rep.SignUp(ctx.Request().Context(), request.Email, request.Password, cl.NewClientInfo(ctx.Request()))
 	if err != nil {
 		return err
 	}
 
-	response, err := c.registry.AuthService.SignupUser(context.Background(), request, userID, token)
+	response, err := c.registry.AuthService.SignupUser(ctx.Request().Context(), request, tokens.UserID, tokens.AuthToken)
 	if err != nil {
 		return err
 	}
 
-	ctx.SetCookie(utils.CreateHTTPOnlyCookie(constants.CookieKeyAuthToken, token, viper.GetInt64(constants.ViperJWTTTLKey)))
+	setSessionCookies(ctx, tokens)
 	ctx.SetCookie(utils.CreateCookie(constants.CookieKeyCSRFToken, response.CSRFToken, viper.GetInt64(constants.ViperCSRFTTLKey)))
 
 	return ctx.JSON(http.StatusOK, response)
@@ -51,27 +51,328 @@ func (c *AuthController) LoginUser(ctx echo.Context) error {
 		return err
 	}
 
-	token, userID, err := c.rep.Login(request.Email, request.Password)
+	tokens, err := c.rep.Login(ctx.Request().Context(), request.Email, request.Password, cl.NewClientInfo(ctx.Request()))
 	if err != nil {
 		return err
 	}
-	response, err := c.registry.AuthService.LoginUser(context.Background(), userID, token)
+	if len(tokens.TwoFactorTicket) != 0 {
+		return ctx.JSON(http.StatusOK, &dto.TwoFactorRequiredResponse{TwoFactorRequired: true, Ticket: tokens.TwoFactorTicket})
+	}
+
+	response, err := c.registry.AuthService.LoginUser(ctx.Request().Context(), tokens.UserID, tokens.AuthToken)
 	if err != nil {
 		return err
 	}
 
-	ctx.SetCookie(utils.CreateHTTPOnlyCookie(constants.CookieKeyAuthToken, token, viper.GetInt64(constants.ViperJWTTTLKey)))
+	setSessionCookies(ctx, tokens)
 	ctx.SetCookie(utils.CreateCookie(constants.CookieKeyCSRFToken, response.CSRFToken, viper.GetInt64(constants.ViperCSRFTTLKey)))
 
 	return ctx.JSON(http.StatusOK, response)
 }
 
+func (c *AuthController) RefreshSession(ctx echo.Context) error {
+	cookieRefresh, err := ctx.Cookie(constants.CookieKeyRefreshToken)
+	if err != nil || len(cookieRefresh.Value) == 0 {
+		return constants.ErrMissingRefreshCookie
+	}
+
+	tokens, err := c.rep.Refresh(ctx.Request().Context(), cookieRefresh.Value, cl.NewClientInfo(ctx.Request()))
+	if err != nil {
+		return err
+	}
+
+	csrfToken, err := utils.GenerateCSRFToken(tokens.UserID)
+	if err != nil {
+		return fmt.Errorf("GenerateCSRFToken: %w", err)
+	}
+
+	setSessionCookies(ctx, tokens)
+	ctx.SetCookie(utils.CreateCookie(constants.CookieKeyCSRFToken, csrfToken, viper.GetInt64(constants.ViperCSRFTTLKey)))
+
+	return ctx.JSON(http.StatusOK, &dto.RefreshSessionResponse{AuthToken: tokens.AuthToken, CSRFToken: csrfToken})
+}
+
 func (c *AuthController) LogoutUser(ctx echo.Context) error {
+	var authToken, refreshToken string
+	if cookie, err := ctx.Cookie(constants.CookieKeyAuthToken); err == nil {
+		authToken = cookie.Value
+	}
+	if cookie, err := ctx.Cookie(constants.CookieKeyRefreshToken); err == nil {
+		refreshToken = cookie.Value
+	}
+
+	if len(authToken) != 0 || len(refreshToken) != 0 {
+		if err := c.rep.Logout(ctx.Request().Context(), authToken, refreshToken); err != nil {
+			c.log.Errorf("Logout error: %s", err)
+		}
+	}
+
 	ctx.SetCookie(utils.CreateHTTPOnlyCookie(constants.CookieKeyAuthToken, "", 0))
+	ctx.SetCookie(utils.CreateHTTPOnlyCookie(constants.CookieKeyRefreshToken, "", 0))
 	ctx.SetCookie(utils.CreateCookie(constants.CookieKeyCSRFToken, "", 0))
 	return ctx.JSON(http.StatusOK, &dto.BasicResponse{})
 }
 
+func (c *AuthController) GetSessions(ctx echo.Context) error {
+	request := new(dto.GetSessionsRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	sessions, err := c.rep.ListSessions(ctx.Request().Context(), request.UserID)
+	if err != nil {
+		return err
+	}
+
+	response := &dto.GetSessionsResponse{Sessions: make([]dto.Session, 0, len(sessions))}
+	for _, session := range sessions {
+		response.Sessions = append(response.Sessions, dto.Session{
+			ID:         session.ID,
+			Device:     session.Device,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID == request.SessionID,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *AuthController) RevokeSession(ctx echo.Context) error {
+	request := new(dto.RevokeSessionRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	if err := c.rep.RevokeSession(ctx.Request().Context(), request.UserID, request.SessionID); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, &dto.RevokeSessionResponse{})
+}
+
+func (c *AuthController) CreateAPIToken(ctx echo.Context) error {
+	request := new(dto.CreateAPITokenRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	token, apiToken, err := c.rep.CreateAPIToken(ctx.Request().Context(), request.UserID, request.Name, request.Scopes, request.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, &dto.CreateAPITokenResponse{Token: token, APIToken: convertAPIToken(apiToken)})
+}
+
+func (c *AuthController) GetAPITokens(ctx echo.Context) error {
+	request := new(dto.GetAPITokensRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	tokens, err := c.rep.ListAPITokens(ctx.Request().Context(), request.UserID)
+	if err != nil {
+		return err
+	}
+
+	response := &dto.GetAPITokensResponse{Tokens: make([]dto.APIToken, 0, len(tokens))}
+	for i := range tokens {
+		response.Tokens = append(response.Tokens, convertAPIToken(&tokens[i]))
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *AuthController) RevokeAPIToken(ctx echo.Context) error {
+	request := new(dto.RevokeAPITokenRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	if err := c.rep.RevokeAPIToken(ctx.Request().Context(), request.UserID, request.TokenID); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, &dto.RevokeAPITokenResponse{})
+}
+
+func (c *AuthController) ChangePassword(ctx echo.Context) error {
+	request := new(dto.ChangePasswordRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	if err := c.rep.ChangePassword(ctx.Request().Context(), request.UserID, request.SessionID, request.OldPassword, request.NewPassword, request.Code); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, &dto.ChangePasswordResponse{})
+}
+
+func (c *AuthController) RequestPasswordReset(ctx echo.Context) error {
+	request := new(dto.RequestPasswordResetRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	if err := c.rep.RequestPasswordReset(ctx.Request().Context(), request.Email); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, &dto.RequestPasswordResetResponse{})
+}
+
+func (c *AuthController) ConfirmPasswordReset(ctx echo.Context) error {
+	request := new(dto.ConfirmPasswordResetRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	if err := c.rep.ConfirmPasswordReset(ctx.Request().Context(), request.Token, request.NewPassword); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, &dto.ConfirmPasswordResetResponse{})
+}
+
+// VerifyEmail confirms the email with the token from the verification email. The current session,
+// if there is one, gets fresh tokens right away, so its restrictions are lifted without waiting for a refresh.
+func (c *AuthController) VerifyEmail(ctx echo.Context) error {
+	request := new(dto.VerifyEmailRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	if _, err := c.rep.VerifyEmail(ctx.Request().Context(), request.Token); err != nil {
+		return err
+	}
+
+	if cookie, err := ctx.Cookie(constants.CookieKeyRefreshToken); err == nil && len(cookie.Value) != 0 {
+		tokens, err := c.rep.Refresh(ctx.Request().Context(), cookie.Value, cl.NewClientInfo(ctx.Request()))
+		if err != nil {
+			c.log.Errorf("Refresh error: %s", err)
+		} else {
+			setSessionCookies(ctx, tokens)
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, &dto.VerifyEmailResponse{})
+}
+
+func (c *AuthController) ResendVerification(ctx echo.Context) error {
+	request := new(dto.ResendVerificationRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	if err := c.rep.ResendVerification(ctx.Request().Context(), request.UserID); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, &dto.ResendVerificationResponse{})
+}
+
+func (c *AuthController) VerifySecondFactor(ctx echo.Context) error {
+	request := new(dto.VerifySecondFactorRequest)
+	if err := ctx.Bind(request); err != nil {
+		c.log.Errorf("Bind error: %s", err)
+		return err
+	}
+
+	tokens, err := c.rep.VerifySecondFactor(ctx.Request().Context(), request.Ticket, request.Code, cl.NewClientInfo(ctx.Request()))
+	if err != nil {
+		return err
+	}
+	response, err := c.registry.AuthService.LoginUser(ctx.Request().Context(), tokens.UserID, tokens.AuthToken)
+	if err != nil {
+		return err
+	}
+
+	setSessionCookies(ctx, tokens)
+	ctx.SetCookie(utils.CreateCookie(constants.CookieKeyCSRFToken, response.CSRFToken, viper.GetInt64(constants.ViperCSRFTTLKey)))
+
+	return ctx.JSON(http.StatusOK, (*dto.VerifySecondFactorResponse)(response))
+}
+
+func (c *AuthController) EnrollTwoFactor(ctx echo.Context) error {
+	request := new(dto.EnrollTwoFactorRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	secret, uri, err := c.rep.EnrollTwoFactor(ctx.Request().Context(), request.UserID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, &dto.EnrollTwoFactorResponse{Secret: secret, URI: uri})
+}
+
+func (c *AuthController) ConfirmTwoFactor(ctx echo.Context) error {
+	request := new(dto.ConfirmTwoFactorRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	recoveryCodes, err := c.rep.ConfirmTwoFactor(ctx.Request().Context(), request.UserID, request.Code)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, &dto.ConfirmTwoFactorResponse{RecoveryCodes: recoveryCodes})
+}
+
+func (c *AuthController) DisableTwoFactor(ctx echo.Context) error {
+	request := new(dto.DisableTwoFactorRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	if err := c.rep.DisableTwoFactor(ctx.Request().Context(), request.UserID, request.Password, request.Code); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, &dto.DisableTwoFactorResponse{})
+}
+
+// DeleteAccount schedules the account for deletion and signs the user out everywhere.
+func (c *AuthController) DeleteAccount(ctx echo.Context) error {
+	request := new(dto.DeleteAccountRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	purgeAt, err := c.rep.DeleteAccount(ctx.Request().Context(), request.UserID, request.SessionID, request.Password, request.Code)
+	if err != nil {
+		return err
+	}
+
+	ctx.SetCookie(utils.CreateHTTPOnlyCookie(constants.CookieKeyAuthToken, "", 0))
+	ctx.SetCookie(utils.CreateHTTPOnlyCookie(constants.CookieKeyRefreshToken, "", 0))
+	ctx.SetCookie(utils.CreateCookie(constants.CookieKeyCSRFToken, "", 0))
+	return ctx.JSON(http.StatusOK, &dto.DeleteAccountResponse{PurgeAt: purgeAt})
+}
+
+func setSessionCookies(ctx echo.Context, tokens *cl.Tokens) {
+	for _, cookie := range utils.CreateSessionCookies(tokens.AuthToken, tokens.RefreshToken) {
+		ctx.SetCookie(cookie)
+	}
+}
+
+func convertAPIToken(token *cl.APIToken) dto.APIToken {
+	return dto.APIToken{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     token.Scopes,
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+	}
+}
+
 func NewAuthController(log *logrus.Entry, registry *service.Registry, rep cl.AuthRepository) *AuthController {
 	return &AuthController{log: log, registry: registry, rep: rep}
 }
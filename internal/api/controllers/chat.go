@@ -1,8 +1,8 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
+	"strconv"
 
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/db"
@@ -25,7 +25,7 @@ func (c *ChatController) CreateChat(ctx echo.Context) error {
 		return err
 	}
 
-	response, err := c.registry.ChatService.CreateChat(context.Background(), request)
+	response, err := c.registry.ChatService.CreateChat(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -49,7 +49,7 @@ func (c *ChatController) GetDialogs(ctx echo.Context) error {
 		request.Page = 1
 	}
 
-	response, err := c.registry.ChatService.GetDialogs(context.Background(), request)
+	response, err := c.registry.ChatService.GetDialogs(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -69,11 +69,7 @@ func (c *ChatController) GetDialog(ctx echo.Context) error {
 		request.Limit = 10
 	}
 
-	if request.Page <= 0 {
-		request.Page = 1
-	}
-
-	response, err := c.registry.ChatService.GetDialog(context.Background(), request)
+	response, err := c.registry.ChatService.GetDialog(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -89,7 +85,7 @@ func (c *ChatController) GetDialogByUserID(ctx echo.Context) error {
 
 	currentUserID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
 
-	response, err := c.registry.ChatService.GetDialogByUserID(context.Background(), request, currentUserID)
+	response, err := c.registry.ChatService.GetDialogByUserID(ctx.Request().Context(), request, currentUserID)
 	if err != nil {
 		return err
 	}
@@ -100,9 +96,167 @@ func (c *ChatController) GetDialogByUserID(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, response)
 }
 
+// EditMessage is the fallback of the "edit" websocket event, the members of the dialog are told all the same.
+func (c *ChatController) EditMessage(ctx echo.Context) error {
+	request := new(dto.EditMessageRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	response, err := c.registry.ChatService.EditMessage(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	msg := chat.ConstructMessage(request.DialogID, constants.EditChat, request.UserID, constants.Empty, request.Body)
+	msg.ID = request.MessageID
+	msg.EditedAt = response.EditedAt
+	if err := chat.Broadcast(ctx.Request().Context(), msg); err != nil {
+		c.log.Errorf("Broadcast error: %s", err)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// DeleteMessage is the fallback of the "delete" websocket event, the members of the dialog are told all the same.
+func (c *ChatController) DeleteMessage(ctx echo.Context) error {
+	request := new(dto.DeleteMessageRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	response, err := c.registry.ChatService.DeleteMessage(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	msg := chat.ConstructMessage(request.DialogID, constants.DeleteChat, request.UserID, constants.Empty, constants.Empty)
+	msg.ID = request.MessageID
+	msg.ForEveryone = request.ForEveryone
+	if err := chat.Broadcast(ctx.Request().Context(), msg); err != nil {
+		c.log.Errorf("Broadcast error: %s", err)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// AddMembers adds the users to the group chat, the members of the chat are told with the system messages.
+func (c *ChatController) AddMembers(ctx echo.Context) error {
+	request := new(dto.AddMembersRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	response, err := c.registry.ChatService.AddMembers(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	c.broadcast(ctx, response)
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *ChatController) RemoveMember(ctx echo.Context) error {
+	request := new(dto.RemoveMemberRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	response, err := c.registry.ChatService.RemoveMember(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	c.broadcast(ctx, response)
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *ChatController) EditChat(ctx echo.Context) error {
+	request := new(dto.EditChatRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	response, err := c.registry.ChatService.EditChat(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	c.broadcast(ctx, response)
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *ChatController) SetChatAdmin(ctx echo.Context) error {
+	request := new(dto.SetChatAdminRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	response, err := c.registry.ChatService.SetChatAdmin(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	c.broadcast(ctx, response)
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *ChatController) LeaveChat(ctx echo.Context) error {
+	request := new(dto.LeaveChatRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	response, err := c.registry.ChatService.LeaveChat(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	c.broadcast(ctx, response)
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// broadcast publishes the events of the changes of the group chat to its members.
+func (c *ChatController) broadcast(ctx echo.Context, response *dto.ChatActionResponse) {
+	for i := range response.Messages {
+		if err := chat.Broadcast(ctx.Request().Context(), &response.Messages[i]); err != nil {
+			c.log.Errorf("Broadcast error: %s", err)
+		}
+	}
+}
+
+// GetEvents returns the chat events the user has missed since the seq.
+func (c *ChatController) GetEvents(ctx echo.Context) error {
+	request := new(dto.GetChatEventsRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	if request.Limit <= 0 || request.Limit > constants.ChatSyncBatchSize {
+		request.Limit = constants.ChatSyncBatchSize
+	}
+
+	response, err := c.registry.ChatEventService.GetEvents(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// WsHandler opens the websocket of the user. With the since query parameter the events missed since that seq
+// are sent first.
 func (c *ChatController) WsHandler(ctx echo.Context) error {
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
-	return chat.SocketHandler(&ctx, c.log, c.registry, userID)
+
+	since := int64(constants.NoSync)
+	if value := ctx.QueryParam("since"); len(value) != 0 {
+		var err error
+		if since, err = strconv.ParseInt(value, 10, 64); err != nil || since < 0 {
+			return constants.ErrBindRequest
+		}
+	}
+	return chat.SocketHandler(&ctx, c.log, c.registry, userID, since)
 }
 
 func NewChatController(log *logrus.Entry, repo *db.Repository, registry *service.Registry) *ChatController {
@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
@@ -25,7 +24,7 @@ func (c *CommentController) CreateComment(ctx echo.Context) error {
 
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
 
-	response, err := c.registry.CommentService.CreateComment(context.Background(), request, userID)
+	response, err := c.registry.CommentService.CreateComment(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -48,7 +47,7 @@ func (c *CommentController) GetComments(ctx echo.Context) error {
 		request.Page = 1
 	}
 
-	response, err := c.registry.CommentService.GetComments(context.Background(), request)
+	response, err := c.registry.CommentService.GetComments(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -64,7 +63,7 @@ func (c *CommentController) EditComment(ctx echo.Context) error {
 	}
 
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
-	response, err := c.registry.CommentService.EditComment(context.Background(), request, userID)
+	response, err := c.registry.CommentService.EditComment(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -80,7 +79,7 @@ func (c *CommentController) DeleteComment(ctx echo.Context) error {
 	}
 
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
-	response, err := c.registry.CommentService.DeleteComment(context.Background(), request, userID)
+	response, err := c.registry.CommentService.DeleteComment(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
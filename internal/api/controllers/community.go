@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
@@ -25,7 +24,7 @@ func (c *CommunityController) CreateCommunity(ctx echo.Context) error {
 
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
 
-	response, err := c.registry.CommunityService.CreateCommunity(context.Background(), request, userID)
+	response, err := c.registry.CommunityService.CreateCommunity(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -42,7 +41,7 @@ func (c *CommunityController) DeleteCommunity(ctx echo.Context) error {
 
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
 
-	response, err := c.registry.CommunityService.DeleteCommunity(context.Background(), request, userID)
+	response, err := c.registry.CommunityService.DeleteCommunity(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -59,7 +58,7 @@ func (c *CommunityController) EditCommunity(ctx echo.Context) error {
 
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
 
-	response, err := c.registry.CommunityService.EditCommunity(context.Background(), request, userID)
+	response, err := c.registry.CommunityService.EditCommunity(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -74,7 +73,7 @@ func (c *CommunityController) GetCommunity(ctx echo.Context) error {
 		return err
 	}
 
-	response, err := c.registry.CommunityService.GetCommunity(context.Background(), request)
+	response, err := c.registry.CommunityService.GetCommunity(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -97,7 +96,7 @@ func (c *CommunityController) GetCommunityPosts(ctx echo.Context) error {
 		request.Page = 1
 	}
 
-	response, err := c.registry.CommunityService.GetCommunityPosts(context.Background(), request, userID)
+	response, err := c.registry.CommunityService.GetCommunityPosts(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -122,7 +121,7 @@ func (c *CommunityController) GetUserCommunities(ctx echo.Context) error {
 		request.Page = 1
 	}
 
-	response, err := c.registry.CommunityService.GetUserCommunities(context.Background(), request)
+	response, err := c.registry.CommunityService.GetUserCommunities(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -148,7 +147,7 @@ func (c *CommunityController) GetUserManageCommunities(ctx echo.Context) error {
 		request.Page = 1
 	}
 
-	response, err := c.registry.CommunityService.GetUserManageCommunities(context.Background(), request)
+	response, err := c.registry.CommunityService.GetUserManageCommunities(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -170,7 +169,7 @@ func (c *CommunityController) GetCommunities(ctx echo.Context) error {
 		request.Page = 1
 	}
 
-	response, err := c.registry.CommunityService.GetCommunities(context.Background(), request)
+	response, err := c.registry.CommunityService.GetCommunities(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -186,7 +185,7 @@ func (c *CommunityController) CreatePostCommunity(ctx echo.Context) error {
 	}
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
 
-	response, err := c.registry.CommunityService.CreatePostCommunity(context.Background(), request, userID)
+	response, err := c.registry.CommunityService.CreatePostCommunity(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -202,7 +201,7 @@ func (c *CommunityController) JoinCommunity(ctx echo.Context) error {
 	}
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
 
-	response, err := c.registry.CommunityService.JoinCommunity(context.Background(), request, userID)
+	response, err := c.registry.CommunityService.JoinCommunity(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -223,7 +222,7 @@ func (c *CommunityController) SearchCommunities(ctx echo.Context) error {
 	if request.Page <= 0 {
 		request.Page = 1
 	}
-	response, err := c.registry.CommunityService.SearchCommunities(context.Background(), request)
+	response, err := c.registry.CommunityService.SearchCommunities(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -244,13 +243,13 @@ func (c *CommunityController) UpdatePhotoCommunity(ctx echo.Context) error {
 		return err
 	}
 
-	url, err := c.registry.StaticService.UploadImage(context.Background(), image)
+	url, err := c.registry.StaticService.UploadImage(ctx.Request().Context(), image)
 	if err != nil {
 		return err
 	}
 
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
-	response, err := c.registry.CommunityService.UpdatePhoto(context.Background(), request, url, userID)
+	response, err := c.registry.CommunityService.UpdatePhoto(ctx.Request().Context(), request, url, userID)
 	if err != nil {
 		return err
 	}
@@ -266,7 +265,7 @@ func (c *CommunityController) LeaveCommunity(ctx echo.Context) error {
 	}
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
 
-	response, err := c.registry.CommunityService.LeaveCommunity(context.Background(), request, userID)
+	response, err := c.registry.CommunityService.LeaveCommunity(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -288,7 +287,7 @@ func (c *CommunityController) GetFollowers(ctx echo.Context) error {
 		request.Page = 1
 	}
 
-	response, err := c.registry.CommunityService.GetFollowers(context.Background(), request)
+	response, err := c.registry.CommunityService.GetFollowers(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -310,7 +309,7 @@ func (c *CommunityController) GetMutualFriends(ctx echo.Context) error {
 	if request.Page <= 0 {
 		request.Page = 1
 	}
-	response, err := c.registry.CommunityService.GetMutualFriends(context.Background(), request, userID)
+	response, err := c.registry.CommunityService.GetMutualFriends(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -326,7 +325,7 @@ func (c *CommunityController) DeletePostCommunity(ctx echo.Context) error {
 	}
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
 
-	response, err := c.registry.CommunityService.DeletePostCommunity(context.Background(), request, userID)
+	response, err := c.registry.CommunityService.DeletePostCommunity(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -342,7 +341,7 @@ func (c *CommunityController) EditPostCommunity(ctx echo.Context) error {
 	}
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
 
-	response, err := c.registry.CommunityService.EditPostCommunity(context.Background(), request, userID)
+	response, err := c.registry.CommunityService.EditPostCommunity(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
@@ -21,7 +20,7 @@ func (c *FriendsController) SendRequest(ctx echo.Context) error {
 		return err
 	}
 
-	response, err := c.registry.FriendsService.SendRequest(context.Background(), request)
+	response, err := c.registry.FriendsService.SendRequest(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -35,7 +34,7 @@ func (c *FriendsController) RevokeRequest(ctx echo.Context) error {
 		return err
 	}
 
-	response, err := c.registry.FriendsService.RevokeRequest(context.Background(), request)
+	response, err := c.registry.FriendsService.RevokeRequest(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -49,7 +48,7 @@ func (c *FriendsController) AcceptRequest(ctx echo.Context) error {
 		return err
 	}
 
-	response, err := c.registry.FriendsService.AcceptRequest(context.Background(), request)
+	response, err := c.registry.FriendsService.AcceptRequest(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -63,7 +62,7 @@ func (c *FriendsController) GetFriends(ctx echo.Context) error {
 		return err
 	}
 
-	response, err := c.registry.FriendsService.GetFriends(context.Background(), request)
+	response, err := c.registry.FriendsService.GetFriends(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -77,7 +76,7 @@ func (c *FriendsController) DeleteFriend(ctx echo.Context) error {
 		return err
 	}
 
-	response, err := c.registry.FriendsService.DeleteFriend(context.Background(), request)
+	response, err := c.registry.FriendsService.DeleteFriend(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -91,7 +90,7 @@ func (c *FriendsController) GetIncomingRequests(ctx echo.Context) error {
 		return err
 	}
 
-	response, err := c.registry.FriendsService.GetIncomingRequests(context.Background(), request)
+	response, err := c.registry.FriendsService.GetIncomingRequests(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -105,7 +104,7 @@ func (c *FriendsController) GetOutcomingRequests(ctx echo.Context) error {
 		return err
 	}
 
-	response, err := c.registry.FriendsService.GetOutcomingRequests(context.Background(), request)
+	response, err := c.registry.FriendsService.GetOutcomingRequests(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
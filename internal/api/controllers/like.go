@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
@@ -25,7 +24,7 @@ func (c *LikeController) IncreaseLike(ctx echo.Context) error {
 
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
 
-	response, err := c.registry.LikeService.IncreaseLike(context.Background(), request, userID)
+	response, err := c.registry.LikeService.IncreaseLike(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -42,7 +41,7 @@ func (c *LikeController) ReduceLike(ctx echo.Context) error {
 
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
 
-	response, err := c.registry.LikeService.ReduceLike(context.Background(), request, userID)
+	response, err := c.registry.LikeService.ReduceLike(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -57,7 +56,7 @@ func (c *LikeController) GetLikePost(ctx echo.Context) error {
 		return err
 	}
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
-	response, err := c.registry.LikeService.GetLikePost(context.Background(), request, userID)
+	response, err := c.registry.LikeService.GetLikePost(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -72,7 +71,7 @@ func (c *LikeController) GetLikePhoto(ctx echo.Context) error {
 		return err
 	}
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
-	response, err := c.registry.LikeService.GetLikePhoto(context.Background(), request, userID)
+	response, err := c.registry.LikeService.GetLikePhoto(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -1,12 +1,16 @@
 package controllers
 
 import (
-	"context"
 	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
+	"time"
 
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/cl"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/oauth"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/service"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/utils"
 	"github.com/labstack/echo/v4"
@@ -15,37 +19,229 @@ import (
 )
 
 type OAuthController struct {
-	log      *logrus.Entry
-	registry *service.Registry
+	log       *logrus.Entry
+	registry  *service.Registry
+	rep       cl.AuthRepository
+	providers map[string]*oauth.Provider
 }
 
+// AuthenticateThroughTelergam logs in the user of the Telegram login widget.
 func (c *OAuthController) AuthenticateThroughTelergam(ctx echo.Context) error {
 	request := new(dto.AuthenticateThroughTelergamRequest)
 	if err := ctx.Bind(request); err != nil {
 		return err
 	}
 
-	err := c.registry.OAuthService.AuthenticateThroughTelergam(context.Background(), request)
+	identity := &cl.ExternalIdentity{Provider: constants.TelegramProvider, Subject: request.ID}
+	profile := &oauth.Profile{
+		Subject:   request.ID,
+		FirstName: request.FirstName,
+		LastName:  request.LastName,
+		Picture:   request.PhotoURL,
+	}
+	return c.login(ctx, identity, profile)
+}
+
+// LinkTelegram links the Telegram identity of the login widget to the logged in user.
+func (c *OAuthController) LinkTelegram(ctx echo.Context) error {
+	request := new(dto.LinkTelegramRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	identity := &cl.ExternalIdentity{Provider: constants.TelegramProvider, Subject: request.ID}
+	if err := c.rep.LinkIdentity(ctx.Request().Context(), request.UserID, identity); err != nil {
+		return err
+	}
+	return ctx.Redirect(http.StatusFound, viper.GetString(constants.ViperOAuthRedirectURLKey))
+}
+
+// GetProviders lists the names of the enabled identity providers.
+func (c *OAuthController) GetProviders(ctx echo.Context) error {
+	names := make([]string, 0, len(c.providers)+1)
+	for name := range c.providers {
+		names = append(names, name)
+	}
+	if len(viper.GetString(constants.ViperOAuthTelegramTokenKey)) != 0 {
+		names = append(names, constants.TelegramProvider)
+	}
+	sort.Strings(names)
+
+	return ctx.JSON(http.StatusOK, &dto.GetOAuthProvidersResponse{Providers: names})
+}
+
+// Login sends the user to authorize at the provider.
+func (c *OAuthController) Login(ctx echo.Context) error {
+	request := new(dto.OAuthLoginRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	return c.authorize(ctx, request.Provider, "")
+}
+
+// Link sends the logged in user to authorize at the provider to link the identity to the account.
+func (c *OAuthController) Link(ctx echo.Context) error {
+	request := new(dto.OAuthLinkRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	return c.authorize(ctx, request.Provider, request.UserID)
+}
+
+// Callback finishes the authorization at the provider: links the identity or logs the user in.
+func (c *OAuthController) Callback(ctx echo.Context) error {
+	request := new(dto.OAuthCallbackRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	provider, ok := c.providers[request.Provider]
+	if !ok {
+		return constants.ErrOAuthProviderNotFound
+	}
+
+	cookie, err := ctx.Cookie(constants.CookieKeyOAuthState)
+	if err != nil || len(cookie.Value) == 0 {
+		return constants.ErrOAuthStateInvalid
+	}
+	// The state is good for one callback only.
+	ctx.SetCookie(utils.CreateHTTPOnlyCookie(constants.CookieKeyOAuthState, "", 0))
+
+	state, err := oauth.DecodeState(cookie.Value, []byte(viper.GetString(constants.ViperOAuthStateSecretKey)))
+	if err != nil {
+		return err
+	}
+	if err := state.Check(request.Provider, request.State); err != nil {
+		return err
+	}
+	if len(request.Error) != 0 || len(request.Code) == 0 {
+		return constants.ErrOAuthDenied
+	}
+
+	profile, err := provider.Exchange(ctx.Request().Context(), request.Code, state, callbackURL(request.Provider))
+	if err != nil {
+		c.log.Errorf("Exchange error: %s", err)
+		return err
+	}
+	identity := &cl.ExternalIdentity{
+		Provider:      request.Provider,
+		Subject:       profile.Subject,
+		Email:         profile.Email,
+		EmailVerified: profile.EmailVerified,
+	}
+
+	if len(state.UserID) != 0 {
+		if err := c.rep.LinkIdentity(ctx.Request().Context(), state.UserID, identity); err != nil {
+			return err
+		}
+		return ctx.Redirect(http.StatusFound, viper.GetString(constants.ViperOAuthRedirectURLKey))
+	}
+
+	return c.login(ctx, identity, profile)
+}
+
+// login starts the session of the user of the external identity, signing the user up on the first login.
+func (c *OAuthController) login(ctx echo.Context, identity *cl.ExternalIdentity, profile *oauth.Profile) error {
+	redirectURL := viper.GetString(constants.ViperOAuthRedirectURLKey)
+
+	tokens, err := c.rep.ExternalLogin(ctx.Request().Context(), identity, cl.NewClientInfo(ctx.Request()))
 	if err != nil {
 		return err
 	}
+	if len(tokens.TwoFactorTicket) != 0 {
+		return ctx.Redirect(http.StatusFound, redirectURL+"?two_factor_ticket="+url.QueryEscape(tokens.TwoFactorTicket))
+	}
 
-	authToken, err := utils.GenerateAuthToken(&utils.AuthTokenWrapper{UserID: request.ID})
+	if tokens.Created {
+		if err := c.registry.OAuthService.CreateExternalUser(ctx.Request().Context(), tokens.UserID, profile); err != nil {
+			return err
+		}
+	}
+
+	response, err := c.registry.AuthService.LoginUser(ctx.Request().Context(), tokens.UserID, tokens.AuthToken)
 	if err != nil {
 		return err
 	}
 
-	csrfToken, err := utils.GenerateCSRFToken(request.ID)
+	setSessionCookies(ctx, tokens)
+	ctx.SetCookie(utils.CreateCookie(constants.CookieKeyCSRFToken, response.CSRFToken, viper.GetInt64(constants.ViperCSRFTTLKey)))
+
+	return ctx.Redirect(http.StatusFound, redirectURL)
+}
+
+func (c *OAuthController) GetIdentities(ctx echo.Context) error {
+	request := new(dto.GetIdentitiesRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	identities, err := c.rep.ListIdentities(ctx.Request().Context(), request.UserID)
 	if err != nil {
-		return fmt.Errorf("GenerateCSRFToken: %w", err)
+		return err
 	}
 
-	ctx.SetCookie(utils.CreateHTTPOnlyCookie(constants.CookieKeyAuthToken, authToken, viper.GetInt64(constants.ViperJWTTTLKey)))
-	ctx.SetCookie(utils.CreateCookie(constants.CookieKeyCSRFToken, csrfToken, viper.GetInt64(constants.ViperCSRFTTLKey)))
+	response := &dto.GetIdentitiesResponse{Identities: make([]dto.LinkedIdentity, 0, len(identities))}
+	for _, identity := range identities {
+		response.Identities = append(response.Identities, dto.LinkedIdentity{
+			Provider: identity.Provider,
+			Email:    identity.Email,
+			LinkedAt: identity.LinkedAt,
+		})
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *OAuthController) UnlinkIdentity(ctx echo.Context) error {
+	request := new(dto.UnlinkIdentityRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	if err := c.rep.UnlinkIdentity(ctx.Request().Context(), request.UserID, request.Provider); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, &dto.UnlinkIdentityResponse{})
+}
+
+// authorize keeps the state of the flow in the cookie and redirects the user to the provider.
+func (c *OAuthController) authorize(ctx echo.Context, name, userID string) error {
+	provider, ok := c.providers[name]
+	if !ok {
+		return constants.ErrOAuthProviderNotFound
+	}
+
+	ttl := viper.GetDuration(constants.ViperOAuthStateTTLKey)
+	if ttl <= 0 {
+		ttl = constants.DefaultOAuthStateTTL
+	}
+
+	state, err := oauth.NewState(name, userID, ttl)
+	if err != nil {
+		return fmt.Errorf("NewState: %w", err)
+	}
+	value, err := state.Encode([]byte(viper.GetString(constants.ViperOAuthStateSecretKey)))
+	if err != nil {
+		return fmt.Errorf("Encode: %w", err)
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx.Request().Context(), state, callbackURL(name))
+	if err != nil {
+		c.log.Errorf("AuthCodeURL error: %s", err)
+		return err
+	}
+
+	ctx.SetCookie(utils.CreateHTTPOnlyCookie(constants.CookieKeyOAuthState, value, int64(ttl/time.Second)))
+	return ctx.Redirect(http.StatusFound, authURL)
+}
 
-	return ctx.Redirect(http.StatusPermanentRedirect, "/")
+// callbackURL is the redirect url registered at the provider.
+func callbackURL(provider string) string {
+	return viper.GetString(constants.ViperOAuthCallbackBaseURLKey) + "/" + provider + "/callback"
 }
 
-func NewOAuthController(log *logrus.Entry, registry *service.Registry) *OAuthController {
-	return &OAuthController{log: log, registry: registry}
+func NewOAuthController(log *logrus.Entry, registry *service.Registry, rep cl.AuthRepository, providers map[string]*oauth.Provider) *OAuthController {
+	return &OAuthController{log: log, registry: registry, rep: rep, providers: providers}
 }
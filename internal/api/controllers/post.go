@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
@@ -24,7 +23,7 @@ func (c *PostController) CreatePost(ctx echo.Context) error {
 
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
 
-	response, err := c.registry.PostService.CreatePost(context.Background(), request, userID)
+	response, err := c.registry.PostService.CreatePost(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -39,7 +38,7 @@ func (c *PostController) GetPost(ctx echo.Context) error {
 		return err
 	}
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
-	response, err := c.registry.PostService.GetPost(context.Background(), request, userID)
+	response, err := c.registry.PostService.GetPost(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -55,7 +54,7 @@ func (c *PostController) EditPost(ctx echo.Context) error {
 	}
 
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
-	response, err := c.registry.PostService.EditPost(context.Background(), request, userID)
+	response, err := c.registry.PostService.EditPost(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -71,7 +70,7 @@ func (c *PostController) DeletePost(ctx echo.Context) error {
 	}
 
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
-	response, err := c.registry.PostService.DeletePost(context.Background(), request, userID)
+	response, err := c.registry.PostService.DeletePost(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
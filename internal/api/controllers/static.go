@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
@@ -21,7 +20,7 @@ func (c *StaticController) UploadImage(ctx echo.Context) error {
 		return err
 	}
 
-	url, err := c.registry.StaticService.UploadImage(context.Background(), image)
+	url, err := c.registry.StaticService.UploadImage(ctx.Request().Context(), image)
 	if err != nil {
 		return err
 	}
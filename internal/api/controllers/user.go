@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
@@ -28,7 +27,8 @@ func (c *UserController) GetUserData(ctx echo.Context) error {
 		request.UserID = ctx.Request().Header.Get(constants.HeaderKeyUserID)
 	}
 
-	response, err := c.registry.UserService.GetUserData(context.Background(), request.UserID)
+	viewerID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
+	response, err := c.registry.UserService.GetUserData(ctx.Request().Context(), viewerID, request.UserID)
 	if err != nil {
 		return err
 	}
@@ -55,7 +55,7 @@ func (c *UserController) GetUserPosts(ctx echo.Context) error {
 		request.Page = 1
 	}
 
-	response, err := c.registry.UserService.GetUserPosts(context.Background(), request)
+	response, err := c.registry.UserService.GetUserPosts(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -80,7 +80,7 @@ func (c *UserController) GetFeed(ctx echo.Context) error {
 		request.Page = 1
 	}
 
-	response, err := c.registry.UserService.GetFeed(context.Background(), userID, request)
+	response, err := c.registry.UserService.GetFeed(ctx.Request().Context(), userID, request)
 	if err != nil {
 		return err
 	}
@@ -99,7 +99,7 @@ func (c *UserController) GetProfile(ctx echo.Context) error {
 		request.UserID = ctx.Request().Header.Get(constants.HeaderKeyUserID)
 	}
 
-	response, err := c.registry.UserService.GetProfile(context.Background(), request)
+	response, err := c.registry.UserService.GetProfile(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -116,7 +116,7 @@ func (c *UserController) EditProfile(ctx echo.Context) error {
 
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
 
-	response, err := c.registry.UserService.EditProfile(context.Background(), request, userID)
+	response, err := c.registry.UserService.EditProfile(ctx.Request().Context(), request, userID)
 	if err != nil {
 		return err
 	}
@@ -130,13 +130,13 @@ func (c *UserController) UpdatePhoto(ctx echo.Context) error {
 		return err
 	}
 
-	url, err := c.registry.StaticService.UploadImage(context.Background(), image)
+	url, err := c.registry.StaticService.UploadImage(ctx.Request().Context(), image)
 	if err != nil {
 		return err
 	}
 
 	userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
-	response, err := c.registry.UserService.UpdatePhoto(context.Background(), url, userID)
+	response, err := c.registry.UserService.UpdatePhoto(ctx.Request().Context(), url, userID)
 	if err != nil {
 		return err
 	}
@@ -158,7 +158,7 @@ func (c *UserController) SearchUsers(ctx echo.Context) error {
 		request.Page = 1
 	}
 
-	response, err := c.registry.UserService.SearchUsers(context.Background(), request)
+	response, err := c.registry.UserService.SearchUsers(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
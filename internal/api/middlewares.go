@@ -2,14 +2,10 @@ package api
 
 import (
 	"bytes"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
-	"io"
+	"context"
+	"errors"
 	"io/ioutil"
-	"net"
 	"net/http"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -20,69 +16,163 @@ import (
 
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/oauth"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/utils"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 )
 
-func (svc *APIService) AuthMiddlewareMicro(rep cl.AuthRepository) echo.MiddlewareFunc {
+// AuthMiddlewareMicro authenticates the request by the session cookies or by the api token sent in the
+// Authorization header. The api tokens are accepted only by the routes of the given resources,
+// and the token must have the read or write scope of every one of them.
+func (svc *APIService) AuthMiddlewareMicro(rep cl.AuthRepository, resources ...string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
+			if token, ok := bearerToken(ctx.Request()); ok {
+				return svc.authenticateAPIToken(ctx, rep, token, resources, next)
+			}
+
+			identity := new(cl.Identity)
 			cookieAuth, err := ctx.Cookie(constants.CookieKeyAuthToken)
 			if err != nil {
-				return constants.ErrMissingAuthCookie
+				err = constants.ErrMissingAuthCookie
+			} else {
+				identity, err = rep.Verify(ctx.Request().Context(), cookieAuth.Value)
 			}
 
-			newToken, UserID, code, err := rep.Check(cookieAuth.Value)
+			// Access token is short-lived: when it is gone, the session is continued with the refresh token.
+			if errors.Is(err, constants.ErrMissingAuthCookie) || errors.Is(err, constants.ErrAuthTokenExpired) {
+				cookieRefresh, cerr := ctx.Cookie(constants.CookieKeyRefreshToken)
+				if cerr != nil || len(cookieRefresh.Value) == 0 {
+					return err
+				}
+
+				tokens, rerr := rep.Refresh(ctx.Request().Context(), cookieRefresh.Value, cl.NewClientInfo(ctx.Request()))
+				if rerr != nil {
+					return rerr
+				}
+				for _, cookie := range utils.CreateSessionCookies(tokens.AuthToken, tokens.RefreshToken) {
+					ctx.SetCookie(cookie)
+				}
+				identity, err = &cl.Identity{UserID: tokens.UserID, SessionID: tokens.SessionID, Unverified: tokens.Unverified}, nil
+			}
 			if err != nil {
 				return err
 			}
-			if len(UserID) != 0 {
-				ctx.Request().Header.Set(constants.HeaderKeyUserID, UserID)
-			}
 
-			if code {
-				ctx.SetCookie(utils.CreateHTTPOnlyCookie(constants.CookieKeyAuthToken, newToken, viper.GetInt64(constants.ViperJWTTTLKey)))
-			}
+			return svc.authenticated(ctx, identity, constants.UserAuthTypeSession, next)
+		}
+	}
+}
 
-			return next(ctx)
+// authenticateAPIToken lets the script or bot through if its token has the scopes for the request.
+func (svc *APIService) authenticateAPIToken(ctx echo.Context, rep cl.AuthRepository, token string, resources []string, next echo.HandlerFunc) error {
+	if len(resources) == 0 || accountManagement(ctx) {
+		return constants.ErrAPITokenNotAllowed
+	}
+
+	identity, err := rep.CheckAPIToken(ctx.Request().Context(), token)
+	if err != nil {
+		return err
+	}
+
+	write := !readOnly(ctx)
+	for _, resource := range resources {
+		if !hasScope(identity.Scopes, constants.APITokenScope(resource, write)) {
+			return constants.ErrInsufficientScope
+		}
+	}
+
+	return svc.authenticated(ctx, identity, constants.UserAuthTypeAPIToken, next)
+}
+
+// authenticated passes the identity to the handlers in the headers.
+func (svc *APIService) authenticated(ctx echo.Context, identity *cl.Identity, authType string, next echo.HandlerFunc) error {
+	if identity.Unverified && !unverifiedAllowed(ctx) {
+		return constants.ErrEmailNotVerified
+	}
+
+	if len(identity.UserID) != 0 {
+		ctx.Request().Header.Set(constants.HeaderKeyUserID, identity.UserID)
+	}
+	// Never trust the session id and the auth type sent by the client itself.
+	ctx.Request().Header.Set(constants.HeaderKeySessionID, identity.SessionID)
+	ctx.Request().Header.Set(constants.HeaderKeyUserAuthType, authType)
+
+	return next(ctx)
+}
+
+// bearerToken returns the api token from the Authorization header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get(echo.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, constants.AuthSchemeBearer) || len(token) == 0 {
+		return "", false
+	}
+	return token, true
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, granted := range scopes {
+		if granted == scope {
+			return true
 		}
 	}
+	return false
 }
 
+// unsafeGetRoutes are the GET routes which change something and so are not read-only.
+var unsafeGetRoutes = map[string]bool{
+	"/api/messenger/ws":      true,
+	"/api/communities/join":  true,
+	"/api/communities/leave": true,
+}
+
+// readOnly tells if the request doesn't change anything.
+func readOnly(ctx echo.Context) bool {
+	method := ctx.Request().Method
+	return (method == http.MethodGet || method == http.MethodHead) && !unsafeGetRoutes[ctx.Path()]
+}
+
+// accountManagement tells if the request manages the account itself: its credentials, sessions or deletion.
+func accountManagement(ctx echo.Context) bool {
+	path := ctx.Path()
+	return strings.HasPrefix(path, "/api/auth/") || (path == "/api/user" && ctx.Request().Method == http.MethodDelete)
+}
+
+// unverifiedAllowed tells if the policy lets the user with unverified email make the request.
+// Managing the account itself is always allowed, so the user can verify the email or delete the account.
+func unverifiedAllowed(ctx echo.Context) bool {
+	if accountManagement(ctx) {
+		return true
+	}
+
+	switch viper.GetString(constants.ViperUnverifiedAccessKey) {
+	case constants.UnverifiedAccessFull:
+		return true
+	case constants.UnverifiedAccessNone:
+		return false
+	default:
+		return readOnly(ctx)
+	}
+}
+
+// OAuthTelegramMiddleware lets through the requests carrying fresh user data signed by the Telegram login widget.
 func (svc *APIService) OAuthTelegramMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
-			queryParams := ctx.Request().URL.Query()
-			kvs := []string{}
-			hash := ""
-			for k, v := range queryParams {
-				if k == "hash" {
-					hash = v[0]
-					continue
-				}
-				kvs = append(kvs, k+"="+v[0])
+			token := viper.GetString(constants.ViperOAuthTelegramTokenKey)
+			if len(token) == 0 {
+				return constants.ErrOAuthProviderNotFound
 			}
-			sort.Strings(kvs)
 
-			var dataCheckString = ""
-			for _, s := range kvs {
-				if dataCheckString != "" {
-					dataCheckString += "\n"
-				}
-				dataCheckString += s
+			ttl := viper.GetDuration(constants.ViperOAuthTelegramAuthTTLKey)
+			if ttl <= 0 {
+				ttl = constants.DefaultTelegramAuthTTL
 			}
 
-			sha256hash := sha256.New()
-
-			telegramToken := viper.GetString("service.telegram_token")
-			_, _ = io.WriteString(sha256hash, telegramToken)
-
-			hmachash := hmac.New(sha256.New, sha256hash.Sum(nil))
-			_, _ = io.WriteString(hmachash, dataCheckString)
-
-			if hash != hex.EncodeToString(hmachash.Sum(nil)) {
-				return constants.ErrHashInvalid
+			if err := oauth.CheckTelegramAuth(ctx.QueryParams(), token, ttl); err != nil {
+				svc.log.Errorf("CheckTelegramAuth error: %s", err)
+				return err
 			}
 
 			return next(ctx)
@@ -93,6 +183,11 @@ func (svc *APIService) OAuthTelegramMiddleware() echo.MiddlewareFunc {
 func (svc *APIService) CSRFMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
+			// Api tokens are never sent by the browser on its own, so they need no csrf protection.
+			if ctx.Request().Header.Get(constants.HeaderKeyUserAuthType) == constants.UserAuthTypeAPIToken {
+				return next(ctx)
+			}
+
 			cookieCSRF, err := ctx.Cookie(constants.CookieKeyCSRFToken)
 			if err != nil || len(cookieCSRF.Value) == 0 {
 				return constants.ErrMissingCSRFCookie
@@ -123,12 +218,15 @@ func (svc *APIService) XRequestIDMiddleware() echo.MiddlewareFunc {
 		return func(ctx echo.Context) error {
 			xRequestID := ctx.Request().Header.Get(constants.HeaderKeyRequestID)
 			if len(xRequestID) == 0 {
-				xRequestID, err := core.GenUUID()
-				if err != nil {
+				var err error
+				if xRequestID, err = core.GenUUID(); err != nil {
 					return err
 				}
 				ctx.Request().Header.Set(constants.HeaderKeyRequestID, xRequestID)
 			}
+			// The request context carries the id to the calls of the auth service.
+			req := ctx.Request()
+			ctx.SetRequest(req.WithContext(context.WithValue(req.Context(), constants.CtxKeyXRequestID{}, xRequestID)))
 			return next(ctx)
 		}
 	}
@@ -156,7 +254,7 @@ func (svc *APIService) LoggingMiddleware() echo.MiddlewareFunc {
 				"host":         req.Host,
 				"uri":          req.RequestURI,
 				"http_method":  req.Method,
-				"user_ip":      getIP(req),
+				"user_ip":      utils.GetIP(req),
 			})
 
 			userID := ctx.Request().Header.Get(constants.HeaderKeyUserID)
@@ -226,29 +324,3 @@ func (svc *APIService) AccessLogMiddleware() echo.MiddlewareFunc {
 		}
 	}
 }
-
-func getIP(r *http.Request) string {
-	ip := r.Header.Get("X-REAL-IP")
-	netIP := net.ParseIP(ip)
-	if netIP != nil {
-		return ip
-	}
-
-	for _, ip := range strings.Split(r.Header.Get("X-FORWARDED-FOR"), ",") {
-		if netIP := net.ParseIP(ip); netIP != nil {
-			return ip
-		}
-	}
-
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return ""
-	}
-
-	netIP = net.ParseIP(ip)
-	if netIP != nil {
-		return ip
-	}
-
-	return ""
-}
@@ -5,9 +5,12 @@ import (
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/monitoring"
 
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/api/controllers"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/db"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/cl"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/handler"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core/chat"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/oauth"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/service"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
@@ -21,18 +24,39 @@ type APIService struct {
 	router  *echo.Echo
 	debug   bool
 	metrics *monitoring.PrometheusMetrics
+
+	auth        cl.AuthRepository
+	revocations cl.Revocations
+	registry    *service.Registry
+	broker      chat.Broker
+	stopPurger  context.CancelFunc
 }
 
 func (svc *APIService) Serve() {
+	ctx, cancel := context.WithCancel(context.Background())
+	svc.stopPurger = cancel
+	go svc.runAccountPurger(ctx)
+
 	svc.log.Info("Starting HTTP server")
 	listenAddr := viper.GetString("service.bind.address") + ":" + viper.GetString("service.bind.port")
 	svc.log.Fatal(svc.router.Start(listenAddr))
 }
 
 func (svc *APIService) Shutdown(ctx context.Context) error {
+	if svc.stopPurger != nil {
+		svc.stopPurger()
+	}
 	if err := svc.router.Shutdown(ctx); err != nil {
 		svc.log.Fatal(err)
 	}
+	if err := svc.broker.Close(); err != nil {
+		svc.log.Errorf("failed to close chat broker: %s", err)
+	}
+	if svc.revocations != nil {
+		if err := svc.revocations.Close(); err != nil {
+			svc.log.Errorf("failed to close token revocations: %s", err)
+		}
+	}
 	return nil
 }
 
@@ -46,7 +70,21 @@ func NewAPIService(log *logrus.Entry, dbConn *mongo.Database, debug bool, grpcCo
 	svc.router.Validator = NewValidator()
 	svc.router.Binder = NewBinder()
 
+	svc.metrics = monitoring.RegisterMonitoring(svc.router)
+
 	authService := cl.NewAuthRepository(log, handler.NewUserAuthClient(grpcConn))
+	if size := viper.GetInt(constants.ConfigAuthTokenCacheSize); size > 0 {
+		ttl := viper.GetDuration(constants.ConfigAuthTokenCacheTTL)
+		if ttl <= 0 {
+			ttl = constants.DefaultAuthTokenCacheTTL
+		}
+		revocations, err := cl.NewRevocations(log)
+		if err != nil {
+			log.Fatal(err)
+		}
+		svc.revocations = revocations
+		authService = cl.NewCachedAuthRepository(log, authService, cl.NewTokenCache(size, ttl, svc.metrics.TokenCache), revocations)
+	}
 
 	repository, err := db.NewRepository(dbConn)
 	if err != nil {
@@ -55,8 +93,27 @@ func NewAPIService(log *logrus.Entry, dbConn *mongo.Database, debug bool, grpcCo
 
 	registry := service.NewRegistry(log, repository)
 
+	providers, err := oauth.LoadProviders()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(providers) != 0 && len(viper.GetString(constants.ViperOAuthStateSecretKey)) == 0 {
+		log.Fatal("oauth state secret is not set")
+	}
+
+	broker, err := chat.NewBroker(log)
+	if err != nil {
+		log.Fatal(err)
+	}
+	chat.DialogBroker = broker
+	chat.DialogEvents = registry.ChatEventService
+
+	svc.auth = authService
+	svc.registry = registry
+	svc.broker = broker
+
 	authCtrl := controllers.NewAuthController(log, registry, authService)
-	oauthCtrl := controllers.NewOAuthController(log, registry)
+	oauthCtrl := controllers.NewOAuthController(log, registry, authService, providers)
 	fileCtrl := controllers.NewFileController(log, registry)
 	userCtrl := controllers.NewUserController(log, registry)
 	friendsCtrl := controllers.NewFriendsController(log, registry)
@@ -69,7 +126,6 @@ func NewAPIService(log *logrus.Entry, dbConn *mongo.Database, debug bool, grpcCo
 
 	svc.router.HTTPErrorHandler = svc.httpErrorHandler
 
-	svc.metrics = monitoring.RegisterMonitoring(svc.router)
 	svc.router.Use(svc.XRequestIDMiddleware(), svc.LoggingMiddleware(), svc.AccessLogMiddleware())
 
 	api := svc.router.Group("/api")
@@ -78,19 +134,49 @@ func NewAPIService(log *logrus.Entry, dbConn *mongo.Database, debug bool, grpcCo
 
 	authAPI.POST("/signup", authCtrl.SignupUser)
 	authAPI.POST("/login", authCtrl.LoginUser)
+	authAPI.POST("/login/2fa", authCtrl.VerifySecondFactor)
+	authAPI.POST("/refresh", authCtrl.RefreshSession)
 	authAPI.DELETE("/logout", authCtrl.LogoutUser)
+	authAPI.GET("/sessions", authCtrl.GetSessions, svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+	authAPI.DELETE("/sessions", authCtrl.RevokeSession, svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+	authAPI.POST("/tokens", authCtrl.CreateAPIToken, svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+	authAPI.GET("/tokens", authCtrl.GetAPITokens, svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+	authAPI.DELETE("/tokens", authCtrl.RevokeAPIToken, svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+
+	authAPI.POST("/verify", authCtrl.VerifyEmail)
+	authAPI.POST("/verify/resend", authCtrl.ResendVerification, svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+
+	passwordAPI := authAPI.Group("/password")
+
+	passwordAPI.POST("/change", authCtrl.ChangePassword, svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+	passwordAPI.POST("/reset", authCtrl.RequestPasswordReset)
+	passwordAPI.POST("/reset/confirm", authCtrl.ConfirmPasswordReset)
+
+	twoFactorAPI := authAPI.Group("/2fa", svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+
+	twoFactorAPI.POST("/enroll", authCtrl.EnrollTwoFactor)
+	twoFactorAPI.POST("/confirm", authCtrl.ConfirmTwoFactor)
+	twoFactorAPI.POST("/disable", authCtrl.DisableTwoFactor)
 
 	oauthAPI := api.Group("/oauth")
 
 	oauthAPI.GET("/telegram", oauthCtrl.AuthenticateThroughTelergam, svc.OAuthTelegramMiddleware())
+	oauthAPI.GET("/telegram/link", oauthCtrl.LinkTelegram, svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware(), svc.OAuthTelegramMiddleware())
+	oauthAPI.GET("/providers", oauthCtrl.GetProviders)
+	oauthAPI.GET("/:provider/login", oauthCtrl.Login)
+	oauthAPI.GET("/:provider/link", oauthCtrl.Link, svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+	oauthAPI.GET("/:provider/callback", oauthCtrl.Callback)
+	oauthAPI.GET("/identities", oauthCtrl.GetIdentities, svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+	oauthAPI.DELETE("/identities", oauthCtrl.UnlinkIdentity, svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
 
-	fileAPI := api.Group("/file", svc.AuthMiddlewareMicro(authService))
+	fileAPI := api.Group("/file", svc.AuthMiddlewareMicro(authService, constants.ScopeResourceFiles))
 
 	fileAPI.POST("/upload", fileCtrl.UploadFile, svc.CSRFMiddleware())
 	fileAPI.GET("/get", fileCtrl.GetFile)
 
-	userAPI := api.Group("/user", svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+	userAPI := api.Group("/user", svc.AuthMiddlewareMicro(authService, constants.ScopeResourceProfile), svc.CSRFMiddleware())
 
+	userAPI.DELETE("", authCtrl.DeleteAccount)
 	userAPI.GET("/get", userCtrl.GetUserData)
 	userAPI.GET("/posts", userCtrl.GetUserPosts)
 	userAPI.GET("/feed", userCtrl.GetFeed)
@@ -99,7 +185,7 @@ func NewAPIService(log *logrus.Entry, dbConn *mongo.Database, debug bool, grpcCo
 	userAPI.GET("/profile", userCtrl.GetProfile)
 	userAPI.POST("/profile/edit", userCtrl.EditProfile)
 
-	friendsAPI := api.Group("/friends", svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+	friendsAPI := api.Group("/friends", svc.AuthMiddlewareMicro(authService, constants.ScopeResourceFriends), svc.CSRFMiddleware())
 
 	friendsAPI.POST("/request/send", friendsCtrl.SendRequest)
 	friendsAPI.POST("/request/revoke", friendsCtrl.RevokeRequest)
@@ -111,14 +197,14 @@ func NewAPIService(log *logrus.Entry, dbConn *mongo.Database, debug bool, grpcCo
 	friendsAPI.GET("/requests/incoming", friendsCtrl.GetIncomingRequests)
 	friendsAPI.GET("/requests/outcoming", friendsCtrl.GetOutcomingRequests)
 
-	postAPI := api.Group("/post", svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+	postAPI := api.Group("/post", svc.AuthMiddlewareMicro(authService, constants.ScopeResourcePosts), svc.CSRFMiddleware())
 
 	postAPI.POST("/create", postCtrl.CreatePost)
 	postAPI.GET("/get", postCtrl.GetPost)
 	postAPI.PUT("/edit", postCtrl.EditPost)
 	postAPI.DELETE("/delete", postCtrl.DeletePost)
 
-	likeAPI := api.Group("/like", svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+	likeAPI := api.Group("/like", svc.AuthMiddlewareMicro(authService, constants.ScopeResourcePosts), svc.CSRFMiddleware())
 
 	likeAPI.POST("/increase", likeCtrl.IncreaseLike)
 	likeAPI.POST("/reduce", likeCtrl.ReduceLike)
@@ -127,17 +213,25 @@ func NewAPIService(log *logrus.Entry, dbConn *mongo.Database, debug bool, grpcCo
 
 	static := api.Group("/static")
 
-	static.POST("/upload", staticCtrl.UploadImage, svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+	static.POST("/upload", staticCtrl.UploadImage, svc.AuthMiddlewareMicro(authService, constants.ScopeResourceFiles), svc.CSRFMiddleware())
 
-	chatAPI := api.Group("/messenger", svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+	chatAPI := api.Group("/messenger", svc.AuthMiddlewareMicro(authService, constants.ScopeResourceMessages), svc.CSRFMiddleware())
 
 	chatAPI.POST("/create", chatCtrl.CreateChat)
 	chatAPI.GET("/dialogs", chatCtrl.GetDialogs)
 	chatAPI.GET("/get", chatCtrl.GetDialog)
 	chatAPI.GET("/user_dialog", chatCtrl.GetDialogByUserID)
+	chatAPI.PUT("/message/edit", chatCtrl.EditMessage)
+	chatAPI.DELETE("/message/delete", chatCtrl.DeleteMessage)
+	chatAPI.POST("/members/add", chatCtrl.AddMembers)
+	chatAPI.DELETE("/members/remove", chatCtrl.RemoveMember)
+	chatAPI.PUT("/edit", chatCtrl.EditChat)
+	chatAPI.PUT("/admins/set", chatCtrl.SetChatAdmin)
+	chatAPI.POST("/leave", chatCtrl.LeaveChat)
+	chatAPI.GET("/events", chatCtrl.GetEvents)
 	chatAPI.GET("/ws", chatCtrl.WsHandler)
 
-	communitiesAPI := api.Group("/communities", svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+	communitiesAPI := api.Group("/communities", svc.AuthMiddlewareMicro(authService, constants.ScopeResourceCommunities), svc.CSRFMiddleware())
 
 	communitiesAPI.GET("/get", communitiesCtrl.GetCommunity)
 	communitiesAPI.GET("/posts", communitiesCtrl.GetCommunityPosts)
@@ -160,7 +254,7 @@ func NewAPIService(log *logrus.Entry, dbConn *mongo.Database, debug bool, grpcCo
 	communitiesPostAPI.PUT("/edit", communitiesCtrl.EditPostCommunity)
 	communitiesPostAPI.DELETE("/delete", communitiesCtrl.DeletePostCommunity)
 
-	commentAPI := api.Group("/comment", svc.AuthMiddlewareMicro(authService), svc.CSRFMiddleware())
+	commentAPI := api.Group("/comment", svc.AuthMiddlewareMicro(authService, constants.ScopeResourceComments), svc.CSRFMiddleware())
 
 	commentAPI.POST("/create", commentCtrl.CreateComment)
 	commentAPI.GET("/get", commentCtrl.GetComments)
@@ -0,0 +1,12 @@
+package constants
+
+import "time"
+
+const (
+	ViperAccountPurgeIntervalKey = "account_deletion.purge_interval"
+
+	// DefaultAccountPurgeInterval is how often the accounts whose deletion is due are purged.
+	DefaultAccountPurgeInterval = time.Hour
+	// AccountPurgeBatch is how many accounts are purged at once.
+	AccountPurgeBatch = 100
+)
@@ -0,0 +1,28 @@
+package constants
+
+const (
+	// AuthSchemeBearer is the scheme of the Authorization header which the api tokens are sent with.
+	AuthSchemeBearer = "Bearer"
+
+	UserAuthTypeSession  = "session"
+	UserAuthTypeAPIToken = "api_token"
+)
+
+// Resources which the api token scopes grant access to, see APITokenScope.
+const (
+	ScopeResourceProfile     = "profile"
+	ScopeResourcePosts       = "posts"
+	ScopeResourceComments    = "comments"
+	ScopeResourceFriends     = "friends"
+	ScopeResourceMessages    = "messages"
+	ScopeResourceCommunities = "communities"
+	ScopeResourceFiles       = "files"
+)
+
+// APITokenScope returns the scope of read or write access to the resource, like read:posts.
+func APITokenScope(resource string, write bool) string {
+	if write {
+		return "write:" + resource
+	}
+	return "read:" + resource
+}
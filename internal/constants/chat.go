@@ -11,6 +11,10 @@ const (
 	PongWait       = 600 * time.Second
 	PingPeriod     = PongWait * 9 / 10
 	MaxMessageSize = 1024 * 1024 * 1024
+	// SendBufferSize is how many events may wait for a slow connection before it is closed.
+	SendBufferSize = 256
+	// TypingTimeout is how long the user is typing after typing_start unless they start again.
+	TypingTimeout = 6 * time.Second
 
 	JoinChat    = "join"
 	LeaveChat   = "leave"
@@ -20,13 +24,88 @@ const (
 	SendFile    = "send_file"
 	SendSticker = "send_sticker"
 	ReadChat    = "read"
+	TypingStart = "typing_start"
+	TypingStop  = "typing_stop"
+	EditChat    = "edit"
+	DeleteChat  = "delete"
+	ReactChat   = "react"
+	UnreactChat = "unreact"
+	SyncedChat  = "synced"
+	ResyncChat  = "resync"
 	Empty       = ""
 
-	ErrChat           = "error"
-	ErrChatDoNotExist = "room does not exit"
-	ErrRequest        = "bad request"
+	// The changes of a group chat, they are stored in the history as the system messages too.
+	AddMember    = "add_member"
+	RemoveMember = "remove_member"
+	RenameChat   = "rename"
+	SetChatImage = "set_image"
+	LeaveGroup   = "leave_chat"
+	AddAdmin     = "add_admin"
+	RemoveAdmin  = "remove_admin"
+
+	ErrChat            = "error"
+	ErrChatDoNotExist  = "room does not exit"
+	ErrChatUnavailable = "chat is unavailable"
+	ErrRequest         = "bad request"
+)
+
+const (
+	// ConfigChatBrokerType chooses how the dialog messages reach the other instances: memory or redis.
+	ConfigChatBrokerType     = "chat.broker.type"
+	ConfigChatBrokerAddress  = "chat.broker.address"
+	ConfigChatBrokerPassword = "chat.broker.password"
+
+	ChatBrokerMemory = "memory"
+	ChatBrokerRedis  = "redis"
+
+	ChatBrokerChannelPrefix = "chat:dialog:"
+
+	// ConfigChatEditTimeLimit is how long the author may edit or delete a message for everyone, e.g. "48h", 0 is unlimited.
+	ConfigChatEditTimeLimit = "chat.edit_time_limit"
+
+	// ReactionMaxLength is the max length of a reaction in bytes, enough for an emoji of several code points.
+	ReactionMaxLength = 32
+
+	// QuoteBodyLength is how many characters of the message which is replied to are quoted,
+	// the same goes for the last message of the dialog in the dialog list.
+	QuoteBodyLength = 100
+
+	AttachmentImage = "image"
+	AttachmentFile  = "file"
+
+	// ConfigChatEventLogTTL is how long the events are kept for the users to catch up on after reconnecting, e.g. "168h".
+	ConfigChatEventLogTTL = "chat.event_log_ttl"
+	// ChatSyncBatchSize is how many missed events are read at once while catching up.
+	ChatSyncBatchSize = 100
+	// ChatEventGapTimeout is how long a seq taken for an event may be missing from the log while the next ones
+	// are there. The event is being added meanwhile, after that it is lost.
+	ChatEventGapTimeout = 5 * time.Second
+	// ChatSyncRetryDelay is how long catching up waits for the events being added.
+	ChatSyncRetryDelay = 100 * time.Millisecond
+	// NoSync is the seq of the connection which doesn't catch up on the missed events.
+	NoSync = -1
 )
 
+// LoggedChatEvents are the events the users catch up on after reconnecting.
+var LoggedChatEvents = map[string]bool{
+	SendChat:    true,
+	SendFile:    true,
+	SendSticker: true,
+	ReadChat:    true,
+	EditChat:    true,
+	DeleteChat:  true,
+	ReactChat:   true,
+	UnreactChat: true,
+
+	AddMember:    true,
+	RemoveMember: true,
+	RenameChat:   true,
+	SetChatImage: true,
+	LeaveGroup:   true,
+	AddAdmin:     true,
+	RemoveAdmin:  true,
+}
+
 var Upgrader = websocket.Upgrader{
 	ReadBufferSize:  4096,
 	WriteBufferSize: 4096,
@@ -0,0 +1,15 @@
+package constants
+
+// DurationConfigKeys are the settings of the service given as durations like "15m".
+// The bare numbers set in them are read as seconds, see utils.SecondsToDurations.
+var DurationConfigKeys = []string{
+	ViperAccessTTLKey,
+	ViperRefreshTTLKey,
+	ConfigAuthTimeout,
+	ConfigAuthTokenCacheTTL,
+	ViperAccountPurgeIntervalKey,
+	ViperOAuthStateTTLKey,
+	ViperOAuthTelegramAuthTTLKey,
+	ConfigChatEditTimeLimit,
+	ConfigChatEventLogTTL,
+}
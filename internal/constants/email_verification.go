@@ -0,0 +1,10 @@
+package constants
+
+const (
+	ViperUnverifiedAccessKey = "email_verification.unverified_access"
+
+	// What users who haven't verified their email yet may do.
+	UnverifiedAccessFull     = "full"
+	UnverifiedAccessReadOnly = "read_only" // default
+	UnverifiedAccessNone     = "none"
+)
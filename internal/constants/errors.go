@@ -24,6 +24,14 @@ var (
 	ErrMissingAuthToken  = &CodedError{errors.New("missing authorization token"), http.StatusUnauthorized}
 	ErrMissingAuthCookie = &CodedError{errors.New("missing authorization cookie"), http.StatusUnauthorized}
 
+	ErrMissingRefreshCookie = &CodedError{errors.New("missing refresh cookie"), http.StatusUnauthorized}
+	ErrRefreshTokenInvalid  = &CodedError{errors.New("refresh token is invalid"), http.StatusUnauthorized}
+	ErrRefreshTokenReused   = &CodedError{errors.New("refresh token reuse detected"), http.StatusUnauthorized}
+	ErrSessionRevoked       = &CodedError{errors.New("session is revoked"), http.StatusUnauthorized}
+
+	ErrTwoFactorCodeInvalid   = &CodedError{errors.New("two-factor code is invalid"), http.StatusUnauthorized}
+	ErrTwoFactorTicketInvalid = &CodedError{errors.New("two-factor ticket is invalid or expired"), http.StatusUnauthorized}
+
 	ErrMissingCSRFCookie = &CodedError{errors.New("missing csrf cookie"), http.StatusUnauthorized}
 	ErrCSRFTokenWrong    = &CodedError{errors.New("wrong csrf token in cookie"), http.StatusUnauthorized}
 
@@ -32,11 +40,35 @@ var (
 	ErrAuthTokenInvalid        = &CodedError{errors.New("authorization token is invalid"), http.StatusUnauthorized}
 	ErrUnexpectedSigningMethod = &CodedError{errors.New("unexpected signing method"), http.StatusUnauthorized}
 
-	ErrHashInvalid = &CodedError{errors.New("hash is invalid"), http.StatusUnauthorized}
+	ErrHashInvalid         = &CodedError{errors.New("hash is invalid"), http.StatusUnauthorized}
+	ErrTelegramAuthExpired = &CodedError{errors.New("telegram authorization is expired"), http.StatusUnauthorized}
+
+	ErrOAuthIDTokenInvalid = &CodedError{errors.New("id token of the identity provider is invalid"), http.StatusUnauthorized}
+
+	ErrAPITokenInvalid = &CodedError{errors.New("api token is invalid or expired"), http.StatusUnauthorized}
 
 	// Forbidden
 	ErrAuthTokenExpired = &CodedError{errors.New("authorization token is expired"), http.StatusForbidden}
 	ErrAuthorIDMismatch = &CodedError{errors.New("author id mismatch"), http.StatusForbidden}
+	ErrReauthRequired   = &CodedError{errors.New("log in again to confirm the change"), http.StatusForbidden}
+	ErrEmailNotVerified = &CodedError{errors.New("email is not verified"), http.StatusForbidden}
+
+	ErrInsufficientScope  = &CodedError{errors.New("api token has no scope for the request"), http.StatusForbidden}
+	ErrAPITokenNotAllowed = &CodedError{errors.New("api tokens can't be used for the request"), http.StatusForbidden}
+
+	ErrNotMessageAuthor   = &CodedError{errors.New("only the author may change the message"), http.StatusForbidden}
+	ErrMessageEditExpired = &CodedError{errors.New("the message can't be changed anymore"), http.StatusForbidden}
+
+	// Not Found
+	ErrSessionNotFound = &CodedError{errors.New("session not found"), http.StatusNotFound}
+	ErrAccountDeleted  = &CodedError{errors.New("account is deleted"), http.StatusNotFound}
+
+	ErrIdentityNotFound      = &CodedError{errors.New("identity of the provider is not linked"), http.StatusNotFound}
+	ErrOAuthProviderNotFound = &CodedError{errors.New("identity provider not found"), http.StatusNotFound}
+
+	ErrAPITokenNotFound = &CodedError{errors.New("api token not found"), http.StatusNotFound}
+
+	ErrMessageNotFound = &CodedError{errors.New("message not found"), http.StatusNotFound}
 
 	// Bad Request
 	ErrBindRequest     = &CodedError{errors.New("failed to bind request"), http.StatusBadRequest}
@@ -45,6 +77,27 @@ var (
 	ErrBadJson         = &CodedError{errors.New("bad json request"), http.StatusBadRequest}
 	ErrPassword        = &CodedError{errors.New("error generating hash"), http.StatusBadRequest}
 
+	ErrTwoFactorNotEnrolled = &CodedError{errors.New("two-factor authentication is not enrolled"), http.StatusBadRequest}
+	ErrTwoFactorNotEnabled  = &CodedError{errors.New("two-factor authentication is not enabled"), http.StatusBadRequest}
+
+	ErrResetTokenInvalid = &CodedError{errors.New("password reset token is invalid or expired"), http.StatusBadRequest}
+
+	ErrVerificationTokenInvalid = &CodedError{errors.New("email verification token is invalid or expired"), http.StatusBadRequest}
+
+	ErrAccountDeletionNotDue = &CodedError{errors.New("account deletion is not due yet"), http.StatusBadRequest}
+
+	ErrLastLoginMethod   = &CodedError{errors.New("can't remove the only way to log in"), http.StatusBadRequest}
+	ErrOAuthStateInvalid = &CodedError{errors.New("oauth state is invalid or expired"), http.StatusBadRequest}
+	ErrOAuthDenied       = &CodedError{errors.New("authorization is denied by the identity provider"), http.StatusBadRequest}
+
+	ErrScopeInvalid = &CodedError{errors.New("unknown api token scope"), http.StatusBadRequest}
+
+	ErrPresenceVisibilityInvalid = &CodedError{errors.New("unknown presence visibility"), http.StatusBadRequest}
+
+	ErrReactionInvalid = &CodedError{errors.New("reaction must be an emoji"), http.StatusBadRequest}
+	ErrReplyNotFound   = &CodedError{errors.New("message replied to is not found in the dialog"), http.StatusBadRequest}
+	ErrPageWithBefore  = &CodedError{errors.New("page and before can't be used together"), http.StatusBadRequest}
+
 	// Internal
 	ErrSignToken      = &CodedError{errors.New("failed to sign token"), http.StatusInternalServerError}
 	ErrGenerateUUID   = &CodedError{errors.New("failed to generate UUID"), http.StatusInternalServerError}
@@ -53,6 +106,20 @@ var (
 	// Conflict
 	ErrEmailAlreadyTaken = &CodedError{errors.New("email is taken already by other user"), http.StatusConflict}
 
+	ErrTwoFactorAlreadyEnabled = &CodedError{errors.New("two-factor authentication is already enabled"), http.StatusConflict}
+	ErrEmailAlreadyVerified    = &CodedError{errors.New("email is verified already"), http.StatusConflict}
+	ErrIdentityAlreadyLinked   = &CodedError{errors.New("identity is linked already"), http.StatusConflict}
+
+	// Too Many Requests
+	ErrTooManyLoginAttempts      = &CodedError{errors.New("too many login attempts, try again later"), http.StatusTooManyRequests}
+	ErrVerificationResendTooSoon = &CodedError{errors.New("verification email was sent recently, try again later"), http.StatusTooManyRequests}
+
+	// Bad Gateway
+	ErrOAuthProvider = &CodedError{errors.New("identity provider request failed"), http.StatusBadGateway}
+
+	// Gateway Timeout
+	ErrAuthServiceTimeout = &CodedError{errors.New("auth service did not respond in time"), http.StatusGatewayTimeout}
+
 	// Not Uniq
 	ErrAddYourself         = &CodedError{errors.New("can't make yourself friend"), http.StatusConflict}
 	ErrRequestAlreadyExist = &CodedError{errors.New("your request already was sent"), http.StatusConflict}
@@ -62,33 +129,12 @@ var (
 	// Chat
 	ErrSingleChat         = &CodedError{errors.New("you can't create dialog with no one"), http.StatusBadRequest}
 	ErrDialogAlreadyExist = &CodedError{errors.New("dialog already exist"), http.StatusConflict}
-)
 
-var (
-	ParseError = map[string]*CodedError{
-		ErrMissingAuthToken.Error():        ErrMissingAuthToken,
-		ErrMissingAuthCookie.Error():       ErrMissingAuthCookie,
-		ErrMissingCSRFCookie.Error():       ErrMissingCSRFCookie,
-		ErrCSRFTokenWrong.Error():          ErrCSRFTokenWrong,
-		ErrPasswordMismatch.Error():        ErrPasswordMismatch,
-		ErrAuthTokenInvalid.Error():        ErrAuthTokenInvalid,
-		ErrUnexpectedSigningMethod.Error(): ErrUnexpectedSigningMethod,
-		ErrAuthTokenExpired.Error():        ErrAuthTokenExpired,
-		ErrAuthorIDMismatch.Error():        ErrAuthorIDMismatch,
-		ErrBindRequest.Error():             ErrBindRequest,
-		ErrValidateRequest.Error():         ErrValidateRequest,
-		ErrDBNotFound.Error():              ErrDBNotFound,
-		ErrBadJson.Error():                 ErrBadJson,
-		ErrPassword.Error():                ErrPassword,
-		ErrSignToken.Error():               ErrSignToken,
-		ErrGenerateUUID.Error():            ErrGenerateUUID,
-		ErrParseAuthToken.Error():          ErrParseAuthToken,
-		ErrEmailAlreadyTaken.Error():       ErrEmailAlreadyTaken,
-		ErrAddYourself.Error():             ErrAddYourself,
-		ErrRequestAlreadyExist.Error():     ErrRequestAlreadyExist,
-		ErrAlreadyFriends.Error():          ErrAlreadyFriends,
-		ErrAlreadyFollower.Error():         ErrAlreadyFollower,
-		ErrSingleChat.Error():              ErrSingleChat,
-		ErrDialogAlreadyExist.Error():      ErrDialogAlreadyExist,
-	}
+	ErrNotGroupChat       = &CodedError{errors.New("dialog is not a group chat"), http.StatusBadRequest}
+	ErrNotChatAdmin       = &CodedError{errors.New("only the admins may change the chat"), http.StatusForbidden}
+	ErrLastChatAdmin      = &CodedError{errors.New("the chat must have an admin"), http.StatusBadRequest}
+	ErrNotChatParticipant = &CodedError{errors.New("user is not a participant of the chat"), http.StatusBadRequest}
+	ErrAlreadyParticipant = &CodedError{errors.New("user is a participant of the chat already"), http.StatusConflict}
+	ErrChatEditEmpty      = &CodedError{errors.New("name or image of the chat is required"), http.StatusBadRequest}
+	ErrNoMembersToAdd     = &CodedError{errors.New("at least one member to add is required"), http.StatusBadRequest}
 )
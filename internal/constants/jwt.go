@@ -1,12 +1,39 @@
 package constants
 
+import "time"
+
 const (
-	ViperJWTTTLKey    = "service.jwt_ttl"
-	ViperJWTSecretKey = "service.jwt_secret"
+	ViperAccessTTLKey  = "service.access_ttl"
+	ViperRefreshTTLKey = "service.refresh_ttl"
 
 	ViperCSRFTTLKey    = "service.csrf_ttl"
 	ViperCSRFSecretKey = "service.csrf_secret"
 
 	ConfigAuthPost = "microservice_auth.port"
 	ConfigAuthHost = "microservice_auth.host"
+	// ConfigAuthJWKSURL is where the auth service publishes the public keys of access tokens.
+	ConfigAuthJWKSURL = "microservice_auth.jwks_url"
+	// ConfigAuthTimeout bounds every call to the auth service, e.g. "3s".
+	ConfigAuthTimeout = "microservice_auth.timeout"
+	// ConfigAuthCheckSessions makes the access tokens verified with JWKS looked up in the auth service as well.
+	// Without it the tokens of the revoked sessions are accepted until they expire, and the verification
+	// of the email is taken from the token, which may predate it.
+	ConfigAuthCheckSessions = "microservice_auth.check_sessions"
+
+	// ConfigAuthTokenCacheSize limits how many verified access tokens are cached, 0 disables the cache.
+	ConfigAuthTokenCacheSize = "microservice_auth.token_cache.size"
+	// ConfigAuthTokenCacheTTL is how long a cached token is trusted before it is verified again, e.g. "30s".
+	ConfigAuthTokenCacheTTL = "microservice_auth.token_cache.ttl"
+
+	// ConfigAuthRevocationsType chooses how the revoked tokens reach the caches of the other instances:
+	// memory or redis, the same ones the chat broker uses.
+	ConfigAuthRevocationsType     = "microservice_auth.token_cache.revocations.type"
+	ConfigAuthRevocationsAddress  = "microservice_auth.token_cache.revocations.address"
+	ConfigAuthRevocationsPassword = "microservice_auth.token_cache.revocations.password"
+
+	// AuthRevocationsChannel is the Redis channel the instances tell each other which cached tokens to drop through.
+	AuthRevocationsChannel = "auth:revocations"
+
+	DefaultAuthTimeout       = 3 * time.Second
+	DefaultAuthTokenCacheTTL = 30 * time.Second
 )
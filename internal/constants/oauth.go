@@ -0,0 +1,29 @@
+package constants
+
+import "time"
+
+const (
+	// ViperOAuthProvidersKey holds the identity providers by their names. See oauth.LoadProviders.
+	ViperOAuthProvidersKey = "oauth.providers"
+
+	ViperOAuthStateSecretKey = "oauth.state_secret"
+	ViperOAuthStateTTLKey    = "oauth.state_ttl"
+
+	// ViperOAuthCallbackBaseURLKey is the public url of the oauth API, the providers redirect back to <url>/<provider>/callback.
+	ViperOAuthCallbackBaseURLKey = "oauth.callback_base_url"
+	// ViperOAuthRedirectURLKey is where the user is sent after the external login.
+	ViperOAuthRedirectURLKey = "oauth.redirect_url"
+
+	// ViperOAuthTelegramTokenKey is the token of the bot of the Telegram login widget, the login is disabled without it.
+	ViperOAuthTelegramTokenKey = "oauth.telegram.token"
+	// ViperOAuthTelegramAuthTTLKey is how long the data signed by Telegram is accepted for, e.g. "5m".
+	ViperOAuthTelegramAuthTTLKey = "oauth.telegram.auth_ttl"
+
+	// TelegramProvider is the name of the Telegram identities.
+	TelegramProvider = "telegram"
+
+	// DefaultOAuthStateTTL is how long the user has to authorize at the provider.
+	DefaultOAuthStateTTL = 10 * time.Minute
+	// DefaultTelegramAuthTTL is how long the user has to come back from the Telegram login widget.
+	DefaultTelegramAuthTTL = 5 * time.Minute
+)
@@ -0,0 +1,16 @@
+package constants
+
+import "time"
+
+const (
+	// Who sees whether the user is online and when they were last seen.
+	PresenceVisibilityEveryone = "everyone"
+	PresenceVisibilityFriends  = "friends"
+	PresenceVisibilityNobody   = "nobody"
+
+	// PresenceHeartbeat is how often an open websocket tells that its user is still online.
+	PresenceHeartbeat = time.Minute
+	// PresenceTimeout is how long the user stays online without a heartbeat,
+	// it covers the instances which stopped without closing their connections.
+	PresenceTimeout = 3 * PresenceHeartbeat
+)
@@ -8,19 +8,28 @@ import (
 
 const (
 	HeaderKeyUserID       = "User-Id"
+	HeaderKeySessionID    = "Session-Id"
 	HeaderKeyRequestID    = "X-Request-Id"
 	HeaderKeyUserAuthType = "User-Auth-Type"
 )
 
 const (
-	CookieKeyAuthToken = "Auth-Token"
-	CookieKeyCSRFToken = "X-CSRF-Token"
+	CookieKeyAuthToken    = "Auth-Token"
+	CookieKeyRefreshToken = "Refresh-Token"
+	CookieKeyCSRFToken    = "X-CSRF-Token"
+	CookieKeyOAuthState   = "OAuth-State"
 )
 
 const (
 	FrameRepresentativeCheckRegisterInitToken = "3"
 )
 
+const (
+	// ViperTrustedProxiesKey lists the CIDRs of the reverse proxies whose X-Real-IP and X-Forwarded-For
+	// headers tell the ip address of the client. The headers of anyone else are ignored.
+	ViperTrustedProxiesKey = "service.trusted_proxies"
+)
+
 const (
 	QueryDelimiter = ","
 )
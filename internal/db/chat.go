@@ -9,7 +9,6 @@ import (
 	"github.com/microcosm-cc/bluemonday"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ChatRepository interface {
@@ -17,9 +16,15 @@ type ChatRepository interface {
 	IsDialogExist(ctx context.Context, userID1 string, userID2 string) (string, error)
 	CreateDialog(ctx context.Context, userID string, name string, authorIDs []string) (*core.Dialog, error)
 	IsChatExist(ctx context.Context, dialogID string) error
-	SendMessage(ctx context.Context, message core.Message, dialogID string) error
-	ReadMessage(ctx context.Context, userID string, messageID string, dialogID string) error
 	GetDialogByID(ctx context.Context, dialogID string) (*core.Dialog, error)
+	LeaveDialogs(ctx context.Context, userID string) error
+
+	AddParticipants(ctx context.Context, dialogID string, userIDs []string) error
+	RemoveParticipant(ctx context.Context, dialogID string, userID string) error
+	EditDialog(ctx context.Context, dialogID string, name string, image string) error
+	SetAdmins(ctx context.Context, dialogID string, adminIDs []string) error
+
+	GetDialogsOverview(ctx context.Context, userID string, skip int64, limit int64) (*core.DialogsOverview, error)
 }
 
 type chatRepositoryImpl struct {
@@ -27,8 +32,13 @@ type chatRepositoryImpl struct {
 	coll *mongo.Collection
 }
 
+// NewChatRepository creates the repository of dialogs and the index the dialogs of a user are found by.
 func NewChatRepository(db *mongo.Database) (*chatRepositoryImpl, error) {
-	return &chatRepositoryImpl{db: db, coll: db.Collection("chats")}, nil
+	repo := &chatRepositoryImpl{db: db, coll: db.Collection("chats")}
+	if err := repo.createIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+	return repo, nil
 }
 
 // NewUserRepositoryTest for Tests (bad)
@@ -36,6 +46,11 @@ func NewChatRepositoryTest(collection *mongo.Collection) (*chatRepositoryImpl, e
 	return &chatRepositoryImpl{coll: collection}, nil
 }
 
+func (repo *chatRepositoryImpl) createIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "participants", Value: 1}}})
+	return err
+}
+
 func (repo *chatRepositoryImpl) IsUniqDialog(ctx context.Context, userID1 string, userID2 string) error {
 	filter := bson.M{"participants": bson.D{{Key: "$all", Value: bson.A{userID1, userID2}}}}
 	if err := repo.coll.FindOne(ctx, filter).Err(); err != mongo.ErrNoDocuments {
@@ -87,54 +102,175 @@ func (repo *chatRepositoryImpl) IsChatExist(ctx context.Context, dialogID string
 	return nil
 }
 
-func (repo *chatRepositoryImpl) SendMessage(ctx context.Context, message core.Message, dialogID string) error {
-	filter := bson.M{"_id": dialogID}
-	update := bson.M{"$push": bson.D{{Key: "messages", Value: message}}}
-	if _, err := repo.coll.UpdateOne(ctx, filter, update); err != nil {
-		return err
+func (repo *chatRepositoryImpl) GetDialogByID(ctx context.Context, DialogID string) (*core.Dialog, error) {
+	dialog := new(core.Dialog)
+	filter := bson.M{"_id": DialogID}
+	err := repo.coll.FindOne(ctx, filter).Decode(dialog)
+
+	// Sanitize
+	p := bluemonday.UGCPolicy()
+
+	dialog.Name = p.Sanitize(dialog.Name)
+	dialog.Image = p.Sanitize(dialog.Image)
+
+	for i := range dialog.Participants {
+		dialog.Participants[i] = p.Sanitize(dialog.Participants[i])
 	}
-	return nil
-}
 
-func (repo *chatRepositoryImpl) ReadMessage(ctx context.Context, userID string, messageID string, dialogID string) error {
+	return dialog, wrapError(err)
+}
 
-	filter := bson.M{"_id": dialogID}
+// LeaveDialogs removes the user from participants and admins of all dialogs, the messages stay.
+func (repo *chatRepositoryImpl) LeaveDialogs(ctx context.Context, userID string) error {
+	filter := bson.M{"participants": userID}
+	_, err := repo.coll.UpdateMany(ctx, filter, bson.M{"$pull": bson.M{"participants": userID, "admin_ids": userID}})
+	return err
+}
 
-	update := bson.M{"$set": bson.D{{Key: "messages.$[n1].is_participants_read.$[n2].is_read", Value: true}}}
-	var s []interface{}
-	ch1 := bson.D{{Key: "n1._id", Value: messageID}}
-	ch2 := bson.D{{Key: "n2._id", Value: userID}}
-	s = append(s, ch1)
-	s = append(s, ch2)
-	arrayFilter := options.FindOneAndUpdateOptions{ArrayFilters: &options.ArrayFilters{Filters: s}}
+// GetDialogsOverview returns a page of the dialogs of the user, the ones with the latest activity first,
+// with their last messages, the numbers of the messages the user hasn't read and the other participants
+// of the personal dialogs. The dialogs are read at once by one aggregation. If limit is 0, all of them are returned.
+func (repo *chatRepositoryImpl) GetDialogsOverview(ctx context.Context, userID string, skip int64, limit int64) (*core.DialogsOverview, error) {
+	// The messages the user sees.
+	visible := func(filter bson.M) bson.M {
+		filter["$expr"] = bson.M{"$eq": bson.A{"$dialog_id", "$$dialog_id"}}
+		filter["deleted"] = bson.M{"$ne": true}
+		filter["deleted_for"] = bson.M{"$ne": userID}
+		return bson.M{"$match": filter}
+	}
 
-	if err := repo.coll.FindOneAndUpdate(ctx, filter, update, &arrayFilter).Err(); err != nil {
-		return wrapError(err)
+	page := bson.A{bson.M{"$sort": bson.D{{Key: "last_activity", Value: -1}, {Key: "_id", Value: -1}}}}
+	if skip > 0 {
+		page = append(page, bson.M{"$skip": skip})
+	}
+	if limit > 0 {
+		page = append(page, bson.M{"$limit": limit})
 	}
+	// The other participant is looked up for the personal dialogs of the page only.
+	page = append(page,
+		bson.M{"$lookup": bson.M{
+			"from": "users",
+			"let":  bson.M{"participants": "$participants", "group": bson.M{"$ifNull": bson.A{"$group", false}}},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+					bson.M{"$not": bson.A{"$$group"}},
+					bson.M{"$lte": bson.A{bson.M{"$size": "$$participants"}, 2}},
+					bson.M{"$in": bson.A{"$_id", "$$participants"}},
+					bson.M{"$ne": bson.A{"$_id", userID}},
+				}}}},
+				bson.M{"$project": bson.M{"name": 1, "images": 1, "presence_visibility": 1}},
+			},
+			"as": "interlocutor",
+		}},
+		bson.M{"$addFields": bson.M{"interlocutor": bson.M{"$arrayElemAt": bson.A{"$interlocutor", 0}}}},
+	)
 
-	return nil
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"participants": userID}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "messages",
+			"let":  bson.M{"dialog_id": "$_id"},
+			"pipeline": bson.A{
+				visible(bson.M{}),
+				bson.M{"$sort": bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+				bson.M{"$limit": 1},
+			},
+			"as": "last_message",
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "messages",
+			"let":  bson.M{"dialog_id": "$_id"},
+			"pipeline": bson.A{
+				visible(bson.M{"is_participants_read": bson.M{"$elemMatch": bson.M{"_id": userID, "is_read": false}}}),
+				bson.M{"$count": "n"},
+			},
+			"as": "unread",
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"last_message": bson.M{"$arrayElemAt": bson.A{"$last_message", 0}},
+			"unread":       bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$unread.n", 0}}, 0}},
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"last_activity": bson.M{"$ifNull": bson.A{"$last_message.created_at", "$created_at"}},
+		}}},
+		{{Key: "$facet", Value: bson.M{
+			"dialogs": page,
+			"total":   bson.A{bson.M{"$count": "n"}},
+			"unread":  bson.A{bson.M{"$group": bson.M{"_id": nil, "n": bson.M{"$sum": "$unread"}}}},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"dialogs": 1,
+			"total":   bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$total.n", 0}}, 0}},
+			"unread":  bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$unread.n", 0}}, 0}},
+		}}},
+	}
 
-}
+	cursor, err := repo.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
 
-func (repo *chatRepositoryImpl) GetDialogByID(ctx context.Context, DialogID string) (*core.Dialog, error) {
-	dialog := new(core.Dialog)
-	filter := bson.M{"_id": DialogID}
-	err := repo.coll.FindOne(ctx, filter).Decode(dialog)
+	var result []core.DialogsOverview
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+	overview := &core.DialogsOverview{Dialogs: []core.DialogOverview{}}
+	if len(result) != 0 && result[0].Dialogs != nil {
+		overview = &result[0]
+	}
 
 	// Sanitize
 	p := bluemonday.UGCPolicy()
+	for i := range overview.Dialogs {
+		dialog := &overview.Dialogs[i]
+		dialog.Name = p.Sanitize(dialog.Name)
+		dialog.Image = p.Sanitize(dialog.Image)
+		if dialog.LastMessage != nil {
+			dialog.LastMessage.Body = p.Sanitize(dialog.LastMessage.Body)
+		}
+	}
+	return overview, nil
+}
 
-	dialog.Name = p.Sanitize(dialog.Name)
+// AddParticipants adds the users to the group chat, the participants are kept once.
+func (repo *chatRepositoryImpl) AddParticipants(ctx context.Context, dialogID string, userIDs []string) error {
+	update := bson.M{"$addToSet": bson.M{"participants": bson.M{"$each": userIDs}}, "$set": bson.M{"group": true}}
+	return repo.updateDialog(ctx, dialogID, update)
+}
 
-	for i := range dialog.Messages {
-		dialog.Messages[i].Body = p.Sanitize(dialog.Messages[i].Body)
-	}
+// RemoveParticipant removes the user from the group chat and its admins.
+// The chat stays a group chat even if two participants are left.
+func (repo *chatRepositoryImpl) RemoveParticipant(ctx context.Context, dialogID string, userID string) error {
+	update := bson.M{"$pull": bson.M{"participants": userID, "admin_ids": userID}, "$set": bson.M{"group": true}}
+	return repo.updateDialog(ctx, dialogID, update)
+}
 
-	for i := range dialog.Participants {
-		dialog.Participants[i] = p.Sanitize(dialog.Participants[i])
+// EditDialog sets the name and the image of the group chat, the empty ones are left as they are.
+func (repo *chatRepositoryImpl) EditDialog(ctx context.Context, dialogID string, name string, image string) error {
+	set := bson.M{}
+	if len(name) != 0 {
+		set["name"] = name
 	}
+	if len(image) != 0 {
+		set["image"] = image
+	}
+	return repo.updateDialog(ctx, dialogID, bson.M{"$set": set})
+}
 
-	return dialog, wrapError(err)
+func (repo *chatRepositoryImpl) SetAdmins(ctx context.Context, dialogID string, adminIDs []string) error {
+	return repo.updateDialog(ctx, dialogID, bson.M{"$set": bson.M{"admin_ids": adminIDs}})
+}
+
+func (repo *chatRepositoryImpl) updateDialog(ctx context.Context, dialogID string, update bson.M) error {
+	res, err := repo.coll.UpdateByID(ctx, dialogID, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return constants.ErrDBNotFound
+	}
+	return nil
 }
 
 func (repo *chatRepositoryImpl) InitDialog(dialog *core.Dialog, userID string, authorIDs []string, name string) error {
@@ -147,5 +283,10 @@ func (repo *chatRepositoryImpl) InitDialog(dialog *core.Dialog, userID string, a
 	dialog.Name = name
 	dialog.Participants = append(dialog.Participants, userID)
 	dialog.Participants = append(dialog.Participants, authorIDs...)
+	// The creator administers the group chat.
+	if len(authorIDs) > 1 {
+		dialog.Group = true
+		dialog.AdminIDs = []string{userID}
+	}
 	return nil
 }
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ChatEventRepository interface {
+	NextSeqs(ctx context.Context, userIDs []string, at int64) (map[string]int64, error)
+	GetCounter(ctx context.Context, userID string) (*core.ChatEventCounter, error)
+	AddEvents(ctx context.Context, events []core.ChatEvent) error
+	GetEvents(ctx context.Context, userID string, since int64, limit int64) ([]core.ChatEvent, error)
+	DeleteUserEvents(ctx context.Context, userID string) error
+}
+
+type chatEventRepositoryImpl struct {
+	db       *mongo.Database
+	coll     *mongo.Collection
+	counters *mongo.Collection
+}
+
+// NewChatEventRepository creates the repository of the event log of the users, the events expire by the TTL index.
+func NewChatEventRepository(db *mongo.Database) (*chatEventRepositoryImpl, error) {
+	repo := &chatEventRepositoryImpl{db: db, coll: db.Collection("chat_events"), counters: db.Collection("chat_event_counters")}
+	if err := repo.createIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// NewChatEventRepositoryTest for Tests (bad)
+func NewChatEventRepositoryTest(collection *mongo.Collection) (*chatEventRepositoryImpl, error) {
+	return &chatEventRepositoryImpl{coll: collection, counters: collection}, nil
+}
+
+func (repo *chatEventRepositoryImpl) createIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "seq", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	return err
+}
+
+// NextSeqs takes the next seq of the events of every user, the counter of the user is created with its first event.
+func (repo *chatEventRepositoryImpl) NextSeqs(ctx context.Context, userIDs []string, at int64) (map[string]int64, error) {
+	update := bson.M{"$inc": bson.M{"seq": 1}, "$set": bson.M{"updated_at": at}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	seqs := make(map[string]int64, len(userIDs))
+	for _, userID := range userIDs {
+		counter := new(core.ChatEventCounter)
+		if err := repo.counters.FindOneAndUpdate(ctx, bson.M{"_id": userID}, update, opts).Decode(counter); err != nil {
+			return nil, err
+		}
+		seqs[userID] = counter.Seq
+	}
+	return seqs, nil
+}
+
+// GetCounter returns the last seq taken for the events of the user.
+func (repo *chatEventRepositoryImpl) GetCounter(ctx context.Context, userID string) (*core.ChatEventCounter, error) {
+	counter := new(core.ChatEventCounter)
+	if err := repo.counters.FindOne(ctx, bson.M{"_id": userID}).Decode(counter); err != nil {
+		return nil, wrapError(err)
+	}
+	return counter, nil
+}
+
+func (repo *chatEventRepositoryImpl) AddEvents(ctx context.Context, events []core.ChatEvent) error {
+	documents := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		documents = append(documents, event)
+	}
+	_, err := repo.coll.InsertMany(ctx, documents)
+	return err
+}
+
+// GetEvents returns up to limit events of the user after the seq since, the oldest first.
+func (repo *chatEventRepositoryImpl) GetEvents(ctx context.Context, userID string, since int64, limit int64) ([]core.ChatEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "seq", Value: 1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	cursor, err := repo.coll.Find(ctx, bson.M{"user_id": userID, "seq": bson.M{"$gt": since}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []core.ChatEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// DeleteUserEvents deletes the event log of the user with its counter.
+func (repo *chatEventRepositoryImpl) DeleteUserEvents(ctx context.Context, userID string) error {
+	if _, err := repo.coll.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return err
+	}
+	_, err := repo.counters.DeleteOne(ctx, bson.M{"_id": userID})
+	return err
+}
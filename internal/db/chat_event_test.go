@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+	"time"
+)
+
+func TestNextSeqs(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		eventCollection, _ := NewChatEventRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "_id", Value: "1"}, {Key: "seq", Value: int64(5)}}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "_id", Value: "2"}, {Key: "seq", Value: int64(1)}}}),
+		)
+		seqs, err := eventCollection.NextSeqs(context.Background(), []string{"1", "2"}, 100)
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]int64{"1": 5, "2": 1}, seqs)
+
+		command := mt.GetStartedEvent().Command
+		assert.Equal(t, "findAndModify", command.Index(0).Key())
+		assert.True(t, command.Lookup("upsert").Boolean(), "the first event of the user must create the counter")
+		assert.True(t, command.Lookup("new").Boolean(), "the seq taken must be returned")
+	})
+
+	mt.Run("error", func(mt *mtest.T) {
+		eventCollection, _ := NewChatEventRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "_id", Value: "1"}, {Key: "seq", Value: int64(5)}}}),
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 1, Message: "error"}),
+		)
+		_, err := eventCollection.NextSeqs(context.Background(), []string{"1", "2"}, 100)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGetCounter(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("not found", func(mt *mtest.T) {
+		eventCollection, _ := NewChatEventRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch))
+		_, err := eventCollection.GetCounter(context.Background(), "1")
+		assert.ErrorIs(t, err, constants.ErrDBNotFound)
+	})
+}
+
+func TestAddEvents(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		eventCollection, _ := NewChatEventRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		err := eventCollection.AddEvents(context.Background(), []core.ChatEvent{
+			{UserID: "1", Seq: 1, DialogID: "d1", Event: constants.SendChat, ExpiresAt: time.Now()},
+			{UserID: "2", Seq: 7, DialogID: "d1", Event: constants.SendChat, ExpiresAt: time.Now()},
+		})
+		assert.Nil(t, err)
+	})
+}
+
+func TestGetEvents(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		eventCollection, _ := NewChatEventRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch,
+			bson.D{{Key: "user_id", Value: "1"}, {Key: "seq", Value: int64(3)}, {Key: "event", Value: constants.SendChat}},
+			bson.D{{Key: "user_id", Value: "1"}, {Key: "seq", Value: int64(4)}, {Key: "event", Value: constants.ReadChat}},
+		))
+		events, err := eventCollection.GetEvents(context.Background(), "1", 2, 10)
+		assert.Nil(t, err)
+		assert.Len(t, events, 2)
+		assert.Equal(t, int64(3), events[0].Seq)
+
+		filter := mt.GetStartedEvent().Command.Lookup("filter").Document()
+		assert.Equal(t, int64(2), filter.Lookup("seq", "$gt").Int64())
+	})
+}
+
+func TestDeleteUserEvents(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		eventCollection, _ := NewChatEventRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+		assert.Nil(t, eventCollection.DeleteUserEvents(context.Background(), "1"))
+	})
+}
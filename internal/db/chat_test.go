@@ -56,7 +56,6 @@ func TestIsChatExist(t *testing.T) {
 			{Key: "_id", Value: expectedDialog.ID},
 			{Key: "name", Value: expectedDialog.Name},
 			{Key: "participants", Value: expectedDialog.Participants},
-			{Key: "messages", Value: expectedDialog.Messages},
 			{Key: "created_at", Value: expectedDialog.CreatedAt},
 		}))
 		ctx := context.Background()
@@ -86,7 +85,6 @@ func TestIsUniqDialog(t *testing.T) {
 			{Key: "_id", Value: expectedDialog.ID},
 			{Key: "name", Value: expectedDialog.Name},
 			{Key: "participants", Value: expectedDialog.Participants},
-			{Key: "messages", Value: expectedDialog.Messages},
 			{Key: "created_at", Value: expectedDialog.CreatedAt},
 		}))
 		ctx := context.Background()
@@ -104,42 +102,6 @@ func TestIsUniqDialog(t *testing.T) {
 	})
 }
 
-func TestSendMessage(t *testing.T) {
-	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
-	defer mt.Close()
-
-	mt.Run("success", func(mt *mtest.T) {
-		chatCollection, _ := NewChatRepositoryTest(mt.Coll)
-
-		mt.AddMockResponses(mtest.CreateSuccessResponse())
-		dialog := TestDialog(t)
-		message := TestMessage(t)
-		ctx := context.Background()
-
-		err := chatCollection.SendMessage(ctx, *message, dialog.ID)
-
-		assert.Nil(t, err)
-	})
-}
-
-func TestReadMessage(t *testing.T) {
-	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
-	defer mt.Close()
-
-	mt.Run("not success", func(mt *mtest.T) {
-		chatCollection, _ := NewChatRepositoryTest(mt.Coll)
-
-		dialog := TestDialog(t)
-		message := TestMessage(t)
-		mt.AddMockResponses(mtest.CreateSuccessResponse())
-		ctx := context.Background()
-
-		err := chatCollection.ReadMessage(ctx, message.IsRead[0].Participant, message.ID, dialog.ID)
-
-		assert.Equal(t, constants.ErrDBNotFound, err)
-	})
-}
-
 func TestGetDialogByID(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 	defer mt.Close()
@@ -152,7 +114,6 @@ func TestGetDialogByID(t *testing.T) {
 			{Key: "_id", Value: expectedDialog.ID},
 			{Key: "name", Value: expectedDialog.Name},
 			{Key: "participants", Value: expectedDialog.Participants},
-			{Key: "messages", Value: expectedDialog.Messages},
 			{Key: "created_at", Value: expectedDialog.CreatedAt},
 		}))
 		ctx := context.Background()
@@ -189,5 +150,120 @@ func TestInitDialog(t *testing.T) {
 		assert.NotNil(t, dialog.CreatedAt)
 		assert.NotNil(t, dialog.Participants)
 		assert.NotNil(t, dialog.Name)
+		assert.True(t, dialog.Group)
+		assert.Equal(t, []string{TestUser(t).ID}, dialog.AdminIDs, "the creator administers the group chat")
+	})
+}
+
+func TestLeaveDialogs(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		chatCollection, _ := NewChatRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}))
+		err := chatCollection.LeaveDialogs(context.Background(), "123")
+		assert.Nil(t, err)
+
+		pull := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u", "$pull").Document()
+		assert.Equal(t, "123", pull.Lookup("admin_ids").StringValue(), "the user no longer administers the group chats")
+	})
+}
+
+func TestGroupChat(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("add participants", func(mt *mtest.T) {
+		chatCollection, _ := NewChatRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := chatCollection.AddParticipants(context.Background(), "12345678", []string{"12345673"})
+		assert.Nil(t, err)
+	})
+
+	mt.Run("remove participant of unknown dialog", func(mt *mtest.T) {
+		chatCollection, _ := NewChatRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}))
+		err := chatCollection.RemoveParticipant(context.Background(), "12345678", "12345673")
+		assert.Equal(t, constants.ErrDBNotFound, err)
+	})
+
+	mt.Run("rename", func(mt *mtest.T) {
+		chatCollection, _ := NewChatRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := chatCollection.EditDialog(context.Background(), "12345678", "friends", "")
+		assert.Nil(t, err)
+
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u").Document()
+		set := update.Lookup("$set").Document()
+		assert.Equal(t, "friends", set.Lookup("name").StringValue())
+		assert.Nil(t, set.Lookup("image").Value, "the empty image must be left as it is")
+	})
+
+	mt.Run("set admins", func(mt *mtest.T) {
+		chatCollection, _ := NewChatRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := chatCollection.SetAdmins(context.Background(), "12345678", []string{"12345671", "12345672"})
+		assert.Nil(t, err)
+	})
+}
+
+func TestGetDialogsOverview(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		chatCollection, _ := NewChatRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch, bson.D{
+			{Key: "dialogs", Value: bson.A{bson.D{
+				{Key: "_id", Value: "12345678"},
+				{Key: "participants", Value: bson.A{"12345671", "12345672"}},
+				{Key: "last_message", Value: bson.D{
+					{Key: "_id", Value: "m1"},
+					{Key: "body", Value: "<script>alert(1)</script>hi"},
+					{Key: "created_at", Value: int64(200)},
+				}},
+				{Key: "unread", Value: int32(2)},
+				{Key: "last_activity", Value: int64(200)},
+				{Key: "interlocutor", Value: bson.D{
+					{Key: "_id", Value: "12345672"},
+					{Key: "name", Value: bson.D{{Key: "first", Value: "Bob"}}},
+				}},
+			}}},
+			{Key: "total", Value: int32(3)},
+			{Key: "unread", Value: int32(5)},
+		}))
+		overview, err := chatCollection.GetDialogsOverview(context.Background(), "12345671", 10, 10)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3), overview.Total)
+		assert.Equal(t, int64(5), overview.Unread)
+		assert.Len(t, overview.Dialogs, 1)
+		assert.Equal(t, int64(2), overview.Dialogs[0].Unread)
+		assert.Equal(t, "hi", overview.Dialogs[0].LastMessage.Body)
+		assert.Equal(t, "Bob", overview.Dialogs[0].Interlocutor.Name.First)
+
+		pipeline := mt.GetStartedEvent().Command.Lookup("pipeline").Array()
+		match := pipeline.Index(0).Value().Document().Lookup("$match").Document()
+		assert.Equal(t, "12345671", match.Lookup("participants").StringValue(), "the dialogs of the user must be read by one aggregation")
+	})
+
+	mt.Run("no dialogs", func(mt *mtest.T) {
+		chatCollection, _ := NewChatRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch, bson.D{
+			{Key: "dialogs", Value: bson.A{}},
+			{Key: "total", Value: int32(0)},
+			{Key: "unread", Value: int32(0)},
+		}))
+		overview, err := chatCollection.GetDialogsOverview(context.Background(), "12345671", 0, 0)
+		assert.Nil(t, err)
+		assert.NotNil(t, overview.Dialogs)
+		assert.Empty(t, overview.Dialogs)
 	})
 }
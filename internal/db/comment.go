@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type CommentRepository interface {
@@ -16,6 +17,8 @@ type CommentRepository interface {
 	GetCommentByID(ctx context.Context, commentID string) (*core.Comment, error)
 	EditComment(ctx context.Context, comment *core.Comment) (*core.Comment, error)
 	DeleteComment(ctx context.Context, commentID string) error
+	DeleteComments(ctx context.Context, commentIDs []string) error
+	GetUserCommentIDs(ctx context.Context, userID string) ([]string, error)
 }
 
 type commentRepositoryImpl struct {
@@ -64,6 +67,35 @@ func (repo *commentRepositoryImpl) DeleteComment(ctx context.Context, commentID
 	return err
 }
 
+func (repo *commentRepositoryImpl) DeleteComments(ctx context.Context, commentIDs []string) error {
+	if len(commentIDs) == 0 {
+		return nil
+	}
+	filter := bson.M{"_id": bson.M{"$in": commentIDs}}
+	_, err := repo.coll.DeleteMany(ctx, filter)
+	return err
+}
+
+// GetUserCommentIDs returns ids of all comments written by the user.
+func (repo *commentRepositoryImpl) GetUserCommentIDs(ctx context.Context, userID string) ([]string, error) {
+	filter := bson.M{"author_id": userID}
+	cursor, err := repo.coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []core.Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	return ids, nil
+}
+
 func (repo *commentRepositoryImpl) InitComment(comment *core.Comment) error {
 	uid, err := core.GenUUID()
 	if err != nil {
@@ -122,3 +122,40 @@ func TestInitComment(t *testing.T) {
 		assert.NotNil(t, comment.CreatedAt)
 	})
 }
+
+func TestGetUserCommentIDs(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		commentCollection, _ := NewCommentRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "1"}},
+			bson.D{{Key: "_id", Value: "2"}},
+		))
+		ids, err := commentCollection.GetUserCommentIDs(context.Background(), "123")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"1", "2"}, ids)
+	})
+}
+
+func TestDeleteComments(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		commentCollection, _ := NewCommentRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		err := commentCollection.DeleteComments(context.Background(), []string{"1", "2"})
+		assert.Nil(t, err)
+	})
+
+	mt.Run("nothing to delete", func(mt *mtest.T) {
+		commentCollection, _ := NewCommentRepositoryTest(mt.Coll)
+
+		err := commentCollection.DeleteComments(context.Background(), nil)
+		assert.Nil(t, err)
+	})
+}
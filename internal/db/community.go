@@ -27,6 +27,8 @@ type CommunityRepository interface {
 	AddFollower(ctx context.Context, communityID string, userID string) error
 	DeleteFollower(ctx context.Context, communityID string, userID string) error
 	DeleteAdmin(ctx context.Context, communityID string, userID string) error
+	AddAdmin(ctx context.Context, communityID string, userID string) error
+	RemoveMember(ctx context.Context, communityID string, userID string) error
 
 	CommunityAddPost(ctx context.Context, communityID string, postID string) error
 	CommunityDeletePost(ctx context.Context, communityID string, postID string) error
@@ -110,6 +112,22 @@ func (repo *comunnityRepositoryImpl) DeleteAdmin(ctx context.Context, communityI
 	return nil
 }
 
+func (repo *comunnityRepositoryImpl) AddAdmin(ctx context.Context, communityID string, userID string) error {
+	if _, err := repo.coll.UpdateByID(ctx, communityID, bson.M{"$addToSet": bson.M{"admins": userID}}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RemoveMember removes the user from both followers and admins, it is no-op if the user is neither.
+func (repo *comunnityRepositoryImpl) RemoveMember(ctx context.Context, communityID string, userID string) error {
+	update := bson.M{"$pull": bson.M{"followers": userID, "admins": userID}}
+	if _, err := repo.coll.UpdateByID(ctx, communityID, update); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (repo *comunnityRepositoryImpl) DeleteCommunity(ctx context.Context, communityID string) error {
 	filter := bson.M{"_id": communityID}
 	_, err := repo.coll.DeleteOne(ctx, filter)
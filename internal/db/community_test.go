@@ -323,3 +323,29 @@ func TestInitCommunity(t *testing.T) {
 		assert.NotNil(t, community.CreatedAt)
 	})
 }
+
+func TestRemoveMember(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		communityCollection, _ := NewCommunityRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := communityCollection.RemoveMember(context.Background(), "1", "123")
+		assert.Nil(t, err)
+	})
+}
+
+func TestAddAdmin(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		communityCollection, _ := NewCommunityRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := communityCollection.AddAdmin(context.Background(), "1", "123")
+		assert.Nil(t, err)
+	})
+}
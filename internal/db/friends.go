@@ -24,6 +24,8 @@ type FriendsRepository interface {
 	GetFriends(ctx context.Context, userID string) ([]string, error)
 	GetIncomingRequests(ctx context.Context, userID string) ([]string, error)
 	GetOutcomingRequests(ctx context.Context, userID string) ([]string, error)
+
+	DeleteUserFriends(ctx context.Context, userID string) error
 }
 
 type friendsRepositoryImpl struct {
@@ -148,6 +150,22 @@ func (repo *friendsRepositoryImpl) GetOutcomingRequests(ctx context.Context, use
 	return friends.OutcomingRequests, wrapError(err)
 }
 
+// DeleteUserFriends removes the user's friend list and the user from friends and requests of everyone else.
+func (repo *friendsRepositoryImpl) DeleteUserFriends(ctx context.Context, userID string) error {
+	filter := bson.M{"$or": []bson.M{
+		{"friends": userID},
+		{"incoming_requests": userID},
+		{"outcoming_requests": userID},
+	}}
+	update := bson.M{"$pull": bson.M{"friends": userID, "incoming_requests": userID, "outcoming_requests": userID}}
+	if _, err := repo.coll.UpdateMany(ctx, filter, update); err != nil {
+		return err
+	}
+
+	_, err := repo.coll.DeleteOne(ctx, bson.M{"_id": userID})
+	return err
+}
+
 func NewFriendsRepository(db *mongo.Database) (*friendsRepositoryImpl, error) {
 	return &friendsRepositoryImpl{db: db, coll: db.Collection("friends")}, nil
 }
@@ -292,3 +292,24 @@ func TestDeleteFriend(t *testing.T) {
 //		assert.Equal(t, testNullFriends.Friends, requests)
 //	})
 //}
+
+func TestDeleteUserFriends(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		friendsCollection, _ := NewFriendsRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+		err := friendsCollection.DeleteUserFriends(context.Background(), "1")
+		assert.Nil(t, err)
+	})
+
+	mt.Run("update error", func(mt *mtest.T) {
+		friendsCollection, _ := NewFriendsRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 1, Message: "error"}))
+		err := friendsCollection.DeleteUserFriends(context.Background(), "1")
+		assert.NotNil(t, err)
+	})
+}
@@ -16,6 +16,7 @@ type LikeRepository interface {
 	GetLikeBySubjectID(ctx context.Context, subjectID string) (*core.Like, error)
 	IncreaseLike(ctx context.Context, subjectID string, userID string) error
 	ReduceLike(ctx context.Context, subjectID string, userID string) error
+	DeleteUserLikes(ctx context.Context, userID string) error
 }
 
 type likeRepositoryImpl struct {
@@ -83,6 +84,14 @@ func (repo *likeRepositoryImpl) ReduceLike(ctx context.Context, subjectID string
 	return nil
 }
 
+// DeleteUserLikes takes back all likes the user has put.
+func (repo *likeRepositoryImpl) DeleteUserLikes(ctx context.Context, userID string) error {
+	filter := bson.M{"user_ids": userID}
+	update := bson.M{"$pull": bson.M{"user_ids": userID}, "$inc": bson.M{"amount": -1}}
+	_, err := repo.coll.UpdateMany(ctx, filter, update)
+	return err
+}
+
 func (repo *likeRepositoryImpl) InitLike(like *core.Like) error {
 	uid, err := core.GenUUID()
 	if err != nil {
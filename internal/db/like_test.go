@@ -120,3 +120,16 @@ func TestInitLike(t *testing.T) {
 		assert.NotNil(t, like.CreatedAt)
 	})
 }
+
+func TestDeleteUserLikes(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		likeCollection, _ := NewLikeRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}))
+		err := likeCollection.DeleteUserLikes(context.Background(), "123")
+		assert.Nil(t, err)
+	})
+}
@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/common"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"github.com/microcosm-cc/bluemonday"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MessageRepository interface {
+	CreateMessage(ctx context.Context, message *core.Message) error
+	GetMessage(ctx context.Context, dialogID string, messageID string) (*core.Message, error)
+	GetMessages(ctx context.Context, dialogID string, userID string, before string, limit int64) ([]core.Message, error)
+	GetMessagesPage(ctx context.Context, dialogID string, userID string, limit, pageNumber int64) ([]core.Message, *common.PageResponse, error)
+	GetMessagesByIDs(ctx context.Context, dialogID string, messageIDs []string) ([]core.Message, error)
+	ReadMessage(ctx context.Context, userID string, messageID string, dialogID string) error
+
+	EditMessage(ctx context.Context, messageID string, body string, editedAt int64) error
+	DeleteMessage(ctx context.Context, messageID string, deletedAt int64) error
+	HideMessage(ctx context.Context, messageID string, userID string) error
+
+	AddReaction(ctx context.Context, messageID string, reaction core.Reaction) error
+	RemoveReaction(ctx context.Context, messageID string, reaction core.Reaction) error
+}
+
+type messageRepositoryImpl struct {
+	db   *mongo.Database
+	coll *mongo.Collection
+}
+
+// NewMessageRepository creates the repository of chat messages and the index their pages are read by.
+func NewMessageRepository(db *mongo.Database) (*messageRepositoryImpl, error) {
+	repo := &messageRepositoryImpl{db: db, coll: db.Collection("messages")}
+	if err := repo.createIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// NewMessageRepositoryTest for Tests (bad)
+func NewMessageRepositoryTest(collection *mongo.Collection) (*messageRepositoryImpl, error) {
+	return &messageRepositoryImpl{coll: collection}, nil
+}
+
+func (repo *messageRepositoryImpl) createIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "dialog_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+	})
+	return err
+}
+
+func (repo *messageRepositoryImpl) CreateMessage(ctx context.Context, message *core.Message) error {
+	_, err := repo.coll.InsertOne(ctx, message)
+	return err
+}
+
+// GetMessage returns the message of the dialog, even if it is deleted.
+func (repo *messageRepositoryImpl) GetMessage(ctx context.Context, dialogID string, messageID string) (*core.Message, error) {
+	message := new(core.Message)
+	if err := repo.coll.FindOne(ctx, bson.M{"_id": messageID, "dialog_id": dialogID}).Decode(message); err != nil {
+		return nil, wrapError(err)
+	}
+	return message, nil
+}
+
+// GetMessages returns up to limit messages of the dialog the user sees, the newest first.
+// If before is set, only the messages older than the message with this id are returned.
+func (repo *messageRepositoryImpl) GetMessages(ctx context.Context, dialogID string, userID string, before string, limit int64) ([]core.Message, error) {
+	filter := visibleMessages(dialogID, userID)
+	if len(before) != 0 {
+		last := new(core.Message)
+		if err := repo.coll.FindOne(ctx, bson.M{"_id": before, "dialog_id": dialogID}).Decode(last); err != nil {
+			return nil, wrapError(err)
+		}
+		// Messages of the same second are ordered by id.
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$lt": last.CreatedAt}},
+			bson.M{"created_at": last.CreatedAt, "_id": bson.M{"$lt": last.ID}},
+		}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	return repo.findMessages(ctx, filter, opts)
+}
+
+// GetMessagesPage returns the page of messages of the dialog the user sees, the newest first.
+// Deprecated: it is kept for the clients which still ask for the pages by number, use GetMessages.
+func (repo *messageRepositoryImpl) GetMessagesPage(ctx context.Context, dialogID string, userID string, limit, pageNumber int64) ([]core.Message, *common.PageResponse, error) {
+	filter := visibleMessages(dialogID, userID)
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	if limit > 0 {
+		opts.SetSkip((pageNumber - 1) * limit)
+		opts.SetLimit(limit)
+	}
+	messages, err := repo.findMessages(ctx, filter, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	total, err := repo.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	page := &common.PageResponse{Total: total, AmountPages: 1}
+	if limit > 0 {
+		page.AmountPages = (total + limit - 1) / limit
+	}
+	return messages, page, nil
+}
+
+// GetMessagesByIDs returns the messages of the dialog with the ids, the deleted ones too.
+func (repo *messageRepositoryImpl) GetMessagesByIDs(ctx context.Context, dialogID string, messageIDs []string) ([]core.Message, error) {
+	return repo.findMessages(ctx, bson.M{"_id": bson.M{"$in": messageIDs}, "dialog_id": dialogID})
+}
+
+// visibleMessages filters the messages of the dialog which aren't deleted for the user.
+func visibleMessages(dialogID string, userID string) bson.M {
+	return bson.M{"dialog_id": dialogID, "deleted": bson.M{"$ne": true}, "deleted_for": bson.M{"$ne": userID}}
+}
+
+func (repo *messageRepositoryImpl) findMessages(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]core.Message, error) {
+	cursor, err := repo.coll.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	messages := []core.Message{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+
+	// Sanitize
+	p := bluemonday.UGCPolicy()
+	for i := range messages {
+		messages[i].Body = p.Sanitize(messages[i].Body)
+	}
+	return messages, nil
+}
+
+// ReadMessage marks the message as read by the user.
+func (repo *messageRepositoryImpl) ReadMessage(ctx context.Context, userID string, messageID string, dialogID string) error {
+	filter := bson.M{"_id": messageID, "dialog_id": dialogID}
+	update := bson.M{"$set": bson.M{"is_participants_read.$[participant].is_read": true}}
+	opts := options.FindOneAndUpdate().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"participant._id": userID}},
+	})
+
+	if err := repo.coll.FindOneAndUpdate(ctx, filter, update, opts).Err(); err != nil {
+		return wrapError(err)
+	}
+	return nil
+}
+
+// EditMessage replaces the body of the message which isn't deleted.
+func (repo *messageRepositoryImpl) EditMessage(ctx context.Context, messageID string, body string, editedAt int64) error {
+	filter := bson.M{"_id": messageID, "deleted": bson.M{"$ne": true}}
+	update := bson.M{"$set": bson.M{"body": body, "edited": true, "edited_at": editedAt}}
+	return repo.updateMessage(ctx, filter, update)
+}
+
+// DeleteMessage deletes the message for everyone. The message is kept to be referred to, but its content is erased.
+func (repo *messageRepositoryImpl) DeleteMessage(ctx context.Context, messageID string, deletedAt int64) error {
+	update := bson.M{
+		"$set":   bson.M{"deleted": true, "deleted_at": deletedAt},
+		"$unset": bson.M{"body": "", "attachments": "", "images": ""},
+	}
+	return repo.updateMessage(ctx, bson.M{"_id": messageID}, update)
+}
+
+// HideMessage deletes the message for the user only.
+func (repo *messageRepositoryImpl) HideMessage(ctx context.Context, messageID string, userID string) error {
+	return repo.updateMessage(ctx, bson.M{"_id": messageID}, bson.M{"$addToSet": bson.M{"deleted_for": userID}})
+}
+
+// AddReaction reacts to the message which isn't deleted, the same reaction of the user is kept once.
+func (repo *messageRepositoryImpl) AddReaction(ctx context.Context, messageID string, reaction core.Reaction) error {
+	filter := bson.M{"_id": messageID, "deleted": bson.M{"$ne": true}}
+	return repo.updateMessage(ctx, filter, bson.M{"$addToSet": bson.M{"reactions": reaction}})
+}
+
+// RemoveReaction takes the reaction of the user back.
+func (repo *messageRepositoryImpl) RemoveReaction(ctx context.Context, messageID string, reaction core.Reaction) error {
+	return repo.updateMessage(ctx, bson.M{"_id": messageID}, bson.M{"$pull": bson.M{"reactions": reaction}})
+}
+
+func (repo *messageRepositoryImpl) updateMessage(ctx context.Context, filter bson.M, update bson.M) error {
+	res, err := repo.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return constants.ErrDBNotFound
+	}
+	return nil
+}
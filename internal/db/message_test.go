@@ -0,0 +1,231 @@
+package db
+
+import (
+	"context"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+)
+
+func TestCreateMessage(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		messageCollection, _ := NewMessageRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		err := messageCollection.CreateMessage(context.Background(), TestMessage(t))
+		assert.Nil(t, err)
+	})
+}
+
+func TestGetMessages(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	message := TestMessage(t)
+	messageDoc := bson.D{
+		{Key: "_id", Value: message.ID},
+		{Key: "dialog_id", Value: message.DialogID},
+		{Key: "body", Value: message.Body},
+		{Key: "author_id", Value: message.AuthorID},
+		{Key: "created_at", Value: message.CreatedAt},
+	}
+
+	mt.Run("first page", func(mt *mtest.T) {
+		messageCollection, _ := NewMessageRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(1, "foo.bar", mtest.FirstBatch, messageDoc),
+			mtest.CreateCursorResponse(0, "foo.bar", mtest.NextBatch),
+		)
+		messages, err := messageCollection.GetMessages(context.Background(), message.DialogID, "12345672", "", 10)
+		assert.Nil(t, err)
+		assert.Equal(t, []core.Message{{ID: message.ID, DialogID: message.DialogID, Body: message.Body, AuthorID: message.AuthorID, CreatedAt: message.CreatedAt}}, messages)
+	})
+
+	mt.Run("before unknown message", func(mt *mtest.T) {
+		messageCollection, _ := NewMessageRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch))
+		_, err := messageCollection.GetMessages(context.Background(), message.DialogID, "12345672", "0", 10)
+		assert.Equal(t, constants.ErrDBNotFound, err)
+	})
+
+	mt.Run("before message", func(mt *mtest.T) {
+		messageCollection, _ := NewMessageRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch, messageDoc),
+			mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch),
+		)
+		messages, err := messageCollection.GetMessages(context.Background(), message.DialogID, "12345672", message.ID, 10)
+		assert.Nil(t, err)
+		assert.Empty(t, messages)
+
+		events := mt.GetAllStartedEvents()
+		filter := events[len(events)-1].Command.Lookup("filter").Document()
+		assert.Equal(t, message.DialogID, filter.Lookup("dialog_id").StringValue())
+		assert.NotNil(t, filter.Lookup("$or").Value, "the older messages must be filtered by the key")
+	})
+}
+
+func TestGetMessagesPage(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	message := TestMessage(t)
+	messageDoc := bson.D{
+		{Key: "_id", Value: message.ID},
+		{Key: "dialog_id", Value: message.DialogID},
+		{Key: "body", Value: message.Body},
+		{Key: "author_id", Value: message.AuthorID},
+		{Key: "created_at", Value: message.CreatedAt},
+	}
+
+	mt.Run("second page", func(mt *mtest.T) {
+		messageCollection, _ := NewMessageRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch, messageDoc),
+			mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch, bson.D{{Key: "n", Value: int64(3)}}),
+		)
+		messages, page, err := messageCollection.GetMessagesPage(context.Background(), message.DialogID, "12345672", 2, 2)
+		assert.Nil(t, err)
+		assert.Len(t, messages, 1)
+		assert.Equal(t, int64(3), page.Total)
+		assert.Equal(t, int64(2), page.AmountPages)
+
+		find := mt.GetStartedEvent().Command
+		assert.Equal(t, int64(2), find.Lookup("skip").AsInt64())
+	})
+}
+
+func TestReadMessage(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("not success", func(mt *mtest.T) {
+		messageCollection, _ := NewMessageRepositoryTest(mt.Coll)
+
+		message := TestMessage(t)
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		ctx := context.Background()
+
+		err := messageCollection.ReadMessage(ctx, message.IsRead[0].Participant, message.ID, message.DialogID)
+
+		assert.Equal(t, constants.ErrDBNotFound, err)
+	})
+}
+
+func TestGetMessage(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("not found", func(mt *mtest.T) {
+		messageCollection, _ := NewMessageRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch))
+		_, err := messageCollection.GetMessage(context.Background(), "12345678", "12345672")
+		assert.ErrorIs(t, err, constants.ErrDBNotFound)
+	})
+}
+
+func TestEditMessage(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		messageCollection, _ := NewMessageRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := messageCollection.EditMessage(context.Background(), "12345678", "edited", 1650000000)
+		assert.Nil(t, err)
+	})
+
+	mt.Run("deleted message", func(mt *mtest.T) {
+		messageCollection, _ := NewMessageRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}))
+		err := messageCollection.EditMessage(context.Background(), "12345678", "edited", 1650000000)
+		assert.Equal(t, constants.ErrDBNotFound, err)
+	})
+}
+
+func TestDeleteMessage(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("for everyone", func(mt *mtest.T) {
+		messageCollection, _ := NewMessageRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := messageCollection.DeleteMessage(context.Background(), "12345678", 1650000000)
+		assert.Nil(t, err)
+	})
+
+	mt.Run("for me", func(mt *mtest.T) {
+		messageCollection, _ := NewMessageRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := messageCollection.HideMessage(context.Background(), "12345678", "12345672")
+		assert.Nil(t, err)
+	})
+}
+
+func TestReactions(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	reaction := core.Reaction{UserID: "12345672", Emoji: "👍"}
+
+	mt.Run("add", func(mt *mtest.T) {
+		messageCollection, _ := NewMessageRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := messageCollection.AddReaction(context.Background(), "12345678", reaction)
+		assert.Nil(t, err)
+	})
+
+	mt.Run("add to deleted message", func(mt *mtest.T) {
+		messageCollection, _ := NewMessageRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}))
+		err := messageCollection.AddReaction(context.Background(), "12345678", reaction)
+		assert.Equal(t, constants.ErrDBNotFound, err)
+	})
+
+	mt.Run("remove", func(mt *mtest.T) {
+		messageCollection, _ := NewMessageRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := messageCollection.RemoveReaction(context.Background(), "12345678", reaction)
+		assert.Nil(t, err)
+	})
+}
+
+func TestGetMessagesByIDs(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		messageCollection, _ := NewMessageRepositoryTest(mt.Coll)
+
+		message := TestMessage(t)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: message.ID},
+			{Key: "dialog_id", Value: message.DialogID},
+			{Key: "body", Value: "<script>alert(1)</script>hi"},
+			{Key: "deleted", Value: true},
+		}))
+		messages, err := messageCollection.GetMessagesByIDs(context.Background(), message.DialogID, []string{message.ID, "unknown"})
+		assert.Nil(t, err)
+		assert.Len(t, messages, 1)
+		assert.True(t, messages[0].Deleted)
+		assert.Equal(t, "hi", messages[0].Body)
+	})
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// embeddedDialog is the dialog which still keeps its messages in itself, as it used to.
+type embeddedDialog struct {
+	ID       string         `bson:"_id"`
+	Messages []core.Message `bson:"messages"`
+}
+
+// MigrateEmbeddedMessages moves the messages embedded into the dialogs to the messages collection
+// and returns how many messages are moved. It can be interrupted and run again: the messages
+// which are moved already are skipped.
+func MigrateEmbeddedMessages(ctx context.Context, dbConn *mongo.Database) (int64, error) {
+	messages, err := NewMessageRepository(dbConn)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create message repository: %w", err)
+	}
+	return migrateEmbeddedMessages(ctx, dbConn.Collection("chats"), messages.coll)
+}
+
+func migrateEmbeddedMessages(ctx context.Context, chats, messages *mongo.Collection) (int64, error) {
+	opts := options.Find().SetProjection(bson.M{"messages": 1})
+	cursor, err := chats.Find(ctx, bson.M{"messages": bson.M{"$exists": true}}, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var moved int64
+	for cursor.Next(ctx) {
+		dialog := new(embeddedDialog)
+		if err := cursor.Decode(dialog); err != nil {
+			return moved, err
+		}
+
+		if len(dialog.Messages) != 0 {
+			docs := make([]interface{}, 0, len(dialog.Messages))
+			for i := range dialog.Messages {
+				dialog.Messages[i].DialogID = dialog.ID
+				docs = append(docs, dialog.Messages[i])
+			}
+			inserted := int64(len(docs))
+			if _, err := messages.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false)); err != nil {
+				skipped, ok := duplicates(err)
+				if !ok {
+					return moved, fmt.Errorf("dialog %s: %w", dialog.ID, err)
+				}
+				inserted -= skipped
+			}
+			moved += inserted
+		}
+
+		// The dialog is cleaned up only when all its messages are in place.
+		if _, err := chats.UpdateByID(ctx, dialog.ID, bson.M{"$unset": bson.M{"messages": ""}}); err != nil {
+			return moved, fmt.Errorf("dialog %s: %w", dialog.ID, err)
+		}
+	}
+	return moved, cursor.Err()
+}
+
+// duplicates returns how many messages failed to insert, if all of them are in the collection already.
+func duplicates(err error) (int64, bool) {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return 0, false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != duplicateKeyCode {
+			return 0, false
+		}
+	}
+	return int64(len(bulkErr.WriteErrors)), true
+}
+
+const duplicateKeyCode = 11000
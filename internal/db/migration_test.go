@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+)
+
+func TestMigrateEmbeddedMessages(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	dialog := TestDialog(t)
+	message := TestMessage(t)
+	dialogDoc := bson.D{
+		{Key: "_id", Value: dialog.ID},
+		{Key: "messages", Value: bson.A{
+			bson.D{{Key: "_id", Value: message.ID}, {Key: "body", Value: message.Body}},
+			bson.D{{Key: "_id", Value: "12345679"}, {Key: "body", Value: message.Body}},
+		}},
+	}
+
+	mt.Run("success", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "foo.chats", mtest.FirstBatch, dialogDoc),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+		)
+
+		moved, err := migrateEmbeddedMessages(context.Background(), mt.Coll, mt.Coll)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), moved)
+
+		events := mt.GetAllStartedEvents()
+		assert.Equal(t, "insert", events[1].CommandName)
+		inserted, _ := events[1].Command.Lookup("documents").Array().Values()
+		assert.Equal(t, dialog.ID, inserted[0].Document().Lookup("dialog_id").StringValue())
+		assert.Equal(t, "update", events[2].CommandName)
+	})
+
+	mt.Run("messages moved already", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "foo.chats", mtest.FirstBatch, dialogDoc),
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key error"}),
+			mtest.CreateSuccessResponse(),
+		)
+
+		moved, err := migrateEmbeddedMessages(context.Background(), mt.Coll, mt.Coll)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), moved)
+	})
+
+	mt.Run("insert error", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "foo.chats", mtest.FirstBatch, dialogDoc),
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 2, Message: "bad value"}),
+		)
+
+		moved, err := migrateEmbeddedMessages(context.Background(), mt.Coll, mt.Coll)
+		assert.NotNil(t, err, "the dialog must keep its messages until all of them are moved")
+		assert.Equal(t, int64(0), moved)
+	})
+}
@@ -30,6 +30,7 @@ type PostRepository interface {
 	PostAddComment(ctx context.Context, postID string, commentID string) error
 	PostCheckComment(ctx context.Context, post *core.Post, commentID string) error
 	PostDeleteComment(ctx context.Context, postID string, commentID string) error
+	PostsDeleteComments(ctx context.Context, commentIDs []string) error
 }
 
 type postRepositoryImpl struct {
@@ -198,3 +199,13 @@ func (repo *postRepositoryImpl) InitPost(post *core.Post) error {
 	post.CommentsIDs = []string{}
 	return nil
 }
+
+// PostsDeleteComments removes the comments from all posts they are left under.
+func (repo *postRepositoryImpl) PostsDeleteComments(ctx context.Context, commentIDs []string) error {
+	if len(commentIDs) == 0 {
+		return nil
+	}
+	filter := bson.M{"comment_ids": bson.M{"$in": commentIDs}}
+	_, err := repo.coll.UpdateMany(ctx, filter, bson.M{"$pull": bson.M{"comment_ids": bson.M{"$in": commentIDs}}})
+	return err
+}
@@ -262,3 +262,16 @@ func TestPostCheckComment(t *testing.T) {
 		assert.Nil(t, err)
 	})
 }
+
+func TestPostsDeleteComments(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		postCollection, _ := NewPostRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := postCollection.PostsDeleteComments(context.Background(), []string{"1"})
+		assert.Nil(t, err)
+	})
+}
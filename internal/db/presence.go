@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PresenceRepository interface {
+	Connect(ctx context.Context, userID string, at int64) error
+	Disconnect(ctx context.Context, userID string, at int64) error
+	Touch(ctx context.Context, userID string, at int64) error
+	GetPresence(ctx context.Context, userIDs []string) ([]core.Presence, error)
+	DeletePresence(ctx context.Context, userID string) error
+}
+
+type presenceRepositoryImpl struct {
+	db   *mongo.Database
+	coll *mongo.Collection
+}
+
+func NewPresenceRepository(db *mongo.Database) (*presenceRepositoryImpl, error) {
+	return &presenceRepositoryImpl{db: db, coll: db.Collection("presence")}, nil
+}
+
+// NewPresenceRepositoryTest for Tests (bad)
+func NewPresenceRepositoryTest(collection *mongo.Collection) (*presenceRepositoryImpl, error) {
+	return &presenceRepositoryImpl{coll: collection}, nil
+}
+
+// Connect counts one more open connection of the user.
+func (repo *presenceRepositoryImpl) Connect(ctx context.Context, userID string, at int64) error {
+	update := bson.M{"$inc": bson.M{"connections": 1}, "$set": bson.M{"last_seen_at": at}}
+	_, err := repo.coll.UpdateByID(ctx, userID, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// Disconnect counts one connection of the user less, the user is last seen at that time.
+func (repo *presenceRepositoryImpl) Disconnect(ctx context.Context, userID string, at int64) error {
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"connections":  bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{"$connections", 1}}}},
+		"last_seen_at": at,
+	}}}}
+	_, err := repo.coll.UpdateByID(ctx, userID, update)
+	return err
+}
+
+// Touch tells that the connections of the user are still open.
+func (repo *presenceRepositoryImpl) Touch(ctx context.Context, userID string, at int64) error {
+	_, err := repo.coll.UpdateByID(ctx, userID, bson.M{"$set": bson.M{"last_seen_at": at}})
+	return err
+}
+
+// GetPresence returns the presence of the users who have ever connected.
+func (repo *presenceRepositoryImpl) GetPresence(ctx context.Context, userIDs []string) ([]core.Presence, error) {
+	cursor, err := repo.coll.Find(ctx, bson.M{"_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	presence := []core.Presence{}
+	if err := cursor.All(ctx, &presence); err != nil {
+		return nil, err
+	}
+	return presence, nil
+}
+
+// DeletePresence forgets when the user was last seen.
+func (repo *presenceRepositoryImpl) DeletePresence(ctx context.Context, userID string) error {
+	_, err := repo.coll.DeleteOne(ctx, bson.M{"_id": userID})
+	return err
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+)
+
+func TestPresenceConnect(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		presenceCollection, _ := NewPresenceRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		assert.Nil(t, presenceCollection.Connect(context.Background(), "1", 100))
+
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.True(t, update.Lookup("upsert").Boolean(), "the first connection of the user must create the presence")
+	})
+}
+
+func TestPresenceDisconnect(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		presenceCollection, _ := NewPresenceRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		assert.Nil(t, presenceCollection.Disconnect(context.Background(), "1", 100))
+	})
+}
+
+func TestGetPresence(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		presenceCollection, _ := NewPresenceRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.presence", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "1"}, {Key: "connections", Value: int64(2)}, {Key: "last_seen_at", Value: int64(100)}},
+		))
+		presence, err := presenceCollection.GetPresence(context.Background(), []string{"1", "2"})
+		assert.Nil(t, err)
+		assert.Equal(t, []core.Presence{{UserID: "1", Connections: 2, LastSeenAt: 100}}, presence)
+	})
+}
+
+func TestDeletePresence(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		presenceCollection, _ := NewPresenceRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+		assert.Nil(t, presenceCollection.DeletePresence(context.Background(), "1"))
+	})
+}
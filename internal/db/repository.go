@@ -11,9 +11,12 @@ type Repository struct {
 	FriendsRepo   FriendsRepository
 	PostRepo      PostRepository
 	ChatRepo      ChatRepository
+	MessageRepo   MessageRepository
 	LikeRepo      LikeRepository
 	CommunityRepo CommunityRepository
 	CommentRepo   CommentRepository
+	PresenceRepo  PresenceRepository
+	ChatEventRepo ChatEventRepository
 }
 
 func NewRepository(dbConn *mongo.Database) (*Repository, error) {
@@ -40,6 +43,11 @@ func NewRepository(dbConn *mongo.Database) (*Repository, error) {
 		return nil, fmt.Errorf("failed to create chats repository: %w", err)
 	}
 
+	repository.MessageRepo, err = NewMessageRepository(dbConn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create message repository: %w", err)
+	}
+
 	repository.LikeRepo, err = NewLikeRepository(dbConn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create like repository: %w", err)
@@ -55,5 +63,15 @@ func NewRepository(dbConn *mongo.Database) (*Repository, error) {
 		return nil, fmt.Errorf("failed to create comment repository: %w", err)
 	}
 
+	repository.PresenceRepo, err = NewPresenceRepository(dbConn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create presence repository: %w", err)
+	}
+
+	repository.ChatEventRepo, err = NewChatEventRepository(dbConn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat event repository: %w", err)
+	}
+
 	return repository, nil
 }
@@ -123,7 +123,6 @@ func TestDialog(t *testing.T) *core.Dialog {
 		ID:           "12345678",
 		Name:         "My dialog",
 		Participants: []string{"12345671", "12345672"},
-		Messages:     []core.Message{},
 		CreatedAt:    124565,
 	}
 }
@@ -132,6 +131,7 @@ func TestMessage(t *testing.T) *core.Message {
 	t.Helper()
 	return &core.Message{
 		ID:        "12345678",
+		DialogID:  "12345678",
 		Body:      "hi message",
 		AuthorID:  "12345671",
 		IsRead:    []core.IsRead{{Participant: "12345672", IsRead: false}},
@@ -40,6 +40,7 @@ type UserRepository interface {
 	GetUserDialogs(ctx context.Context, userID string) ([]string, error)
 	IsUserInDialog(ctx context.Context, userID string, dialogID string) (bool, error)
 	UserCheckDialog(ctx context.Context, dialogID string, userID string) error
+	RemoveDialog(ctx context.Context, dialogID string, userID string) error
 
 	UserAddCommunity(ctx context.Context, userID string, communityID string) error
 	UserDeleteCommunity(ctx context.Context, userID string, communityID string) error
@@ -259,6 +260,12 @@ func (repo *userRepositoryImpl) AddDialog(ctx context.Context, dialogID string,
 	return nil
 }
 
+// RemoveDialog removes the dialog from the dialogs of the user who is no more its participant.
+func (repo *userRepositoryImpl) RemoveDialog(ctx context.Context, dialogID string, userID string) error {
+	_, err := repo.coll.UpdateByID(ctx, userID, bson.M{"$pull": bson.M{"dialog_ids": dialogID}})
+	return err
+}
+
 func (repo *userRepositoryImpl) UserCheckDialog(ctx context.Context, dialogID string, userID string) error {
 	filter := bson.M{"_id": userID, "dialog_ids": dialogID}
 	if err := repo.coll.FindOne(ctx, filter).Err(); err == mongo.ErrNoDocuments {
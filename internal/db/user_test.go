@@ -17,6 +17,8 @@ func TestNewRepository(t *testing.T) {
 	defer mt.Close()
 
 	mt.Run("success", func(mt *mtest.T) {
+		// The indexes of the chats, messages and chat events collections.
+		mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
 
 		_, err := NewRepository(mt.DB)
 		assert.Nil(t, err)
@@ -349,6 +351,19 @@ func TestAddDialog(t *testing.T) {
 	})
 }
 
+func TestRemoveDialog(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		userCollection, _ := NewUserRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		err := userCollection.RemoveDialog(context.Background(), TestDialog(t).ID, TestUser(t).ID)
+		assert.Nil(t, err)
+	})
+}
+
 func TestUserCheckDialog(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 	defer mt.Close()
@@ -2,68 +2,572 @@ package cl
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"net/http"
+	"time"
+
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
 	handler "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/handler"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/utils"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
+// Tokens is a pair of tokens issued for the user's session. When the user
+// has two-factor authentication enabled, login returns only TwoFactorTicket.
+type Tokens struct {
+	UserID          string
+	SessionID       string
+	AuthToken       string
+	RefreshToken    string
+	TwoFactorTicket string
+	Unverified      bool
+	Created         bool // the user is signed up by the external login
+}
+
+// Identity is who the access token is issued to.
+// Requests authorized with an api token have APITokenID and Scopes instead of SessionID.
+type Identity struct {
+	UserID     string
+	SessionID  string
+	Unverified bool // the user's email is not verified yet
+	APITokenID string
+	Scopes     []string
+}
+
+// ClientInfo describes the client which the session is used from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+	Device    string
+}
+
+// ExternalIdentity is the account of the user at an external identity provider.
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// LinkedIdentity is an external identity linked to the user.
+type LinkedIdentity struct {
+	Provider string
+	Email    string
+	LinkedAt int64
+}
+
+// APIToken is the personal access token of the user, without the token itself.
+type APIToken struct {
+	ID         string
+	Name       string
+	Scopes     []string
+	CreatedAt  int64
+	ExpiresAt  int64
+	LastUsedAt int64
+}
+
+// Session is an active login session of the user.
+type Session struct {
+	ID         string
+	Device     string
+	UserAgent  string
+	IP         string
+	CreatedAt  int64
+	LastSeenAt int64
+}
+
 type AuthRepository interface {
-	Login(email, pass string) (string, string, error)
-	Check(token string) (string, string, bool, error)
-	SignUp(email, pass string) (string, string, error)
+	Login(ctx context.Context, email, pass string, client *ClientInfo) (*Tokens, error)
+	Check(ctx context.Context, token string) (*Identity, error)
+	Verify(ctx context.Context, token string) (*Identity, error)
+	SignUp(ctx context.Context, email, pass string, client *ClientInfo) (*Tokens, error)
+	Refresh(ctx context.Context, refreshToken string, client *ClientInfo) (*Tokens, error)
+	Logout(ctx context.Context, token, refreshToken string) error
+	ListSessions(ctx context.Context, userID string) ([]Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	ChangePassword(ctx context.Context, userID, sessionID, oldPass, newPass, code string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ConfirmPasswordReset(ctx context.Context, token, newPass string) error
+	VerifySecondFactor(ctx context.Context, ticket, code string, client *ClientInfo) (*Tokens, error)
+	EnrollTwoFactor(ctx context.Context, userID string) (string, string, error)
+	ConfirmTwoFactor(ctx context.Context, userID, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userID, pass, code string) error
+	DeleteAccount(ctx context.Context, userID, sessionID, pass, code string) (int64, error)
+	ClaimAccountDeletions(ctx context.Context, limit int64) ([]string, error)
+	PurgeAccount(ctx context.Context, userID string) error
+	VerifyEmail(ctx context.Context, token string) (string, error)
+	ResendVerification(ctx context.Context, userID string) error
+	ExternalLogin(ctx context.Context, identity *ExternalIdentity, client *ClientInfo) (*Tokens, error)
+	LinkIdentity(ctx context.Context, userID string, identity *ExternalIdentity) error
+	UnlinkIdentity(ctx context.Context, userID, provider string) error
+	ListIdentities(ctx context.Context, userID string) ([]LinkedIdentity, error)
+	CreateAPIToken(ctx context.Context, userID, name string, scopes []string, expiresAt int64) (string, *APIToken, error)
+	ListAPITokens(ctx context.Context, userID string) ([]APIToken, error)
+	RevokeAPIToken(ctx context.Context, userID, tokenID string) error
+	CheckAPIToken(ctx context.Context, token string) (*Identity, error)
+}
+
+func NewClientInfo(r *http.Request) *ClientInfo {
+	return &ClientInfo{
+		UserAgent: r.UserAgent(),
+		IP:        utils.GetIP(r),
+		Device:    utils.GetDevice(r.UserAgent()),
+	}
 }
 
 type AuthRepositoryImpl struct {
-	log    *logrus.Entry
-	client handler.UserAuthClient
+	log     *logrus.Entry
+	client  handler.UserAuthClient
+	keys    *jwksCache
+	timeout time.Duration
+	// checkSessions makes Verify look up the session of the token, see constants.ConfigAuthCheckSessions.
+	checkSessions bool
 }
 
 func NewAuthRepository(log *logrus.Entry, cl handler.UserAuthClient) AuthRepository {
-	return &AuthRepositoryImpl{log: log, client: cl}
+	repository := &AuthRepositoryImpl{
+		log:           log,
+		client:        cl,
+		timeout:       constants.DefaultAuthTimeout,
+		checkSessions: viper.GetBool(constants.ConfigAuthCheckSessions),
+	}
+	if timeout := viper.GetDuration(constants.ConfigAuthTimeout); timeout > 0 {
+		repository.timeout = timeout
+	}
+	if url := viper.GetString(constants.ConfigAuthJWKSURL); len(url) != 0 {
+		repository.keys = newJWKSCache(log, url)
+	}
+	return repository
 }
 
+// callContext bounds the call to the auth service by the timeout and passes the X-Request-Id
+// of the API request along, so the logs of the auth service can be correlated with the API ones.
+func (redisConnect *AuthRepositoryImpl) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if requestID, ok := ctx.Value(constants.CtxKeyXRequestID{}).(string); ok && len(requestID) != 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, auth_constants.MetadataKeyRequestID, requestID)
+	}
+	return context.WithTimeout(ctx, redisConnect.timeout)
+}
+
+// ParseError turns the status returned by the auth service into the error of the main service
+// by the reason in its ErrorDetail, or by its code if there is no reason.
 func (redisConnect *AuthRepositoryImpl) ParseError(err error) error {
-	getErr, ok := status.FromError(err)
-	if ok {
-		if val, ok := constants.ParseError[getErr.Message()]; ok {
-			return val
+	st := status.Convert(err)
+	for _, detail := range st.Details() {
+		if errDetail, ok := detail.(*handler.ErrorDetail); ok {
+			if codedErr, ok := reasonErrors[errDetail.GetReason()]; ok {
+				return codedErr
+			}
 		}
 	}
-	return fmt.Errorf(getErr.Message())
+	if codedErr, ok := codeErrors[st.Code()]; ok {
+		return codedErr
+	}
+	return errors.New(st.Message())
 }
 
-func (redisConnect *AuthRepositoryImpl) Login(email, pass string) (string, string, error) {
-	res, err := redisConnect.client.Login(context.Background(), &handler.LoginReq{
-		Email: email,
-		Pwd:   pass,
+func (redisConnect *AuthRepositoryImpl) Login(ctx context.Context, email, pass string, client *ClientInfo) (*Tokens, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.Login(ctx, &handler.LoginReq{
+		Email:  email,
+		Pwd:    pass,
+		Client: client.toHandler(),
 	})
 	if err != nil {
-		return "", "", redisConnect.ParseError(err)
+		return nil, redisConnect.ParseError(err)
 
 	}
-	return res.Token, res.UserID, err
+	return &Tokens{UserID: res.UserID, AuthToken: res.Token, RefreshToken: res.RefreshToken, TwoFactorTicket: res.TwoFactorTicket}, nil
 }
 
-func (redisConnect *AuthRepositoryImpl) SignUp(email, pass string) (string, string, error) {
-	redisConnect.log.Info(email, pass)
-	res, err := redisConnect.client.SignUp(context.Background(), &handler.SignUpReq{
-		Email: email,
-		Pwd:   pass,
+func (redisConnect *AuthRepositoryImpl) SignUp(ctx context.Context, email, pass string, client *ClientInfo) (*Tokens, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.SignUp(ctx, &handler.SignUpReq{
+		Email:  email,
+		Pwd:    pass,
+		Client: client.toHandler(),
 	})
+	if err != nil {
+		return nil, redisConnect.ParseError(err)
+
+	}
+	return &Tokens{UserID: res.UserID, AuthToken: res.Token, RefreshToken: res.RefreshToken}, nil
+}
+
+// Check validates the access token and returns who it is issued to.
+func (redisConnect *AuthRepositoryImpl) Check(ctx context.Context, token string) (*Identity, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.Check(ctx, &handler.CheckReq{Token: token})
+	if err != nil {
+		return nil, redisConnect.ParseError(err)
+
+	}
+	return &Identity{UserID: res.UserID, SessionID: res.SessionID, Unverified: res.Unverified}, nil
+}
+
+func (redisConnect *AuthRepositoryImpl) Refresh(ctx context.Context, refreshToken string, client *ClientInfo) (*Tokens, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.Refresh(ctx, &handler.RefreshReq{
+		RefreshToken: refreshToken,
+		Client:       client.toHandler(),
+	})
+	if err != nil {
+		return nil, redisConnect.ParseError(err)
+	}
+	return &Tokens{
+		UserID:       res.UserID,
+		SessionID:    res.SessionID,
+		AuthToken:    res.Token,
+		RefreshToken: res.RefreshToken,
+		Unverified:   res.Unverified,
+	}, nil
+}
+
+func (redisConnect *AuthRepositoryImpl) Logout(ctx context.Context, token, refreshToken string) error {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	_, err := redisConnect.client.Logout(ctx, &handler.LogoutReq{Token: token, RefreshToken: refreshToken})
+	if err != nil {
+		return redisConnect.ParseError(err)
+	}
+	return nil
+}
+
+func (redisConnect *AuthRepositoryImpl) ListSessions(ctx context.Context, userID string) ([]Session, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.ListSessions(ctx, &handler.ListSessionsReq{UserID: userID})
+	if err != nil {
+		return nil, redisConnect.ParseError(err)
+	}
+
+	sessions := make([]Session, 0, len(res.Sessions))
+	for _, session := range res.Sessions {
+		sessions = append(sessions, Session{
+			ID:         session.Id,
+			Device:     session.Device,
+			UserAgent:  session.UserAgent,
+			IP:         session.Ip,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+		})
+	}
+	return sessions, nil
+}
+
+func (redisConnect *AuthRepositoryImpl) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	_, err := redisConnect.client.RevokeSession(ctx, &handler.RevokeSessionReq{UserID: userID, SessionID: sessionID})
+	if err != nil {
+		return redisConnect.ParseError(err)
+	}
+	return nil
+}
+
+func (redisConnect *AuthRepositoryImpl) ChangePassword(ctx context.Context, userID, sessionID, oldPass, newPass, code string) error {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	_, err := redisConnect.client.ChangePassword(ctx, &handler.ChangePasswordReq{
+		UserID:    userID,
+		SessionID: sessionID,
+		OldPwd:    oldPass,
+		NewPwd:    newPass,
+		Code:      code,
+	})
+	if err != nil {
+		return redisConnect.ParseError(err)
+	}
+	return nil
+}
+
+func (redisConnect *AuthRepositoryImpl) RequestPasswordReset(ctx context.Context, email string) error {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	_, err := redisConnect.client.RequestPasswordReset(ctx, &handler.RequestPasswordResetReq{Email: email})
+	if err != nil {
+		return redisConnect.ParseError(err)
+	}
+	return nil
+}
+
+func (redisConnect *AuthRepositoryImpl) ConfirmPasswordReset(ctx context.Context, token, newPass string) error {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	_, err := redisConnect.client.ConfirmPasswordReset(ctx, &handler.ConfirmPasswordResetReq{Token: token, NewPwd: newPass})
+	if err != nil {
+		return redisConnect.ParseError(err)
+	}
+	return nil
+}
+
+func (redisConnect *AuthRepositoryImpl) VerifySecondFactor(ctx context.Context, ticket, code string, client *ClientInfo) (*Tokens, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.VerifySecondFactor(ctx, &handler.VerifySecondFactorReq{
+		Ticket: ticket,
+		Code:   code,
+		Client: client.toHandler(),
+	})
+	if err != nil {
+		return nil, redisConnect.ParseError(err)
+	}
+	return &Tokens{UserID: res.UserID, AuthToken: res.Token, RefreshToken: res.RefreshToken}, nil
+}
+
+// EnrollTwoFactor returns the pending TOTP secret and its otpauth:// URI.
+func (redisConnect *AuthRepositoryImpl) EnrollTwoFactor(ctx context.Context, userID string) (string, string, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.EnrollTwoFactor(ctx, &handler.EnrollTwoFactorReq{UserID: userID})
 	if err != nil {
 		return "", "", redisConnect.ParseError(err)
+	}
+	return res.Secret, res.Uri, nil
+}
+
+func (redisConnect *AuthRepositoryImpl) ConfirmTwoFactor(ctx context.Context, userID, code string) ([]string, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.ConfirmTwoFactor(ctx, &handler.ConfirmTwoFactorReq{UserID: userID, Code: code})
+	if err != nil {
+		return nil, redisConnect.ParseError(err)
+	}
+	return res.RecoveryCodes, nil
+}
+
+func (redisConnect *AuthRepositoryImpl) DisableTwoFactor(ctx context.Context, userID, pass, code string) error {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	_, err := redisConnect.client.DisableTwoFactor(ctx, &handler.DisableTwoFactorReq{UserID: userID, Pwd: pass, Code: code})
+	if err != nil {
+		return redisConnect.ParseError(err)
+	}
+	return nil
+}
+
+// DeleteAccount schedules the account for deletion and returns when it is going to be purged.
+func (redisConnect *AuthRepositoryImpl) DeleteAccount(ctx context.Context, userID, sessionID, pass, code string) (int64, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.DeleteAccount(ctx, &handler.DeleteAccountReq{UserID: userID, SessionID: sessionID, Pwd: pass, Code: code})
+	if err != nil {
+		return 0, redisConnect.ParseError(err)
+	}
+	return res.PurgeAt, nil
+}
+
+func (redisConnect *AuthRepositoryImpl) ClaimAccountDeletions(ctx context.Context, limit int64) ([]string, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.ClaimAccountDeletions(ctx, &handler.ClaimAccountDeletionsReq{Limit: limit})
+	if err != nil {
+		return nil, redisConnect.ParseError(err)
+	}
+	return res.UserIDs, nil
+}
+
+func (redisConnect *AuthRepositoryImpl) PurgeAccount(ctx context.Context, userID string) error {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	_, err := redisConnect.client.PurgeAccount(ctx, &handler.PurgeAccountReq{UserID: userID})
+	if err != nil {
+		return redisConnect.ParseError(err)
+	}
+	return nil
+}
+
+// VerifyEmail confirms the email with the token from the verification email and returns the id of its user.
+func (redisConnect *AuthRepositoryImpl) VerifyEmail(ctx context.Context, token string) (string, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.VerifyEmail(ctx, &handler.VerifyEmailReq{Token: token})
+	if err != nil {
+		return "", redisConnect.ParseError(err)
+	}
+	return res.UserID, nil
+}
+
+func (redisConnect *AuthRepositoryImpl) ResendVerification(ctx context.Context, userID string) error {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	_, err := redisConnect.client.ResendVerification(ctx, &handler.ResendVerificationReq{UserID: userID})
+	if err != nil {
+		return redisConnect.ParseError(err)
+	}
+	return nil
+}
+
+// ExternalLogin logs in the user of the external identity, signing the user up if the identity is new.
+func (redisConnect *AuthRepositoryImpl) ExternalLogin(ctx context.Context, identity *ExternalIdentity, client *ClientInfo) (*Tokens, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.ExternalLogin(ctx, &handler.ExternalLoginReq{
+		Identity: identity.toHandler(),
+		Client:   client.toHandler(),
+	})
+	if err != nil {
+		return nil, redisConnect.ParseError(err)
+	}
+	return &Tokens{
+		UserID:          res.UserID,
+		AuthToken:       res.Token,
+		RefreshToken:    res.RefreshToken,
+		TwoFactorTicket: res.TwoFactorTicket,
+		Created:         res.Created,
+	}, nil
+}
+
+func (redisConnect *AuthRepositoryImpl) LinkIdentity(ctx context.Context, userID string, identity *ExternalIdentity) error {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	_, err := redisConnect.client.LinkIdentity(ctx, &handler.LinkIdentityReq{UserID: userID, Identity: identity.toHandler()})
+	if err != nil {
+		return redisConnect.ParseError(err)
+	}
+	return nil
+}
+
+func (redisConnect *AuthRepositoryImpl) UnlinkIdentity(ctx context.Context, userID, provider string) error {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	_, err := redisConnect.client.UnlinkIdentity(ctx, &handler.UnlinkIdentityReq{UserID: userID, Provider: provider})
+	if err != nil {
+		return redisConnect.ParseError(err)
+	}
+	return nil
+}
+
+func (redisConnect *AuthRepositoryImpl) ListIdentities(ctx context.Context, userID string) ([]LinkedIdentity, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.ListIdentities(ctx, &handler.ListIdentitiesReq{UserID: userID})
+	if err != nil {
+		return nil, redisConnect.ParseError(err)
+	}
+
+	identities := make([]LinkedIdentity, 0, len(res.Identities))
+	for _, identity := range res.Identities {
+		identities = append(identities, LinkedIdentity{
+			Provider: identity.Provider,
+			Email:    identity.Email,
+			LinkedAt: identity.LinkedAt,
+		})
+	}
+	return identities, nil
+}
+
+// CreateAPIToken returns the new token, which is never shown again, and its description.
+func (redisConnect *AuthRepositoryImpl) CreateAPIToken(ctx context.Context, userID, name string, scopes []string, expiresAt int64) (string, *APIToken, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.CreateAPIToken(ctx, &handler.CreateAPITokenReq{
+		UserID:    userID,
+		Name:      name,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", nil, redisConnect.ParseError(err)
+	}
+	token := apiTokenFromHandler(res.ApiToken)
+	return res.Token, &token, nil
+}
 
+func (redisConnect *AuthRepositoryImpl) ListAPITokens(ctx context.Context, userID string) ([]APIToken, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.ListAPITokens(ctx, &handler.ListAPITokensReq{UserID: userID})
+	if err != nil {
+		return nil, redisConnect.ParseError(err)
 	}
-	return res.Token, res.UserID, err
+
+	tokens := make([]APIToken, 0, len(res.Tokens))
+	for _, token := range res.Tokens {
+		tokens = append(tokens, apiTokenFromHandler(token))
+	}
+	return tokens, nil
 }
 
-func (redisConnect *AuthRepositoryImpl) Check(token string) (string, string, bool, error) {
-	res, err := redisConnect.client.Check(context.Background(), &handler.CheckReq{Token: token})
+func (redisConnect *AuthRepositoryImpl) RevokeAPIToken(ctx context.Context, userID, tokenID string) error {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	_, err := redisConnect.client.RevokeAPIToken(ctx, &handler.RevokeAPITokenReq{UserID: userID, TokenID: tokenID})
 	if err != nil {
-		return "", "", false, redisConnect.ParseError(err)
+		return redisConnect.ParseError(err)
+	}
+	return nil
+}
+
+// CheckAPIToken validates the api token and returns who it is issued to and its scopes.
+func (redisConnect *AuthRepositoryImpl) CheckAPIToken(ctx context.Context, token string) (*Identity, error) {
+	ctx, cancel := redisConnect.callContext(ctx)
+	defer cancel()
+
+	res, err := redisConnect.client.CheckAPIToken(ctx, &handler.CheckAPITokenReq{Token: token})
+	if err != nil {
+		return nil, redisConnect.ParseError(err)
+	}
+	return &Identity{UserID: res.UserID, Unverified: res.Unverified, APITokenID: res.TokenID, Scopes: res.Scopes}, nil
+}
+
+func apiTokenFromHandler(token *handler.APIToken) APIToken {
+	return APIToken{
+		ID:         token.GetId(),
+		Name:       token.GetName(),
+		Scopes:     token.GetScopes(),
+		CreatedAt:  token.GetCreatedAt(),
+		ExpiresAt:  token.GetExpiresAt(),
+		LastUsedAt: token.GetLastUsedAt(),
+	}
+}
+
+func (identity *ExternalIdentity) toHandler() *handler.ExternalIdentity {
+	return &handler.ExternalIdentity{
+		Provider:      identity.Provider,
+		Subject:       identity.Subject,
+		Email:         identity.Email,
+		EmailVerified: identity.EmailVerified,
+	}
+}
 
+func (client *ClientInfo) toHandler() *handler.ClientInfo {
+	if client == nil {
+		return nil
 	}
-	return res.NewToken, res.UserID, res.Code, err
+	return &handler.ClientInfo{UserAgent: client.UserAgent, Ip: client.IP, Device: client.Device}
 }
@@ -0,0 +1,41 @@
+package cl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestCallContext(t *testing.T) {
+	rep := &AuthRepositoryImpl{timeout: time.Second}
+
+	t.Run("Request id", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), constants.CtxKeyXRequestID{}, "42")
+		callCtx, cancel := rep.callContext(ctx)
+		defer cancel()
+
+		md, _ := metadata.FromOutgoingContext(callCtx)
+		assert.Equal(t, []string{"42"}, md.Get(auth_constants.MetadataKeyRequestID))
+		deadline, ok := callCtx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+	})
+
+	t.Run("Earlier deadline of the request", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		callCtx, cancel := rep.callContext(ctx)
+		defer cancel()
+
+		_, ok := metadata.FromOutgoingContext(callCtx)
+		assert.False(t, ok)
+		deadline, _ := callCtx.Deadline()
+		requestDeadline, _ := ctx.Deadline()
+		assert.Equal(t, requestDeadline, deadline)
+	})
+}
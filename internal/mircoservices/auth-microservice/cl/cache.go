@@ -0,0 +1,292 @@
+package cl
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/golang-jwt/jwt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// Results of the token cache lookups, the label values of the metrics.
+const (
+	cacheHit   = "hit"
+	cacheMiss  = "miss"
+	cacheStale = "stale" // served while the auth service is unavailable
+)
+
+type cacheEntry struct {
+	hash       [sha256.Size]byte
+	identity   Identity
+	freshUntil time.Time
+	expiresAt  time.Time // the token's own expiry, the entry is never served after it
+}
+
+// TokenCache keeps the recently verified access tokens, so that most requests don't call the auth service.
+// It holds at most size tokens, evicting the least recently used ones.
+type TokenCache struct {
+	size     int
+	ttl      time.Duration
+	requests *prometheus.CounterVec
+
+	mu      sync.Mutex
+	order   *list.List // of *cacheEntry, the most recently used first
+	entries map[[sha256.Size]byte]*list.Element
+}
+
+// NewTokenCache creates the cache of size tokens, each one verified again after ttl.
+// requests counts the lookups by result and may be nil.
+func NewTokenCache(size int, ttl time.Duration, requests *prometheus.CounterVec) *TokenCache {
+	return &TokenCache{
+		size:     size,
+		ttl:      ttl,
+		requests: requests,
+		order:    list.New(),
+		entries:  make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+// get returns the identity of the token and whether it is still fresh.
+func (c *TokenCache) get(hash [sha256.Size]byte, now time.Time) (*Identity, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[hash]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !now.Before(entry.expiresAt) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	identity := entry.identity
+	return &identity, now.Before(entry.freshUntil)
+}
+
+func (c *TokenCache) put(hash [sha256.Size]byte, identity *Identity, expiresAt, now time.Time) {
+	freshUntil := now.Add(c.ttl)
+	if freshUntil.After(expiresAt) {
+		freshUntil = expiresAt
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[hash]; ok {
+		c.remove(elem)
+	}
+	entry := &cacheEntry{hash: hash, identity: *identity, freshUntil: freshUntil, expiresAt: expiresAt}
+	c.entries[hash] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// revoke drops the tokens matching the revocation.
+func (c *TokenCache) revoke(revocation *Revocation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if entry := elem.Value.(*cacheEntry); revocation.matches(entry.hash, &entry.identity) {
+			c.remove(elem)
+		}
+		elem = next
+	}
+}
+
+func (c *TokenCache) evictToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[sha256.Sum256([]byte(token))]; ok {
+		c.remove(elem)
+	}
+}
+
+func (c *TokenCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).hash)
+}
+
+func (c *TokenCache) count(result string) {
+	if c.requests != nil {
+		c.requests.WithLabelValues(result).Inc()
+	}
+}
+
+// Len returns the number of cached tokens.
+func (c *TokenCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// cachedAuthRepository verifies the tokens through the cache. The calls which end sessions
+// pass through it as well and revoke their tokens in the caches of all instances.
+type cachedAuthRepository struct {
+	AuthRepository
+	log         *logrus.Entry
+	cache       *TokenCache
+	revocations Revocations
+}
+
+// NewCachedAuthRepository puts the token cache in front of the repository.
+// The cache drops the tokens revoked by any instance subscribed to the revocations.
+func NewCachedAuthRepository(log *logrus.Entry, rep AuthRepository, cache *TokenCache, revocations Revocations) AuthRepository {
+	revocations.Subscribe(cache.revoke)
+	return &cachedAuthRepository{AuthRepository: rep, log: log, cache: cache, revocations: revocations}
+}
+
+// Verify returns the cached identity of the token while it is fresh. A stale identity
+// is served only if the auth service can't be reached, and never after the token expires.
+func (rep *cachedAuthRepository) Verify(ctx context.Context, token string) (*Identity, error) {
+	return rep.lookup(ctx, token, rep.AuthRepository.Verify, func(time.Time) (time.Time, bool) {
+		return tokenExpiry(token)
+	})
+}
+
+// CheckAPIToken caches the api tokens as well. Their expiry is not known here,
+// so they are never served stale.
+func (rep *cachedAuthRepository) CheckAPIToken(ctx context.Context, token string) (*Identity, error) {
+	return rep.lookup(ctx, token, rep.AuthRepository.CheckAPIToken, func(now time.Time) (time.Time, bool) {
+		return now.Add(rep.cache.ttl), true
+	})
+}
+
+// lookup returns the identity of the token from the cache or checks it and caches it until expiry.
+func (rep *cachedAuthRepository) lookup(ctx context.Context, token string,
+	check func(context.Context, string) (*Identity, error), expiry func(now time.Time) (time.Time, bool)) (*Identity, error) {
+	hash := sha256.Sum256([]byte(token))
+	now := time.Now()
+
+	cached, fresh := rep.cache.get(hash, now)
+	if fresh {
+		rep.cache.count(cacheHit)
+		return cached, nil
+	}
+
+	identity, err := check(ctx, token)
+	if err != nil {
+		if cached != nil && unavailable(err) {
+			rep.cache.count(cacheStale)
+			return cached, nil
+		}
+		rep.cache.evictToken(token)
+		rep.cache.count(cacheMiss)
+		return nil, err
+	}
+	rep.cache.count(cacheMiss)
+
+	if expiresAt, ok := expiry(now); ok {
+		rep.cache.put(hash, identity, expiresAt, now)
+	}
+	return identity, nil
+}
+
+func (rep *cachedAuthRepository) Logout(ctx context.Context, token, refreshToken string) error {
+	err := rep.AuthRepository.Logout(ctx, token, refreshToken)
+	rep.revoke(rep.sessionOf(token, err == nil))
+	return err
+}
+
+func (rep *cachedAuthRepository) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	defer rep.revoke(&Revocation{SessionID: sessionID})
+	return rep.AuthRepository.RevokeSession(ctx, userID, sessionID)
+}
+
+func (rep *cachedAuthRepository) RevokeAPIToken(ctx context.Context, userID, tokenID string) error {
+	defer rep.revoke(&Revocation{APITokenID: tokenID})
+	return rep.AuthRepository.RevokeAPIToken(ctx, userID, tokenID)
+}
+
+func (rep *cachedAuthRepository) ChangePassword(ctx context.Context, userID, sessionID, oldPass, newPass, code string) error {
+	defer rep.revoke(&Revocation{UserID: userID})
+	return rep.AuthRepository.ChangePassword(ctx, userID, sessionID, oldPass, newPass, code)
+}
+
+// Refresh drops the whole cache when the auth service revokes the session for the reused refresh token,
+// the session of the opaque refresh token is not known here.
+func (rep *cachedAuthRepository) Refresh(ctx context.Context, refreshToken string, client *ClientInfo) (*Tokens, error) {
+	tokens, err := rep.AuthRepository.Refresh(ctx, refreshToken, client)
+	if errors.Is(err, constants.ErrRefreshTokenReused) {
+		rep.revoke(&Revocation{All: true})
+	}
+	return tokens, err
+}
+
+// ConfirmPasswordReset ends all sessions of the user, who is not known here, so the whole cache is dropped.
+func (rep *cachedAuthRepository) ConfirmPasswordReset(ctx context.Context, token, newPass string) error {
+	defer rep.revoke(&Revocation{All: true})
+	return rep.AuthRepository.ConfirmPasswordReset(ctx, token, newPass)
+}
+
+func (rep *cachedAuthRepository) DeleteAccount(ctx context.Context, userID, sessionID, pass, code string) (int64, error) {
+	defer rep.revoke(&Revocation{UserID: userID})
+	return rep.AuthRepository.DeleteAccount(ctx, userID, sessionID, pass, code)
+}
+
+func (rep *cachedAuthRepository) PurgeAccount(ctx context.Context, userID string) error {
+	defer rep.revoke(&Revocation{UserID: userID})
+	return rep.AuthRepository.PurgeAccount(ctx, userID)
+}
+
+// VerifyEmail revokes the tokens of the user, since they are cached as unverified.
+func (rep *cachedAuthRepository) VerifyEmail(ctx context.Context, token string) (string, error) {
+	userID, err := rep.AuthRepository.VerifyEmail(ctx, token)
+	if err == nil {
+		rep.revoke(&Revocation{UserID: userID})
+	}
+	return userID, err
+}
+
+// sessionOf revokes the token and all tokens of its session. The session is taken from the cache,
+// or from the token itself once the auth service has accepted it.
+func (rep *cachedAuthRepository) sessionOf(token string, accepted bool) *Revocation {
+	hash := sha256.Sum256([]byte(token))
+	revocation := &Revocation{TokenHash: hex.EncodeToString(hash[:])}
+	if cached, _ := rep.cache.get(hash, time.Now()); cached != nil {
+		revocation.SessionID = cached.SessionID
+	} else if accepted {
+		claims := new(auth_utils.AuthTokenWrapper)
+		if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err == nil {
+			revocation.SessionID = claims.SessionID
+		}
+	}
+	return revocation
+}
+
+// revoke drops the tokens from the cache here at once, and from the caches of the other instances
+// once they receive the revocation.
+func (rep *cachedAuthRepository) revoke(revocation *Revocation) {
+	rep.cache.revoke(revocation)
+	if err := rep.revocations.Publish(context.Background(), revocation); err != nil {
+		rep.log.Errorf("failed to publish token revocation: %s", err)
+	}
+}
+
+// unavailable tells if the error is about reaching the auth service rather than about the token.
+func unavailable(err error) bool {
+	var coded *constants.CodedError
+	return !errors.As(err, &coded) || errors.Is(err, constants.ErrAuthServiceTimeout)
+}
+
+// tokenExpiry reads the expiry of the token which has been verified already.
+func tokenExpiry(token string) (time.Time, bool) {
+	claims := new(auth_utils.AuthTokenWrapper)
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err != nil || claims.ExpiresAt == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.ExpiresAt, 0), true
+}
@@ -0,0 +1,272 @@
+package cl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/golang-jwt/jwt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuthRepository answers Verify with the identities by token and counts the calls.
+type fakeAuthRepository struct {
+	AuthRepository
+	identities map[string]*Identity
+	err        error
+	calls      int
+}
+
+func (rep *fakeAuthRepository) Verify(_ context.Context, token string) (*Identity, error) {
+	rep.calls++
+	if rep.err != nil {
+		return nil, rep.err
+	}
+	identity, ok := rep.identities[token]
+	if !ok {
+		return nil, constants.ErrSessionRevoked
+	}
+	return identity, nil
+}
+
+func (rep *fakeAuthRepository) CheckAPIToken(ctx context.Context, token string) (*Identity, error) {
+	return rep.Verify(ctx, token)
+}
+
+func (rep *fakeAuthRepository) RevokeAPIToken(context.Context, string, string) error {
+	return nil
+}
+
+func (rep *fakeAuthRepository) Logout(context.Context, string, string) error {
+	return nil
+}
+
+func (rep *fakeAuthRepository) RevokeSession(context.Context, string, string) error {
+	return nil
+}
+
+func (rep *fakeAuthRepository) Refresh(context.Context, string, *ClientInfo) (*Tokens, error) {
+	return nil, constants.ErrRefreshTokenReused
+}
+
+// cacheToken is an unsigned token of the session, the cache only reads its expiry.
+func cacheToken(t *testing.T, sessionID string, expiresIn time.Duration) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, &auth_utils.AuthTokenWrapper{
+		UserID:         "1",
+		SessionID:      sessionID,
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(expiresIn).Unix()},
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	return token
+}
+
+func newCachedRepository(size int, ttl time.Duration, tokens ...string) (*fakeAuthRepository, AuthRepository, *prometheus.CounterVec) {
+	fake := &fakeAuthRepository{identities: make(map[string]*Identity)}
+	for i, token := range tokens {
+		fake.identities[token] = &Identity{UserID: "1", SessionID: string(rune('a' + i))}
+	}
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests"}, []string{"result"})
+	return fake, NewCachedAuthRepository(testLog, fake, NewTokenCache(size, ttl, requests), NewMemoryRevocations()), requests
+}
+
+var testLog = logrus.NewEntry(logrus.New())
+
+func TestTokenCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Hit", func(t *testing.T) {
+		token := cacheToken(t, "a", time.Minute)
+		fake, rep, requests := newCachedRepository(10, time.Minute, token)
+
+		for i := 0; i < 3; i++ {
+			identity, err := rep.Verify(ctx, token)
+			require.NoError(t, err)
+			assert.Equal(t, "a", identity.SessionID)
+		}
+		assert.Equal(t, 1, fake.calls)
+		assert.Equal(t, 2.0, testutil.ToFloat64(requests.WithLabelValues(cacheHit)))
+		assert.Equal(t, 1.0, testutil.ToFloat64(requests.WithLabelValues(cacheMiss)))
+	})
+
+	t.Run("TTL is capped at the token expiry", func(t *testing.T) {
+		token := cacheToken(t, "a", time.Second)
+		fake, rep, _ := newCachedRepository(10, time.Hour, token)
+
+		_, err := rep.Verify(ctx, token)
+		require.NoError(t, err)
+		time.Sleep(time.Second + 100*time.Millisecond)
+
+		fake.err = errors.New("connection refused")
+		_, err = rep.Verify(ctx, token)
+		assert.Error(t, err, "expired tokens must not be served even when the auth service is down")
+		assert.Equal(t, 2, fake.calls)
+	})
+
+	t.Run("Stale while the auth service is down", func(t *testing.T) {
+		token := cacheToken(t, "a", time.Minute)
+		fake, rep, requests := newCachedRepository(10, time.Nanosecond, token)
+
+		_, err := rep.Verify(ctx, token)
+		require.NoError(t, err)
+
+		fake.err = constants.ErrAuthServiceTimeout
+		identity, err := rep.Verify(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, "a", identity.SessionID)
+		assert.Equal(t, 1.0, testutil.ToFloat64(requests.WithLabelValues(cacheStale)))
+
+		fake.err = constants.ErrSessionRevoked
+		_, err = rep.Verify(ctx, token)
+		assert.Equal(t, constants.ErrSessionRevoked, err)
+
+		fake.err = errors.New("connection refused")
+		_, err = rep.Verify(ctx, token)
+		assert.Error(t, err, "rejected tokens must be evicted")
+	})
+
+	t.Run("Size limit", func(t *testing.T) {
+		first, second := cacheToken(t, "a", time.Minute), cacheToken(t, "b", time.Minute)
+		fake, rep, _ := newCachedRepository(1, time.Minute, first, second)
+
+		for _, token := range []string{first, second, first} {
+			_, err := rep.Verify(ctx, token)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 3, fake.calls, "the least recently used token must be evicted")
+		assert.Equal(t, 1, rep.(*cachedAuthRepository).cache.Len())
+	})
+
+	t.Run("Logout", func(t *testing.T) {
+		token := cacheToken(t, "a", time.Minute)
+		fake, rep, _ := newCachedRepository(10, time.Minute, token)
+
+		_, err := rep.Verify(ctx, token)
+		require.NoError(t, err)
+		require.NoError(t, rep.Logout(ctx, token, ""))
+
+		delete(fake.identities, token)
+		_, err = rep.Verify(ctx, token)
+		assert.Equal(t, constants.ErrSessionRevoked, err)
+	})
+
+	t.Run("Revoked session", func(t *testing.T) {
+		first, second := cacheToken(t, "a", time.Minute), cacheToken(t, "b", time.Minute)
+		fake, rep, _ := newCachedRepository(10, time.Minute, first, second)
+
+		for _, token := range []string{first, second} {
+			_, err := rep.Verify(ctx, token)
+			require.NoError(t, err)
+		}
+		require.NoError(t, rep.RevokeSession(ctx, "1", "a"))
+		assert.Equal(t, 1, rep.(*cachedAuthRepository).cache.Len())
+
+		delete(fake.identities, first)
+		_, err := rep.Verify(ctx, first)
+		assert.Equal(t, constants.ErrSessionRevoked, err)
+		_, err = rep.Verify(ctx, second)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, fake.calls)
+	})
+
+	t.Run("Reused refresh token", func(t *testing.T) {
+		first, second := cacheToken(t, "a", time.Minute), cacheToken(t, "b", time.Minute)
+		_, rep, _ := newCachedRepository(10, time.Minute, first, second)
+
+		for _, token := range []string{first, second} {
+			_, err := rep.Verify(ctx, token)
+			require.NoError(t, err)
+		}
+		_, err := rep.Refresh(ctx, "refresh", &ClientInfo{})
+		assert.Equal(t, constants.ErrRefreshTokenReused, err)
+		assert.Equal(t, 0, rep.(*cachedAuthRepository).cache.Len(), "the session revoked for the reuse must not be served")
+	})
+
+	t.Run("Revoked api token", func(t *testing.T) {
+		fake, rep, _ := newCachedRepository(10, time.Minute)
+		fake.identities["cj_pat_a"] = &Identity{UserID: "1", APITokenID: "t1", Scopes: []string{"read:posts"}}
+
+		identity, err := rep.CheckAPIToken(ctx, "cj_pat_a")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"read:posts"}, identity.Scopes)
+		require.NoError(t, rep.RevokeAPIToken(ctx, "1", "t1"))
+
+		delete(fake.identities, "cj_pat_a")
+		_, err = rep.CheckAPIToken(ctx, "cj_pat_a")
+		assert.Equal(t, constants.ErrSessionRevoked, err)
+		assert.Equal(t, 2, fake.calls)
+	})
+}
+
+// TestTokenCacheRevocations runs two caches as if they were two instances of the app, the tokens
+// revoked through one of them must not be served from the cache of the other one.
+func TestTokenCacheRevocations(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+
+	newRedisRevocations := func(t *testing.T) Revocations {
+		revocations, err := NewRedisRevocations(testLog, &redis.Options{Addr: server.Addr()})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = revocations.Close() })
+		return revocations
+	}
+
+	// The revocations of the local and the remote instance, the memory ones are shared within one process only.
+	newRevocations := map[string]func(t *testing.T) (Revocations, Revocations){
+		"Memory": func(t *testing.T) (Revocations, Revocations) {
+			revocations := NewMemoryRevocations()
+			return revocations, revocations
+		},
+		"Redis": func(t *testing.T) (Revocations, Revocations) {
+			return newRedisRevocations(t), newRedisRevocations(t)
+		},
+	}
+
+	for name, newRevocations := range newRevocations {
+		t.Run(name, func(t *testing.T) {
+			first, second := cacheToken(t, "a", time.Minute), cacheToken(t, "b", time.Minute)
+			fake := &fakeAuthRepository{identities: map[string]*Identity{
+				first:      {UserID: "1", SessionID: "a"},
+				second:     {UserID: "2", SessionID: "b"},
+				"cj_pat_a": {UserID: "2", APITokenID: "t1"},
+			}}
+
+			localRevocations, remoteRevocations := newRevocations(t)
+			local := NewCachedAuthRepository(testLog, fake, NewTokenCache(10, time.Minute, nil), localRevocations)
+			remote := NewCachedAuthRepository(testLog, fake, NewTokenCache(10, time.Minute, nil), remoteRevocations)
+			remoteCache := remote.(*cachedAuthRepository).cache
+
+			for _, token := range []string{first, second} {
+				_, err := remote.Verify(ctx, token)
+				require.NoError(t, err)
+			}
+			_, err := remote.CheckAPIToken(ctx, "cj_pat_a")
+			require.NoError(t, err)
+			require.Equal(t, 3, remoteCache.Len())
+
+			require.NoError(t, local.Logout(ctx, first, ""))
+			assert.Eventually(t, func() bool { return remoteCache.Len() == 2 }, time.Second, 10*time.Millisecond,
+				"the session logged out on one instance must be dropped on the other one")
+
+			require.NoError(t, local.RevokeAPIToken(ctx, "2", "t1"))
+			assert.Eventually(t, func() bool { return remoteCache.Len() == 1 }, time.Second, 10*time.Millisecond,
+				"the api token revoked on one instance must be dropped on the other one")
+
+			require.NoError(t, local.RevokeSession(ctx, "2", "b"))
+			assert.Eventually(t, func() bool { return remoteCache.Len() == 0 }, time.Second, 10*time.Millisecond,
+				"the session revoked on one instance must be dropped on the other one")
+
+			delete(fake.identities, second)
+			_, err = remote.Verify(ctx, second)
+			assert.Equal(t, constants.ErrSessionRevoked, err)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package cl
+
+import (
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	handler "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/handler"
+	"google.golang.org/grpc/codes"
+)
+
+// reasonErrors maps the reasons sent by the auth service to errors of the main service.
+var reasonErrors = map[handler.ErrorReason]*constants.CodedError{
+	handler.ErrorReason_MISSING_AUTH_TOKEN:           constants.ErrMissingAuthToken,
+	handler.ErrorReason_MISSING_AUTH_COOKIE:          constants.ErrMissingAuthCookie,
+	handler.ErrorReason_PASSWORD_MISMATCH:            constants.ErrPasswordMismatch,
+	handler.ErrorReason_AUTH_TOKEN_INVALID:           constants.ErrAuthTokenInvalid,
+	handler.ErrorReason_UNEXPECTED_SIGNING_METHOD:    constants.ErrUnexpectedSigningMethod,
+	handler.ErrorReason_REFRESH_TOKEN_INVALID:        constants.ErrRefreshTokenInvalid,
+	handler.ErrorReason_REFRESH_TOKEN_REUSED:         constants.ErrRefreshTokenReused,
+	handler.ErrorReason_SESSION_REVOKED:              constants.ErrSessionRevoked,
+	handler.ErrorReason_TWO_FACTOR_CODE_INVALID:      constants.ErrTwoFactorCodeInvalid,
+	handler.ErrorReason_TWO_FACTOR_TICKET_INVALID:    constants.ErrTwoFactorTicketInvalid,
+	handler.ErrorReason_AUTH_TOKEN_EXPIRED:           constants.ErrAuthTokenExpired,
+	handler.ErrorReason_AUTHOR_ID_MISMATCH:           constants.ErrAuthorIDMismatch,
+	handler.ErrorReason_SESSION_NOT_FOUND:            constants.ErrSessionNotFound,
+	handler.ErrorReason_VALIDATE_REQUEST:             constants.ErrValidateRequest,
+	handler.ErrorReason_DB_NOT_FOUND:                 constants.ErrDBNotFound,
+	handler.ErrorReason_PASSWORD_HASH:                constants.ErrPassword,
+	handler.ErrorReason_PASSWORD_SALT:                constants.ErrPassword,
+	handler.ErrorReason_PASSWORD_ALGO:                constants.ErrPassword,
+	handler.ErrorReason_TWO_FACTOR_NOT_ENROLLED:      constants.ErrTwoFactorNotEnrolled,
+	handler.ErrorReason_TWO_FACTOR_NOT_ENABLED:       constants.ErrTwoFactorNotEnabled,
+	handler.ErrorReason_RESET_TOKEN_INVALID:          constants.ErrResetTokenInvalid,
+	handler.ErrorReason_SIGN_TOKEN:                   constants.ErrSignToken,
+	handler.ErrorReason_GENERATE_UUID:                constants.ErrGenerateUUID,
+	handler.ErrorReason_PARSE_AUTH_TOKEN:             constants.ErrParseAuthToken,
+	handler.ErrorReason_EMAIL_ALREADY_TAKEN:          constants.ErrEmailAlreadyTaken,
+	handler.ErrorReason_TWO_FACTOR_ALREADY_ENABLED:   constants.ErrTwoFactorAlreadyEnabled,
+	handler.ErrorReason_TOO_MANY_LOGIN_ATTEMPTS:      constants.ErrTooManyLoginAttempts,
+	handler.ErrorReason_ACCOUNT_DELETED:              constants.ErrAccountDeleted,
+	handler.ErrorReason_ACCOUNT_DELETION_NOT_DUE:     constants.ErrAccountDeletionNotDue,
+	handler.ErrorReason_EMAIL_ALREADY_VERIFIED:       constants.ErrEmailAlreadyVerified,
+	handler.ErrorReason_VERIFICATION_TOKEN_INVALID:   constants.ErrVerificationTokenInvalid,
+	handler.ErrorReason_VERIFICATION_RESEND_TOO_SOON: constants.ErrVerificationResendTooSoon,
+	handler.ErrorReason_IDENTITY_ALREADY_LINKED:      constants.ErrIdentityAlreadyLinked,
+	handler.ErrorReason_IDENTITY_NOT_FOUND:           constants.ErrIdentityNotFound,
+	handler.ErrorReason_LAST_LOGIN_METHOD:            constants.ErrLastLoginMethod,
+	handler.ErrorReason_API_TOKEN_INVALID:            constants.ErrAPITokenInvalid,
+	handler.ErrorReason_API_TOKEN_NOT_FOUND:          constants.ErrAPITokenNotFound,
+	handler.ErrorReason_SCOPE_INVALID:                constants.ErrScopeInvalid,
+	handler.ErrorReason_REAUTH_REQUIRED:              constants.ErrReauthRequired,
+}
+
+// codeErrors maps the codes of the statuses sent without a reason, e.g. by the interceptors
+// of the auth service or by grpc itself, to errors of the main service.
+var codeErrors = map[codes.Code]*constants.CodedError{
+	codes.DeadlineExceeded:  constants.ErrAuthServiceTimeout,
+	codes.Unauthenticated:   constants.ErrAuthTokenInvalid,
+	codes.PermissionDenied:  constants.ErrAuthorIDMismatch,
+	codes.NotFound:          constants.ErrDBNotFound,
+	codes.InvalidArgument:   constants.ErrValidateRequest,
+	codes.ResourceExhausted: constants.ErrTooManyLoginAttempts,
+}
@@ -0,0 +1,88 @@
+package cl
+
+import (
+	"testing"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	handler "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/handler"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestReasonErrorsComplete(t *testing.T) {
+	for value, name := range handler.ErrorReason_name {
+		if handler.ErrorReason(value) == handler.ErrorReason_UNSPECIFIED {
+			continue
+		}
+		_, ok := reasonErrors[handler.ErrorReason(value)]
+		assert.True(t, ok, "reason %s is not mapped", name)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	rep := &AuthRepositoryImpl{}
+
+	tests := []struct {
+		name string
+		err  error
+		res  error
+	}{
+		{
+			name: "Coded error",
+			err:  status.Convert(auth_constants.ErrEmailAlreadyTaken).Err(),
+			res:  constants.ErrEmailAlreadyTaken,
+		},
+		{
+			name: "Expired token",
+			err:  status.Convert(auth_constants.ErrAuthTokenExpired).Err(),
+			res:  constants.ErrAuthTokenExpired,
+		},
+		{
+			name: "Deadline exceeded",
+			err:  status.Error(codes.DeadlineExceeded, "context deadline exceeded"),
+			res:  constants.ErrAuthServiceTimeout,
+		},
+		{
+			name: "Unauthenticated without detail",
+			err:  status.Error(codes.Unauthenticated, "missing metadata"),
+			res:  constants.ErrAuthTokenInvalid,
+		},
+		{
+			name: "Permission denied without detail",
+			err:  status.Error(codes.PermissionDenied, "permission denied"),
+			res:  constants.ErrAuthorIDMismatch,
+		},
+		{
+			name: "Invalid argument without detail",
+			err:  status.Error(codes.InvalidArgument, "bad request"),
+			res:  constants.ErrValidateRequest,
+		},
+		{
+			name: "No detail",
+			err:  status.Error(codes.Unavailable, "connection refused"),
+			res:  nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := rep.ParseError(test.err)
+			if test.res == nil {
+				assert.EqualError(t, res, "connection refused")
+				return
+			}
+			assert.Equal(t, test.res, res)
+		})
+	}
+}
+
+func TestCodedErrorStatus(t *testing.T) {
+	st := status.Convert(auth_constants.ErrPasswordMismatch)
+	assert.Equal(t, codes.Unauthenticated, st.Code())
+	assert.Equal(t, auth_constants.ErrPasswordMismatch.Error(), st.Message())
+	if assert.Len(t, st.Details(), 1) {
+		assert.Equal(t, handler.ErrorReason_PASSWORD_MISMATCH, st.Details()[0].(*handler.ErrorDetail).GetReason())
+	}
+}
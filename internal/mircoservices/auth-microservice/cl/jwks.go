@@ -0,0 +1,116 @@
+package cl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/golang-jwt/jwt"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// jwksRefreshPeriod is how often the published keys are reloaded.
+	jwksRefreshPeriod = 5 * time.Minute
+	// jwksMissRefreshPeriod limits reloads caused by tokens with unknown key ids.
+	jwksMissRefreshPeriod = 10 * time.Second
+	jwksRequestTimeout    = 5 * time.Second
+)
+
+// jwksCache keeps the public keys of access tokens fetched from the auth service.
+type jwksCache struct {
+	log    *logrus.Entry
+	url    string
+	client *http.Client
+
+	mu        sync.Mutex
+	keyRing   *auth_utils.KeyRing
+	fetchedAt time.Time
+}
+
+func newJWKSCache(log *logrus.Entry, url string) *jwksCache {
+	return &jwksCache{log: log, url: url, client: &http.Client{Timeout: jwksRequestTimeout}}
+}
+
+// get returns the keys, reloading them when they are stale or the key id is not known yet.
+// Keys loaded before are kept if the auth service is unavailable.
+func (c *jwksCache) get(kid string) (*auth_utils.KeyRing, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	since := time.Since(c.fetchedAt)
+	if c.keyRing != nil && since < jwksRefreshPeriod && (c.keyRing.Has(kid) || since < jwksMissRefreshPeriod) {
+		return c.keyRing, nil
+	}
+
+	keyRing, err := c.fetch()
+	c.fetchedAt = time.Now()
+	if err != nil {
+		if c.keyRing == nil {
+			return nil, err
+		}
+		c.log.Errorf("fetch JWKS error: %s", err)
+		return c.keyRing, nil
+	}
+	c.keyRing = keyRing
+	return keyRing, nil
+}
+
+func (c *jwksCache) fetch() (*auth_utils.KeyRing, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	set := new(auth_utils.JWKSet)
+	if err := json.NewDecoder(res.Body).Decode(set); err != nil {
+		return nil, err
+	}
+	return auth_utils.NewKeyRingFromJWKS(set)
+}
+
+// Verify validates the signature and the expiry of the access token locally with the keys published
+// by the auth service, so that the requests don't wait for it. The session of the token is looked up
+// with Check only when it is enabled in the config: then the revoked sessions are rejected and
+// the verification of the email is taken from the session, since the token may predate it.
+// Otherwise the token is trusted until it expires, so the revoked sessions stay usable for the access ttl
+// at most. Without JWKS url in the config it is the same as Check.
+func (redisConnect *AuthRepositoryImpl) Verify(ctx context.Context, token string) (*Identity, error) {
+	unverified, _, err := new(jwt.Parser).ParseUnverified(token, &auth_utils.AuthTokenWrapper{})
+	if err != nil {
+		return nil, constants.ErrAuthTokenInvalid
+	}
+	if redisConnect.keys == nil {
+		return redisConnect.Check(ctx, token)
+	}
+
+	kid, _ := unverified.Header["kid"].(string)
+	keyRing, err := redisConnect.keys.get(kid)
+	if err != nil {
+		redisConnect.log.Errorf("get JWKS error: %s", err)
+		return redisConnect.Check(ctx, token)
+	}
+
+	claims, err := keyRing.Parse(token)
+	if err != nil {
+		return nil, redisConnect.ParseError(err)
+	}
+	if redisConnect.checkSessions {
+		return redisConnect.Check(ctx, token)
+	}
+	return &Identity{UserID: claims.UserID, SessionID: claims.SessionID, Unverified: claims.Unverified}, nil
+}
@@ -0,0 +1,177 @@
+package cl
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/handler"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/golang-jwt/jwt"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func testKeyRing(t *testing.T, ids ...string) *auth_utils.KeyRing {
+	t.Helper()
+	keys := make([]*auth_utils.SigningKey, 0, len(ids))
+	for _, id := range ids {
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		keys = append(keys, &auth_utils.SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, Private: private, Public: public})
+	}
+	keyRing, err := auth_utils.NewKeyRing(ids[0], keys)
+	require.NoError(t, err)
+	return keyRing
+}
+
+func testClaims(expiresIn time.Duration) *auth_utils.AuthTokenWrapper {
+	return &auth_utils.AuthTokenWrapper{UserID: "1", SessionID: "2", StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(expiresIn).Unix()}}
+}
+
+// testSessions is the auth service which knows the sessions, it is told the tokens checked.
+type testSessions struct {
+	handler.UserAuthClient
+	checked    int32
+	revoked    bool
+	unverified bool
+}
+
+func (s *testSessions) Check(_ context.Context, in *handler.CheckReq, _ ...grpc.CallOption) (*handler.CheckRes, error) {
+	atomic.AddInt32(&s.checked, 1)
+	if s.revoked {
+		return nil, auth_constants.ErrSessionRevoked
+	}
+	claims := new(auth_utils.AuthTokenWrapper)
+	if _, _, err := new(jwt.Parser).ParseUnverified(in.Token, claims); err != nil {
+		return nil, err
+	}
+	return &handler.CheckRes{UserID: claims.UserID, SessionID: claims.SessionID, Unverified: s.unverified}, nil
+}
+
+func TestVerify(t *testing.T) {
+	keyRing := testKeyRing(t, "first")
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_ = json.NewEncoder(w).Encode(keyRing.JWKS())
+	}))
+	defer server.Close()
+
+	sessions := &testSessions{}
+	rep := &AuthRepositoryImpl{log: logrus.NewEntry(logrus.New()), client: sessions, keys: newJWKSCache(logrus.NewEntry(logrus.New()), server.URL), timeout: time.Second}
+
+	t.Run("Valid token", func(t *testing.T) {
+		token, err := keyRing.Sign(testClaims(time.Minute))
+		require.NoError(t, err)
+
+		identity, err := rep.Verify(context.Background(), token)
+		assert.Nil(t, err)
+		assert.Equal(t, "1", identity.UserID)
+		assert.Equal(t, "2", identity.SessionID)
+		assert.False(t, identity.Unverified)
+
+		_, err = rep.Verify(context.Background(), token)
+		assert.Nil(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests), "keys must be cached")
+		assert.Equal(t, int32(0), atomic.LoadInt32(&sessions.checked), "sessions must not be looked up unless enabled")
+	})
+
+	t.Run("Unverified token", func(t *testing.T) {
+		claims := testClaims(time.Minute)
+		claims.Unverified = true
+		token, err := keyRing.Sign(claims)
+		require.NoError(t, err)
+
+		identity, err := rep.Verify(context.Background(), token)
+		assert.Nil(t, err)
+		assert.True(t, identity.Unverified)
+	})
+
+	t.Run("Verified since the token is issued", func(t *testing.T) {
+		rep.checkSessions = true
+		defer func() { rep.checkSessions = false }()
+
+		claims := testClaims(time.Minute)
+		claims.Unverified = true
+		token, err := keyRing.Sign(claims)
+		require.NoError(t, err)
+
+		identity, err := rep.Verify(context.Background(), token)
+		assert.Nil(t, err)
+		assert.False(t, identity.Unverified, "the verification must be taken from the session")
+
+		sessions.unverified = true
+		identity, err = rep.Verify(context.Background(), token)
+		sessions.unverified = false
+		assert.Nil(t, err)
+		assert.True(t, identity.Unverified)
+	})
+
+	t.Run("Revoked session", func(t *testing.T) {
+		token, err := keyRing.Sign(testClaims(time.Minute))
+		require.NoError(t, err)
+
+		rep.checkSessions = true
+		sessions.revoked = true
+		defer func() { rep.checkSessions, sessions.revoked = false, false }()
+		identity, err := rep.Verify(context.Background(), token)
+		assert.Nil(t, identity)
+		assert.Equal(t, constants.ErrSessionRevoked, err, "the valid token of the revoked session must be rejected")
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		token, err := keyRing.Sign(testClaims(-time.Minute))
+		require.NoError(t, err)
+
+		checked := atomic.LoadInt32(&sessions.checked)
+		_, err = rep.Verify(context.Background(), token)
+		assert.Equal(t, constants.ErrAuthTokenExpired, err)
+		assert.Equal(t, checked, atomic.LoadInt32(&sessions.checked), "the expired token must not reach the auth service")
+	})
+
+	t.Run("Shared secret token", func(t *testing.T) {
+		// Tokens signed with a shared secret are never accepted, whatever key id they claim.
+		jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims(time.Minute))
+		jwtToken.Header["kid"] = "first"
+		token, err := jwtToken.SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		identity, err := rep.Verify(context.Background(), token)
+		assert.Nil(t, identity)
+		assert.Equal(t, constants.ErrUnexpectedSigningMethod, err)
+	})
+
+	t.Run("Rotated key", func(t *testing.T) {
+		keyRing = testKeyRing(t, "second", "first")
+		rep.keys.fetchedAt = time.Now().Add(-jwksMissRefreshPeriod)
+
+		token, err := keyRing.Sign(testClaims(time.Minute))
+		require.NoError(t, err)
+
+		identity, err := rep.Verify(context.Background(), token)
+		assert.Nil(t, err)
+		assert.Equal(t, "1", identity.UserID)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("Unknown key", func(t *testing.T) {
+		token, err := testKeyRing(t, "unknown").Sign(testClaims(time.Minute))
+		require.NoError(t, err)
+
+		_, err = rep.Verify(context.Background(), token)
+		assert.Equal(t, constants.ErrAuthTokenInvalid, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests), "unknown keys must not reload keys too often")
+	})
+}
@@ -0,0 +1,147 @@
+package cl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Revocation tells the token caches which tokens to drop: the ones of the user, of the session,
+// of the api token, the token itself or all of them.
+type Revocation struct {
+	UserID     string `json:"user_id,omitempty"`
+	SessionID  string `json:"session_id,omitempty"`
+	APITokenID string `json:"api_token_id,omitempty"`
+	TokenHash  string `json:"token_hash,omitempty"` // hex of the sha256 of the token
+	All        bool   `json:"all,omitempty"`
+}
+
+func (r *Revocation) matches(hash [sha256.Size]byte, identity *Identity) bool {
+	return r.All ||
+		(len(r.UserID) != 0 && identity.UserID == r.UserID) ||
+		(len(r.SessionID) != 0 && identity.SessionID == r.SessionID) ||
+		(len(r.APITokenID) != 0 && identity.APITokenID == r.APITokenID) ||
+		(len(r.TokenHash) != 0 && hex.EncodeToString(hash[:]) == r.TokenHash)
+}
+
+// Revocations delivers the revocations to the token caches of every instance of the service,
+// so a session ended through one instance is not served from the caches of the others.
+type Revocations interface {
+	Publish(ctx context.Context, revocation *Revocation) error
+	// Subscribe calls handle for every revocation published by any instance, this one included.
+	Subscribe(handle func(revocation *Revocation))
+	Close() error
+}
+
+// NewRevocations creates the revocations delivered through the broker chosen in the config,
+// every instance running with the token cache needs the same one.
+func NewRevocations(log *logrus.Entry) (Revocations, error) {
+	switch brokerType := viper.GetString(constants.ConfigAuthRevocationsType); brokerType {
+	case constants.ChatBrokerMemory, "":
+		return NewMemoryRevocations(), nil
+	case constants.ChatBrokerRedis:
+		return NewRedisRevocations(log, &redis.Options{
+			Addr:     viper.GetString(constants.ConfigAuthRevocationsAddress),
+			Password: viper.GetString(constants.ConfigAuthRevocationsPassword),
+		})
+	default:
+		return nil, fmt.Errorf("unknown token revocations type: %s", brokerType)
+	}
+}
+
+// MemoryRevocations delivers the revocations within the process, it is enough for a single instance.
+type MemoryRevocations struct {
+	sync.Mutex
+	handlers []func(revocation *Revocation)
+}
+
+func NewMemoryRevocations() *MemoryRevocations {
+	return &MemoryRevocations{}
+}
+
+func (r *MemoryRevocations) Publish(_ context.Context, revocation *Revocation) error {
+	r.Lock()
+	handlers := append([]func(revocation *Revocation){}, r.handlers...)
+	r.Unlock()
+
+	for _, handle := range handlers {
+		handle(revocation)
+	}
+	return nil
+}
+
+func (r *MemoryRevocations) Subscribe(handle func(revocation *Revocation)) {
+	r.Lock()
+	defer r.Unlock()
+	r.handlers = append(r.handlers, handle)
+}
+
+func (r *MemoryRevocations) Close() error {
+	return nil
+}
+
+// RedisRevocations delivers the revocations through Redis pub/sub to every instance of the service.
+type RedisRevocations struct {
+	client *redis.Client
+	pubsub *redis.PubSub
+	local  *MemoryRevocations
+	log    *logrus.Entry
+}
+
+func NewRedisRevocations(log *logrus.Entry, opts *redis.Options) (*RedisRevocations, error) {
+	client := redis.NewClient(opts)
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	pubsub := client.Subscribe(context.Background(), constants.AuthRevocationsChannel)
+	// The revocations published before the subscription is confirmed would be missed.
+	if _, err := pubsub.Receive(context.Background()); err != nil {
+		_ = pubsub.Close()
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to subscribe to revocations: %w", err)
+	}
+
+	r := &RedisRevocations{client: client, pubsub: pubsub, local: NewMemoryRevocations(), log: log}
+	go r.receive()
+	return r, nil
+}
+
+func (r *RedisRevocations) receive() {
+	for m := range r.pubsub.Channel() {
+		revocation := new(Revocation)
+		if err := json.Unmarshal([]byte(m.Payload), revocation); err != nil {
+			r.log.Errorf("failed to decode token revocation: %s", err)
+			continue
+		}
+		_ = r.local.Publish(context.Background(), revocation)
+	}
+}
+
+func (r *RedisRevocations) Publish(ctx context.Context, revocation *Revocation) error {
+	payload, err := json.Marshal(revocation)
+	if err != nil {
+		return err
+	}
+	return r.client.Publish(ctx, constants.AuthRevocationsChannel, payload).Err()
+}
+
+func (r *RedisRevocations) Subscribe(handle func(revocation *Revocation)) {
+	r.local.Subscribe(handle)
+}
+
+func (r *RedisRevocations) Close() error {
+	if err := r.pubsub.Close(); err != nil {
+		return err
+	}
+	return r.client.Close()
+}
@@ -0,0 +1,20 @@
+package auth_constants
+
+import "time"
+
+const (
+	ViperAccountDeletionGraceKey = "account_deletion.grace_period"
+)
+
+const (
+	// DefaultAccountDeletionGrace is how long the deletion can be cancelled by logging in.
+	DefaultAccountDeletionGrace = 30 * 24 * time.Hour
+
+	// DefaultAccountDeletionClaimLimit is how many accounts are handed out for purging at once.
+	DefaultAccountDeletionClaimLimit = 100
+
+	// AccountDeletionRetryBackoff is how long an account claimed for purging waits to be claimed again,
+	// it doubles with every attempt up to AccountDeletionMaxRetryBackoff.
+	AccountDeletionRetryBackoff    = time.Minute
+	AccountDeletionMaxRetryBackoff = 24 * time.Hour
+)
@@ -0,0 +1,26 @@
+package auth_constants
+
+const (
+	// APITokenPrefix marks the personal access tokens, so they are easy to tell from other secrets.
+	APITokenPrefix = "cj_pat_"
+	// APITokenTouchPeriod is how often (in seconds) the last use time of the api token is updated.
+	APITokenTouchPeriod = 60
+)
+
+// APITokenScopes are the scopes which the api tokens may be granted: read or write access to a resource.
+var APITokenScopes = map[string]bool{
+	"read:profile":      true,
+	"write:profile":     true,
+	"read:posts":        true,
+	"write:posts":       true,
+	"read:comments":     true,
+	"write:comments":    true,
+	"read:friends":      true,
+	"write:friends":     true,
+	"read:messages":     true,
+	"write:messages":    true,
+	"read:communities":  true,
+	"write:communities": true,
+	"read:files":        true,
+	"write:files":       true,
+}
@@ -0,0 +1,20 @@
+package auth_constants
+
+// DurationConfigKeys are the settings of the auth service given as durations like "15m".
+// The bare numbers set in them are read as seconds, see utils.SecondsToDurations.
+var DurationConfigKeys = []string{
+	ViperAccessTTLKey,
+	ViperRefreshTTLKey,
+	ViperRefreshReuseGraceKey,
+	ViperPasswordResetTTLKey,
+	ViperPasswordResetCooldownKey,
+	ViperLoginTicketTTLKey,
+	ViperEmailVerificationTTLKey,
+	ViperEmailVerificationResendCooldownKey,
+	ViperLoginBaseDelayKey,
+	ViperLoginMaxDelayKey,
+	ViperLoginLockoutKey,
+	ViperLoginWindowKey,
+	ViperAccountDeletionGraceKey,
+	ViperReauthMaxAgeKey,
+}
@@ -0,0 +1,13 @@
+package auth_constants
+
+import "time"
+
+const (
+	ViperEmailVerificationTTLKey            = "email_verification.ttl"
+	ViperEmailVerificationResendCooldownKey = "email_verification.resend_cooldown"
+)
+
+const (
+	// DefaultEmailVerificationResendCooldown is how long the user waits before another verification email.
+	DefaultEmailVerificationResendCooldown = time.Minute
+)
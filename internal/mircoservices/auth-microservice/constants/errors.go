@@ -2,48 +2,104 @@ package auth_constants
 
 import (
 	"errors"
-	"net/http"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/handler"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-// CodedError is an error wrapper which wraps errors with http status codes.
+// CodedError is an error wrapper which wraps errors with gRPC status codes and reasons.
+// It is converted to the gRPC status with ErrorDetail, so clients never parse error texts.
 type CodedError struct {
-	err  error
-	code int
+	err    error
+	code   codes.Code
+	reason handler.ErrorReason
 }
 
 func (ce *CodedError) Error() string {
 	return ce.err.Error()
 }
 
-func (ce *CodedError) Code() int {
+func (ce *CodedError) Code() codes.Code {
 	return ce.code
 }
 
+func (ce *CodedError) Reason() handler.ErrorReason {
+	return ce.reason
+}
+
+// GRPCStatus is used by grpc to send the error to the client.
+func (ce *CodedError) GRPCStatus() *status.Status {
+	st := status.New(ce.code, ce.Error())
+	if detailed, err := st.WithDetails(&handler.ErrorDetail{Reason: ce.reason}); err == nil {
+		return detailed
+	}
+	return st
+}
+
 var (
-	// Unathorized
-	ErrMissingAuthToken  = &CodedError{errors.New("missing authorization token"), http.StatusUnauthorized}
-	ErrMissingAuthCookie = &CodedError{errors.New("missing authorization cookie"), http.StatusUnauthorized}
+	// Unauthenticated
+	ErrMissingAuthToken  = &CodedError{errors.New("missing authorization token"), codes.Unauthenticated, handler.ErrorReason_MISSING_AUTH_TOKEN}
+	ErrMissingAuthCookie = &CodedError{errors.New("missing authorization cookie"), codes.Unauthenticated, handler.ErrorReason_MISSING_AUTH_COOKIE}
+
+	ErrPasswordMismatch = &CodedError{errors.New("password mismatch"), codes.Unauthenticated, handler.ErrorReason_PASSWORD_MISMATCH}
+
+	ErrAuthTokenInvalid        = &CodedError{errors.New("authorization token is invalid"), codes.Unauthenticated, handler.ErrorReason_AUTH_TOKEN_INVALID}
+	ErrAuthTokenExpired        = &CodedError{errors.New("authorization token is expired"), codes.Unauthenticated, handler.ErrorReason_AUTH_TOKEN_EXPIRED}
+	ErrUnexpectedSigningMethod = &CodedError{errors.New("unexpected signing method"), codes.Unauthenticated, handler.ErrorReason_UNEXPECTED_SIGNING_METHOD}
+
+	ErrRefreshTokenInvalid = &CodedError{errors.New("refresh token is invalid"), codes.Unauthenticated, handler.ErrorReason_REFRESH_TOKEN_INVALID}
+	ErrRefreshTokenReused  = &CodedError{errors.New("refresh token reuse detected"), codes.Unauthenticated, handler.ErrorReason_REFRESH_TOKEN_REUSED}
+	ErrSessionRevoked      = &CodedError{errors.New("session is revoked"), codes.Unauthenticated, handler.ErrorReason_SESSION_REVOKED}
 
-	ErrPasswordMismatch = &CodedError{errors.New("password mismatch"), http.StatusUnauthorized}
+	ErrTwoFactorCodeInvalid   = &CodedError{errors.New("two-factor code is invalid"), codes.Unauthenticated, handler.ErrorReason_TWO_FACTOR_CODE_INVALID}
+	ErrTwoFactorTicketInvalid = &CodedError{errors.New("two-factor ticket is invalid or expired"), codes.Unauthenticated, handler.ErrorReason_TWO_FACTOR_TICKET_INVALID}
 
-	ErrAuthTokenInvalid        = &CodedError{errors.New("authorization token is invalid"), http.StatusUnauthorized}
-	ErrUnexpectedSigningMethod = &CodedError{errors.New("unexpected signing method"), http.StatusUnauthorized}
+	ErrAPITokenInvalid = &CodedError{errors.New("api token is invalid, expired or revoked"), codes.Unauthenticated, handler.ErrorReason_API_TOKEN_INVALID}
 
-	// Forbidden
-	ErrAuthTokenExpired = &CodedError{errors.New("authorization token is expired"), http.StatusForbidden}
-	ErrAuthorIDMismatch = &CodedError{errors.New("author id mismatch"), http.StatusForbidden}
+	// Permission Denied
+	ErrAuthorIDMismatch = &CodedError{errors.New("author id mismatch"), codes.PermissionDenied, handler.ErrorReason_AUTHOR_ID_MISMATCH}
+	ErrReauthRequired   = &CodedError{errors.New("log in again to confirm the change"), codes.PermissionDenied, handler.ErrorReason_REAUTH_REQUIRED}
 
-	// Bad Request
-	ErrValidateRequest = &CodedError{errors.New("failed to validate request"), http.StatusBadRequest}
-	ErrDBNotFound      = &CodedError{errors.New("not found in the database"), http.StatusBadRequest}
-	ErrPassword        = &CodedError{errors.New("error generating hash"), http.StatusBadRequest}
-	ErrPasswordSalt    = &CodedError{errors.New("error generating salt"), http.StatusBadRequest}
+	// Not Found
+	ErrSessionNotFound = &CodedError{errors.New("session not found"), codes.NotFound, handler.ErrorReason_SESSION_NOT_FOUND}
+	ErrDBNotFound      = &CodedError{errors.New("not found in the database"), codes.NotFound, handler.ErrorReason_DB_NOT_FOUND}
+	ErrAccountDeleted  = &CodedError{errors.New("account is deleted"), codes.NotFound, handler.ErrorReason_ACCOUNT_DELETED}
+
+	ErrIdentityNotFound = &CodedError{errors.New("external identity is not linked"), codes.NotFound, handler.ErrorReason_IDENTITY_NOT_FOUND}
+	ErrAPITokenNotFound = &CodedError{errors.New("api token not found"), codes.NotFound, handler.ErrorReason_API_TOKEN_NOT_FOUND}
+
+	// Invalid Argument
+	ErrValidateRequest   = &CodedError{errors.New("failed to validate request"), codes.InvalidArgument, handler.ErrorReason_VALIDATE_REQUEST}
+	ErrResetTokenInvalid = &CodedError{errors.New("password reset token is invalid or expired"), codes.InvalidArgument, handler.ErrorReason_RESET_TOKEN_INVALID}
+
+	ErrVerificationTokenInvalid = &CodedError{errors.New("email verification token is invalid or expired"), codes.InvalidArgument, handler.ErrorReason_VERIFICATION_TOKEN_INVALID}
+
+	ErrScopeInvalid = &CodedError{errors.New("unknown api token scope"), codes.InvalidArgument, handler.ErrorReason_SCOPE_INVALID}
+
+	// Failed Precondition
+	ErrTwoFactorNotEnrolled = &CodedError{errors.New("two-factor authentication is not enrolled"), codes.FailedPrecondition, handler.ErrorReason_TWO_FACTOR_NOT_ENROLLED}
+	ErrTwoFactorNotEnabled  = &CodedError{errors.New("two-factor authentication is not enabled"), codes.FailedPrecondition, handler.ErrorReason_TWO_FACTOR_NOT_ENABLED}
+
+	ErrAccountDeletionNotDue = &CodedError{errors.New("account deletion is not due yet"), codes.FailedPrecondition, handler.ErrorReason_ACCOUNT_DELETION_NOT_DUE}
+
+	ErrLastLoginMethod = &CodedError{errors.New("can't remove the only way to log in"), codes.FailedPrecondition, handler.ErrorReason_LAST_LOGIN_METHOD}
 
 	// Internal
-	ErrSignToken      = &CodedError{errors.New("failed to sign token"), http.StatusInternalServerError}
-	ErrGenerateUUID   = &CodedError{errors.New("failed to generate UUID"), http.StatusInternalServerError}
-	ErrParseAuthToken = &CodedError{errors.New("failed to parse authorization token"), http.StatusInternalServerError}
+	ErrPassword       = &CodedError{errors.New("error generating hash"), codes.Internal, handler.ErrorReason_PASSWORD_HASH}
+	ErrPasswordSalt   = &CodedError{errors.New("error generating salt"), codes.Internal, handler.ErrorReason_PASSWORD_SALT}
+	ErrPasswordAlgo   = &CodedError{errors.New("unknown password hashing algorithm"), codes.Internal, handler.ErrorReason_PASSWORD_ALGO}
+	ErrSignToken      = &CodedError{errors.New("failed to sign token"), codes.Internal, handler.ErrorReason_SIGN_TOKEN}
+	ErrGenerateUUID   = &CodedError{errors.New("failed to generate UUID"), codes.Internal, handler.ErrorReason_GENERATE_UUID}
+	ErrParseAuthToken = &CodedError{errors.New("failed to parse authorization token"), codes.Internal, handler.ErrorReason_PARSE_AUTH_TOKEN}
+
+	// Already Exists
+	ErrEmailAlreadyTaken       = &CodedError{errors.New("email is taken already by other user"), codes.AlreadyExists, handler.ErrorReason_EMAIL_ALREADY_TAKEN}
+	ErrTwoFactorAlreadyEnabled = &CodedError{errors.New("two-factor authentication is already enabled"), codes.AlreadyExists, handler.ErrorReason_TWO_FACTOR_ALREADY_ENABLED}
+	ErrEmailAlreadyVerified    = &CodedError{errors.New("email is verified already"), codes.AlreadyExists, handler.ErrorReason_EMAIL_ALREADY_VERIFIED}
+	ErrIdentityAlreadyLinked   = &CodedError{errors.New("external identity is already linked"), codes.AlreadyExists, handler.ErrorReason_IDENTITY_ALREADY_LINKED}
 
-	// Conflict
-	ErrEmailAlreadyTaken = &CodedError{errors.New("email is taken already by other user"), http.StatusConflict}
+	// Resource Exhausted
+	ErrTooManyLoginAttempts      = &CodedError{errors.New("too many login attempts, try again later"), codes.ResourceExhausted, handler.ErrorReason_TOO_MANY_LOGIN_ATTEMPTS}
+	ErrVerificationResendTooSoon = &CodedError{errors.New("verification email was sent recently, try again later"), codes.ResourceExhausted, handler.ErrorReason_VERIFICATION_RESEND_TOO_SOON}
 )
@@ -1,6 +1,31 @@
 package auth_constants
 
+import "time"
+
 const (
-	ViperJWTTTLKey    = "service.jwt_ttl"
-	ViperJWTSecretKey = "service.jwt_secret"
+	// ViperJWTActiveKeyKey is the id (kid) of the key which signs new access tokens.
+	ViperJWTActiveKeyKey = "jwt.active_key"
+	// ViperJWTKeysKey maps key ids to PEM files. Retired keys may be public only.
+	ViperJWTKeysKey = "jwt.keys"
+	// ViperJWTEphemeralKeyKey lets the service start without keys, for development only: the tokens are signed
+	// with a key generated at start and stop being accepted after a restart.
+	ViperJWTEphemeralKeyKey = "jwt.ephemeral_key"
+
+	ViperAccessTTLKey  = "service.access_ttl"
+	ViperRefreshTTLKey = "service.refresh_ttl"
+	// ViperRefreshReuseGraceKey is how long the just rotated refresh token is rejected without revoking the session,
+	// e.g. "10s": the requests sent at once with the same cookie all try to rotate it.
+	ViperRefreshReuseGraceKey = "service.refresh_reuse_grace"
+
+	ViperPasswordResetTTLKey = "service.password_reset_ttl"
+	// ViperPasswordResetCooldownKey is how long another password reset email isn't sent to the same address.
+	ViperPasswordResetCooldownKey = "service.password_reset_cooldown"
 )
+
+const (
+	DefaultRefreshReuseGrace     = 10 * time.Second
+	DefaultPasswordResetCooldown = time.Minute
+)
+
+// JWKSPath is where the public keys of access tokens are published.
+const JWKSPath = "/.well-known/jwks.json"
@@ -0,0 +1,35 @@
+package auth_constants
+
+import "time"
+
+const (
+	ViperLoginEmailMaxFailuresKey = "login_protection.email_max_failures"
+	ViperLoginIPMaxFailuresKey    = "login_protection.ip_max_failures"
+	ViperLoginUserMaxFailuresKey  = "login_protection.second_factor_max_failures"
+	ViperLoginDelayAfterKey       = "login_protection.delay_after"
+	ViperLoginBaseDelayKey        = "login_protection.base_delay"
+	ViperLoginMaxDelayKey         = "login_protection.max_delay"
+	ViperLoginLockoutKey          = "login_protection.lockout"
+	ViperLoginWindowKey           = "login_protection.window"
+)
+
+// Default login protection thresholds, used when the config doesn't set them.
+const (
+	DefaultLoginEmailMaxFailures = 10
+	DefaultLoginIPMaxFailures    = 100
+	DefaultLoginUserMaxFailures  = 10
+	DefaultLoginDelayAfter       = 3
+	DefaultLoginBaseDelay        = time.Second
+	DefaultLoginMaxDelay         = time.Minute
+	DefaultLoginLockout          = 15 * time.Minute
+	DefaultLoginWindow           = time.Hour
+)
+
+// Scopes which failed login attempts are counted in.
+const (
+	LoginScopeEmail = "email"
+	LoginScopeIP    = "ip"
+	// LoginScopeUser counts the wrong second factor codes of the user, whatever login tickets they are entered with,
+	// and the wrong passwords and codes the signed in user confirms sensitive changes with.
+	LoginScopeUser = "user"
+)
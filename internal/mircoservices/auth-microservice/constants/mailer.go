@@ -0,0 +1,14 @@
+package auth_constants
+
+const (
+	ViperMailerTypeKey = "mailer.type"
+	ViperMailerPathKey = "mailer.path"
+
+	ViperPasswordResetURLKey     = "mailer.password_reset_url"
+	ViperEmailVerificationURLKey = "mailer.email_verification_url"
+)
+
+const (
+	MailerTypeLog  = "log"
+	MailerTypeFile = "file"
+)
@@ -0,0 +1,21 @@
+package auth_constants
+
+const (
+	ViperArgon2TimeKey    = "password.argon2.time"
+	ViperArgon2MemoryKey  = "password.argon2.memory"
+	ViperArgon2ThreadsKey = "password.argon2.threads"
+)
+
+// Algorithms of password hashing.
+const (
+	PasswordAlgorithmSHA512   = "sha512" // legacy, the records without algorithm use it
+	PasswordAlgorithmArgon2id = "argon2id"
+)
+
+// Default Argon2id cost, used when the config doesn't set it.
+const (
+	DefaultArgon2Time    = 3
+	DefaultArgon2Memory  = 64 * 1024 // KiB
+	DefaultArgon2Threads = 2
+	Argon2KeyLen         = 32
+)
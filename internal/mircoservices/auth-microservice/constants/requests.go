@@ -3,3 +3,8 @@ package auth_constants
 const (
 	Nothing = ""
 )
+
+const (
+	// MetadataKeyRequestID carries the X-Request-Id of the API request which the call is made for.
+	MetadataKeyRequestID = "x-request-id"
+)
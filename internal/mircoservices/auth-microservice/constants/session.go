@@ -0,0 +1,17 @@
+package auth_constants
+
+import "time"
+
+const (
+	ViperReauthMaxAgeKey = "reauth.max_age"
+)
+
+const (
+	// DefaultReauthMaxAge is how recent the login of the users without a password has to be to confirm sensitive changes.
+	DefaultReauthMaxAge = 10 * time.Minute
+
+	// SessionTouchPeriod is how often (in seconds) the last activity time of the session is updated.
+	SessionTouchPeriod = 60
+	// SessionUsedHashesKept is how many rotated refresh tokens of the session are remembered to detect their reuse.
+	SessionUsedHashesKept = 50
+)
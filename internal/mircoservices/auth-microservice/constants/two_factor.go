@@ -0,0 +1,18 @@
+package auth_constants
+
+const (
+	ViperTOTPIssuerKey     = "two_factor.issuer"
+	ViperLoginTicketTTLKey = "two_factor.ticket_ttl"
+)
+
+const (
+	TOTPDigits = 6
+	TOTPPeriod = 30 // seconds
+	// TOTPSkew is how many periods before and after the current one are accepted to tolerate clock drift.
+	TOTPSkew = 1
+
+	RecoveryCodesCount = 10
+
+	// LoginTicketMaxAttempts is how many wrong codes can be entered with one ticket.
+	LoginTicketMaxAttempts = 5
+)
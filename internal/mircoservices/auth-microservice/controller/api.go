@@ -3,9 +3,9 @@ package controller
 import (
 	"context"
 
-	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
 	auth_db "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/db"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/handler"
+	auth_mailer "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/mailer"
 	auth_dto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
 	auth_service "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/service"
 	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
@@ -24,23 +24,33 @@ func CreateAuthServer(dbConn *mongo.Database, log *logrus.Entry) *AuthServerImpl
 	if err != nil {
 		log.Fatal(err)
 	}
-	registry := auth_service.NewRegistry(log, repository)
+	mailer, err := auth_mailer.NewMailer(log)
+	if err != nil {
+		log.Fatal(err)
+	}
+	registry := auth_service.NewRegistry(log, repository, mailer)
 	return &AuthServerImpl{rep: registry, log: log}
 }
 
+// logger is the log entry of the call, see RequestIDInterceptor.
+func (s *AuthServerImpl) logger(ctx context.Context) *logrus.Entry {
+	return auth_utils.Logger(ctx, s.log)
+}
+
 func (s *AuthServerImpl) Login(ctx context.Context, in *handler.LoginReq) (*handler.LoginRes, error) {
 	request := new(auth_dto.LoginUserRequest)
 
 	request.Email = in.Email
 	request.Password = in.Pwd
+	request.Client = clientInfo(in.Client)
 
-	response, err := s.rep.AuthService.LoginUser(context.Background(), request)
+	response, err := s.rep.AuthService.LoginUser(ctx, request)
 	if err != nil {
-		s.log.Errorf("SignupUser error: %s", err)
+		s.logger(ctx).Errorf("SignupUser error: %s", err)
 		return &handler.LoginRes{}, err
 	}
 
-	return &handler.LoginRes{Token: response.AuthToken, UserID: response.UserID}, nil
+	return &handler.LoginRes{Token: response.AuthToken, UserID: response.UserID, RefreshToken: response.RefreshToken, TwoFactorTicket: response.TwoFactorTicket}, nil
 }
 
 func (s *AuthServerImpl) SignUp(ctx context.Context, in *handler.SignUpReq) (*handler.SignUpRes, error) {
@@ -48,37 +58,445 @@ func (s *AuthServerImpl) SignUp(ctx context.Context, in *handler.SignUpReq) (*ha
 
 	request.Email = in.Email
 	request.Password = in.Pwd
+	request.Client = clientInfo(in.Client)
 
-	response, err := s.rep.AuthService.SignupUser(context.Background(), request)
+	response, err := s.rep.AuthService.SignupUser(ctx, request)
 	if err != nil {
-		s.log.Errorf("SignupUser: %s", err)
+		s.logger(ctx).Errorf("SignupUser: %s", err)
 		return &handler.SignUpRes{}, err
 	}
 
-	return &handler.SignUpRes{Token: response.AuthToken, UserID: response.UserID}, nil
+	return &handler.SignUpRes{Token: response.AuthToken, UserID: response.UserID, RefreshToken: response.RefreshToken}, nil
 }
 
 func (s *AuthServerImpl) Check(ctx context.Context, in *handler.CheckReq) (*handler.CheckRes, error) {
-	res := &handler.CheckRes{
-		Code:     false,
-		NewToken: auth_constants.Nothing,
-		UserID:   auth_constants.Nothing,
+	request := new(auth_dto.CheckRequest)
+
+	request.AuthToken = in.Token
+
+	response, err := s.rep.AuthService.Check(ctx, request)
+	if err != nil {
+		return &handler.CheckRes{}, err
+	}
+
+	return &handler.CheckRes{UserID: response.UserID, SessionID: response.SessionID, Unverified: response.Unverified}, nil
+}
+
+func (s *AuthServerImpl) Refresh(ctx context.Context, in *handler.RefreshReq) (*handler.RefreshRes, error) {
+	request := new(auth_dto.RefreshRequest)
+
+	request.RefreshToken = in.RefreshToken
+	request.Client = clientInfo(in.Client)
+
+	response, err := s.rep.AuthService.Refresh(ctx, request)
+	if err != nil {
+		s.logger(ctx).Errorf("Refresh error: %s", err)
+		return &handler.RefreshRes{}, err
+	}
+
+	return &handler.RefreshRes{
+		Token:        response.AuthToken,
+		UserID:       response.UserID,
+		RefreshToken: response.RefreshToken,
+		SessionID:    response.SessionID,
+		Unverified:   response.Unverified,
+	}, nil
+}
+
+func (s *AuthServerImpl) Logout(ctx context.Context, in *handler.LogoutReq) (*handler.LogoutRes, error) {
+	request := new(auth_dto.LogoutRequest)
+
+	request.AuthToken = in.Token
+	request.RefreshToken = in.RefreshToken
+
+	if err := s.rep.AuthService.Logout(ctx, request); err != nil {
+		s.logger(ctx).Errorf("Logout error: %s", err)
+		return &handler.LogoutRes{}, err
+	}
+
+	return &handler.LogoutRes{}, nil
+}
+
+func (s *AuthServerImpl) ListSessions(ctx context.Context, in *handler.ListSessionsReq) (*handler.ListSessionsRes, error) {
+	request := new(auth_dto.ListSessionsRequest)
+
+	request.UserID = in.UserID
+
+	response, err := s.rep.AuthService.ListSessions(ctx, request)
+	if err != nil {
+		s.logger(ctx).Errorf("ListSessions error: %s", err)
+		return &handler.ListSessionsRes{}, err
+	}
+
+	sessions := make([]*handler.SessionInfo, 0, len(response.Sessions))
+	for _, session := range response.Sessions {
+		sessions = append(sessions, &handler.SessionInfo{
+			Id:         session.ID,
+			Device:     session.Device,
+			UserAgent:  session.UserAgent,
+			Ip:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+		})
+	}
+
+	return &handler.ListSessionsRes{Sessions: sessions}, nil
+}
+
+func (s *AuthServerImpl) RevokeSession(ctx context.Context, in *handler.RevokeSessionReq) (*handler.RevokeSessionRes, error) {
+	request := new(auth_dto.RevokeSessionRequest)
+
+	request.UserID = in.UserID
+	request.SessionID = in.SessionID
+
+	if err := s.rep.AuthService.RevokeSession(ctx, request); err != nil {
+		s.logger(ctx).Errorf("RevokeSession error: %s", err)
+		return &handler.RevokeSessionRes{}, err
+	}
+
+	return &handler.RevokeSessionRes{}, nil
+}
+
+func (s *AuthServerImpl) ChangePassword(ctx context.Context, in *handler.ChangePasswordReq) (*handler.ChangePasswordRes, error) {
+	request := new(auth_dto.ChangePasswordRequest)
+
+	request.UserID = in.UserID
+	request.SessionID = in.SessionID
+	request.OldPassword = in.OldPwd
+	request.NewPassword = in.NewPwd
+	request.Code = in.Code
+
+	if err := s.rep.PasswordService.ChangePassword(ctx, request); err != nil {
+		s.logger(ctx).Errorf("ChangePassword error: %s", err)
+		return &handler.ChangePasswordRes{}, err
+	}
+
+	return &handler.ChangePasswordRes{}, nil
+}
+
+func (s *AuthServerImpl) RequestPasswordReset(ctx context.Context, in *handler.RequestPasswordResetReq) (*handler.RequestPasswordResetRes, error) {
+	request := new(auth_dto.RequestPasswordResetRequest)
+
+	request.Email = in.Email
+
+	if err := s.rep.PasswordService.RequestPasswordReset(ctx, request); err != nil {
+		s.logger(ctx).Errorf("RequestPasswordReset error: %s", err)
+		return &handler.RequestPasswordResetRes{}, err
+	}
+
+	return &handler.RequestPasswordResetRes{}, nil
+}
+
+func (s *AuthServerImpl) ConfirmPasswordReset(ctx context.Context, in *handler.ConfirmPasswordResetReq) (*handler.ConfirmPasswordResetRes, error) {
+	request := new(auth_dto.ConfirmPasswordResetRequest)
+
+	request.Token = in.Token
+	request.NewPassword = in.NewPwd
+
+	if err := s.rep.PasswordService.ConfirmPasswordReset(ctx, request); err != nil {
+		s.logger(ctx).Errorf("ConfirmPasswordReset error: %s", err)
+		return &handler.ConfirmPasswordResetRes{}, err
+	}
+
+	return &handler.ConfirmPasswordResetRes{}, nil
+}
+
+func (s *AuthServerImpl) VerifySecondFactor(ctx context.Context, in *handler.VerifySecondFactorReq) (*handler.VerifySecondFactorRes, error) {
+	request := new(auth_dto.VerifySecondFactorRequest)
+
+	request.Ticket = in.Ticket
+	request.Code = in.Code
+	request.Client = clientInfo(in.Client)
+
+	response, err := s.rep.AuthService.VerifySecondFactor(ctx, request)
+	if err != nil {
+		s.logger(ctx).Errorf("VerifySecondFactor error: %s", err)
+		return &handler.VerifySecondFactorRes{}, err
+	}
+
+	return &handler.VerifySecondFactorRes{Token: response.AuthToken, UserID: response.UserID, RefreshToken: response.RefreshToken}, nil
+}
+
+func (s *AuthServerImpl) EnrollTwoFactor(ctx context.Context, in *handler.EnrollTwoFactorReq) (*handler.EnrollTwoFactorRes, error) {
+	request := new(auth_dto.EnrollTwoFactorRequest)
+
+	request.UserID = in.UserID
+
+	response, err := s.rep.TwoFactorService.EnrollTwoFactor(ctx, request)
+	if err != nil {
+		s.logger(ctx).Errorf("EnrollTwoFactor error: %s", err)
+		return &handler.EnrollTwoFactorRes{}, err
+	}
+
+	return &handler.EnrollTwoFactorRes{Secret: response.Secret, Uri: response.URI}, nil
+}
+
+func (s *AuthServerImpl) ConfirmTwoFactor(ctx context.Context, in *handler.ConfirmTwoFactorReq) (*handler.ConfirmTwoFactorRes, error) {
+	request := new(auth_dto.ConfirmTwoFactorRequest)
+
+	request.UserID = in.UserID
+	request.Code = in.Code
+
+	response, err := s.rep.TwoFactorService.ConfirmTwoFactor(ctx, request)
+	if err != nil {
+		s.logger(ctx).Errorf("ConfirmTwoFactor error: %s", err)
+		return &handler.ConfirmTwoFactorRes{}, err
+	}
+
+	return &handler.ConfirmTwoFactorRes{RecoveryCodes: response.RecoveryCodes}, nil
+}
+
+func (s *AuthServerImpl) DisableTwoFactor(ctx context.Context, in *handler.DisableTwoFactorReq) (*handler.DisableTwoFactorRes, error) {
+	request := new(auth_dto.DisableTwoFactorRequest)
+
+	request.UserID = in.UserID
+	request.Password = in.Pwd
+	request.Code = in.Code
+
+	if err := s.rep.TwoFactorService.DisableTwoFactor(ctx, request); err != nil {
+		s.logger(ctx).Errorf("DisableTwoFactor error: %s", err)
+		return &handler.DisableTwoFactorRes{}, err
+	}
+
+	return &handler.DisableTwoFactorRes{}, nil
+}
+
+func (s *AuthServerImpl) DeleteAccount(ctx context.Context, in *handler.DeleteAccountReq) (*handler.DeleteAccountRes, error) {
+	request := new(auth_dto.DeleteAccountRequest)
+
+	request.UserID = in.UserID
+	request.SessionID = in.SessionID
+	request.Password = in.Pwd
+	request.Code = in.Code
+
+	response, err := s.rep.AccountService.DeleteAccount(ctx, request)
+	if err != nil {
+		s.logger(ctx).Errorf("DeleteAccount error: %s", err)
+		return &handler.DeleteAccountRes{}, err
+	}
+
+	return &handler.DeleteAccountRes{PurgeAt: response.PurgeAt}, nil
+}
+
+func (s *AuthServerImpl) ClaimAccountDeletions(ctx context.Context, in *handler.ClaimAccountDeletionsReq) (*handler.ClaimAccountDeletionsRes, error) {
+	request := new(auth_dto.ClaimAccountDeletionsRequest)
+
+	request.Limit = in.Limit
+
+	response, err := s.rep.AccountService.ClaimAccountDeletions(ctx, request)
+	if err != nil {
+		s.logger(ctx).Errorf("ClaimAccountDeletions error: %s", err)
+		return &handler.ClaimAccountDeletionsRes{}, err
+	}
+
+	return &handler.ClaimAccountDeletionsRes{UserIDs: response.UserIDs}, nil
+}
+
+func (s *AuthServerImpl) PurgeAccount(ctx context.Context, in *handler.PurgeAccountReq) (*handler.PurgeAccountRes, error) {
+	request := new(auth_dto.PurgeAccountRequest)
+
+	request.UserID = in.UserID
+
+	if err := s.rep.AccountService.PurgeAccount(ctx, request); err != nil {
+		s.logger(ctx).Errorf("PurgeAccount error: %s", err)
+		return &handler.PurgeAccountRes{}, err
+	}
+
+	return &handler.PurgeAccountRes{}, nil
+}
+
+func (s *AuthServerImpl) VerifyEmail(ctx context.Context, in *handler.VerifyEmailReq) (*handler.VerifyEmailRes, error) {
+	request := new(auth_dto.VerifyEmailRequest)
+
+	request.Token = in.Token
+
+	response, err := s.rep.EmailVerificationService.VerifyEmail(ctx, request)
+	if err != nil {
+		s.logger(ctx).Errorf("VerifyEmail error: %s", err)
+		return &handler.VerifyEmailRes{}, err
+	}
+
+	return &handler.VerifyEmailRes{UserID: response.UserID}, nil
+}
+
+func (s *AuthServerImpl) ResendVerification(ctx context.Context, in *handler.ResendVerificationReq) (*handler.ResendVerificationRes, error) {
+	request := new(auth_dto.ResendVerificationRequest)
+
+	request.UserID = in.UserID
+
+	if err := s.rep.EmailVerificationService.ResendVerification(ctx, request); err != nil {
+		s.logger(ctx).Errorf("ResendVerification error: %s", err)
+		return &handler.ResendVerificationRes{}, err
+	}
+
+	return &handler.ResendVerificationRes{}, nil
+}
+
+func (s *AuthServerImpl) ExternalLogin(ctx context.Context, in *handler.ExternalLoginReq) (*handler.ExternalLoginRes, error) {
+	request := new(auth_dto.ExternalLoginRequest)
+
+	request.Identity = externalIdentity(in.Identity)
+	request.Client = clientInfo(in.Client)
+
+	response, err := s.rep.AuthService.ExternalLogin(ctx, request)
+	if err != nil {
+		s.logger(ctx).Errorf("ExternalLogin error: %s", err)
+		return &handler.ExternalLoginRes{}, err
+	}
+
+	return &handler.ExternalLoginRes{
+		Token:           response.AuthToken,
+		UserID:          response.UserID,
+		RefreshToken:    response.RefreshToken,
+		TwoFactorTicket: response.TwoFactorTicket,
+		Created:         response.Created,
+	}, nil
+}
+
+func (s *AuthServerImpl) LinkIdentity(ctx context.Context, in *handler.LinkIdentityReq) (*handler.LinkIdentityRes, error) {
+	request := new(auth_dto.LinkIdentityRequest)
+
+	request.UserID = in.UserID
+	request.Identity = externalIdentity(in.Identity)
+
+	if err := s.rep.IdentityService.LinkIdentity(ctx, request); err != nil {
+		s.logger(ctx).Errorf("LinkIdentity error: %s", err)
+		return &handler.LinkIdentityRes{}, err
+	}
+
+	return &handler.LinkIdentityRes{}, nil
+}
+
+func (s *AuthServerImpl) UnlinkIdentity(ctx context.Context, in *handler.UnlinkIdentityReq) (*handler.UnlinkIdentityRes, error) {
+	request := new(auth_dto.UnlinkIdentityRequest)
+
+	request.UserID = in.UserID
+	request.Provider = in.Provider
+
+	if err := s.rep.IdentityService.UnlinkIdentity(ctx, request); err != nil {
+		s.logger(ctx).Errorf("UnlinkIdentity error: %s", err)
+		return &handler.UnlinkIdentityRes{}, err
+	}
+
+	return &handler.UnlinkIdentityRes{}, nil
+}
+
+func (s *AuthServerImpl) ListIdentities(ctx context.Context, in *handler.ListIdentitiesReq) (*handler.ListIdentitiesRes, error) {
+	request := new(auth_dto.ListIdentitiesRequest)
+
+	request.UserID = in.UserID
+
+	response, err := s.rep.IdentityService.ListIdentities(ctx, request)
+	if err != nil {
+		s.logger(ctx).Errorf("ListIdentities error: %s", err)
+		return &handler.ListIdentitiesRes{}, err
 	}
-	tw, err := auth_utils.ParseAuthToken(in.Token)
+
+	identities := make([]*handler.LinkedIdentity, 0, len(response.Identities))
+	for _, identity := range response.Identities {
+		identities = append(identities, &handler.LinkedIdentity{
+			Provider: identity.Provider,
+			Email:    identity.Email,
+			LinkedAt: identity.LinkedAt,
+		})
+	}
+
+	return &handler.ListIdentitiesRes{Identities: identities}, nil
+}
+
+func (s *AuthServerImpl) CreateAPIToken(ctx context.Context, in *handler.CreateAPITokenReq) (*handler.CreateAPITokenRes, error) {
+	request := new(auth_dto.CreateAPITokenRequest)
+
+	request.UserID = in.UserID
+	request.Name = in.Name
+	request.Scopes = in.Scopes
+	request.ExpiresAt = in.ExpiresAt
+
+	response, err := s.rep.APITokenService.CreateAPIToken(ctx, request)
+	if err != nil {
+		s.logger(ctx).Errorf("CreateAPIToken error: %s", err)
+		return &handler.CreateAPITokenRes{}, err
+	}
+
+	return &handler.CreateAPITokenRes{Token: response.Token, ApiToken: apiToken(&response.APIToken)}, nil
+}
+
+func (s *AuthServerImpl) ListAPITokens(ctx context.Context, in *handler.ListAPITokensReq) (*handler.ListAPITokensRes, error) {
+	request := new(auth_dto.ListAPITokensRequest)
+
+	request.UserID = in.UserID
+
+	response, err := s.rep.APITokenService.ListAPITokens(ctx, request)
 	if err != nil {
-		return res, err
+		s.logger(ctx).Errorf("ListAPITokens error: %s", err)
+		return &handler.ListAPITokensRes{}, err
+	}
+
+	tokens := make([]*handler.APIToken, 0, len(response.Tokens))
+	for i := range response.Tokens {
+		tokens = append(tokens, apiToken(&response.Tokens[i]))
+	}
+
+	return &handler.ListAPITokensRes{Tokens: tokens}, nil
+}
+
+func (s *AuthServerImpl) RevokeAPIToken(ctx context.Context, in *handler.RevokeAPITokenReq) (*handler.RevokeAPITokenRes, error) {
+	request := new(auth_dto.RevokeAPITokenRequest)
+
+	request.UserID = in.UserID
+	request.TokenID = in.TokenID
+
+	if err := s.rep.APITokenService.RevokeAPIToken(ctx, request); err != nil {
+		s.logger(ctx).Errorf("RevokeAPIToken error: %s", err)
+		return &handler.RevokeAPITokenRes{}, err
 	}
 
-	res.UserID = tw.UserID
+	return &handler.RevokeAPITokenRes{}, nil
+}
+
+func (s *AuthServerImpl) CheckAPIToken(ctx context.Context, in *handler.CheckAPITokenReq) (*handler.CheckAPITokenRes, error) {
+	request := new(auth_dto.CheckAPITokenRequest)
+
+	request.Token = in.Token
 
-	authToken, err := auth_utils.RefreshIfNeededAuthToken(tw)
-	res.NewToken = authToken
+	response, err := s.rep.APITokenService.CheckAPIToken(ctx, request)
 	if err != nil {
-		return res, err
+		s.logger(ctx).Errorf("CheckAPIToken error: %s", err)
+		return &handler.CheckAPITokenRes{}, err
 	}
 
-	if len(authToken) != 0 {
-		res.Code = true
+	return &handler.CheckAPITokenRes{
+		UserID:     response.UserID,
+		TokenID:    response.TokenID,
+		Scopes:     response.Scopes,
+		Unverified: response.Unverified,
+	}, nil
+}
+
+func apiToken(in *auth_dto.APIToken) *handler.APIToken {
+	return &handler.APIToken{
+		Id:         in.ID,
+		Name:       in.Name,
+		Scopes:     in.Scopes,
+		CreatedAt:  in.CreatedAt,
+		ExpiresAt:  in.ExpiresAt,
+		LastUsedAt: in.LastUsedAt,
+	}
+}
+
+func externalIdentity(in *handler.ExternalIdentity) auth_dto.ExternalIdentity {
+	return auth_dto.ExternalIdentity{
+		Provider:      in.GetProvider(),
+		Subject:       in.GetSubject(),
+		Email:         in.GetEmail(),
+		EmailVerified: in.GetEmailVerified(),
+	}
+}
+
+func clientInfo(in *handler.ClientInfo) auth_dto.ClientInfo {
+	return auth_dto.ClientInfo{
+		UserAgent: in.GetUserAgent(),
+		IP:        in.GetIp(),
+		Device:    in.GetDevice(),
 	}
-	return res, nil
 }
@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"context"
+
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDInterceptor tags the log entries of the call with the X-Request-Id of the API request
+// which the call is made for, so the logs of both services can be correlated.
+func RequestIDInterceptor(log *logrus.Entry) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		entry := log.WithField("method", info.FullMethod)
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if ids := md.Get(auth_constants.MetadataKeyRequestID); len(ids) != 0 {
+				entry = entry.WithField("x_request_id", ids[0])
+			}
+		}
+		return handler(auth_utils.WithLogger(ctx, entry), req)
+	}
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRequestIDInterceptor(t *testing.T) {
+	log := logrus.NewEntry(logrus.New())
+	interceptor := RequestIDInterceptor(log)
+	info := &grpc.UnaryServerInfo{FullMethod: "/handler.UserAuth/Check"}
+
+	var entry *logrus.Entry
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		entry = auth_utils.Logger(ctx, log)
+		return nil, nil
+	}
+
+	t.Run("With request id", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(auth_constants.MetadataKeyRequestID, "42"))
+		_, err := interceptor(ctx, nil, info, handler)
+		assert.Nil(t, err)
+		assert.Equal(t, "42", entry.Data["x_request_id"])
+		assert.Equal(t, info.FullMethod, entry.Data["method"])
+	})
+
+	t.Run("Without request id", func(t *testing.T) {
+		_, err := interceptor(context.Background(), nil, info, handler)
+		assert.Nil(t, err)
+		assert.NotContains(t, entry.Data, "x_request_id")
+	})
+}
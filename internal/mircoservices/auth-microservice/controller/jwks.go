@@ -0,0 +1,17 @@
+package controller
+
+import (
+	"net/http"
+
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// jwksMaxAge is how long (in seconds) clients may cache the published keys.
+const jwksMaxAge = "max-age=300"
+
+// JWKS publishes public keys of access tokens, so other services verify them without calling Check.
+func JWKS(ctx echo.Context) error {
+	ctx.Response().Header().Set(echo.HeaderCacheControl, jwksMaxAge)
+	return ctx.JSON(http.StatusOK, auth_utils.JWKS())
+}
@@ -0,0 +1,108 @@
+package auth_db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type APITokenRepository interface {
+	CreateAPIToken(ctx context.Context, token *auth_core.APIToken) error
+	GetAPITokenByHash(ctx context.Context, hash string) (*auth_core.APIToken, error)
+	GetUserAPITokens(ctx context.Context, userID string) ([]auth_core.APIToken, error)
+	TouchAPIToken(ctx context.Context, ID string, lastUsedAt int64) error
+	DeleteAPIToken(ctx context.Context, userID string, ID string) error
+	DeleteUserAPITokens(ctx context.Context, userID string) error
+}
+
+type apiTokenRepositoryImpl struct {
+	db   *mongo.Database
+	coll *mongo.Collection
+}
+
+func NewAPITokenRepository(db *mongo.Database) (*apiTokenRepositoryImpl, error) {
+	return &apiTokenRepositoryImpl{db: db, coll: db.Collection("api_tokens")}, nil
+}
+
+// NewAPITokenRepositoryTest for Tests (bad)
+func NewAPITokenRepositoryTest(collection *mongo.Collection) (*apiTokenRepositoryImpl, error) {
+	return &apiTokenRepositoryImpl{coll: collection}, nil
+}
+
+// CreateAPIToken generates id for the given token and inserts it to the db.
+func (repo *apiTokenRepositoryImpl) CreateAPIToken(ctx context.Context, token *auth_core.APIToken) error {
+	id, err := auth_core.GenUUID()
+	if err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	token.ID = id
+	token.CreatedAt = time.Now().Unix()
+
+	if _, err := repo.coll.InsertOne(ctx, token); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+func (repo *apiTokenRepositoryImpl) GetAPITokenByHash(ctx context.Context, hash string) (*auth_core.APIToken, error) {
+	token := new(auth_core.APIToken)
+	if err := repo.coll.FindOne(ctx, bson.M{"hash": hash}).Decode(token); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, auth_constants.ErrDBNotFound
+		}
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return token, nil
+}
+
+// GetUserAPITokens returns the tokens of the user, the most recently created first. Expired tokens are listed too.
+func (repo *apiTokenRepositoryImpl) GetUserAPITokens(ctx context.Context, userID string) ([]auth_core.APIToken, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := repo.coll.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	defer cursor.Close(ctx)
+
+	tokens := []auth_core.APIToken{}
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return tokens, nil
+}
+
+// TouchAPIToken updates the last use time of the token.
+func (repo *apiTokenRepositoryImpl) TouchAPIToken(ctx context.Context, ID string, lastUsedAt int64) error {
+	if _, err := repo.coll.UpdateByID(ctx, ID, bson.M{"$set": bson.M{"last_used_at": lastUsedAt}}); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+// DeleteAPIToken revokes the user's token.
+func (repo *apiTokenRepositoryImpl) DeleteAPIToken(ctx context.Context, userID string, ID string) error {
+	res, err := repo.coll.DeleteOne(ctx, bson.M{"_id": ID, "user_id": userID})
+	if err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	if res.DeletedCount == 0 {
+		return auth_constants.ErrDBNotFound
+	}
+	return nil
+}
+
+func (repo *apiTokenRepositoryImpl) DeleteUserAPITokens(ctx context.Context, userID string) error {
+	if _, err := repo.coll.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
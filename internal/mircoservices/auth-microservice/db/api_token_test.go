@@ -0,0 +1,102 @@
+package auth_db
+
+import (
+	"context"
+	"testing"
+
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestCreateAPIToken(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		tokenCollection, _ := NewAPITokenRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		token := &auth_core.APIToken{UserID: "1", Name: "bot", Hash: "hash", Scopes: []string{"read:posts"}}
+		err := tokenCollection.CreateAPIToken(context.Background(), token)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, token.ID)
+		assert.NotZero(t, token.CreatedAt)
+	})
+}
+
+func TestGetAPITokenByHash(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		tokenCollection, _ := NewAPITokenRepositoryTest(mt.Coll)
+		expected := &auth_core.APIToken{ID: "2", UserID: "1", Name: "bot", Hash: "hash", Scopes: []string{"read:posts"}, CreatedAt: 12}
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "foo.bar", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: expected.ID},
+			{Key: "user_id", Value: expected.UserID},
+			{Key: "name", Value: expected.Name},
+			{Key: "hash", Value: expected.Hash},
+			{Key: "scopes", Value: bson.A{"read:posts"}},
+			{Key: "created_at", Value: expected.CreatedAt},
+		}))
+		token, err := tokenCollection.GetAPITokenByHash(context.Background(), "hash")
+		assert.Nil(t, err)
+		assert.Equal(t, expected, token)
+	})
+
+	mt.Run("not found", func(mt *mtest.T) {
+		tokenCollection, _ := NewAPITokenRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch))
+		token, err := tokenCollection.GetAPITokenByHash(context.Background(), "hash")
+		assert.Equal(t, auth_constants.ErrDBNotFound, err)
+		assert.Nil(t, token)
+	})
+}
+
+func TestGetUserAPITokens(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		tokenCollection, _ := NewAPITokenRepositoryTest(mt.Coll)
+
+		first := mtest.CreateCursorResponse(1, "foo.bar", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "2"},
+			{Key: "user_id", Value: "1"},
+			{Key: "name", Value: "bot"},
+		})
+		killCursors := mtest.CreateCursorResponse(0, "foo.bar", mtest.NextBatch)
+		mt.AddMockResponses(first, killCursors)
+
+		tokens, err := tokenCollection.GetUserAPITokens(context.Background(), "1")
+		assert.Nil(t, err)
+		assert.Len(t, tokens, 1)
+		assert.Equal(t, "bot", tokens[0].Name)
+	})
+}
+
+func TestDeleteAPIToken(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		tokenCollection, _ := NewAPITokenRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+		err := tokenCollection.DeleteAPIToken(context.Background(), "1", "2")
+		assert.Nil(t, err)
+	})
+
+	mt.Run("not found", func(mt *mtest.T) {
+		tokenCollection, _ := NewAPITokenRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}))
+		err := tokenCollection.DeleteAPIToken(context.Background(), "1", "2")
+		assert.Equal(t, auth_constants.ErrDBNotFound, err)
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/microcosm-cc/bluemonday"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"time"
@@ -15,8 +16,16 @@ import (
 
 type AuthRepository interface {
 	CreateUser(ctx context.Context, user *auth_core.User) (string, error)
+	GetUserByID(ctx context.Context, ID string) (*auth_core.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*auth_core.User, error)
 	CheckUserEmailExistence(ctx context.Context, email string) (bool, error)
+	UpdatePassword(ctx context.Context, ID string, password auth_core.UserPassword) error
+	MarkEmailVerified(ctx context.Context, ID string) error
+
+	ScheduleDeletion(ctx context.Context, ID string, deletion auth_core.AccountDeletion) error
+	CancelDeletion(ctx context.Context, ID string) error
+	ClaimDueDeletions(ctx context.Context, now int64, limit int64) ([]string, error)
+	DeleteUser(ctx context.Context, ID string) error
 }
 
 type authRepositoryImpl struct {
@@ -36,12 +45,15 @@ func NewAuthRepositoryTest(collection *mongo.Collection) (*authRepositoryImpl, e
 // CreateUser tries to insert given user to the db:
 // returns error if the email is already taken, otherwise inserts.
 func (repo *authRepositoryImpl) CreateUser(ctx context.Context, user *auth_core.User) (string, error) {
-	filter := bson.M{"email": user.Email}
-	if err := repo.coll.FindOne(ctx, filter).Err(); err != mongo.ErrNoDocuments {
-		if err == nil {
-			return auth_constants.Nothing, status.Error(codes.Internal, auth_constants.ErrEmailAlreadyTaken.Error())
-		} else {
-			return auth_constants.Nothing, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	// Users of external identity providers may have no email.
+	if len(user.Email) != 0 {
+		filter := bson.M{"email": user.Email}
+		if err := repo.coll.FindOne(ctx, filter).Err(); err != mongo.ErrNoDocuments {
+			if err == nil {
+				return auth_constants.Nothing, auth_constants.ErrEmailAlreadyTaken
+			} else {
+				return auth_constants.Nothing, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+			}
 		}
 	}
 
@@ -98,6 +110,109 @@ func (repo *authRepositoryImpl) CheckUserEmailExistence(ctx context.Context, ema
 	return true, nil
 }
 
+// UpdatePassword replaces hash and salt of the user's password.
+func (repo *authRepositoryImpl) UpdatePassword(ctx context.Context, ID string, password auth_core.UserPassword) error {
+	res, err := repo.coll.UpdateByID(ctx, ID, bson.M{"$set": bson.M{"password": password}})
+	if err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	if res.MatchedCount == 0 {
+		return auth_constants.ErrDBNotFound
+	}
+	return nil
+}
+
+// MarkEmailVerified lifts the restrictions of the unverified email from the user.
+func (repo *authRepositoryImpl) MarkEmailVerified(ctx context.Context, ID string) error {
+	res, err := repo.coll.UpdateByID(ctx, ID, bson.M{"$unset": bson.M{"email_unverified": ""}})
+	if err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	if res.MatchedCount == 0 {
+		return auth_constants.ErrDBNotFound
+	}
+	return nil
+}
+
+// ScheduleDeletion marks the user's account to be purged after the grace period.
+func (repo *authRepositoryImpl) ScheduleDeletion(ctx context.Context, ID string, deletion auth_core.AccountDeletion) error {
+	res, err := repo.coll.UpdateByID(ctx, ID, bson.M{"$set": bson.M{"deletion": deletion}})
+	if err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	if res.MatchedCount == 0 {
+		return auth_constants.ErrDBNotFound
+	}
+	return nil
+}
+
+// CancelDeletion takes the deletion back unless the account is already claimed for purging.
+func (repo *authRepositoryImpl) CancelDeletion(ctx context.Context, ID string) error {
+	filter := bson.M{"_id": ID, "deletion.purging": false}
+	res, err := repo.coll.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{"deletion": ""}})
+	if err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	if res.MatchedCount == 0 {
+		return auth_constants.ErrDBNotFound
+	}
+	return nil
+}
+
+// ClaimDueDeletions marks the accounts whose grace period is over as being purged and returns
+// up to limit of them, the ones claimed the longest ago first. Every account is claimed on its own,
+// so concurrent callers never get the same one. An account claimed before but not purged yet is
+// claimed again after a backoff which doubles with every attempt, so failing accounts don't hold
+// the others back.
+func (repo *authRepositoryImpl) ClaimDueDeletions(ctx context.Context, now int64, limit int64) ([]string, error) {
+	due := bson.M{"deletion.purging": false, "deletion.purge_at": bson.M{"$lte": now}}
+	if _, err := repo.coll.UpdateMany(ctx, due, bson.M{"$set": bson.M{"deletion.purging": true}}); err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+
+	filter := bson.M{
+		"deletion.purging": true,
+		"$or": bson.A{
+			bson.M{"deletion.retry_at": bson.M{"$exists": false}},
+			bson.M{"deletion.retry_at": bson.M{"$lte": now}},
+		},
+	}
+	attempts := bson.M{"$ifNull": bson.A{"$deletion.attempts", 0}}
+	backoff := bson.M{"$min": bson.A{
+		int64(auth_constants.AccountDeletionMaxRetryBackoff.Seconds()),
+		bson.M{"$multiply": bson.A{int64(auth_constants.AccountDeletionRetryBackoff.Seconds()), bson.M{"$pow": bson.A{2, attempts}}}},
+	}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"deletion.attempts":   bson.M{"$add": bson.A{attempts, 1}},
+		"deletion.claimed_at": now,
+		"deletion.retry_at":   bson.M{"$add": bson.A{now, backoff}},
+	}}}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "deletion.claimed_at", Value: 1}}).
+		SetProjection(bson.M{"_id": 1})
+
+	ids := make([]string, 0)
+	for int64(len(ids)) < limit {
+		user := new(auth_core.User)
+		if err := repo.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(user); err != nil {
+			if err == mongo.ErrNoDocuments {
+				break
+			}
+			return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+		}
+		ids = append(ids, user.ID)
+	}
+	return ids, nil
+}
+
+// DeleteUser removes the user's credentials from the db.
+func (repo *authRepositoryImpl) DeleteUser(ctx context.Context, ID string) error {
+	if _, err := repo.coll.DeleteOne(ctx, bson.M{"_id": ID}); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
 // Help func for defense from XSS attacks
 func userSanitize(user *auth_core.User) {
 	p := bluemonday.UGCPolicy()
@@ -2,6 +2,7 @@ package auth_db
 
 import (
 	"context"
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
 	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson"
@@ -152,8 +153,153 @@ func TestNewRepository(t *testing.T) {
 	defer mt.Close()
 
 	mt.Run("success", func(mt *mtest.T) {
+		// The indexes of sessions, password resets, login tickets, login attempts, email verifications and identities.
+		for i := 0; i < 6; i++ {
+			mt.AddMockResponses(mtest.CreateSuccessResponse())
+		}
 
 		_, err := NewRepository(mt.DB)
 		assert.Nil(t, err)
 	})
 }
+
+func TestUpdatePassword(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		authCollection, _ := NewAuthRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := authCollection.UpdatePassword(context.Background(), "123", auth_core.UserPassword{Hash: "hash", Salt: "salt"})
+		assert.Nil(t, err)
+	})
+
+	mt.Run("don't find in collection", func(mt *mtest.T) {
+		authCollection, _ := NewAuthRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+		err := authCollection.UpdatePassword(context.Background(), "123", auth_core.UserPassword{Hash: "hash", Salt: "salt"})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestMarkEmailVerified(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		authCollection, _ := NewAuthRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := authCollection.MarkEmailVerified(context.Background(), "123")
+		assert.Nil(t, err)
+	})
+
+	mt.Run("don't find in collection", func(mt *mtest.T) {
+		authCollection, _ := NewAuthRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+		err := authCollection.MarkEmailVerified(context.Background(), "123")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestScheduleDeletion(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		authCollection, _ := NewAuthRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := authCollection.ScheduleDeletion(context.Background(), "123", auth_core.AccountDeletion{RequestedAt: 1, PurgeAt: 2})
+		assert.Nil(t, err)
+	})
+
+	mt.Run("don't find in collection", func(mt *mtest.T) {
+		authCollection, _ := NewAuthRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+		err := authCollection.ScheduleDeletion(context.Background(), "123", auth_core.AccountDeletion{RequestedAt: 1, PurgeAt: 2})
+		assert.Equal(t, auth_constants.ErrDBNotFound, err)
+	})
+}
+
+func TestCancelDeletion(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		authCollection, _ := NewAuthRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := authCollection.CancelDeletion(context.Background(), "123")
+		assert.Nil(t, err)
+	})
+
+	mt.Run("already purging", func(mt *mtest.T) {
+		authCollection, _ := NewAuthRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+		err := authCollection.CancelDeletion(context.Background(), "123")
+		assert.Equal(t, auth_constants.ErrDBNotFound, err)
+	})
+}
+
+func TestClaimDueDeletions(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		authCollection, _ := NewAuthRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "_id", Value: "1"}}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "_id", Value: "2"}}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil}),
+		)
+		ids, err := authCollection.ClaimDueDeletions(context.Background(), 100, 10)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"1", "2"}, ids)
+	})
+
+	mt.Run("limit", func(mt *mtest.T) {
+		authCollection, _ := NewAuthRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "_id", Value: "1"}}}),
+		)
+		ids, err := authCollection.ClaimDueDeletions(context.Background(), 100, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"1"}, ids)
+
+		mt.GetStartedEvent()
+		filter := mt.GetStartedEvent().Command.Lookup("query").Document()
+		assert.Equal(t, int64(100), filter.Lookup("$or").Array().Index(1).Value().Document().Lookup("deletion.retry_at", "$lte").Int64(),
+			"the accounts failed to be purged are backed off")
+	})
+
+	mt.Run("update error", func(mt *mtest.T) {
+		authCollection, _ := NewAuthRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 1, Message: "error"}))
+		_, err := authCollection.ClaimDueDeletions(context.Background(), 100, 10)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestDeleteUser(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		authCollection, _ := NewAuthRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+		err := authCollection.DeleteUser(context.Background(), "123")
+		assert.Nil(t, err)
+	})
+}
@@ -0,0 +1,91 @@
+package auth_db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type EmailVerificationRepository interface {
+	CreateEmailVerification(ctx context.Context, verification *auth_core.EmailVerification) error
+	ConsumeEmailVerification(ctx context.Context, hash string) (*auth_core.EmailVerification, error)
+	GetLastEmailVerification(ctx context.Context, userID string) (*auth_core.EmailVerification, error)
+	DeleteUserEmailVerifications(ctx context.Context, userID string) error
+}
+
+type emailVerificationRepositoryImpl struct {
+	db   *mongo.Database
+	coll *mongo.Collection
+}
+
+func NewEmailVerificationRepository(db *mongo.Database) (*emailVerificationRepositoryImpl, error) {
+	repo := &emailVerificationRepositoryImpl{db: db, coll: db.Collection("email_verifications")}
+	if err := repo.createIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// NewEmailVerificationRepositoryTest for Tests (bad)
+func NewEmailVerificationRepositoryTest(collection *mongo.Collection) (*emailVerificationRepositoryImpl, error) {
+	return &emailVerificationRepositoryImpl{coll: collection}, nil
+}
+
+func (repo *emailVerificationRepositoryImpl) createIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		expiryIndex(),
+	})
+	return err
+}
+
+func (repo *emailVerificationRepositoryImpl) CreateEmailVerification(ctx context.Context, verification *auth_core.EmailVerification) error {
+	verification.CreatedAt = time.Now().Unix()
+	verification.DeleteAt = time.Unix(verification.ExpiresAt, 0)
+	if _, err := repo.coll.InsertOne(ctx, verification); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+// ConsumeEmailVerification removes the verification with the provided token hash from the db and returns it,
+// so every token can be used only once.
+func (repo *emailVerificationRepositoryImpl) ConsumeEmailVerification(ctx context.Context, hash string) (*auth_core.EmailVerification, error) {
+	verification := new(auth_core.EmailVerification)
+	if err := repo.coll.FindOneAndDelete(ctx, bson.M{"_id": hash}).Decode(verification); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, auth_constants.ErrDBNotFound
+		}
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return verification, nil
+}
+
+// GetLastEmailVerification returns the verification issued for the user most recently.
+func (repo *emailVerificationRepositoryImpl) GetLastEmailVerification(ctx context.Context, userID string) (*auth_core.EmailVerification, error) {
+	verification := new(auth_core.EmailVerification)
+	opts := options.FindOne().SetSort(bson.M{"created_at": -1})
+	if err := repo.coll.FindOne(ctx, bson.M{"user_id": userID}, opts).Decode(verification); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, auth_constants.ErrDBNotFound
+		}
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return verification, nil
+}
+
+// DeleteUserEmailVerifications invalidates all verification tokens issued for the user.
+func (repo *emailVerificationRepositoryImpl) DeleteUserEmailVerifications(ctx context.Context, userID string) error {
+	if _, err := repo.coll.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package auth_db
+
+import (
+	"context"
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+)
+
+func TestCreateEmailVerification(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		verificationCollection, _ := NewEmailVerificationRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		verification := &auth_core.EmailVerification{Hash: "hash", UserID: "123", ExpiresAt: 24}
+		err := verificationCollection.CreateEmailVerification(context.Background(), verification)
+		assert.Nil(t, err)
+		assert.NotZero(t, verification.CreatedAt)
+	})
+}
+
+func TestConsumeEmailVerification(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		verificationCollection, _ := NewEmailVerificationRepositoryTest(mt.Coll)
+		expected := &auth_core.EmailVerification{Hash: "hash", UserID: "123", CreatedAt: 12, ExpiresAt: 24}
+
+		mt.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "value", Value: bson.D{
+				{Key: "_id", Value: expected.Hash},
+				{Key: "user_id", Value: expected.UserID},
+				{Key: "created_at", Value: expected.CreatedAt},
+				{Key: "expires_at", Value: expected.ExpiresAt},
+			}},
+		})
+		verification, err := verificationCollection.ConsumeEmailVerification(context.Background(), expected.Hash)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, verification)
+	})
+
+	mt.Run("already used", func(mt *mtest.T) {
+		verificationCollection, _ := NewEmailVerificationRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: nil}})
+		verification, err := verificationCollection.ConsumeEmailVerification(context.Background(), "hash")
+		assert.NotNil(t, err)
+		assert.Nil(t, verification)
+	})
+}
+
+func TestGetLastEmailVerification(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		verificationCollection, _ := NewEmailVerificationRepositoryTest(mt.Coll)
+		expected := &auth_core.EmailVerification{Hash: "hash", UserID: "123", CreatedAt: 12, ExpiresAt: 24}
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "foo.bar", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: expected.Hash},
+			{Key: "user_id", Value: expected.UserID},
+			{Key: "created_at", Value: expected.CreatedAt},
+			{Key: "expires_at", Value: expected.ExpiresAt},
+		}))
+		verification, err := verificationCollection.GetLastEmailVerification(context.Background(), expected.UserID)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, verification)
+	})
+
+	mt.Run("none", func(mt *mtest.T) {
+		verificationCollection, _ := NewEmailVerificationRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch))
+		verification, err := verificationCollection.GetLastEmailVerification(context.Background(), "123")
+		assert.NotNil(t, err)
+		assert.Nil(t, verification)
+	})
+}
+
+func TestDeleteUserEmailVerifications(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		verificationCollection, _ := NewEmailVerificationRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}))
+		err := verificationCollection.DeleteUserEmailVerifications(context.Background(), "123")
+		assert.Nil(t, err)
+	})
+}
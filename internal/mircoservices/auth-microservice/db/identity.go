@@ -0,0 +1,112 @@
+package auth_db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type IdentityRepository interface {
+	CreateIdentity(ctx context.Context, identity *auth_core.ExternalIdentity) error
+	GetIdentity(ctx context.Context, provider string, subject string) (*auth_core.ExternalIdentity, error)
+	GetUserIdentities(ctx context.Context, userID string) ([]auth_core.ExternalIdentity, error)
+	DeleteIdentity(ctx context.Context, userID string, provider string) error
+	DeleteUserIdentities(ctx context.Context, userID string) error
+}
+
+type identityRepositoryImpl struct {
+	db   *mongo.Database
+	coll *mongo.Collection
+}
+
+// NewIdentityRepository creates the repository of external identities and the unique indexes
+// which keep every identity linked to one user at most and every user linked to one identity of a provider.
+func NewIdentityRepository(db *mongo.Database) (*identityRepositoryImpl, error) {
+	repo := &identityRepositoryImpl{db: db, coll: db.Collection("external_identities")}
+	if err := repo.createIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// NewIdentityRepositoryTest for Tests (bad)
+func NewIdentityRepositoryTest(collection *mongo.Collection) (*identityRepositoryImpl, error) {
+	return &identityRepositoryImpl{coll: collection}, nil
+}
+
+func (repo *identityRepositoryImpl) createIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "provider", Value: 1}, {Key: "subject", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "provider", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	return err
+}
+
+// CreateIdentity links the identity to the user. Every identity is linked to one user at most,
+// and the user has one identity of every provider at most.
+func (repo *identityRepositoryImpl) CreateIdentity(ctx context.Context, identity *auth_core.ExternalIdentity) error {
+	identity.ID = identityID(identity.Provider, identity.Subject)
+	identity.LinkedAt = time.Now().Unix()
+	if _, err := repo.coll.InsertOne(ctx, identity); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return auth_constants.ErrIdentityAlreadyLinked
+		}
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+func (repo *identityRepositoryImpl) GetIdentity(ctx context.Context, provider string, subject string) (*auth_core.ExternalIdentity, error) {
+	identity := new(auth_core.ExternalIdentity)
+	if err := repo.coll.FindOne(ctx, bson.M{"_id": identityID(provider, subject)}).Decode(identity); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, auth_constants.ErrDBNotFound
+		}
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return identity, nil
+}
+
+func (repo *identityRepositoryImpl) GetUserIdentities(ctx context.Context, userID string) ([]auth_core.ExternalIdentity, error) {
+	cursor, err := repo.coll.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+
+	var identities []auth_core.ExternalIdentity
+	if err := cursor.All(ctx, &identities); err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return identities, nil
+}
+
+// DeleteIdentity unlinks the user's identity of the provider.
+func (repo *identityRepositoryImpl) DeleteIdentity(ctx context.Context, userID string, provider string) error {
+	res, err := repo.coll.DeleteOne(ctx, bson.M{"user_id": userID, "provider": provider})
+	if err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	if res.DeletedCount == 0 {
+		return auth_constants.ErrDBNotFound
+	}
+	return nil
+}
+
+func (repo *identityRepositoryImpl) DeleteUserIdentities(ctx context.Context, userID string) error {
+	if _, err := repo.coll.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+func identityID(provider string, subject string) string {
+	return provider + ":" + subject
+}
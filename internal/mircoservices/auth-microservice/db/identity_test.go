@@ -0,0 +1,126 @@
+package auth_db
+
+import (
+	"context"
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+)
+
+func TestCreateIdentity(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		identityCollection, _ := NewIdentityRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		identity := &auth_core.ExternalIdentity{Provider: "google", Subject: "42", UserID: "1"}
+		err := identityCollection.CreateIdentity(context.Background(), identity)
+		assert.Nil(t, err)
+		assert.Equal(t, "google:42", identity.ID)
+		assert.NotZero(t, identity.LinkedAt)
+	})
+
+	mt.Run("already linked", func(mt *mtest.T) {
+		identityCollection, _ := NewIdentityRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key error"}))
+		err := identityCollection.CreateIdentity(context.Background(), &auth_core.ExternalIdentity{Provider: "google", Subject: "42", UserID: "1"})
+		assert.Equal(t, auth_constants.ErrIdentityAlreadyLinked, err)
+	})
+}
+
+func TestGetIdentity(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		identityCollection, _ := NewIdentityRepositoryTest(mt.Coll)
+		expected := &auth_core.ExternalIdentity{ID: "google:42", Provider: "google", Subject: "42", UserID: "1", LinkedAt: 12}
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "foo.bar", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: expected.ID},
+			{Key: "provider", Value: expected.Provider},
+			{Key: "subject", Value: expected.Subject},
+			{Key: "user_id", Value: expected.UserID},
+			{Key: "linked_at", Value: expected.LinkedAt},
+		}))
+		identity, err := identityCollection.GetIdentity(context.Background(), "google", "42")
+		assert.Nil(t, err)
+		assert.Equal(t, expected, identity)
+	})
+
+	mt.Run("not linked", func(mt *mtest.T) {
+		identityCollection, _ := NewIdentityRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch))
+		identity, err := identityCollection.GetIdentity(context.Background(), "google", "42")
+		assert.Equal(t, auth_constants.ErrDBNotFound, err)
+		assert.Nil(t, identity)
+	})
+}
+
+func TestGetUserIdentities(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		identityCollection, _ := NewIdentityRepositoryTest(mt.Coll)
+
+		first := mtest.CreateCursorResponse(1, "foo.bar", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "google:42"},
+			{Key: "provider", Value: "google"},
+			{Key: "user_id", Value: "1"},
+		})
+		second := mtest.CreateCursorResponse(1, "foo.bar", mtest.NextBatch, bson.D{
+			{Key: "_id", Value: "vk:7"},
+			{Key: "provider", Value: "vk"},
+			{Key: "user_id", Value: "1"},
+		})
+		killCursors := mtest.CreateCursorResponse(0, "foo.bar", mtest.NextBatch)
+		mt.AddMockResponses(first, second, killCursors)
+
+		identities, err := identityCollection.GetUserIdentities(context.Background(), "1")
+		assert.Nil(t, err)
+		assert.Len(t, identities, 2)
+		assert.Equal(t, "vk", identities[1].Provider)
+	})
+}
+
+func TestDeleteIdentity(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		identityCollection, _ := NewIdentityRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+		err := identityCollection.DeleteIdentity(context.Background(), "1", "google")
+		assert.Nil(t, err)
+	})
+
+	mt.Run("not linked", func(mt *mtest.T) {
+		identityCollection, _ := NewIdentityRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}))
+		err := identityCollection.DeleteIdentity(context.Background(), "1", "google")
+		assert.Equal(t, auth_constants.ErrDBNotFound, err)
+	})
+}
+
+func TestDeleteUserIdentities(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		identityCollection, _ := NewIdentityRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}))
+		err := identityCollection.DeleteUserIdentities(context.Background(), "1")
+		assert.Nil(t, err)
+	})
+}
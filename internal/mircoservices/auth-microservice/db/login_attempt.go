@@ -0,0 +1,91 @@
+package auth_db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type LoginAttemptRepository interface {
+	GetLoginAttempt(ctx context.Context, key string) (*auth_core.LoginAttempt, error)
+	RegisterLoginFailure(ctx context.Context, key string, now int64, windowStart int64) (*auth_core.LoginAttempt, error)
+	LockLogin(ctx context.Context, key string, lockedUntil int64) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+}
+
+type loginAttemptRepositoryImpl struct {
+	db   *mongo.Database
+	coll *mongo.Collection
+}
+
+func NewLoginAttemptRepository(db *mongo.Database) (*loginAttemptRepositoryImpl, error) {
+	repo := &loginAttemptRepositoryImpl{db: db, coll: db.Collection("login_attempts")}
+	if _, err := repo.coll.Indexes().CreateOne(context.Background(), expiryIndex()); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// NewLoginAttemptRepositoryTest for Tests (bad)
+func NewLoginAttemptRepositoryTest(collection *mongo.Collection) (*loginAttemptRepositoryImpl, error) {
+	return &loginAttemptRepositoryImpl{coll: collection}, nil
+}
+
+func (repo *loginAttemptRepositoryImpl) GetLoginAttempt(ctx context.Context, key string) (*auth_core.LoginAttempt, error) {
+	attempt := new(auth_core.LoginAttempt)
+	if err := repo.coll.FindOne(ctx, bson.M{"_id": key}).Decode(attempt); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, auth_constants.ErrDBNotFound
+		}
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return attempt, nil
+}
+
+// RegisterLoginFailure counts one more failed login and returns the updated counter.
+// Failures made before windowStart are forgotten unless the key is still locked,
+// the counter is removed once the window of the last failure is over.
+func (repo *loginAttemptRepositoryImpl) RegisterLoginFailure(ctx context.Context, key string, now int64, windowStart int64) (*auth_core.LoginAttempt, error) {
+	stale := bson.M{"_id": key, "last_failure_at": bson.M{"$lt": windowStart}, "locked_until": bson.M{"$lt": now}}
+	if _, err := repo.coll.DeleteOne(ctx, stale); err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+
+	window := now - windowStart
+	update := bson.M{
+		"$inc":         bson.M{"failures": 1},
+		"$set":         bson.M{"last_failure_at": now},
+		"$max":         bson.M{deleteAtField: time.Unix(now+window, 0)},
+		"$setOnInsert": bson.M{"locked_until": int64(0)},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	attempt := new(auth_core.LoginAttempt)
+	if err := repo.coll.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(attempt); err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return attempt, nil
+}
+
+func (repo *loginAttemptRepositoryImpl) LockLogin(ctx context.Context, key string, lockedUntil int64) error {
+	update := bson.M{"$max": bson.M{"locked_until": lockedUntil, deleteAtField: time.Unix(lockedUntil, 0)}}
+	if _, err := repo.coll.UpdateOne(ctx, bson.M{"_id": key}, update); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+func (repo *loginAttemptRepositoryImpl) ResetLoginAttempts(ctx context.Context, key string) error {
+	if _, err := repo.coll.DeleteOne(ctx, bson.M{"_id": key}); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package auth_db
+
+import (
+	"context"
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+)
+
+func TestGetLoginAttempt(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		attemptCollection, _ := NewLoginAttemptRepositoryTest(mt.Coll)
+		expected := &auth_core.LoginAttempt{Key: "email:email@e", Failures: 3, LastFailureAt: 12, LockedUntil: 24}
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "foo.bar", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: expected.Key},
+			{Key: "failures", Value: expected.Failures},
+			{Key: "last_failure_at", Value: expected.LastFailureAt},
+			{Key: "locked_until", Value: expected.LockedUntil},
+		}))
+		attempt, err := attemptCollection.GetLoginAttempt(context.Background(), expected.Key)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, attempt)
+	})
+
+	mt.Run("don't find in collection", func(mt *mtest.T) {
+		attemptCollection, _ := NewLoginAttemptRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch))
+		attempt, err := attemptCollection.GetLoginAttempt(context.Background(), "email:email@e")
+		assert.NotNil(t, err)
+		assert.Nil(t, attempt)
+	})
+}
+
+func TestRegisterLoginFailure(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		attemptCollection, _ := NewLoginAttemptRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}),
+			bson.D{
+				{Key: "ok", Value: 1},
+				{Key: "value", Value: bson.D{
+					{Key: "_id", Value: "ip:127.0.0.1"},
+					{Key: "failures", Value: 4},
+					{Key: "last_failure_at", Value: 30},
+					{Key: "locked_until", Value: 0},
+				}},
+			},
+		)
+		attempt, err := attemptCollection.RegisterLoginFailure(context.Background(), "ip:127.0.0.1", 30, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, &auth_core.LoginAttempt{Key: "ip:127.0.0.1", Failures: 4, LastFailureAt: 30}, attempt)
+	})
+}
+
+func TestLockLogin(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		attemptCollection, _ := NewLoginAttemptRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := attemptCollection.LockLogin(context.Background(), "email:email@e", 60)
+		assert.Nil(t, err)
+	})
+}
+
+func TestResetLoginAttempts(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		attemptCollection, _ := NewLoginAttemptRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+		err := attemptCollection.ResetLoginAttempts(context.Background(), "email:email@e")
+		assert.Nil(t, err)
+	})
+}
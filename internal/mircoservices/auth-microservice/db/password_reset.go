@@ -0,0 +1,91 @@
+package auth_db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type PasswordResetRepository interface {
+	CreatePasswordReset(ctx context.Context, reset *auth_core.PasswordReset) error
+	ConsumePasswordReset(ctx context.Context, hash string) (*auth_core.PasswordReset, error)
+	GetLastPasswordReset(ctx context.Context, userID string) (*auth_core.PasswordReset, error)
+	DeleteUserPasswordResets(ctx context.Context, userID string) error
+}
+
+type passwordResetRepositoryImpl struct {
+	db   *mongo.Database
+	coll *mongo.Collection
+}
+
+func NewPasswordResetRepository(db *mongo.Database) (*passwordResetRepositoryImpl, error) {
+	repo := &passwordResetRepositoryImpl{db: db, coll: db.Collection("password_resets")}
+	if err := repo.createIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// NewPasswordResetRepositoryTest for Tests (bad)
+func NewPasswordResetRepositoryTest(collection *mongo.Collection) (*passwordResetRepositoryImpl, error) {
+	return &passwordResetRepositoryImpl{coll: collection}, nil
+}
+
+func (repo *passwordResetRepositoryImpl) createIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		expiryIndex(),
+	})
+	return err
+}
+
+func (repo *passwordResetRepositoryImpl) CreatePasswordReset(ctx context.Context, reset *auth_core.PasswordReset) error {
+	reset.CreatedAt = time.Now().Unix()
+	reset.DeleteAt = time.Unix(reset.ExpiresAt, 0)
+	if _, err := repo.coll.InsertOne(ctx, reset); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+// ConsumePasswordReset removes the password reset with the provided token hash from the db and returns it,
+// so every token can be used only once.
+func (repo *passwordResetRepositoryImpl) ConsumePasswordReset(ctx context.Context, hash string) (*auth_core.PasswordReset, error) {
+	reset := new(auth_core.PasswordReset)
+	if err := repo.coll.FindOneAndDelete(ctx, bson.M{"_id": hash}).Decode(reset); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, auth_constants.ErrDBNotFound
+		}
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return reset, nil
+}
+
+// GetLastPasswordReset returns the password reset issued for the user most recently.
+func (repo *passwordResetRepositoryImpl) GetLastPasswordReset(ctx context.Context, userID string) (*auth_core.PasswordReset, error) {
+	reset := new(auth_core.PasswordReset)
+	opts := options.FindOne().SetSort(bson.M{"created_at": -1})
+	if err := repo.coll.FindOne(ctx, bson.M{"user_id": userID}, opts).Decode(reset); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, auth_constants.ErrDBNotFound
+		}
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return reset, nil
+}
+
+// DeleteUserPasswordResets invalidates all password reset tokens issued for the user.
+func (repo *passwordResetRepositoryImpl) DeleteUserPasswordResets(ctx context.Context, userID string) error {
+	if _, err := repo.coll.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
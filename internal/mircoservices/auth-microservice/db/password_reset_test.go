@@ -0,0 +1,86 @@
+package auth_db
+
+import (
+	"context"
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+)
+
+func TestCreatePasswordReset(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		resetCollection, _ := NewPasswordResetRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		reset := &auth_core.PasswordReset{Hash: "hash", UserID: "123", ExpiresAt: 24}
+		err := resetCollection.CreatePasswordReset(context.Background(), reset)
+		assert.Nil(t, err)
+		assert.NotZero(t, reset.CreatedAt)
+	})
+}
+
+func TestConsumePasswordReset(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		resetCollection, _ := NewPasswordResetRepositoryTest(mt.Coll)
+		expectedReset := &auth_core.PasswordReset{Hash: "hash", UserID: "123", CreatedAt: 12, ExpiresAt: 24}
+
+		mt.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "value", Value: bson.D{
+				{Key: "_id", Value: expectedReset.Hash},
+				{Key: "user_id", Value: expectedReset.UserID},
+				{Key: "created_at", Value: expectedReset.CreatedAt},
+				{Key: "expires_at", Value: expectedReset.ExpiresAt},
+			}},
+		})
+		reset, err := resetCollection.ConsumePasswordReset(context.Background(), expectedReset.Hash)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedReset, reset)
+	})
+
+	mt.Run("already used", func(mt *mtest.T) {
+		resetCollection, _ := NewPasswordResetRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: nil}})
+		reset, err := resetCollection.ConsumePasswordReset(context.Background(), "hash")
+		assert.NotNil(t, err)
+		assert.Nil(t, reset)
+	})
+}
+
+func TestGetLastPasswordReset(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		resetCollection, _ := NewPasswordResetRepositoryTest(mt.Coll)
+		expected := &auth_core.PasswordReset{Hash: "hash", UserID: "123", CreatedAt: 12, ExpiresAt: 24}
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "foo.bar", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: expected.Hash},
+			{Key: "user_id", Value: expected.UserID},
+			{Key: "created_at", Value: expected.CreatedAt},
+			{Key: "expires_at", Value: expected.ExpiresAt},
+		}))
+		reset, err := resetCollection.GetLastPasswordReset(context.Background(), expected.UserID)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, reset)
+	})
+
+	mt.Run("none", func(mt *mtest.T) {
+		resetCollection, _ := NewPasswordResetRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch))
+		reset, err := resetCollection.GetLastPasswordReset(context.Background(), "123")
+		assert.NotNil(t, err)
+		assert.Nil(t, reset)
+	})
+}
@@ -5,11 +5,30 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// deleteAtField holds the date the expired document is removed at by the TTL index.
+// TTL indexes work with dates only, so it is kept next to the unix timestamps the documents are checked by.
+const deleteAtField = "delete_at"
+
+// expiryIndex removes the documents once their deleteAtField is in the past.
+func expiryIndex() mongo.IndexModel {
+	return mongo.IndexModel{Keys: bson.D{{Key: deleteAtField, Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)}
+}
+
 type Repository struct {
-	AuthRepo AuthRepository
+	AuthRepo          AuthRepository
+	SessionRepo       SessionRepository
+	PasswordResetRepo PasswordResetRepository
+	TwoFactorRepo     TwoFactorRepository
+	LoginAttemptRepo  LoginAttemptRepository
+
+	EmailVerificationRepo EmailVerificationRepository
+	IdentityRepo          IdentityRepository
+	APITokenRepo          APITokenRepository
 }
 
 func NewRepository(dbConn *mongo.Database) (*Repository, error) {
@@ -20,5 +39,40 @@ func NewRepository(dbConn *mongo.Database) (*Repository, error) {
 	if err != nil {
 		return nil, status.Error(codes.Internal, fmt.Errorf("create repository %s", err).Error())
 	}
+
+	repository.SessionRepo, err = NewSessionRepository(dbConn)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("create session repository %s", err).Error())
+	}
+
+	repository.PasswordResetRepo, err = NewPasswordResetRepository(dbConn)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("create password reset repository %s", err).Error())
+	}
+
+	repository.TwoFactorRepo, err = NewTwoFactorRepository(dbConn)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("create two factor repository %s", err).Error())
+	}
+
+	repository.LoginAttemptRepo, err = NewLoginAttemptRepository(dbConn)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("create login attempt repository %s", err).Error())
+	}
+
+	repository.EmailVerificationRepo, err = NewEmailVerificationRepository(dbConn)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("create email verification repository %s", err).Error())
+	}
+
+	repository.IdentityRepo, err = NewIdentityRepository(dbConn)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("create identity repository %s", err).Error())
+	}
+
+	repository.APITokenRepo, err = NewAPITokenRepository(dbConn)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("create api token repository %s", err).Error())
+	}
 	return repository, nil
 }
@@ -0,0 +1,195 @@
+package auth_db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *auth_core.Session) error
+	GetSessionByID(ctx context.Context, ID string) (*auth_core.Session, error)
+	GetSessionByRefreshHash(ctx context.Context, hash string) (*auth_core.Session, error)
+	GetSessionByUsedHash(ctx context.Context, hash string) (*auth_core.Session, error)
+	GetUserSessions(ctx context.Context, userID string) ([]auth_core.Session, error)
+	RotateRefreshHash(ctx context.Context, ID string, oldHash string, newHash string, expiresAt int64) (bool, error)
+	TouchSession(ctx context.Context, ID string, ip string, lastSeenAt int64) error
+	RevokeSession(ctx context.Context, ID string) error
+	RevokeUserSessions(ctx context.Context, userID string, exceptID string) error
+	DeleteUserSessions(ctx context.Context, userID string) error
+	MarkUserSessionsVerified(ctx context.Context, userID string) error
+}
+
+type sessionRepositoryImpl struct {
+	db   *mongo.Database
+	coll *mongo.Collection
+}
+
+// NewSessionRepository creates the repository of sessions and the indexes they are looked up by.
+func NewSessionRepository(db *mongo.Database) (*sessionRepositoryImpl, error) {
+	repo := &sessionRepositoryImpl{db: db, coll: db.Collection("sessions")}
+	if err := repo.createIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// NewSessionRepositoryTest for Tests (bad)
+func NewSessionRepositoryTest(collection *mongo.Collection) (*sessionRepositoryImpl, error) {
+	return &sessionRepositoryImpl{coll: collection}, nil
+}
+
+func (repo *sessionRepositoryImpl) createIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "refresh_hash", Value: 1}}},
+		{Keys: bson.D{{Key: "used_hashes", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		expiryIndex(),
+	})
+	return err
+}
+
+// CreateSession generates id for the given session and inserts it to the db.
+func (repo *sessionRepositoryImpl) CreateSession(ctx context.Context, session *auth_core.Session) error {
+	if err := repo.InitSession(session); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	session.DeleteAt = time.Unix(session.ExpiresAt, 0)
+
+	if _, err := repo.coll.InsertOne(ctx, session); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+func (repo *sessionRepositoryImpl) GetSessionByID(ctx context.Context, ID string) (*auth_core.Session, error) {
+	return repo.findSession(ctx, bson.M{"_id": ID})
+}
+
+// GetSessionByRefreshHash looks up in the db for session whose current refresh token has the provided hash.
+func (repo *sessionRepositoryImpl) GetSessionByRefreshHash(ctx context.Context, hash string) (*auth_core.Session, error) {
+	return repo.findSession(ctx, bson.M{"refresh_hash": hash})
+}
+
+// GetSessionByUsedHash looks up in the db for session which has already rotated refresh token with the provided hash.
+func (repo *sessionRepositoryImpl) GetSessionByUsedHash(ctx context.Context, hash string) (*auth_core.Session, error) {
+	return repo.findSession(ctx, bson.M{"used_hashes": hash})
+}
+
+// GetUserSessions returns active sessions of the user, the most recently used first.
+func (repo *sessionRepositoryImpl) GetUserSessions(ctx context.Context, userID string) ([]auth_core.Session, error) {
+	filter := bson.M{"user_id": userID, "revoked": false, "expires_at": bson.M{"$gt": time.Now().Unix()}}
+	opts := options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}})
+
+	cursor, err := repo.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []auth_core.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return sessions, nil
+}
+
+// TouchSession updates the last activity time of the session and, if provided, its ip address.
+func (repo *sessionRepositoryImpl) TouchSession(ctx context.Context, ID string, ip string, lastSeenAt int64) error {
+	set := bson.M{"last_seen_at": lastSeenAt}
+	if len(ip) != 0 {
+		set["ip"] = ip
+	}
+	if _, err := repo.coll.UpdateByID(ctx, ID, bson.M{"$set": set}); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+// RotateRefreshHash replaces current refresh token hash of the active session with the new one.
+// Only the last rotated hashes are kept to detect their reuse.
+// Returns false if the session was revoked or its refresh token was rotated concurrently.
+func (repo *sessionRepositoryImpl) RotateRefreshHash(ctx context.Context, ID string, oldHash string, newHash string, expiresAt int64) (bool, error) {
+	filter := bson.M{"_id": ID, "refresh_hash": oldHash, "revoked": false}
+	update := bson.M{
+		"$set": bson.M{
+			"refresh_hash": newHash,
+			"expires_at":   expiresAt,
+			"rotated_at":   time.Now().Unix(),
+			deleteAtField:  time.Unix(expiresAt, 0),
+		},
+		"$push": bson.M{"used_hashes": bson.M{"$each": bson.A{oldHash}, "$slice": -auth_constants.SessionUsedHashesKept}},
+	}
+	res, err := repo.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// RevokeSession marks the session as revoked, so none of its tokens are accepted anymore.
+func (repo *sessionRepositoryImpl) RevokeSession(ctx context.Context, ID string) error {
+	if _, err := repo.coll.UpdateByID(ctx, ID, bson.M{"$set": bson.M{"revoked": true}}); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+// RevokeUserSessions revokes all sessions of the user except the one with exceptID (if it is not empty).
+func (repo *sessionRepositoryImpl) RevokeUserSessions(ctx context.Context, userID string, exceptID string) error {
+	filter := bson.M{"user_id": userID, "revoked": false}
+	if len(exceptID) != 0 {
+		filter["_id"] = bson.M{"$ne": exceptID}
+	}
+	if _, err := repo.coll.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked": true}}); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+// DeleteUserSessions removes all sessions of the user, including the revoked ones.
+func (repo *sessionRepositoryImpl) DeleteUserSessions(ctx context.Context, userID string) error {
+	if _, err := repo.coll.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+// MarkUserSessionsVerified lets the user's sessions issue tokens without the unverified email restrictions.
+func (repo *sessionRepositoryImpl) MarkUserSessionsVerified(ctx context.Context, userID string) error {
+	filter := bson.M{"user_id": userID, "unverified": true}
+	if _, err := repo.coll.UpdateMany(ctx, filter, bson.M{"$unset": bson.M{"unverified": ""}}); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+func (repo *sessionRepositoryImpl) findSession(ctx context.Context, filter bson.M) (*auth_core.Session, error) {
+	session := new(auth_core.Session)
+	if err := repo.coll.FindOne(ctx, filter).Decode(session); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, auth_constants.ErrDBNotFound
+		}
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return session, nil
+}
+
+func (repo *sessionRepositoryImpl) InitSession(session *auth_core.Session) error {
+	sid, err := auth_core.GenUUID()
+	if err != nil {
+		return err
+	}
+	session.ID = sid
+	session.CreatedAt = time.Now().Unix()
+	session.LastSeenAt = session.CreatedAt
+	return nil
+}
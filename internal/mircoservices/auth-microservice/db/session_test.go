@@ -0,0 +1,202 @@
+package auth_db
+
+import (
+	"context"
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+)
+
+func AuthSession(t *testing.T) *auth_core.Session {
+	t.Helper()
+
+	return &auth_core.Session{
+		ID:          "1",
+		UserID:      "123",
+		RefreshHash: "hash",
+		Device:      "Firefox on Linux",
+		UserAgent:   "Mozilla/5.0 (X11; Linux x86_64; rv:100.0) Gecko/20100101 Firefox/100.0",
+		IP:          "127.0.0.1",
+		CreatedAt:   12,
+		LastSeenAt:  18,
+		ExpiresAt:   24,
+	}
+}
+
+func TestCreateSession(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		sessionCollection, _ := NewSessionRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		session := &auth_core.Session{UserID: "123", RefreshHash: "hash"}
+		err := sessionCollection.CreateSession(context.Background(), session)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, session.ID)
+		assert.NotZero(t, session.CreatedAt)
+		assert.Equal(t, session.CreatedAt, session.LastSeenAt)
+	})
+}
+
+func TestGetSessionByRefreshHash(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		sessionCollection, _ := NewSessionRepositoryTest(mt.Coll)
+		expectedSession := AuthSession(t)
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "foo.bar", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: expectedSession.ID},
+			{Key: "user_id", Value: expectedSession.UserID},
+			{Key: "refresh_hash", Value: expectedSession.RefreshHash},
+			{Key: "revoked", Value: expectedSession.Revoked},
+			{Key: "device", Value: expectedSession.Device},
+			{Key: "user_agent", Value: expectedSession.UserAgent},
+			{Key: "ip", Value: expectedSession.IP},
+			{Key: "created_at", Value: expectedSession.CreatedAt},
+			{Key: "last_seen_at", Value: expectedSession.LastSeenAt},
+			{Key: "expires_at", Value: expectedSession.ExpiresAt},
+		}))
+		session, err := sessionCollection.GetSessionByRefreshHash(context.Background(), expectedSession.RefreshHash)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedSession, session)
+	})
+
+	mt.Run("don't find in collection", func(mt *mtest.T) {
+		sessionCollection, _ := NewSessionRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch))
+		session, err := sessionCollection.GetSessionByRefreshHash(context.Background(), "hash")
+		assert.NotNil(t, err)
+		assert.Nil(t, session)
+	})
+}
+
+func TestGetUserSessions(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		sessionCollection, _ := NewSessionRepositoryTest(mt.Coll)
+		expectedSession := AuthSession(t)
+		first := mtest.CreateCursorResponse(1, "foo.bar", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: expectedSession.ID},
+			{Key: "user_id", Value: expectedSession.UserID},
+			{Key: "refresh_hash", Value: expectedSession.RefreshHash},
+			{Key: "revoked", Value: expectedSession.Revoked},
+			{Key: "device", Value: expectedSession.Device},
+			{Key: "user_agent", Value: expectedSession.UserAgent},
+			{Key: "ip", Value: expectedSession.IP},
+			{Key: "created_at", Value: expectedSession.CreatedAt},
+			{Key: "last_seen_at", Value: expectedSession.LastSeenAt},
+			{Key: "expires_at", Value: expectedSession.ExpiresAt},
+		})
+		killCursors := mtest.CreateCursorResponse(0, "foo.bar", mtest.NextBatch)
+		mt.AddMockResponses(first, killCursors)
+
+		sessions, err := sessionCollection.GetUserSessions(context.Background(), expectedSession.UserID)
+		assert.Nil(t, err)
+		assert.Equal(t, []auth_core.Session{*expectedSession}, sessions)
+	})
+
+	mt.Run("no sessions", func(mt *mtest.T) {
+		sessionCollection, _ := NewSessionRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch))
+		sessions, err := sessionCollection.GetUserSessions(context.Background(), "123")
+		assert.Nil(t, err)
+		assert.Empty(t, sessions)
+	})
+}
+
+func TestTouchSession(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		sessionCollection, _ := NewSessionRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := sessionCollection.TouchSession(context.Background(), "1", "127.0.0.1", 30)
+		assert.Nil(t, err)
+	})
+}
+
+func TestRotateRefreshHash(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		sessionCollection, _ := NewSessionRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		rotated, err := sessionCollection.RotateRefreshHash(context.Background(), "1", "old", "new", 24)
+		assert.Nil(t, err)
+		assert.True(t, rotated)
+	})
+
+	mt.Run("already rotated", func(mt *mtest.T) {
+		sessionCollection, _ := NewSessionRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+		rotated, err := sessionCollection.RotateRefreshHash(context.Background(), "1", "old", "new", 24)
+		assert.Nil(t, err)
+		assert.False(t, rotated)
+	})
+}
+
+func TestRevokeSession(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		sessionCollection, _ := NewSessionRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		err := sessionCollection.RevokeSession(context.Background(), "1")
+		assert.Nil(t, err)
+	})
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		sessionCollection, _ := NewSessionRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}))
+		err := sessionCollection.RevokeUserSessions(context.Background(), "123", "1")
+		assert.Nil(t, err)
+	})
+}
+
+func TestDeleteUserSessions(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		sessionCollection, _ := NewSessionRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}))
+		err := sessionCollection.DeleteUserSessions(context.Background(), "123")
+		assert.Nil(t, err)
+	})
+}
+
+func TestMarkUserSessionsVerified(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		sessionCollection, _ := NewSessionRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}))
+		err := sessionCollection.MarkUserSessionsVerified(context.Background(), "123")
+		assert.Nil(t, err)
+	})
+}
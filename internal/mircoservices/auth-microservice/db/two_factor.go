@@ -0,0 +1,153 @@
+package auth_db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type TwoFactorRepository interface {
+	GetTwoFactor(ctx context.Context, userID string) (*auth_core.TwoFactor, error)
+	SetPendingSecret(ctx context.Context, userID string, secret string) error
+	EnableTwoFactor(ctx context.Context, userID string, secret string, recoveryHashes []string) error
+	DisableTwoFactor(ctx context.Context, userID string) error
+	UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID string, hash string) (bool, error)
+
+	CreateLoginTicket(ctx context.Context, ticket *auth_core.LoginTicket) error
+	UseLoginTicketAttempt(ctx context.Context, hash string, maxAttempts int, now int64) (*auth_core.LoginTicket, error)
+	DeleteLoginTicket(ctx context.Context, hash string) error
+	DeleteUserLoginTickets(ctx context.Context, userID string) error
+}
+
+type twoFactorRepositoryImpl struct {
+	db      *mongo.Database
+	coll    *mongo.Collection
+	tickets *mongo.Collection
+}
+
+func NewTwoFactorRepository(db *mongo.Database) (*twoFactorRepositoryImpl, error) {
+	repo := &twoFactorRepositoryImpl{db: db, coll: db.Collection("two_factor"), tickets: db.Collection("login_tickets")}
+	if err := repo.createIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// NewTwoFactorRepositoryTest for Tests (bad)
+func NewTwoFactorRepositoryTest(collection *mongo.Collection) (*twoFactorRepositoryImpl, error) {
+	return &twoFactorRepositoryImpl{coll: collection, tickets: collection}, nil
+}
+
+func (repo *twoFactorRepositoryImpl) createIndexes(ctx context.Context) error {
+	_, err := repo.tickets.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		expiryIndex(),
+	})
+	return err
+}
+
+func (repo *twoFactorRepositoryImpl) GetTwoFactor(ctx context.Context, userID string) (*auth_core.TwoFactor, error) {
+	twoFactor := new(auth_core.TwoFactor)
+	if err := repo.coll.FindOne(ctx, bson.M{"_id": userID}).Decode(twoFactor); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, auth_constants.ErrDBNotFound
+		}
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return twoFactor, nil
+}
+
+// SetPendingSecret stores the enrolled secret until the user confirms it with a code.
+func (repo *twoFactorRepositoryImpl) SetPendingSecret(ctx context.Context, userID string, secret string) error {
+	update := bson.M{"$set": bson.M{"pending_secret": secret}}
+	if _, err := repo.coll.UpdateByID(ctx, userID, update, options.Update().SetUpsert(true)); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+func (repo *twoFactorRepositoryImpl) EnableTwoFactor(ctx context.Context, userID string, secret string, recoveryHashes []string) error {
+	update := bson.M{
+		"$set":   bson.M{"enabled": true, "secret": secret, "recovery_hashes": recoveryHashes},
+		"$unset": bson.M{"pending_secret": ""},
+	}
+	if _, err := repo.coll.UpdateByID(ctx, userID, update); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+func (repo *twoFactorRepositoryImpl) DisableTwoFactor(ctx context.Context, userID string) error {
+	if _, err := repo.coll.DeleteOne(ctx, bson.M{"_id": userID}); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+// UseTOTPStep marks the TOTP period as used. Returns false if a code of this or a later period was already accepted.
+func (repo *twoFactorRepositoryImpl) UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	filter := bson.M{"_id": userID, "last_used_step": bson.M{"$lt": step}}
+	res, err := repo.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"last_used_step": step}})
+	if err != nil {
+		return false, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// UseRecoveryCode removes the recovery code. Returns false if there is no such code.
+func (repo *twoFactorRepositoryImpl) UseRecoveryCode(ctx context.Context, userID string, hash string) (bool, error) {
+	filter := bson.M{"_id": userID, "recovery_hashes": hash}
+	res, err := repo.coll.UpdateOne(ctx, filter, bson.M{"$pull": bson.M{"recovery_hashes": hash}})
+	if err != nil {
+		return false, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (repo *twoFactorRepositoryImpl) CreateLoginTicket(ctx context.Context, ticket *auth_core.LoginTicket) error {
+	ticket.DeleteAt = time.Unix(ticket.ExpiresAt, 0)
+	if _, err := repo.tickets.InsertOne(ctx, ticket); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+// UseLoginTicketAttempt counts one more attempt to enter the code with the ticket.
+// Returns not found error if the ticket doesn't exist, is expired or has no attempts left.
+func (repo *twoFactorRepositoryImpl) UseLoginTicketAttempt(ctx context.Context, hash string, maxAttempts int, now int64) (*auth_core.LoginTicket, error) {
+	filter := bson.M{"_id": hash, "attempts": bson.M{"$lt": maxAttempts}, "expires_at": bson.M{"$gt": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	ticket := new(auth_core.LoginTicket)
+	if err := repo.tickets.FindOneAndUpdate(ctx, filter, bson.M{"$inc": bson.M{"attempts": 1}}, opts).Decode(ticket); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, auth_constants.ErrDBNotFound
+		}
+		return nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return ticket, nil
+}
+
+func (repo *twoFactorRepositoryImpl) DeleteLoginTicket(ctx context.Context, hash string) error {
+	if _, err := repo.tickets.DeleteOne(ctx, bson.M{"_id": hash}); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
+
+// DeleteUserLoginTickets removes all login tickets issued for the user.
+func (repo *twoFactorRepositoryImpl) DeleteUserLoginTickets(ctx context.Context, userID string) error {
+	if _, err := repo.tickets.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return nil
+}
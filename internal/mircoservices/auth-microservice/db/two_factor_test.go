@@ -0,0 +1,109 @@
+package auth_db
+
+import (
+	"context"
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+)
+
+func TestGetTwoFactor(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		twoFactorCollection, _ := NewTwoFactorRepositoryTest(mt.Coll)
+		expected := &auth_core.TwoFactor{UserID: "1", Enabled: true, Secret: "SECRET", RecoveryHashes: []string{"hash"}, LastUsedStep: 10}
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "foo.bar", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: expected.UserID},
+			{Key: "enabled", Value: expected.Enabled},
+			{Key: "secret", Value: expected.Secret},
+			{Key: "recovery_hashes", Value: bson.A{"hash"}},
+			{Key: "last_used_step", Value: expected.LastUsedStep},
+		}))
+		twoFactor, err := twoFactorCollection.GetTwoFactor(context.Background(), "1")
+		assert.Nil(t, err)
+		assert.Equal(t, expected, twoFactor)
+	})
+
+	mt.Run("don't find in collection", func(mt *mtest.T) {
+		twoFactorCollection, _ := NewTwoFactorRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch))
+		twoFactor, err := twoFactorCollection.GetTwoFactor(context.Background(), "1")
+		assert.NotNil(t, err)
+		assert.Nil(t, twoFactor)
+	})
+}
+
+func TestUseTOTPStep(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("new step", func(mt *mtest.T) {
+		twoFactorCollection, _ := NewTwoFactorRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		used, err := twoFactorCollection.UseTOTPStep(context.Background(), "1", 11)
+		assert.Nil(t, err)
+		assert.True(t, used)
+	})
+
+	mt.Run("replayed step", func(mt *mtest.T) {
+		twoFactorCollection, _ := NewTwoFactorRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+		used, err := twoFactorCollection.UseTOTPStep(context.Background(), "1", 10)
+		assert.Nil(t, err)
+		assert.False(t, used)
+	})
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		twoFactorCollection, _ := NewTwoFactorRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		used, err := twoFactorCollection.UseRecoveryCode(context.Background(), "1", "hash")
+		assert.Nil(t, err)
+		assert.True(t, used)
+	})
+}
+
+func TestUseLoginTicketAttempt(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		twoFactorCollection, _ := NewTwoFactorRepositoryTest(mt.Coll)
+		expected := &auth_core.LoginTicket{Hash: "hash", UserID: "1", Attempts: 1, ExpiresAt: 24}
+
+		mt.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "value", Value: bson.D{
+				{Key: "_id", Value: expected.Hash},
+				{Key: "user_id", Value: expected.UserID},
+				{Key: "attempts", Value: expected.Attempts},
+				{Key: "expires_at", Value: expected.ExpiresAt},
+			}},
+		})
+		ticket, err := twoFactorCollection.UseLoginTicketAttempt(context.Background(), "hash", 5, 12)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, ticket)
+	})
+
+	mt.Run("no attempts left", func(mt *mtest.T) {
+		twoFactorCollection, _ := NewTwoFactorRepositoryTest(mt.Coll)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: nil}})
+		ticket, err := twoFactorCollection.UseLoginTicketAttempt(context.Background(), "hash", 5, 12)
+		assert.NotNil(t, err)
+		assert.Nil(t, ticket)
+	})
+}
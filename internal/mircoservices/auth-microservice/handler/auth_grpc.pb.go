@@ -25,6 +25,30 @@ type UserAuthClient interface {
 	Login(ctx context.Context, in *LoginReq, opts ...grpc.CallOption) (*LoginRes, error)
 	SignUp(ctx context.Context, in *SignUpReq, opts ...grpc.CallOption) (*SignUpRes, error)
 	Check(ctx context.Context, in *CheckReq, opts ...grpc.CallOption) (*CheckRes, error)
+	Refresh(ctx context.Context, in *RefreshReq, opts ...grpc.CallOption) (*RefreshRes, error)
+	Logout(ctx context.Context, in *LogoutReq, opts ...grpc.CallOption) (*LogoutRes, error)
+	ListSessions(ctx context.Context, in *ListSessionsReq, opts ...grpc.CallOption) (*ListSessionsRes, error)
+	RevokeSession(ctx context.Context, in *RevokeSessionReq, opts ...grpc.CallOption) (*RevokeSessionRes, error)
+	ChangePassword(ctx context.Context, in *ChangePasswordReq, opts ...grpc.CallOption) (*ChangePasswordRes, error)
+	RequestPasswordReset(ctx context.Context, in *RequestPasswordResetReq, opts ...grpc.CallOption) (*RequestPasswordResetRes, error)
+	ConfirmPasswordReset(ctx context.Context, in *ConfirmPasswordResetReq, opts ...grpc.CallOption) (*ConfirmPasswordResetRes, error)
+	VerifySecondFactor(ctx context.Context, in *VerifySecondFactorReq, opts ...grpc.CallOption) (*VerifySecondFactorRes, error)
+	EnrollTwoFactor(ctx context.Context, in *EnrollTwoFactorReq, opts ...grpc.CallOption) (*EnrollTwoFactorRes, error)
+	ConfirmTwoFactor(ctx context.Context, in *ConfirmTwoFactorReq, opts ...grpc.CallOption) (*ConfirmTwoFactorRes, error)
+	DisableTwoFactor(ctx context.Context, in *DisableTwoFactorReq, opts ...grpc.CallOption) (*DisableTwoFactorRes, error)
+	DeleteAccount(ctx context.Context, in *DeleteAccountReq, opts ...grpc.CallOption) (*DeleteAccountRes, error)
+	ClaimAccountDeletions(ctx context.Context, in *ClaimAccountDeletionsReq, opts ...grpc.CallOption) (*ClaimAccountDeletionsRes, error)
+	PurgeAccount(ctx context.Context, in *PurgeAccountReq, opts ...grpc.CallOption) (*PurgeAccountRes, error)
+	VerifyEmail(ctx context.Context, in *VerifyEmailReq, opts ...grpc.CallOption) (*VerifyEmailRes, error)
+	ResendVerification(ctx context.Context, in *ResendVerificationReq, opts ...grpc.CallOption) (*ResendVerificationRes, error)
+	ExternalLogin(ctx context.Context, in *ExternalLoginReq, opts ...grpc.CallOption) (*ExternalLoginRes, error)
+	LinkIdentity(ctx context.Context, in *LinkIdentityReq, opts ...grpc.CallOption) (*LinkIdentityRes, error)
+	UnlinkIdentity(ctx context.Context, in *UnlinkIdentityReq, opts ...grpc.CallOption) (*UnlinkIdentityRes, error)
+	ListIdentities(ctx context.Context, in *ListIdentitiesReq, opts ...grpc.CallOption) (*ListIdentitiesRes, error)
+	CreateAPIToken(ctx context.Context, in *CreateAPITokenReq, opts ...grpc.CallOption) (*CreateAPITokenRes, error)
+	ListAPITokens(ctx context.Context, in *ListAPITokensReq, opts ...grpc.CallOption) (*ListAPITokensRes, error)
+	RevokeAPIToken(ctx context.Context, in *RevokeAPITokenReq, opts ...grpc.CallOption) (*RevokeAPITokenRes, error)
+	CheckAPIToken(ctx context.Context, in *CheckAPITokenReq, opts ...grpc.CallOption) (*CheckAPITokenRes, error)
 }
 
 type userAuthClient struct {
@@ -62,6 +86,222 @@ func (c *userAuthClient) Check(ctx context.Context, in *CheckReq, opts ...grpc.C
 	return out, nil
 }
 
+func (c *userAuthClient) Refresh(ctx context.Context, in *RefreshReq, opts ...grpc.CallOption) (*RefreshRes, error) {
+	out := new(RefreshRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/Refresh", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) Logout(ctx context.Context, in *LogoutReq, opts ...grpc.CallOption) (*LogoutRes, error) {
+	out := new(LogoutRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/Logout", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) ListSessions(ctx context.Context, in *ListSessionsReq, opts ...grpc.CallOption) (*ListSessionsRes, error) {
+	out := new(ListSessionsRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/ListSessions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) RevokeSession(ctx context.Context, in *RevokeSessionReq, opts ...grpc.CallOption) (*RevokeSessionRes, error) {
+	out := new(RevokeSessionRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/RevokeSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) ChangePassword(ctx context.Context, in *ChangePasswordReq, opts ...grpc.CallOption) (*ChangePasswordRes, error) {
+	out := new(ChangePasswordRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/ChangePassword", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) RequestPasswordReset(ctx context.Context, in *RequestPasswordResetReq, opts ...grpc.CallOption) (*RequestPasswordResetRes, error) {
+	out := new(RequestPasswordResetRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/RequestPasswordReset", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) ConfirmPasswordReset(ctx context.Context, in *ConfirmPasswordResetReq, opts ...grpc.CallOption) (*ConfirmPasswordResetRes, error) {
+	out := new(ConfirmPasswordResetRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/ConfirmPasswordReset", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) VerifySecondFactor(ctx context.Context, in *VerifySecondFactorReq, opts ...grpc.CallOption) (*VerifySecondFactorRes, error) {
+	out := new(VerifySecondFactorRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/VerifySecondFactor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) EnrollTwoFactor(ctx context.Context, in *EnrollTwoFactorReq, opts ...grpc.CallOption) (*EnrollTwoFactorRes, error) {
+	out := new(EnrollTwoFactorRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/EnrollTwoFactor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) ConfirmTwoFactor(ctx context.Context, in *ConfirmTwoFactorReq, opts ...grpc.CallOption) (*ConfirmTwoFactorRes, error) {
+	out := new(ConfirmTwoFactorRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/ConfirmTwoFactor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) DisableTwoFactor(ctx context.Context, in *DisableTwoFactorReq, opts ...grpc.CallOption) (*DisableTwoFactorRes, error) {
+	out := new(DisableTwoFactorRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/DisableTwoFactor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) DeleteAccount(ctx context.Context, in *DeleteAccountReq, opts ...grpc.CallOption) (*DeleteAccountRes, error) {
+	out := new(DeleteAccountRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/DeleteAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) ClaimAccountDeletions(ctx context.Context, in *ClaimAccountDeletionsReq, opts ...grpc.CallOption) (*ClaimAccountDeletionsRes, error) {
+	out := new(ClaimAccountDeletionsRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/ClaimAccountDeletions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) PurgeAccount(ctx context.Context, in *PurgeAccountReq, opts ...grpc.CallOption) (*PurgeAccountRes, error) {
+	out := new(PurgeAccountRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/PurgeAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) VerifyEmail(ctx context.Context, in *VerifyEmailReq, opts ...grpc.CallOption) (*VerifyEmailRes, error) {
+	out := new(VerifyEmailRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/VerifyEmail", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) ResendVerification(ctx context.Context, in *ResendVerificationReq, opts ...grpc.CallOption) (*ResendVerificationRes, error) {
+	out := new(ResendVerificationRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/ResendVerification", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) ExternalLogin(ctx context.Context, in *ExternalLoginReq, opts ...grpc.CallOption) (*ExternalLoginRes, error) {
+	out := new(ExternalLoginRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/ExternalLogin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) LinkIdentity(ctx context.Context, in *LinkIdentityReq, opts ...grpc.CallOption) (*LinkIdentityRes, error) {
+	out := new(LinkIdentityRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/LinkIdentity", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) UnlinkIdentity(ctx context.Context, in *UnlinkIdentityReq, opts ...grpc.CallOption) (*UnlinkIdentityRes, error) {
+	out := new(UnlinkIdentityRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/UnlinkIdentity", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) ListIdentities(ctx context.Context, in *ListIdentitiesReq, opts ...grpc.CallOption) (*ListIdentitiesRes, error) {
+	out := new(ListIdentitiesRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/ListIdentities", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) CreateAPIToken(ctx context.Context, in *CreateAPITokenReq, opts ...grpc.CallOption) (*CreateAPITokenRes, error) {
+	out := new(CreateAPITokenRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/CreateAPIToken", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) ListAPITokens(ctx context.Context, in *ListAPITokensReq, opts ...grpc.CallOption) (*ListAPITokensRes, error) {
+	out := new(ListAPITokensRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/ListAPITokens", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) RevokeAPIToken(ctx context.Context, in *RevokeAPITokenReq, opts ...grpc.CallOption) (*RevokeAPITokenRes, error) {
+	out := new(RevokeAPITokenRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/RevokeAPIToken", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthClient) CheckAPIToken(ctx context.Context, in *CheckAPITokenReq, opts ...grpc.CallOption) (*CheckAPITokenRes, error) {
+	out := new(CheckAPITokenRes)
+	err := c.cc.Invoke(ctx, "/handler.UserAuth/CheckAPIToken", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UserAuthServer is the server API for UserAuth service.
 // All implementations must embed UnimplementedUserAuthServer
 // for forward compatibility
@@ -69,6 +309,30 @@ type UserAuthServer interface {
 	Login(context.Context, *LoginReq) (*LoginRes, error)
 	SignUp(context.Context, *SignUpReq) (*SignUpRes, error)
 	Check(context.Context, *CheckReq) (*CheckRes, error)
+	Refresh(context.Context, *RefreshReq) (*RefreshRes, error)
+	Logout(context.Context, *LogoutReq) (*LogoutRes, error)
+	ListSessions(context.Context, *ListSessionsReq) (*ListSessionsRes, error)
+	RevokeSession(context.Context, *RevokeSessionReq) (*RevokeSessionRes, error)
+	ChangePassword(context.Context, *ChangePasswordReq) (*ChangePasswordRes, error)
+	RequestPasswordReset(context.Context, *RequestPasswordResetReq) (*RequestPasswordResetRes, error)
+	ConfirmPasswordReset(context.Context, *ConfirmPasswordResetReq) (*ConfirmPasswordResetRes, error)
+	VerifySecondFactor(context.Context, *VerifySecondFactorReq) (*VerifySecondFactorRes, error)
+	EnrollTwoFactor(context.Context, *EnrollTwoFactorReq) (*EnrollTwoFactorRes, error)
+	ConfirmTwoFactor(context.Context, *ConfirmTwoFactorReq) (*ConfirmTwoFactorRes, error)
+	DisableTwoFactor(context.Context, *DisableTwoFactorReq) (*DisableTwoFactorRes, error)
+	DeleteAccount(context.Context, *DeleteAccountReq) (*DeleteAccountRes, error)
+	ClaimAccountDeletions(context.Context, *ClaimAccountDeletionsReq) (*ClaimAccountDeletionsRes, error)
+	PurgeAccount(context.Context, *PurgeAccountReq) (*PurgeAccountRes, error)
+	VerifyEmail(context.Context, *VerifyEmailReq) (*VerifyEmailRes, error)
+	ResendVerification(context.Context, *ResendVerificationReq) (*ResendVerificationRes, error)
+	ExternalLogin(context.Context, *ExternalLoginReq) (*ExternalLoginRes, error)
+	LinkIdentity(context.Context, *LinkIdentityReq) (*LinkIdentityRes, error)
+	UnlinkIdentity(context.Context, *UnlinkIdentityReq) (*UnlinkIdentityRes, error)
+	ListIdentities(context.Context, *ListIdentitiesReq) (*ListIdentitiesRes, error)
+	CreateAPIToken(context.Context, *CreateAPITokenReq) (*CreateAPITokenRes, error)
+	ListAPITokens(context.Context, *ListAPITokensReq) (*ListAPITokensRes, error)
+	RevokeAPIToken(context.Context, *RevokeAPITokenReq) (*RevokeAPITokenRes, error)
+	CheckAPIToken(context.Context, *CheckAPITokenReq) (*CheckAPITokenRes, error)
 	mustEmbedUnimplementedUserAuthServer()
 }
 
@@ -85,6 +349,78 @@ func (UnimplementedUserAuthServer) SignUp(context.Context, *SignUpReq) (*SignUpR
 func (UnimplementedUserAuthServer) Check(context.Context, *CheckReq) (*CheckRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Check not implemented")
 }
+func (UnimplementedUserAuthServer) Refresh(context.Context, *RefreshReq) (*RefreshRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Refresh not implemented")
+}
+func (UnimplementedUserAuthServer) Logout(context.Context, *LogoutReq) (*LogoutRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Logout not implemented")
+}
+func (UnimplementedUserAuthServer) ListSessions(context.Context, *ListSessionsReq) (*ListSessionsRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedUserAuthServer) RevokeSession(context.Context, *RevokeSessionReq) (*RevokeSessionRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeSession not implemented")
+}
+func (UnimplementedUserAuthServer) ChangePassword(context.Context, *ChangePasswordReq) (*ChangePasswordRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePassword not implemented")
+}
+func (UnimplementedUserAuthServer) RequestPasswordReset(context.Context, *RequestPasswordResetReq) (*RequestPasswordResetRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestPasswordReset not implemented")
+}
+func (UnimplementedUserAuthServer) ConfirmPasswordReset(context.Context, *ConfirmPasswordResetReq) (*ConfirmPasswordResetRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmPasswordReset not implemented")
+}
+func (UnimplementedUserAuthServer) VerifySecondFactor(context.Context, *VerifySecondFactorReq) (*VerifySecondFactorRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifySecondFactor not implemented")
+}
+func (UnimplementedUserAuthServer) EnrollTwoFactor(context.Context, *EnrollTwoFactorReq) (*EnrollTwoFactorRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnrollTwoFactor not implemented")
+}
+func (UnimplementedUserAuthServer) ConfirmTwoFactor(context.Context, *ConfirmTwoFactorReq) (*ConfirmTwoFactorRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmTwoFactor not implemented")
+}
+func (UnimplementedUserAuthServer) DisableTwoFactor(context.Context, *DisableTwoFactorReq) (*DisableTwoFactorRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableTwoFactor not implemented")
+}
+func (UnimplementedUserAuthServer) DeleteAccount(context.Context, *DeleteAccountReq) (*DeleteAccountRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAccount not implemented")
+}
+func (UnimplementedUserAuthServer) ClaimAccountDeletions(context.Context, *ClaimAccountDeletionsReq) (*ClaimAccountDeletionsRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClaimAccountDeletions not implemented")
+}
+func (UnimplementedUserAuthServer) PurgeAccount(context.Context, *PurgeAccountReq) (*PurgeAccountRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgeAccount not implemented")
+}
+func (UnimplementedUserAuthServer) VerifyEmail(context.Context, *VerifyEmailReq) (*VerifyEmailRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyEmail not implemented")
+}
+func (UnimplementedUserAuthServer) ResendVerification(context.Context, *ResendVerificationReq) (*ResendVerificationRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResendVerification not implemented")
+}
+func (UnimplementedUserAuthServer) ExternalLogin(context.Context, *ExternalLoginReq) (*ExternalLoginRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExternalLogin not implemented")
+}
+func (UnimplementedUserAuthServer) LinkIdentity(context.Context, *LinkIdentityReq) (*LinkIdentityRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LinkIdentity not implemented")
+}
+func (UnimplementedUserAuthServer) UnlinkIdentity(context.Context, *UnlinkIdentityReq) (*UnlinkIdentityRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnlinkIdentity not implemented")
+}
+func (UnimplementedUserAuthServer) ListIdentities(context.Context, *ListIdentitiesReq) (*ListIdentitiesRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListIdentities not implemented")
+}
+func (UnimplementedUserAuthServer) CreateAPIToken(context.Context, *CreateAPITokenReq) (*CreateAPITokenRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAPIToken not implemented")
+}
+func (UnimplementedUserAuthServer) ListAPITokens(context.Context, *ListAPITokensReq) (*ListAPITokensRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAPITokens not implemented")
+}
+func (UnimplementedUserAuthServer) RevokeAPIToken(context.Context, *RevokeAPITokenReq) (*RevokeAPITokenRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeAPIToken not implemented")
+}
+func (UnimplementedUserAuthServer) CheckAPIToken(context.Context, *CheckAPITokenReq) (*CheckAPITokenRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckAPIToken not implemented")
+}
 func (UnimplementedUserAuthServer) mustEmbedUnimplementedUserAuthServer() {}
 
 // UnsafeUserAuthServer may be embedded to opt out of forward compatibility for this service.
@@ -152,24 +488,552 @@ func _UserAuth_Check_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
-// UserAuth_ServiceDesc is the grpc.ServiceDesc for UserAuth service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var UserAuth_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "handler.UserAuth",
-	HandlerType: (*UserAuthServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Login",
-			Handler:    _UserAuth_Login_Handler,
-		},
-		{
-			MethodName: "SignUp",
-			Handler:    _UserAuth_SignUp_Handler,
-		},
-		{
-			MethodName: "Check",
-			Handler:    _UserAuth_Check_Handler,
+func _UserAuth_Refresh_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).Refresh(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/Refresh",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).Refresh(ctx, req.(*RefreshReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogoutReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).Logout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/Logout",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).Logout(ctx, req.(*LogoutReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/ListSessions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).ListSessions(ctx, req.(*ListSessionsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_RevokeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeSessionReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).RevokeSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/RevokeSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).RevokeSession(ctx, req.(*RevokeSessionReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangePasswordReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).ChangePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/ChangePassword",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).ChangePassword(ctx, req.(*ChangePasswordReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_RequestPasswordReset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestPasswordResetReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).RequestPasswordReset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/RequestPasswordReset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).RequestPasswordReset(ctx, req.(*RequestPasswordResetReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_ConfirmPasswordReset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmPasswordResetReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).ConfirmPasswordReset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/ConfirmPasswordReset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).ConfirmPasswordReset(ctx, req.(*ConfirmPasswordResetReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_VerifySecondFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifySecondFactorReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).VerifySecondFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/VerifySecondFactor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).VerifySecondFactor(ctx, req.(*VerifySecondFactorReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_EnrollTwoFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnrollTwoFactorReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).EnrollTwoFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/EnrollTwoFactor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).EnrollTwoFactor(ctx, req.(*EnrollTwoFactorReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_ConfirmTwoFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmTwoFactorReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).ConfirmTwoFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/ConfirmTwoFactor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).ConfirmTwoFactor(ctx, req.(*ConfirmTwoFactorReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_DisableTwoFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DisableTwoFactorReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).DisableTwoFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/DisableTwoFactor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).DisableTwoFactor(ctx, req.(*DisableTwoFactorReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_DeleteAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAccountReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).DeleteAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/DeleteAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).DeleteAccount(ctx, req.(*DeleteAccountReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_ClaimAccountDeletions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClaimAccountDeletionsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).ClaimAccountDeletions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/ClaimAccountDeletions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).ClaimAccountDeletions(ctx, req.(*ClaimAccountDeletionsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_PurgeAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeAccountReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).PurgeAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/PurgeAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).PurgeAccount(ctx, req.(*PurgeAccountReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_VerifyEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyEmailReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).VerifyEmail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/VerifyEmail",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).VerifyEmail(ctx, req.(*VerifyEmailReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_ResendVerification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResendVerificationReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).ResendVerification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/ResendVerification",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).ResendVerification(ctx, req.(*ResendVerificationReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_ExternalLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExternalLoginReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).ExternalLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/ExternalLogin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).ExternalLogin(ctx, req.(*ExternalLoginReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_LinkIdentity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LinkIdentityReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).LinkIdentity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/LinkIdentity",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).LinkIdentity(ctx, req.(*LinkIdentityReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_UnlinkIdentity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnlinkIdentityReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).UnlinkIdentity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/UnlinkIdentity",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).UnlinkIdentity(ctx, req.(*UnlinkIdentityReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_ListIdentities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListIdentitiesReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).ListIdentities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/ListIdentities",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).ListIdentities(ctx, req.(*ListIdentitiesReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_CreateAPIToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAPITokenReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).CreateAPIToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/CreateAPIToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).CreateAPIToken(ctx, req.(*CreateAPITokenReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_ListAPITokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAPITokensReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).ListAPITokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/ListAPITokens",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).ListAPITokens(ctx, req.(*ListAPITokensReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_RevokeAPIToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeAPITokenReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).RevokeAPIToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/RevokeAPIToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).RevokeAPIToken(ctx, req.(*RevokeAPITokenReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuth_CheckAPIToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckAPITokenReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServer).CheckAPIToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/handler.UserAuth/CheckAPIToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServer).CheckAPIToken(ctx, req.(*CheckAPITokenReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UserAuth_ServiceDesc is the grpc.ServiceDesc for UserAuth service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UserAuth_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "handler.UserAuth",
+	HandlerType: (*UserAuthServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Login",
+			Handler:    _UserAuth_Login_Handler,
+		},
+		{
+			MethodName: "SignUp",
+			Handler:    _UserAuth_SignUp_Handler,
+		},
+		{
+			MethodName: "Check",
+			Handler:    _UserAuth_Check_Handler,
+		},
+		{
+			MethodName: "Refresh",
+			Handler:    _UserAuth_Refresh_Handler,
+		},
+		{
+			MethodName: "Logout",
+			Handler:    _UserAuth_Logout_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _UserAuth_ListSessions_Handler,
+		},
+		{
+			MethodName: "RevokeSession",
+			Handler:    _UserAuth_RevokeSession_Handler,
+		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _UserAuth_ChangePassword_Handler,
+		},
+		{
+			MethodName: "RequestPasswordReset",
+			Handler:    _UserAuth_RequestPasswordReset_Handler,
+		},
+		{
+			MethodName: "ConfirmPasswordReset",
+			Handler:    _UserAuth_ConfirmPasswordReset_Handler,
+		},
+		{
+			MethodName: "VerifySecondFactor",
+			Handler:    _UserAuth_VerifySecondFactor_Handler,
+		},
+		{
+			MethodName: "EnrollTwoFactor",
+			Handler:    _UserAuth_EnrollTwoFactor_Handler,
+		},
+		{
+			MethodName: "ConfirmTwoFactor",
+			Handler:    _UserAuth_ConfirmTwoFactor_Handler,
+		},
+		{
+			MethodName: "DisableTwoFactor",
+			Handler:    _UserAuth_DisableTwoFactor_Handler,
+		},
+		{
+			MethodName: "DeleteAccount",
+			Handler:    _UserAuth_DeleteAccount_Handler,
+		},
+		{
+			MethodName: "ClaimAccountDeletions",
+			Handler:    _UserAuth_ClaimAccountDeletions_Handler,
+		},
+		{
+			MethodName: "PurgeAccount",
+			Handler:    _UserAuth_PurgeAccount_Handler,
+		},
+		{
+			MethodName: "VerifyEmail",
+			Handler:    _UserAuth_VerifyEmail_Handler,
+		},
+		{
+			MethodName: "ResendVerification",
+			Handler:    _UserAuth_ResendVerification_Handler,
+		},
+		{
+			MethodName: "ExternalLogin",
+			Handler:    _UserAuth_ExternalLogin_Handler,
+		},
+		{
+			MethodName: "LinkIdentity",
+			Handler:    _UserAuth_LinkIdentity_Handler,
+		},
+		{
+			MethodName: "UnlinkIdentity",
+			Handler:    _UserAuth_UnlinkIdentity_Handler,
+		},
+		{
+			MethodName: "ListIdentities",
+			Handler:    _UserAuth_ListIdentities_Handler,
+		},
+		{
+			MethodName: "CreateAPIToken",
+			Handler:    _UserAuth_CreateAPIToken_Handler,
+		},
+		{
+			MethodName: "ListAPITokens",
+			Handler:    _UserAuth_ListAPITokens_Handler,
+		},
+		{
+			MethodName: "RevokeAPIToken",
+			Handler:    _UserAuth_RevokeAPIToken_Handler,
+		},
+		{
+			MethodName: "CheckAPIToken",
+			Handler:    _UserAuth_CheckAPIToken_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
@@ -0,0 +1,36 @@
+package auth_mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileMailer appends emails to the file instead of sending them, for local development and tests.
+type fileMailer struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileMailer(path string) *fileMailer {
+	return &fileMailer{path: path}
+}
+
+func (m *fileMailer) Send(ctx context.Context, msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open mail file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC1123Z), msg.To, msg.Subject, msg.Body)
+	if err != nil {
+		return fmt.Errorf("write mail file: %w", err)
+	}
+	return nil
+}
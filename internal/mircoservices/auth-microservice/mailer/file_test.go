@@ -0,0 +1,25 @@
+package auth_mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileMailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	mailer := NewFileMailer(path)
+
+	err := mailer.Send(context.Background(), &Message{To: "mail@example.com", Subject: "First", Body: "token-1"})
+	assert.Nil(t, err)
+	err = mailer.Send(context.Background(), &Message{To: "mail@example.com", Subject: "Second", Body: "token-2"})
+	assert.Nil(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "To: mail@example.com\nSubject: First\n\ntoken-1")
+	assert.Contains(t, string(data), "To: mail@example.com\nSubject: Second\n\ntoken-2")
+}
@@ -0,0 +1,21 @@
+package auth_mailer
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logMailer writes emails to the log instead of sending them, for local development.
+type logMailer struct {
+	log *logrus.Entry
+}
+
+func NewLogMailer(log *logrus.Entry) *logMailer {
+	return &logMailer{log: log}
+}
+
+func (m *logMailer) Send(ctx context.Context, msg *Message) error {
+	m.log.WithField("to", msg.To).Infof("mail %q: %s", msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,34 @@
+package auth_mailer
+
+import (
+	"context"
+	"fmt"
+
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Message describes an email sent to the user.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails to users.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewMailer creates the mailer chosen in the config.
+func NewMailer(log *logrus.Entry) (Mailer, error) {
+	switch mailerType := viper.GetString(auth_constants.ViperMailerTypeKey); mailerType {
+	case auth_constants.MailerTypeLog, "":
+		return NewLogMailer(log), nil
+	case auth_constants.MailerTypeFile:
+		return NewFileMailer(viper.GetString(auth_constants.ViperMailerPathKey)), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer type: %s", mailerType)
+	}
+}
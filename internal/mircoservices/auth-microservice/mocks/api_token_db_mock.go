@@ -0,0 +1,122 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/mircoservices/auth-microservice/db/api_token.go
+
+// Package mock_auth_db is a generated GoMock package.
+package mock_auth_db
+
+import (
+	context "context"
+	reflect "reflect"
+
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAPITokenRepository is a mock of APITokenRepository interface.
+type MockAPITokenRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPITokenRepositoryMockRecorder
+}
+
+// MockAPITokenRepositoryMockRecorder is the mock recorder for MockAPITokenRepository.
+type MockAPITokenRepositoryMockRecorder struct {
+	mock *MockAPITokenRepository
+}
+
+// NewMockAPITokenRepository creates a new mock instance.
+func NewMockAPITokenRepository(ctrl *gomock.Controller) *MockAPITokenRepository {
+	mock := &MockAPITokenRepository{ctrl: ctrl}
+	mock.recorder = &MockAPITokenRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPITokenRepository) EXPECT() *MockAPITokenRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateAPIToken mocks base method.
+func (m *MockAPITokenRepository) CreateAPIToken(ctx context.Context, token *auth_core.APIToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIToken", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAPIToken indicates an expected call of CreateAPIToken.
+func (mr *MockAPITokenRepositoryMockRecorder) CreateAPIToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIToken", reflect.TypeOf((*MockAPITokenRepository)(nil).CreateAPIToken), ctx, token)
+}
+
+// DeleteAPIToken mocks base method.
+func (m *MockAPITokenRepository) DeleteAPIToken(ctx context.Context, userID, ID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPIToken", ctx, userID, ID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAPIToken indicates an expected call of DeleteAPIToken.
+func (mr *MockAPITokenRepositoryMockRecorder) DeleteAPIToken(ctx, userID, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIToken", reflect.TypeOf((*MockAPITokenRepository)(nil).DeleteAPIToken), ctx, userID, ID)
+}
+
+// DeleteUserAPITokens mocks base method.
+func (m *MockAPITokenRepository) DeleteUserAPITokens(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserAPITokens", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserAPITokens indicates an expected call of DeleteUserAPITokens.
+func (mr *MockAPITokenRepositoryMockRecorder) DeleteUserAPITokens(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserAPITokens", reflect.TypeOf((*MockAPITokenRepository)(nil).DeleteUserAPITokens), ctx, userID)
+}
+
+// GetAPITokenByHash mocks base method.
+func (m *MockAPITokenRepository) GetAPITokenByHash(ctx context.Context, hash string) (*auth_core.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPITokenByHash", ctx, hash)
+	ret0, _ := ret[0].(*auth_core.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPITokenByHash indicates an expected call of GetAPITokenByHash.
+func (mr *MockAPITokenRepositoryMockRecorder) GetAPITokenByHash(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPITokenByHash", reflect.TypeOf((*MockAPITokenRepository)(nil).GetAPITokenByHash), ctx, hash)
+}
+
+// GetUserAPITokens mocks base method.
+func (m *MockAPITokenRepository) GetUserAPITokens(ctx context.Context, userID string) ([]auth_core.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserAPITokens", ctx, userID)
+	ret0, _ := ret[0].([]auth_core.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserAPITokens indicates an expected call of GetUserAPITokens.
+func (mr *MockAPITokenRepositoryMockRecorder) GetUserAPITokens(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAPITokens", reflect.TypeOf((*MockAPITokenRepository)(nil).GetUserAPITokens), ctx, userID)
+}
+
+// TouchAPIToken mocks base method.
+func (m *MockAPITokenRepository) TouchAPIToken(ctx context.Context, ID string, lastUsedAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchAPIToken", ctx, ID, lastUsedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchAPIToken indicates an expected call of TouchAPIToken.
+func (mr *MockAPITokenRepositoryMockRecorder) TouchAPIToken(ctx, ID, lastUsedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIToken", reflect.TypeOf((*MockAPITokenRepository)(nil).TouchAPIToken), ctx, ID, lastUsedAt)
+}
@@ -35,6 +35,20 @@ func (m *MockAuthRepository) EXPECT() *MockAuthRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CancelDeletion mocks base method.
+func (m *MockAuthRepository) CancelDeletion(ctx context.Context, ID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDeletion", ctx, ID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelDeletion indicates an expected call of CancelDeletion.
+func (mr *MockAuthRepositoryMockRecorder) CancelDeletion(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDeletion", reflect.TypeOf((*MockAuthRepository)(nil).CancelDeletion), ctx, ID)
+}
+
 // CheckUserEmailExistence mocks base method.
 func (m *MockAuthRepository) CheckUserEmailExistence(ctx context.Context, email string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +64,21 @@ func (mr *MockAuthRepositoryMockRecorder) CheckUserEmailExistence(ctx, email int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckUserEmailExistence", reflect.TypeOf((*MockAuthRepository)(nil).CheckUserEmailExistence), ctx, email)
 }
 
+// ClaimDueDeletions mocks base method.
+func (m *MockAuthRepository) ClaimDueDeletions(ctx context.Context, now, limit int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDueDeletions", ctx, now, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDueDeletions indicates an expected call of ClaimDueDeletions.
+func (mr *MockAuthRepositoryMockRecorder) ClaimDueDeletions(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueDeletions", reflect.TypeOf((*MockAuthRepository)(nil).ClaimDueDeletions), ctx, now, limit)
+}
+
 // CreateUser mocks base method.
 func (m *MockAuthRepository) CreateUser(ctx context.Context, user *auth_core.User) (string, error) {
 	m.ctrl.T.Helper()
@@ -65,6 +94,20 @@ func (mr *MockAuthRepositoryMockRecorder) CreateUser(ctx, user interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthRepository)(nil).CreateUser), ctx, user)
 }
 
+// DeleteUser mocks base method.
+func (m *MockAuthRepository) DeleteUser(ctx context.Context, ID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, ID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockAuthRepositoryMockRecorder) DeleteUser(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockAuthRepository)(nil).DeleteUser), ctx, ID)
+}
+
 // GetUserByEmail mocks base method.
 func (m *MockAuthRepository) GetUserByEmail(ctx context.Context, email string) (*auth_core.User, error) {
 	m.ctrl.T.Helper()
@@ -79,3 +122,60 @@ func (mr *MockAuthRepositoryMockRecorder) GetUserByEmail(ctx, email interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockAuthRepository)(nil).GetUserByEmail), ctx, email)
 }
+
+// GetUserByID mocks base method.
+func (m *MockAuthRepository) GetUserByID(ctx context.Context, ID string) (*auth_core.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, ID)
+	ret0, _ := ret[0].(*auth_core.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockAuthRepositoryMockRecorder) GetUserByID(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockAuthRepository)(nil).GetUserByID), ctx, ID)
+}
+
+// MarkEmailVerified mocks base method.
+func (m *MockAuthRepository) MarkEmailVerified(ctx context.Context, ID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailVerified", ctx, ID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEmailVerified indicates an expected call of MarkEmailVerified.
+func (mr *MockAuthRepositoryMockRecorder) MarkEmailVerified(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailVerified", reflect.TypeOf((*MockAuthRepository)(nil).MarkEmailVerified), ctx, ID)
+}
+
+// ScheduleDeletion mocks base method.
+func (m *MockAuthRepository) ScheduleDeletion(ctx context.Context, ID string, deletion auth_core.AccountDeletion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleDeletion", ctx, ID, deletion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScheduleDeletion indicates an expected call of ScheduleDeletion.
+func (mr *MockAuthRepositoryMockRecorder) ScheduleDeletion(ctx, ID, deletion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleDeletion", reflect.TypeOf((*MockAuthRepository)(nil).ScheduleDeletion), ctx, ID, deletion)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockAuthRepository) UpdatePassword(ctx context.Context, ID string, password auth_core.UserPassword) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, ID, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockAuthRepositoryMockRecorder) UpdatePassword(ctx, ID, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockAuthRepository)(nil).UpdatePassword), ctx, ID, password)
+}
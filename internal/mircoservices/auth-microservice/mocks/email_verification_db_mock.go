@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/mircoservices/auth-microservice/db/email_verification.go
+
+// Package mock_auth_db is a generated GoMock package.
+package mock_auth_db
+
+import (
+	context "context"
+	reflect "reflect"
+
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockEmailVerificationRepository is a mock of EmailVerificationRepository interface.
+type MockEmailVerificationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailVerificationRepositoryMockRecorder
+}
+
+// MockEmailVerificationRepositoryMockRecorder is the mock recorder for MockEmailVerificationRepository.
+type MockEmailVerificationRepositoryMockRecorder struct {
+	mock *MockEmailVerificationRepository
+}
+
+// NewMockEmailVerificationRepository creates a new mock instance.
+func NewMockEmailVerificationRepository(ctrl *gomock.Controller) *MockEmailVerificationRepository {
+	mock := &MockEmailVerificationRepository{ctrl: ctrl}
+	mock.recorder = &MockEmailVerificationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailVerificationRepository) EXPECT() *MockEmailVerificationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ConsumeEmailVerification mocks base method.
+func (m *MockEmailVerificationRepository) ConsumeEmailVerification(ctx context.Context, hash string) (*auth_core.EmailVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeEmailVerification", ctx, hash)
+	ret0, _ := ret[0].(*auth_core.EmailVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeEmailVerification indicates an expected call of ConsumeEmailVerification.
+func (mr *MockEmailVerificationRepositoryMockRecorder) ConsumeEmailVerification(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeEmailVerification", reflect.TypeOf((*MockEmailVerificationRepository)(nil).ConsumeEmailVerification), ctx, hash)
+}
+
+// CreateEmailVerification mocks base method.
+func (m *MockEmailVerificationRepository) CreateEmailVerification(ctx context.Context, verification *auth_core.EmailVerification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmailVerification", ctx, verification)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEmailVerification indicates an expected call of CreateEmailVerification.
+func (mr *MockEmailVerificationRepositoryMockRecorder) CreateEmailVerification(ctx, verification interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmailVerification", reflect.TypeOf((*MockEmailVerificationRepository)(nil).CreateEmailVerification), ctx, verification)
+}
+
+// DeleteUserEmailVerifications mocks base method.
+func (m *MockEmailVerificationRepository) DeleteUserEmailVerifications(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserEmailVerifications", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserEmailVerifications indicates an expected call of DeleteUserEmailVerifications.
+func (mr *MockEmailVerificationRepositoryMockRecorder) DeleteUserEmailVerifications(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserEmailVerifications", reflect.TypeOf((*MockEmailVerificationRepository)(nil).DeleteUserEmailVerifications), ctx, userID)
+}
+
+// GetLastEmailVerification mocks base method.
+func (m *MockEmailVerificationRepository) GetLastEmailVerification(ctx context.Context, userID string) (*auth_core.EmailVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastEmailVerification", ctx, userID)
+	ret0, _ := ret[0].(*auth_core.EmailVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastEmailVerification indicates an expected call of GetLastEmailVerification.
+func (mr *MockEmailVerificationRepositoryMockRecorder) GetLastEmailVerification(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastEmailVerification", reflect.TypeOf((*MockEmailVerificationRepository)(nil).GetLastEmailVerification), ctx, userID)
+}
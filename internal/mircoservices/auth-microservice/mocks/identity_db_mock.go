@@ -0,0 +1,108 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/mircoservices/auth-microservice/db/identity.go
+
+// Package mock_auth_db is a generated GoMock package.
+package mock_auth_db
+
+import (
+	context "context"
+	reflect "reflect"
+
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIdentityRepository is a mock of IdentityRepository interface.
+type MockIdentityRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityRepositoryMockRecorder
+}
+
+// MockIdentityRepositoryMockRecorder is the mock recorder for MockIdentityRepository.
+type MockIdentityRepositoryMockRecorder struct {
+	mock *MockIdentityRepository
+}
+
+// NewMockIdentityRepository creates a new mock instance.
+func NewMockIdentityRepository(ctrl *gomock.Controller) *MockIdentityRepository {
+	mock := &MockIdentityRepository{ctrl: ctrl}
+	mock.recorder = &MockIdentityRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentityRepository) EXPECT() *MockIdentityRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateIdentity mocks base method.
+func (m *MockIdentityRepository) CreateIdentity(ctx context.Context, identity *auth_core.ExternalIdentity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdentity", ctx, identity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateIdentity indicates an expected call of CreateIdentity.
+func (mr *MockIdentityRepositoryMockRecorder) CreateIdentity(ctx, identity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdentity", reflect.TypeOf((*MockIdentityRepository)(nil).CreateIdentity), ctx, identity)
+}
+
+// DeleteIdentity mocks base method.
+func (m *MockIdentityRepository) DeleteIdentity(ctx context.Context, userID, provider string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdentity", ctx, userID, provider)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdentity indicates an expected call of DeleteIdentity.
+func (mr *MockIdentityRepositoryMockRecorder) DeleteIdentity(ctx, userID, provider interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdentity", reflect.TypeOf((*MockIdentityRepository)(nil).DeleteIdentity), ctx, userID, provider)
+}
+
+// DeleteUserIdentities mocks base method.
+func (m *MockIdentityRepository) DeleteUserIdentities(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserIdentities", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserIdentities indicates an expected call of DeleteUserIdentities.
+func (mr *MockIdentityRepositoryMockRecorder) DeleteUserIdentities(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserIdentities", reflect.TypeOf((*MockIdentityRepository)(nil).DeleteUserIdentities), ctx, userID)
+}
+
+// GetIdentity mocks base method.
+func (m *MockIdentityRepository) GetIdentity(ctx context.Context, provider, subject string) (*auth_core.ExternalIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdentity", ctx, provider, subject)
+	ret0, _ := ret[0].(*auth_core.ExternalIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdentity indicates an expected call of GetIdentity.
+func (mr *MockIdentityRepositoryMockRecorder) GetIdentity(ctx, provider, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdentity", reflect.TypeOf((*MockIdentityRepository)(nil).GetIdentity), ctx, provider, subject)
+}
+
+// GetUserIdentities mocks base method.
+func (m *MockIdentityRepository) GetUserIdentities(ctx context.Context, userID string) ([]auth_core.ExternalIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserIdentities", ctx, userID)
+	ret0, _ := ret[0].([]auth_core.ExternalIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserIdentities indicates an expected call of GetUserIdentities.
+func (mr *MockIdentityRepositoryMockRecorder) GetUserIdentities(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdentities", reflect.TypeOf((*MockIdentityRepository)(nil).GetUserIdentities), ctx, userID)
+}
@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/mircoservices/auth-microservice/db/login_attempt.go
+
+// Package mock_auth_db is a generated GoMock package.
+package mock_auth_db
+
+import (
+	context "context"
+	reflect "reflect"
+
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockLoginAttemptRepository is a mock of LoginAttemptRepository interface.
+type MockLoginAttemptRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginAttemptRepositoryMockRecorder
+}
+
+// MockLoginAttemptRepositoryMockRecorder is the mock recorder for MockLoginAttemptRepository.
+type MockLoginAttemptRepositoryMockRecorder struct {
+	mock *MockLoginAttemptRepository
+}
+
+// NewMockLoginAttemptRepository creates a new mock instance.
+func NewMockLoginAttemptRepository(ctrl *gomock.Controller) *MockLoginAttemptRepository {
+	mock := &MockLoginAttemptRepository{ctrl: ctrl}
+	mock.recorder = &MockLoginAttemptRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginAttemptRepository) EXPECT() *MockLoginAttemptRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetLoginAttempt mocks base method.
+func (m *MockLoginAttemptRepository) GetLoginAttempt(ctx context.Context, key string) (*auth_core.LoginAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginAttempt", ctx, key)
+	ret0, _ := ret[0].(*auth_core.LoginAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginAttempt indicates an expected call of GetLoginAttempt.
+func (mr *MockLoginAttemptRepositoryMockRecorder) GetLoginAttempt(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginAttempt", reflect.TypeOf((*MockLoginAttemptRepository)(nil).GetLoginAttempt), ctx, key)
+}
+
+// LockLogin mocks base method.
+func (m *MockLoginAttemptRepository) LockLogin(ctx context.Context, key string, lockedUntil int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", ctx, key, lockedUntil)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockLoginAttemptRepositoryMockRecorder) LockLogin(ctx, key, lockedUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockLoginAttemptRepository)(nil).LockLogin), ctx, key, lockedUntil)
+}
+
+// RegisterLoginFailure mocks base method.
+func (m *MockLoginAttemptRepository) RegisterLoginFailure(ctx context.Context, key string, now, windowStart int64) (*auth_core.LoginAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterLoginFailure", ctx, key, now, windowStart)
+	ret0, _ := ret[0].(*auth_core.LoginAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterLoginFailure indicates an expected call of RegisterLoginFailure.
+func (mr *MockLoginAttemptRepositoryMockRecorder) RegisterLoginFailure(ctx, key, now, windowStart interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterLoginFailure", reflect.TypeOf((*MockLoginAttemptRepository)(nil).RegisterLoginFailure), ctx, key, now, windowStart)
+}
+
+// ResetLoginAttempts mocks base method.
+func (m *MockLoginAttemptRepository) ResetLoginAttempts(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginAttempts", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginAttempts indicates an expected call of ResetLoginAttempts.
+func (mr *MockLoginAttemptRepositoryMockRecorder) ResetLoginAttempts(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginAttempts", reflect.TypeOf((*MockLoginAttemptRepository)(nil).ResetLoginAttempts), ctx, key)
+}
@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/mircoservices/auth-microservice/db/password_reset.go
+
+// Package mock_auth_db is a generated GoMock package.
+package mock_auth_db
+
+import (
+	context "context"
+	reflect "reflect"
+
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPasswordResetRepository is a mock of PasswordResetRepository interface.
+type MockPasswordResetRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordResetRepositoryMockRecorder
+}
+
+// MockPasswordResetRepositoryMockRecorder is the mock recorder for MockPasswordResetRepository.
+type MockPasswordResetRepositoryMockRecorder struct {
+	mock *MockPasswordResetRepository
+}
+
+// NewMockPasswordResetRepository creates a new mock instance.
+func NewMockPasswordResetRepository(ctrl *gomock.Controller) *MockPasswordResetRepository {
+	mock := &MockPasswordResetRepository{ctrl: ctrl}
+	mock.recorder = &MockPasswordResetRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordResetRepository) EXPECT() *MockPasswordResetRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ConsumePasswordReset mocks base method.
+func (m *MockPasswordResetRepository) ConsumePasswordReset(ctx context.Context, hash string) (*auth_core.PasswordReset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumePasswordReset", ctx, hash)
+	ret0, _ := ret[0].(*auth_core.PasswordReset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumePasswordReset indicates an expected call of ConsumePasswordReset.
+func (mr *MockPasswordResetRepositoryMockRecorder) ConsumePasswordReset(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumePasswordReset", reflect.TypeOf((*MockPasswordResetRepository)(nil).ConsumePasswordReset), ctx, hash)
+}
+
+// CreatePasswordReset mocks base method.
+func (m *MockPasswordResetRepository) CreatePasswordReset(ctx context.Context, reset *auth_core.PasswordReset) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordReset", ctx, reset)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePasswordReset indicates an expected call of CreatePasswordReset.
+func (mr *MockPasswordResetRepositoryMockRecorder) CreatePasswordReset(ctx, reset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordReset", reflect.TypeOf((*MockPasswordResetRepository)(nil).CreatePasswordReset), ctx, reset)
+}
+
+// DeleteUserPasswordResets mocks base method.
+func (m *MockPasswordResetRepository) DeleteUserPasswordResets(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserPasswordResets", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserPasswordResets indicates an expected call of DeleteUserPasswordResets.
+func (mr *MockPasswordResetRepositoryMockRecorder) DeleteUserPasswordResets(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserPasswordResets", reflect.TypeOf((*MockPasswordResetRepository)(nil).DeleteUserPasswordResets), ctx, userID)
+}
+
+// GetLastPasswordReset mocks base method.
+func (m *MockPasswordResetRepository) GetLastPasswordReset(ctx context.Context, userID string) (*auth_core.PasswordReset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastPasswordReset", ctx, userID)
+	ret0, _ := ret[0].(*auth_core.PasswordReset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastPasswordReset indicates an expected call of GetLastPasswordReset.
+func (mr *MockPasswordResetRepositoryMockRecorder) GetLastPasswordReset(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastPasswordReset", reflect.TypeOf((*MockPasswordResetRepository)(nil).GetLastPasswordReset), ctx, userID)
+}
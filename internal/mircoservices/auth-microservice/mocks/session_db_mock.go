@@ -0,0 +1,195 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/mircoservices/auth-microservice/db/session.go
+
+// Package mock_auth_db is a generated GoMock package.
+package mock_auth_db
+
+import (
+	context "context"
+	reflect "reflect"
+
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSessionRepository is a mock of SessionRepository interface.
+type MockSessionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionRepositoryMockRecorder
+}
+
+// MockSessionRepositoryMockRecorder is the mock recorder for MockSessionRepository.
+type MockSessionRepositoryMockRecorder struct {
+	mock *MockSessionRepository
+}
+
+// NewMockSessionRepository creates a new mock instance.
+func NewMockSessionRepository(ctrl *gomock.Controller) *MockSessionRepository {
+	mock := &MockSessionRepository{ctrl: ctrl}
+	mock.recorder = &MockSessionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionRepository) EXPECT() *MockSessionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateSession mocks base method.
+func (m *MockSessionRepository) CreateSession(ctx context.Context, session *auth_core.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockSessionRepositoryMockRecorder) CreateSession(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockSessionRepository)(nil).CreateSession), ctx, session)
+}
+
+// DeleteUserSessions mocks base method.
+func (m *MockSessionRepository) DeleteUserSessions(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserSessions", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserSessions indicates an expected call of DeleteUserSessions.
+func (mr *MockSessionRepositoryMockRecorder) DeleteUserSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserSessions", reflect.TypeOf((*MockSessionRepository)(nil).DeleteUserSessions), ctx, userID)
+}
+
+// GetSessionByID mocks base method.
+func (m *MockSessionRepository) GetSessionByID(ctx context.Context, ID string) (*auth_core.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionByID", ctx, ID)
+	ret0, _ := ret[0].(*auth_core.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessionByID indicates an expected call of GetSessionByID.
+func (mr *MockSessionRepositoryMockRecorder) GetSessionByID(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionByID", reflect.TypeOf((*MockSessionRepository)(nil).GetSessionByID), ctx, ID)
+}
+
+// GetSessionByRefreshHash mocks base method.
+func (m *MockSessionRepository) GetSessionByRefreshHash(ctx context.Context, hash string) (*auth_core.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionByRefreshHash", ctx, hash)
+	ret0, _ := ret[0].(*auth_core.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessionByRefreshHash indicates an expected call of GetSessionByRefreshHash.
+func (mr *MockSessionRepositoryMockRecorder) GetSessionByRefreshHash(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionByRefreshHash", reflect.TypeOf((*MockSessionRepository)(nil).GetSessionByRefreshHash), ctx, hash)
+}
+
+// GetSessionByUsedHash mocks base method.
+func (m *MockSessionRepository) GetSessionByUsedHash(ctx context.Context, hash string) (*auth_core.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionByUsedHash", ctx, hash)
+	ret0, _ := ret[0].(*auth_core.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessionByUsedHash indicates an expected call of GetSessionByUsedHash.
+func (mr *MockSessionRepositoryMockRecorder) GetSessionByUsedHash(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionByUsedHash", reflect.TypeOf((*MockSessionRepository)(nil).GetSessionByUsedHash), ctx, hash)
+}
+
+// GetUserSessions mocks base method.
+func (m *MockSessionRepository) GetUserSessions(ctx context.Context, userID string) ([]auth_core.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserSessions", ctx, userID)
+	ret0, _ := ret[0].([]auth_core.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserSessions indicates an expected call of GetUserSessions.
+func (mr *MockSessionRepositoryMockRecorder) GetUserSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSessions", reflect.TypeOf((*MockSessionRepository)(nil).GetUserSessions), ctx, userID)
+}
+
+// MarkUserSessionsVerified mocks base method.
+func (m *MockSessionRepository) MarkUserSessionsVerified(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkUserSessionsVerified", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkUserSessionsVerified indicates an expected call of MarkUserSessionsVerified.
+func (mr *MockSessionRepositoryMockRecorder) MarkUserSessionsVerified(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkUserSessionsVerified", reflect.TypeOf((*MockSessionRepository)(nil).MarkUserSessionsVerified), ctx, userID)
+}
+
+// RevokeSession mocks base method.
+func (m *MockSessionRepository) RevokeSession(ctx context.Context, ID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, ID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockSessionRepositoryMockRecorder) RevokeSession(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockSessionRepository)(nil).RevokeSession), ctx, ID)
+}
+
+// RevokeUserSessions mocks base method.
+func (m *MockSessionRepository) RevokeUserSessions(ctx context.Context, userID, exceptID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSessions", ctx, userID, exceptID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserSessions indicates an expected call of RevokeUserSessions.
+func (mr *MockSessionRepositoryMockRecorder) RevokeUserSessions(ctx, userID, exceptID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSessions", reflect.TypeOf((*MockSessionRepository)(nil).RevokeUserSessions), ctx, userID, exceptID)
+}
+
+// RotateRefreshHash mocks base method.
+func (m *MockSessionRepository) RotateRefreshHash(ctx context.Context, ID, oldHash, newHash string, expiresAt int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshHash", ctx, ID, oldHash, newHash, expiresAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateRefreshHash indicates an expected call of RotateRefreshHash.
+func (mr *MockSessionRepositoryMockRecorder) RotateRefreshHash(ctx, ID, oldHash, newHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshHash", reflect.TypeOf((*MockSessionRepository)(nil).RotateRefreshHash), ctx, ID, oldHash, newHash, expiresAt)
+}
+
+// TouchSession mocks base method.
+func (m *MockSessionRepository) TouchSession(ctx context.Context, ID, ip string, lastSeenAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchSession", ctx, ID, ip, lastSeenAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchSession indicates an expected call of TouchSession.
+func (mr *MockSessionRepositoryMockRecorder) TouchSession(ctx, ID, ip, lastSeenAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchSession", reflect.TypeOf((*MockSessionRepository)(nil).TouchSession), ctx, ID, ip, lastSeenAt)
+}
@@ -0,0 +1,180 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/mircoservices/auth-microservice/db/two_factor.go
+
+// Package mock_auth_db is a generated GoMock package.
+package mock_auth_db
+
+import (
+	context "context"
+	reflect "reflect"
+
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTwoFactorRepository is a mock of TwoFactorRepository interface.
+type MockTwoFactorRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTwoFactorRepositoryMockRecorder
+}
+
+// MockTwoFactorRepositoryMockRecorder is the mock recorder for MockTwoFactorRepository.
+type MockTwoFactorRepositoryMockRecorder struct {
+	mock *MockTwoFactorRepository
+}
+
+// NewMockTwoFactorRepository creates a new mock instance.
+func NewMockTwoFactorRepository(ctrl *gomock.Controller) *MockTwoFactorRepository {
+	mock := &MockTwoFactorRepository{ctrl: ctrl}
+	mock.recorder = &MockTwoFactorRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTwoFactorRepository) EXPECT() *MockTwoFactorRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateLoginTicket mocks base method.
+func (m *MockTwoFactorRepository) CreateLoginTicket(ctx context.Context, ticket *auth_core.LoginTicket) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLoginTicket", ctx, ticket)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateLoginTicket indicates an expected call of CreateLoginTicket.
+func (mr *MockTwoFactorRepositoryMockRecorder) CreateLoginTicket(ctx, ticket interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoginTicket", reflect.TypeOf((*MockTwoFactorRepository)(nil).CreateLoginTicket), ctx, ticket)
+}
+
+// DeleteLoginTicket mocks base method.
+func (m *MockTwoFactorRepository) DeleteLoginTicket(ctx context.Context, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginTicket", ctx, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLoginTicket indicates an expected call of DeleteLoginTicket.
+func (mr *MockTwoFactorRepositoryMockRecorder) DeleteLoginTicket(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginTicket", reflect.TypeOf((*MockTwoFactorRepository)(nil).DeleteLoginTicket), ctx, hash)
+}
+
+// DeleteUserLoginTickets mocks base method.
+func (m *MockTwoFactorRepository) DeleteUserLoginTickets(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserLoginTickets", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserLoginTickets indicates an expected call of DeleteUserLoginTickets.
+func (mr *MockTwoFactorRepositoryMockRecorder) DeleteUserLoginTickets(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserLoginTickets", reflect.TypeOf((*MockTwoFactorRepository)(nil).DeleteUserLoginTickets), ctx, userID)
+}
+
+// DisableTwoFactor mocks base method.
+func (m *MockTwoFactorRepository) DisableTwoFactor(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTwoFactor", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTwoFactor indicates an expected call of DisableTwoFactor.
+func (mr *MockTwoFactorRepositoryMockRecorder) DisableTwoFactor(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTwoFactor", reflect.TypeOf((*MockTwoFactorRepository)(nil).DisableTwoFactor), ctx, userID)
+}
+
+// EnableTwoFactor mocks base method.
+func (m *MockTwoFactorRepository) EnableTwoFactor(ctx context.Context, userID, secret string, recoveryHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTwoFactor", ctx, userID, secret, recoveryHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTwoFactor indicates an expected call of EnableTwoFactor.
+func (mr *MockTwoFactorRepositoryMockRecorder) EnableTwoFactor(ctx, userID, secret, recoveryHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTwoFactor", reflect.TypeOf((*MockTwoFactorRepository)(nil).EnableTwoFactor), ctx, userID, secret, recoveryHashes)
+}
+
+// GetTwoFactor mocks base method.
+func (m *MockTwoFactorRepository) GetTwoFactor(ctx context.Context, userID string) (*auth_core.TwoFactor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTwoFactor", ctx, userID)
+	ret0, _ := ret[0].(*auth_core.TwoFactor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTwoFactor indicates an expected call of GetTwoFactor.
+func (mr *MockTwoFactorRepositoryMockRecorder) GetTwoFactor(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTwoFactor", reflect.TypeOf((*MockTwoFactorRepository)(nil).GetTwoFactor), ctx, userID)
+}
+
+// SetPendingSecret mocks base method.
+func (m *MockTwoFactorRepository) SetPendingSecret(ctx context.Context, userID, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPendingSecret", ctx, userID, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPendingSecret indicates an expected call of SetPendingSecret.
+func (mr *MockTwoFactorRepositoryMockRecorder) SetPendingSecret(ctx, userID, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPendingSecret", reflect.TypeOf((*MockTwoFactorRepository)(nil).SetPendingSecret), ctx, userID, secret)
+}
+
+// UseLoginTicketAttempt mocks base method.
+func (m *MockTwoFactorRepository) UseLoginTicketAttempt(ctx context.Context, hash string, maxAttempts int, now int64) (*auth_core.LoginTicket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseLoginTicketAttempt", ctx, hash, maxAttempts, now)
+	ret0, _ := ret[0].(*auth_core.LoginTicket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseLoginTicketAttempt indicates an expected call of UseLoginTicketAttempt.
+func (mr *MockTwoFactorRepositoryMockRecorder) UseLoginTicketAttempt(ctx, hash, maxAttempts, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseLoginTicketAttempt", reflect.TypeOf((*MockTwoFactorRepository)(nil).UseLoginTicketAttempt), ctx, hash, maxAttempts, now)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID, hash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, userID, hash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockTwoFactorRepositoryMockRecorder) UseRecoveryCode(ctx, userID, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockTwoFactorRepository)(nil).UseRecoveryCode), ctx, userID, hash)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockTwoFactorRepository) UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", ctx, userID, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockTwoFactorRepositoryMockRecorder) UseTOTPStep(ctx, userID, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockTwoFactorRepository)(nil).UseTOTPStep), ctx, userID, step)
+}
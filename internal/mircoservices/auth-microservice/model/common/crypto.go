@@ -4,12 +4,24 @@ import (
 	"crypto/rand"
 	"crypto/sha512"
 	"fmt"
+
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/argon2"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 const saltSize = 32
 
+// Argon2Params is a cost of Argon2id hashing.
+type Argon2Params struct {
+	Time    uint32 `bson:"time"`
+	Memory  uint32 `bson:"memory"` // KiB
+	Threads uint8  `bson:"threads"`
+	KeyLen  uint32 `bson:"key_len"`
+}
+
 func GetSalt() ([]byte, error) {
 	passwordSalt := make([]byte, saltSize)
 	_, err := rand.Read(passwordSalt)
@@ -19,6 +31,7 @@ func GetSalt() ([]byte, error) {
 	return passwordSalt, nil
 }
 
+// GetHash512 is a legacy password hash, only used to validate the passwords stored before Argon2id.
 func GetHash512(password string, salt []byte) ([]byte, error) {
 	var passwordHash []byte
 	sha512Hasher := sha512.New()
@@ -28,3 +41,27 @@ func GetHash512(password string, salt []byte) ([]byte, error) {
 	passwordHash = sha512Hasher.Sum(nil)
 	return passwordHash, nil
 }
+
+func GetHashArgon2id(password string, salt []byte, params Argon2Params) []byte {
+	return argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+}
+
+// GetArgon2Params returns Argon2id cost set in the config.
+func GetArgon2Params() Argon2Params {
+	params := Argon2Params{
+		Time:    viper.GetUint32(auth_constants.ViperArgon2TimeKey),
+		Memory:  viper.GetUint32(auth_constants.ViperArgon2MemoryKey),
+		Threads: uint8(viper.GetUint(auth_constants.ViperArgon2ThreadsKey)),
+		KeyLen:  auth_constants.Argon2KeyLen,
+	}
+	if params.Time == 0 {
+		params.Time = auth_constants.DefaultArgon2Time
+	}
+	if params.Memory == 0 {
+		params.Memory = auth_constants.DefaultArgon2Memory
+	}
+	if params.Threads == 0 {
+		params.Threads = auth_constants.DefaultArgon2Threads
+	}
+	return params
+}
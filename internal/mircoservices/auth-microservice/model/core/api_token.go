@@ -0,0 +1,14 @@
+package auth_core
+
+// APIToken is the personal access token of the user for scripts and bots.
+// Only the hash of the token is stored, the token itself is shown once on creation.
+type APIToken struct {
+	ID         string   `bson:"_id"`
+	UserID     string   `bson:"user_id"`
+	Name       string   `bson:"name"`
+	Hash       string   `bson:"hash"`
+	Scopes     []string `bson:"scopes"`
+	CreatedAt  int64    `bson:"created_at"`             // unix timestamp
+	ExpiresAt  int64    `bson:"expires_at,omitempty"`   // unix timestamp, 0 if the token never expires
+	LastUsedAt int64    `bson:"last_used_at,omitempty"` // unix timestamp
+}
@@ -1,6 +1,7 @@
 package auth_core
 
 import (
+	"crypto/subtle"
 	"encoding/base64"
 	"fmt"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
@@ -11,32 +12,47 @@ import (
 
 // UserPassword describes user's password data
 type UserPassword struct {
-	Hash string `bson:"hash"`
-	Salt string `bson:"salt"`
+	Algorithm string                    `bson:"algorithm,omitempty"` // empty for legacy SHA-512 hashes
+	Hash      string                    `bson:"hash"`
+	Salt      string                    `bson:"salt"`
+	Params    *auth_common.Argon2Params `bson:"params,omitempty"`
 }
 
 // User describes a user entity
 type User struct {
-	ID        string       `bson:"_id"`
-	Email     string       `bson:"email"`
-	Password  UserPassword `bson:"password"`
-	CreatedAt int64        `bson:"created_at"` // unix timestamp
+	ID              string           `bson:"_id"`
+	Email           string           `bson:"email"`
+	EmailUnverified bool             `bson:"email_unverified,omitempty"` // legacy users have verified emails
+	Password        UserPassword     `bson:"password"`
+	CreatedAt       int64            `bson:"created_at"` // unix timestamp
+	Deletion        *AccountDeletion `bson:"deletion,omitempty"`
+}
+
+// AccountDeletion is set when the user asks to delete the account. Until PurgeAt the deletion
+// is cancelled by logging in, after it the account is claimed for purging and can't be restored.
+type AccountDeletion struct {
+	RequestedAt int64 `bson:"requested_at"` // unix timestamp
+	PurgeAt     int64 `bson:"purge_at"`     // unix timestamp
+	Purging     bool  `bson:"purging"`
+	Attempts    int64 `bson:"attempts,omitempty"`   // how many times the account has been claimed for purging
+	ClaimedAt   int64 `bson:"claimed_at,omitempty"` // unix timestamp
+	RetryAt     int64 `bson:"retry_at,omitempty"`   // unix timestamp, the account isn't claimed again before it
 }
 
 // Init generates salt and hash with given password and fills corresponding fields.
 func (up *UserPassword) Init(password string) error {
 	salt, err := auth_common.GetSalt()
 	if err != nil {
-		return status.Error(codes.Internal, auth_constants.ErrPasswordSalt.Error())
+		return auth_constants.ErrPasswordSalt
 	}
 
-	hash, err := auth_common.GetHash512(password, salt)
-	if err != nil {
-		return status.Error(codes.Internal, auth_constants.ErrPassword.Error())
-	}
+	params := auth_common.GetArgon2Params()
+	hash := auth_common.GetHashArgon2id(password, salt, params)
 
+	up.Algorithm = auth_constants.PasswordAlgorithmArgon2id
 	up.Salt = base64.URLEncoding.EncodeToString(salt)
 	up.Hash = base64.URLEncoding.EncodeToString(hash)
+	up.Params = &params
 
 	return nil
 }
@@ -48,14 +64,39 @@ func (up *UserPassword) Validate(password string) error {
 		return status.Error(codes.Internal, fmt.Errorf("error decoding user's salt: %s", err).Error())
 	}
 
-	hash, err := auth_common.GetHash512(password, salt)
-	if err != nil {
-		return status.Error(codes.Internal, fmt.Errorf("error generating hash: %s", err).Error())
+	var hash []byte
+	switch up.Algorithm {
+	case auth_constants.PasswordAlgorithmArgon2id:
+		if up.Params == nil {
+			return auth_constants.ErrPasswordAlgo
+		}
+		hash = auth_common.GetHashArgon2id(password, salt, *up.Params)
+	case auth_constants.PasswordAlgorithmSHA512, "":
+		hash, err = auth_common.GetHash512(password, salt)
+		if err != nil {
+			return status.Error(codes.Internal, fmt.Errorf("error generating hash: %s", err).Error())
+		}
+	default:
+		return auth_constants.ErrPasswordAlgo
 	}
 
-	if base64.URLEncoding.EncodeToString(hash) != up.Hash {
-		return status.Error(codes.Internal, auth_constants.ErrPasswordMismatch.Error())
+	if subtle.ConstantTimeCompare([]byte(base64.URLEncoding.EncodeToString(hash)), []byte(up.Hash)) != 1 {
+		return auth_constants.ErrPasswordMismatch
 	}
 
 	return nil
 }
+
+// IsSet reports whether the user has a password at all, the users signed up with OAuth or Telegram have none.
+func (up *UserPassword) IsSet() bool {
+	return len(up.Hash) != 0
+}
+
+// NeedsRehash reports whether the password is hashed with an outdated algorithm or cost,
+// so it should be hashed again once the plain password is known.
+func (up *UserPassword) NeedsRehash() bool {
+	if up.Algorithm != auth_constants.PasswordAlgorithmArgon2id || up.Params == nil {
+		return true
+	}
+	return *up.Params != auth_common.GetArgon2Params()
+}
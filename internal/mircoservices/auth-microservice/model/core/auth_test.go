@@ -0,0 +1,63 @@
+package auth_core
+
+import (
+	"encoding/base64"
+	"testing"
+
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	auth_common "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/common"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/status"
+)
+
+// legacyPassword builds the record the way it was stored before Argon2id.
+func legacyPassword(t *testing.T, password string) UserPassword {
+	t.Helper()
+
+	salt, err := auth_common.GetSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := auth_common.GetHash512(password, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return UserPassword{
+		Hash: base64.URLEncoding.EncodeToString(hash),
+		Salt: base64.URLEncoding.EncodeToString(salt),
+	}
+}
+
+func TestUserPasswordArgon2id(t *testing.T) {
+	var up UserPassword
+	assert.Nil(t, up.Init("password"))
+	assert.Equal(t, auth_constants.PasswordAlgorithmArgon2id, up.Algorithm)
+	assert.NotNil(t, up.Params)
+
+	assert.Nil(t, up.Validate("password"))
+	assert.Equal(t, auth_constants.ErrPasswordMismatch.Error(), status.Convert(up.Validate("wrong")).Message())
+	assert.False(t, up.NeedsRehash())
+}
+
+func TestUserPasswordLegacy(t *testing.T) {
+	up := legacyPassword(t, "password")
+
+	assert.Nil(t, up.Validate("password"))
+	assert.Equal(t, auth_constants.ErrPasswordMismatch.Error(), status.Convert(up.Validate("wrong")).Message())
+	assert.True(t, up.NeedsRehash())
+}
+
+func TestUserPasswordOutdatedCost(t *testing.T) {
+	var up UserPassword
+	assert.Nil(t, up.Init("password"))
+
+	up.Params.Time++
+	assert.True(t, up.NeedsRehash())
+}
+
+func TestUserPasswordUnknownAlgorithm(t *testing.T) {
+	up := legacyPassword(t, "password")
+	up.Algorithm = "md5"
+
+	assert.Equal(t, auth_constants.ErrPasswordAlgo.Error(), status.Convert(up.Validate("password")).Message())
+}
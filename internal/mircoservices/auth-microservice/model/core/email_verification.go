@@ -0,0 +1,13 @@
+package auth_core
+
+import "time"
+
+// EmailVerification confirms that the user owns the email the account is registered with.
+// Only the hash of the token is stored, the token itself is sent to the user's email.
+type EmailVerification struct {
+	Hash      string    `bson:"_id"`
+	UserID    string    `bson:"user_id"`
+	CreatedAt int64     `bson:"created_at"` // unix timestamp
+	ExpiresAt int64     `bson:"expires_at"` // unix timestamp
+	DeleteAt  time.Time `bson:"delete_at"`  // the TTL index removes the document at this time
+}
@@ -0,0 +1,11 @@
+package auth_core
+
+// ExternalIdentity links the account of an external identity provider (VK, Google etc.) to the user.
+type ExternalIdentity struct {
+	ID       string `bson:"_id"` // provider:subject
+	Provider string `bson:"provider"`
+	Subject  string `bson:"subject"` // id of the account at the provider
+	UserID   string `bson:"user_id"`
+	Email    string `bson:"email,omitempty"`
+	LinkedAt int64  `bson:"linked_at"` // unix timestamp
+}
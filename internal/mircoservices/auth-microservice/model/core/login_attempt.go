@@ -0,0 +1,12 @@
+package auth_core
+
+import "time"
+
+// LoginAttempt counts failed logins made with the same email or from the same IP.
+type LoginAttempt struct {
+	Key           string    `bson:"_id"` // scope and value, e.g. "email:user@example.com"
+	Failures      int       `bson:"failures"`
+	LastFailureAt int64     `bson:"last_failure_at"` // unix timestamp
+	LockedUntil   int64     `bson:"locked_until"`    // unix timestamp, no logins are allowed before it
+	DeleteAt      time.Time `bson:"delete_at"`       // the TTL index removes the counter at this time
+}
@@ -0,0 +1,13 @@
+package auth_core
+
+import "time"
+
+// PasswordReset is a one-time permission to set a new password without knowing the old one.
+// Only the hash of the token is stored, the token itself is sent to the user's email.
+type PasswordReset struct {
+	Hash      string    `bson:"_id"`
+	UserID    string    `bson:"user_id"`
+	CreatedAt int64     `bson:"created_at"` // unix timestamp
+	ExpiresAt int64     `bson:"expires_at"` // unix timestamp
+	DeleteAt  time.Time `bson:"delete_at"`  // the TTL index removes the document at this time
+}
@@ -0,0 +1,23 @@
+package auth_core
+
+import "time"
+
+// Session describes a login session of the user.
+// All refresh tokens issued for the session form one token family: only the
+// latest one is valid, the rotated ones are kept to detect their reuse.
+type Session struct {
+	ID          string    `bson:"_id"`
+	UserID      string    `bson:"user_id"`
+	RefreshHash string    `bson:"refresh_hash"`
+	UsedHashes  []string  `bson:"used_hashes,omitempty"`
+	Revoked     bool      `bson:"revoked"`
+	Unverified  bool      `bson:"unverified,omitempty"` // the user's email was not verified when the session started
+	Device      string    `bson:"device"`
+	UserAgent   string    `bson:"user_agent"`
+	IP          string    `bson:"ip"`
+	CreatedAt   int64     `bson:"created_at"`           // unix timestamp
+	LastSeenAt  int64     `bson:"last_seen_at"`         // unix timestamp
+	RotatedAt   int64     `bson:"rotated_at,omitempty"` // unix timestamp of the last refresh token rotation
+	ExpiresAt   int64     `bson:"expires_at"`           // unix timestamp
+	DeleteAt    time.Time `bson:"delete_at"`            // the TTL index removes the session at this time
+}
@@ -0,0 +1,24 @@
+package auth_core
+
+import "time"
+
+// TwoFactor describes TOTP second factor of the user.
+type TwoFactor struct {
+	UserID  string `bson:"_id"`
+	Enabled bool   `bson:"enabled"`
+	Secret  string `bson:"secret,omitempty"`
+	// PendingSecret is enrolled but not confirmed with a code yet.
+	PendingSecret  string   `bson:"pending_secret,omitempty"`
+	RecoveryHashes []string `bson:"recovery_hashes,omitempty"`
+	// LastUsedStep is the TOTP period of the last accepted code, so the same code can't be used twice.
+	LastUsedStep int64 `bson:"last_used_step"`
+}
+
+// LoginTicket is issued instead of tokens when the password is correct, but the second factor is still required.
+type LoginTicket struct {
+	Hash      string    `bson:"_id"`
+	UserID    string    `bson:"user_id"`
+	Attempts  int       `bson:"attempts"`
+	ExpiresAt int64     `bson:"expires_at"` // unix timestamp
+	DeleteAt  time.Time `bson:"delete_at"`  // the TTL index removes the document at this time
+}
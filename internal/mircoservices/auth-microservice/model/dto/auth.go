@@ -1,23 +1,267 @@
 package auth_dto
 
+// ClientInfo describes the client which the session is started from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+	Device    string
+}
+
 type SignupUserRequest struct {
 	Email    string `validate:"required,email"`
 	Password string `validate:"required"`
+	Client   ClientInfo
 }
 
 type SignupUserResponse struct {
-	AuthToken string
-	UserID    string
+	AuthToken    string
+	RefreshToken string
+	UserID       string
 }
 
 type LoginUserRequest struct {
 	Email    string `validate:"required,email"`
 	Password string `validate:"required"`
+	Client   ClientInfo
 }
 
+// LoginUserResponse contains either tokens or, if the second factor is required, the ticket to pass it.
 type LoginUserResponse struct {
-	AuthToken string
-	UserID    string
+	AuthToken       string
+	RefreshToken    string
+	UserID          string
+	TwoFactorTicket string
+}
+
+type RefreshRequest struct {
+	RefreshToken string `validate:"required"`
+	Client       ClientInfo
+}
+
+type RefreshResponse struct {
+	AuthToken    string
+	RefreshToken string
+	UserID       string
+	SessionID    string
+	Unverified   bool
+}
+
+type CheckRequest struct {
+	AuthToken string `validate:"required"`
+}
+
+type CheckResponse struct {
+	UserID     string
+	SessionID  string
+	Unverified bool
+}
+
+type LogoutRequest struct {
+	AuthToken    string
+	RefreshToken string
+}
+
+type SessionInfo struct {
+	ID         string
+	Device     string
+	UserAgent  string
+	IP         string
+	CreatedAt  int64
+	LastSeenAt int64
+}
+
+type ListSessionsRequest struct {
+	UserID string `validate:"required"`
+}
+
+type ListSessionsResponse struct {
+	Sessions []SessionInfo
+}
+
+type RevokeSessionRequest struct {
+	UserID    string `validate:"required"`
+	SessionID string `validate:"required"`
+}
+
+type ChangePasswordRequest struct {
+	UserID      string `validate:"required"`
+	SessionID   string
+	OldPassword string // empty for the users without a password, they set the first one
+	NewPassword string `validate:"required"`
+	Code        string
+}
+
+type RequestPasswordResetRequest struct {
+	Email string `validate:"required,email"`
+}
+
+type ConfirmPasswordResetRequest struct {
+	Token       string `validate:"required"`
+	NewPassword string `validate:"required"`
+}
+
+type VerifySecondFactorRequest struct {
+	Ticket string `validate:"required"`
+	Code   string `validate:"required"`
+	Client ClientInfo
+}
+
+type VerifySecondFactorResponse struct {
+	AuthToken    string
+	RefreshToken string
+	UserID       string
+}
+
+type EnrollTwoFactorRequest struct {
+	UserID string `validate:"required"`
+}
+
+type EnrollTwoFactorResponse struct {
+	Secret string
+	URI    string
+}
+
+type ConfirmTwoFactorRequest struct {
+	UserID string `validate:"required"`
+	Code   string `validate:"required"`
+}
+
+type ConfirmTwoFactorResponse struct {
+	RecoveryCodes []string
+}
+
+type DisableTwoFactorRequest struct {
+	UserID   string `validate:"required"`
+	Password string
+	Code     string `validate:"required"`
+}
+
+type DeleteAccountRequest struct {
+	UserID    string `validate:"required"`
+	SessionID string
+	Password  string
+	Code      string
+}
+
+type DeleteAccountResponse struct {
+	PurgeAt int64
+}
+
+type ClaimAccountDeletionsRequest struct {
+	Limit int64
+}
+
+type ClaimAccountDeletionsResponse struct {
+	UserIDs []string
+}
+
+type PurgeAccountRequest struct {
+	UserID string `validate:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `validate:"required"`
+}
+
+type VerifyEmailResponse struct {
+	UserID string
+}
+
+type ResendVerificationRequest struct {
+	UserID string `validate:"required"`
+}
+
+// ExternalIdentity is the account of the user at an external identity provider.
+type ExternalIdentity struct {
+	Provider      string `validate:"required"`
+	Subject       string `validate:"required"`
+	Email         string `validate:"omitempty,email"`
+	EmailVerified bool
+}
+
+type ExternalLoginRequest struct {
+	Identity ExternalIdentity
+	Client   ClientInfo
+}
+
+// ExternalLoginResponse contains either tokens or, if the second factor is required, the ticket to pass it.
+// Created is set when the user is signed up by this login.
+type ExternalLoginResponse struct {
+	AuthToken       string
+	RefreshToken    string
+	UserID          string
+	TwoFactorTicket string
+	Created         bool
+}
+
+type LinkIdentityRequest struct {
+	UserID   string `validate:"required"`
+	Identity ExternalIdentity
+}
+
+type UnlinkIdentityRequest struct {
+	UserID   string `validate:"required"`
+	Provider string `validate:"required"`
+}
+
+type LinkedIdentity struct {
+	Provider string
+	Email    string
+	LinkedAt int64
+}
+
+type ListIdentitiesRequest struct {
+	UserID string `validate:"required"`
+}
+
+type ListIdentitiesResponse struct {
+	Identities []LinkedIdentity
+}
+
+type CreateAPITokenRequest struct {
+	UserID    string   `validate:"required"`
+	Name      string   `validate:"required,max=64"`
+	Scopes    []string `validate:"required,min=1"`
+	ExpiresAt int64    // unix timestamp, 0 if the token never expires
+}
+
+// CreateAPITokenResponse contains the token itself, it is never shown again.
+type CreateAPITokenResponse struct {
+	Token    string
+	APIToken APIToken
+}
+
+type APIToken struct {
+	ID         string
+	Name       string
+	Scopes     []string
+	CreatedAt  int64
+	ExpiresAt  int64
+	LastUsedAt int64
+}
+
+type ListAPITokensRequest struct {
+	UserID string `validate:"required"`
+}
+
+type ListAPITokensResponse struct {
+	Tokens []APIToken
+}
+
+type RevokeAPITokenRequest struct {
+	UserID  string `validate:"required"`
+	TokenID string `validate:"required"`
+}
+
+type CheckAPITokenRequest struct {
+	Token string `validate:"required"`
+}
+
+type CheckAPITokenResponse struct {
+	UserID     string
+	TokenID    string
+	Scopes     []string
+	Unverified bool
 }
 
 type BasicResponse struct{}
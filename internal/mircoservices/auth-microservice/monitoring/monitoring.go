@@ -0,0 +1,21 @@
+package auth_monitoring
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// LoginLockouts counts logins locked out after too many failures, by scope (email or ip).
+	LoginLockouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_login_lockouts_total",
+		Help: "Number of login lockouts caused by too many failed attempts",
+	}, []string{"scope"})
+
+	// LoginThrottled counts login attempts rejected because of a delay or a lockout, by scope (email or ip).
+	LoginThrottled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_login_throttled_total",
+		Help: "Number of login attempts rejected while the delay or the lockout is active",
+	}, []string{"scope"})
+)
+
+func RegisterMonitoring(reg prometheus.Registerer) {
+	reg.MustRegister(LoginLockouts, LoginThrottled)
+}
@@ -0,0 +1,150 @@
+package auth_service
+
+import (
+	"context"
+	"time"
+
+	authconstants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	authdb "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/db"
+	authcore "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	authdto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
+	"github.com/sirupsen/logrus"
+)
+
+type AccountService interface {
+	DeleteAccount(ctx context.Context, request *authdto.DeleteAccountRequest) (*authdto.DeleteAccountResponse, error)
+	ClaimAccountDeletions(ctx context.Context, request *authdto.ClaimAccountDeletionsRequest) (*authdto.ClaimAccountDeletionsResponse, error)
+	PurgeAccount(ctx context.Context, request *authdto.PurgeAccountRequest) error
+}
+
+type accountServiceImpl struct {
+	log *logrus.Entry
+	db  *authdb.Repository
+}
+
+// DeleteAccount schedules the account to be purged after the grace period and signs out all sessions.
+// Logging in before the grace period is over cancels the deletion. The users without a password
+// confirm it with a second factor code or a recent login, see reauthenticate.
+func (svc *accountServiceImpl) DeleteAccount(ctx context.Context, request *authdto.DeleteAccountRequest) (*authdto.DeleteAccountResponse, error) {
+	user, err := svc.db.AuthRepo.GetUserByID(ctx, request.UserID)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GetUserByID error: %s", err)
+		return nil, err
+	}
+
+	if err := reauthenticate(ctx, svc.log, svc.db, user, request.SessionID, request.Password, request.Code); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	deletion := authcore.AccountDeletion{
+		RequestedAt: now,
+		PurgeAt:     now + configSeconds(authconstants.ViperAccountDeletionGraceKey, authconstants.DefaultAccountDeletionGrace),
+	}
+	if err := svc.db.AuthRepo.ScheduleDeletion(ctx, user.ID, deletion); err != nil {
+		logger(ctx, svc.log).Errorf("ScheduleDeletion error: %s", err)
+		return nil, err
+	}
+
+	if err := svc.db.PasswordResetRepo.DeleteUserPasswordResets(ctx, user.ID); err != nil {
+		logger(ctx, svc.log).Errorf("DeleteUserPasswordResets error: %s", err)
+		return nil, err
+	}
+
+	if err := svc.db.SessionRepo.RevokeUserSessions(ctx, user.ID, ""); err != nil {
+		logger(ctx, svc.log).Errorf("RevokeUserSessions error: %s", err)
+		return nil, err
+	}
+
+	if err := svc.db.APITokenRepo.DeleteUserAPITokens(ctx, user.ID); err != nil {
+		logger(ctx, svc.log).Errorf("DeleteUserAPITokens error: %s", err)
+		return nil, err
+	}
+
+	logger(ctx, svc.log).Infof("account %s is scheduled for deletion at %d", user.ID, deletion.PurgeAt)
+	return &authdto.DeleteAccountResponse{PurgeAt: deletion.PurgeAt}, nil
+}
+
+// ClaimAccountDeletions returns the accounts whose grace period is over. From now on they can't be restored,
+// the caller purges their data and then calls PurgeAccount for every one of them.
+func (svc *accountServiceImpl) ClaimAccountDeletions(ctx context.Context, request *authdto.ClaimAccountDeletionsRequest) (*authdto.ClaimAccountDeletionsResponse, error) {
+	limit := request.Limit
+	if limit <= 0 {
+		limit = authconstants.DefaultAccountDeletionClaimLimit
+	}
+
+	ids, err := svc.db.AuthRepo.ClaimDueDeletions(ctx, time.Now().Unix(), limit)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("ClaimDueDeletions error: %s", err)
+		return nil, err
+	}
+	return &authdto.ClaimAccountDeletionsResponse{UserIDs: ids}, nil
+}
+
+// PurgeAccount removes the credentials and everything else the auth service keeps about the claimed account.
+func (svc *accountServiceImpl) PurgeAccount(ctx context.Context, request *authdto.PurgeAccountRequest) error {
+	user, err := svc.db.AuthRepo.GetUserByID(ctx, request.UserID)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GetUserByID error: %s", err)
+		return err
+	}
+
+	if user.Deletion == nil || !user.Deletion.Purging {
+		return authconstants.ErrAccountDeletionNotDue
+	}
+
+	if err := svc.db.SessionRepo.DeleteUserSessions(ctx, user.ID); err != nil {
+		logger(ctx, svc.log).Errorf("DeleteUserSessions error: %s", err)
+		return err
+	}
+
+	if err := svc.db.PasswordResetRepo.DeleteUserPasswordResets(ctx, user.ID); err != nil {
+		logger(ctx, svc.log).Errorf("DeleteUserPasswordResets error: %s", err)
+		return err
+	}
+
+	if err := svc.db.EmailVerificationRepo.DeleteUserEmailVerifications(ctx, user.ID); err != nil {
+		logger(ctx, svc.log).Errorf("DeleteUserEmailVerifications error: %s", err)
+		return err
+	}
+
+	if err := svc.db.IdentityRepo.DeleteUserIdentities(ctx, user.ID); err != nil {
+		logger(ctx, svc.log).Errorf("DeleteUserIdentities error: %s", err)
+		return err
+	}
+
+	if err := svc.db.APITokenRepo.DeleteUserAPITokens(ctx, user.ID); err != nil {
+		logger(ctx, svc.log).Errorf("DeleteUserAPITokens error: %s", err)
+		return err
+	}
+
+	if err := svc.db.TwoFactorRepo.DisableTwoFactor(ctx, user.ID); err != nil {
+		logger(ctx, svc.log).Errorf("DisableTwoFactor error: %s", err)
+		return err
+	}
+
+	if err := svc.db.TwoFactorRepo.DeleteUserLoginTickets(ctx, user.ID); err != nil {
+		logger(ctx, svc.log).Errorf("DeleteUserLoginTickets error: %s", err)
+		return err
+	}
+
+	for _, scope := range loginScopes(user.Email, "") {
+		if err := svc.db.LoginAttemptRepo.ResetLoginAttempts(ctx, scope.key); err != nil {
+			logger(ctx, svc.log).Errorf("ResetLoginAttempts error: %s", err)
+			return err
+		}
+	}
+
+	// The credentials go last, so the purge is retried until everything else is removed.
+	if err := svc.db.AuthRepo.DeleteUser(ctx, user.ID); err != nil {
+		logger(ctx, svc.log).Errorf("DeleteUser error: %s", err)
+		return err
+	}
+
+	logger(ctx, svc.log).Infof("account %s is purged", user.ID)
+	return nil
+}
+
+func NewAccountService(log *logrus.Entry, db *authdb.Repository) AccountService {
+	return &accountServiceImpl{log: log, db: db}
+}
@@ -0,0 +1,277 @@
+package auth_service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	mock_auth_db "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/mocks"
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	authdto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	accountImpl := NewAccountService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+	user := &auth_core.User{ID: "1", Email: "mail@example.com"}
+	if err := user.Password.Init("1234"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Wrong password", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil),
+			testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "user:1").Return(nil, auth_constants.ErrDBNotFound),
+			testRepo.mockLoginAttemptR.EXPECT().RegisterLoginFailure(ctx, "user:1", gomock.Any(), gomock.Any()).Return(&auth_core.LoginAttempt{Key: "user:1", Failures: 1}, nil),
+		)
+
+		_, err := AccountService.DeleteAccount(accountImpl, ctx, &authdto.DeleteAccountRequest{UserID: "1", Password: "wrong"})
+		assert.Equal(t, auth_constants.ErrPasswordMismatch, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var scheduled auth_core.AccountDeletion
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil),
+			testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "user:1").Return(nil, auth_constants.ErrDBNotFound),
+			testRepo.mockLoginAttemptR.EXPECT().ResetLoginAttempts(ctx, "user:1").Return(nil),
+			testRepo.mockUserR.EXPECT().ScheduleDeletion(ctx, "1", gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ string, deletion auth_core.AccountDeletion) error {
+					scheduled = deletion
+					return nil
+				}),
+			testRepo.mockPasswordResetR.EXPECT().DeleteUserPasswordResets(ctx, "1").Return(nil),
+			testRepo.mockSessionR.EXPECT().RevokeUserSessions(ctx, "1", "").Return(nil),
+			testRepo.mockAPITokenR.EXPECT().DeleteUserAPITokens(ctx, "1").Return(nil),
+		)
+
+		res, err := AccountService.DeleteAccount(accountImpl, ctx, &authdto.DeleteAccountRequest{UserID: "1", Password: "1234"})
+		assert.Nil(t, err)
+		assert.False(t, scheduled.Purging)
+		assert.Equal(t, scheduled.PurgeAt, res.PurgeAt)
+		assert.Equal(t, int64(auth_constants.DefaultAccountDeletionGrace/time.Second), scheduled.PurgeAt-scheduled.RequestedAt)
+	})
+
+	passwordless := &auth_core.User{ID: "1"}
+
+	t.Run("Passwordless without recent login", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(passwordless, nil),
+			testRepo.mockSessionR.EXPECT().GetSessionByID(ctx, "2").Return(&auth_core.Session{
+				ID:        "2",
+				UserID:    "1",
+				CreatedAt: time.Now().Add(-time.Hour).Unix(),
+			}, nil),
+		)
+
+		_, err := AccountService.DeleteAccount(accountImpl, ctx, &authdto.DeleteAccountRequest{UserID: "1", SessionID: "2"})
+		assert.Equal(t, auth_constants.ErrReauthRequired, err)
+	})
+
+	t.Run("Passwordless with wrong code", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(passwordless, nil),
+			testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "user:1").Return(nil, auth_constants.ErrDBNotFound),
+			testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "1").Return(&auth_core.TwoFactor{UserID: "1", Enabled: true, Secret: "SECRET"}, nil),
+			testRepo.mockTwoFactorR.EXPECT().UseRecoveryCode(ctx, "1", gomock.Any()).Return(false, nil),
+			testRepo.mockLoginAttemptR.EXPECT().RegisterLoginFailure(ctx, "user:1", gomock.Any(), gomock.Any()).Return(&auth_core.LoginAttempt{Key: "user:1", Failures: 1}, nil),
+		)
+
+		_, err := AccountService.DeleteAccount(accountImpl, ctx, &authdto.DeleteAccountRequest{UserID: "1", SessionID: "2", Code: "aaaaa-bbbbb"})
+		assert.Equal(t, auth_constants.ErrTwoFactorCodeInvalid, err)
+	})
+
+	t.Run("Passwordless locked out", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(passwordless, nil),
+			testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "user:1").Return(&auth_core.LoginAttempt{Key: "user:1", LockedUntil: time.Now().Unix() + 60}, nil),
+		)
+
+		_, err := AccountService.DeleteAccount(accountImpl, ctx, &authdto.DeleteAccountRequest{UserID: "1", SessionID: "2", Code: "aaaaa-bbbbb"})
+		assert.Equal(t, auth_constants.ErrTooManyLoginAttempts, err)
+	})
+
+	t.Run("Passwordless with recent login", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(passwordless, nil),
+			testRepo.mockSessionR.EXPECT().GetSessionByID(ctx, "2").Return(&auth_core.Session{
+				ID:        "2",
+				UserID:    "1",
+				CreatedAt: time.Now().Unix(),
+			}, nil),
+			testRepo.mockUserR.EXPECT().ScheduleDeletion(ctx, "1", gomock.Any()).Return(nil),
+			testRepo.mockPasswordResetR.EXPECT().DeleteUserPasswordResets(ctx, "1").Return(nil),
+			testRepo.mockSessionR.EXPECT().RevokeUserSessions(ctx, "1", "").Return(nil),
+			testRepo.mockAPITokenR.EXPECT().DeleteUserAPITokens(ctx, "1").Return(nil),
+		)
+
+		_, err := AccountService.DeleteAccount(accountImpl, ctx, &authdto.DeleteAccountRequest{UserID: "1", SessionID: "2"})
+		assert.Nil(t, err)
+	})
+}
+
+func TestClaimAccountDeletions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	accountImpl := NewAccountService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+
+	testRepo.mockUserR.EXPECT().ClaimDueDeletions(ctx, gomock.Any(), int64(auth_constants.DefaultAccountDeletionClaimLimit)).Return([]string{"1", "2"}, nil)
+
+	res, err := AccountService.ClaimAccountDeletions(accountImpl, ctx, &authdto.ClaimAccountDeletionsRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2"}, res.UserIDs)
+}
+
+func TestPurgeAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	accountImpl := NewAccountService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+
+	t.Run("Not claimed", func(t *testing.T) {
+		user := &auth_core.User{ID: "1", Email: "mail@example.com", Deletion: &auth_core.AccountDeletion{PurgeAt: 100}}
+		testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil)
+
+		err := AccountService.PurgeAccount(accountImpl, ctx, &authdto.PurgeAccountRequest{UserID: "1"})
+		assert.Equal(t, auth_constants.ErrAccountDeletionNotDue, err)
+	})
+
+	t.Run("Not scheduled", func(t *testing.T) {
+		user := &auth_core.User{ID: "1", Email: "mail@example.com"}
+		testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil)
+
+		err := AccountService.PurgeAccount(accountImpl, ctx, &authdto.PurgeAccountRequest{UserID: "1"})
+		assert.Equal(t, auth_constants.ErrAccountDeletionNotDue, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		user := &auth_core.User{ID: "1", Email: "Mail@example.com", Deletion: &auth_core.AccountDeletion{PurgeAt: 100, Purging: true}}
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil),
+			testRepo.mockSessionR.EXPECT().DeleteUserSessions(ctx, "1").Return(nil),
+			testRepo.mockPasswordResetR.EXPECT().DeleteUserPasswordResets(ctx, "1").Return(nil),
+			testRepo.mockEmailVerificationR.EXPECT().DeleteUserEmailVerifications(ctx, "1").Return(nil),
+			testRepo.mockIdentityR.EXPECT().DeleteUserIdentities(ctx, "1").Return(nil),
+			testRepo.mockAPITokenR.EXPECT().DeleteUserAPITokens(ctx, "1").Return(nil),
+			testRepo.mockTwoFactorR.EXPECT().DisableTwoFactor(ctx, "1").Return(nil),
+			testRepo.mockTwoFactorR.EXPECT().DeleteUserLoginTickets(ctx, "1").Return(nil),
+			testRepo.mockLoginAttemptR.EXPECT().ResetLoginAttempts(ctx, "email:mail@example.com").Return(nil),
+			testRepo.mockUserR.EXPECT().DeleteUser(ctx, "1").Return(nil),
+		)
+
+		err := AccountService.PurgeAccount(accountImpl, ctx, &authdto.PurgeAccountRequest{UserID: "1"})
+		assert.Nil(t, err)
+	})
+}
+
+func TestLoginUserCancelsAccountDeletion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	authImpl := NewAuthService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
+
+	ctx := context.Background()
+	notFound := auth_constants.ErrDBNotFound
+
+	newUser := func(deletion *auth_core.AccountDeletion) *auth_core.User {
+		user := &auth_core.User{ID: "1", Email: "email@e", Deletion: deletion}
+		if err := user.Password.Init("1234"); err != nil {
+			t.Fatal(err)
+		}
+		return user
+	}
+
+	t.Run("Pending deletion is cancelled", func(t *testing.T) {
+		user := newUser(&auth_core.AccountDeletion{PurgeAt: 100})
+		gomock.InOrder(
+			testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "email:email@e").Return(nil, notFound),
+			testRepo.mockUserR.EXPECT().GetUserByEmail(ctx, user.Email).Return(user, nil),
+			testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, user.ID).Return(nil, notFound),
+			testRepo.mockLoginAttemptR.EXPECT().ResetLoginAttempts(ctx, "email:email@e").Return(nil),
+			testRepo.mockUserR.EXPECT().CancelDeletion(ctx, user.ID).Return(nil),
+			testRepo.mockSessionR.EXPECT().CreateSession(ctx, gomock.Any()).Return(nil),
+		)
+
+		res, err := AuthService.LoginUser(authImpl, ctx, &authdto.LoginUserRequest{Email: user.Email, Password: "1234"})
+		assert.Nil(t, err)
+		assert.Equal(t, user.ID, res.UserID)
+	})
+
+	t.Run("Password alone doesn't cancel it with second factor", func(t *testing.T) {
+		user := newUser(&auth_core.AccountDeletion{PurgeAt: 100})
+		gomock.InOrder(
+			testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "email:email@e").Return(nil, notFound),
+			testRepo.mockUserR.EXPECT().GetUserByEmail(ctx, user.Email).Return(user, nil),
+			testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, user.ID).Return(&auth_core.TwoFactor{UserID: user.ID, Enabled: true, Secret: "SECRET"}, nil),
+			testRepo.mockTwoFactorR.EXPECT().CreateLoginTicket(ctx, gomock.Any()).Return(nil),
+		)
+
+		res, err := AuthService.LoginUser(authImpl, ctx, &authdto.LoginUserRequest{Email: user.Email, Password: "1234"})
+		assert.Nil(t, err)
+		assert.NotEmpty(t, res.TwoFactorTicket)
+	})
+
+	t.Run("Second factor cancels it", func(t *testing.T) {
+		user := newUser(&auth_core.AccountDeletion{PurgeAt: 100})
+		hash := auth_utils.HashOpaqueToken("ticket")
+		gomock.InOrder(
+			testRepo.mockTwoFactorR.EXPECT().UseLoginTicketAttempt(ctx, hash, auth_constants.LoginTicketMaxAttempts, gomock.Any()).Return(&auth_core.LoginTicket{Hash: hash, UserID: user.ID}, nil),
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, user.ID).Return(user, nil),
+			testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, gomock.Any()).Return(nil, notFound).Times(2),
+			testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, user.ID).Return(&auth_core.TwoFactor{UserID: user.ID, Enabled: true, Secret: "SECRET"}, nil),
+			testRepo.mockTwoFactorR.EXPECT().UseRecoveryCode(ctx, user.ID, auth_utils.HashRecoveryCode("aaaaa-bbbbb")).Return(true, nil),
+			testRepo.mockLoginAttemptR.EXPECT().ResetLoginAttempts(ctx, gomock.Any()).Return(nil).Times(2),
+			testRepo.mockTwoFactorR.EXPECT().DeleteLoginTicket(ctx, hash).Return(nil),
+			testRepo.mockUserR.EXPECT().CancelDeletion(ctx, user.ID).Return(nil),
+			testRepo.mockSessionR.EXPECT().CreateSession(ctx, gomock.Any()).Return(nil),
+		)
+
+		res, err := AuthService.VerifySecondFactor(authImpl, ctx, &authdto.VerifySecondFactorRequest{Ticket: "ticket", Code: "aaaaa-bbbbb"})
+		assert.Nil(t, err)
+		assert.Equal(t, user.ID, res.UserID)
+	})
+
+	t.Run("Purging account can't log in", func(t *testing.T) {
+		user := newUser(&auth_core.AccountDeletion{PurgeAt: 100, Purging: true})
+		gomock.InOrder(
+			testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "email:email@e").Return(nil, notFound),
+			testRepo.mockUserR.EXPECT().GetUserByEmail(ctx, user.Email).Return(user, nil),
+			testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, user.ID).Return(nil, notFound),
+			testRepo.mockLoginAttemptR.EXPECT().ResetLoginAttempts(ctx, "email:email@e").Return(nil),
+		)
+
+		_, err := AuthService.LoginUser(authImpl, ctx, &authdto.LoginUserRequest{Email: user.Email, Password: "1234"})
+		assert.Equal(t, auth_constants.ErrAccountDeleted, err)
+	})
+
+	t.Run("Claimed while logging in", func(t *testing.T) {
+		user := newUser(&auth_core.AccountDeletion{PurgeAt: 100})
+		gomock.InOrder(
+			testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "email:email@e").Return(nil, notFound),
+			testRepo.mockUserR.EXPECT().GetUserByEmail(ctx, user.Email).Return(user, nil),
+			testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, user.ID).Return(nil, notFound),
+			testRepo.mockLoginAttemptR.EXPECT().ResetLoginAttempts(ctx, "email:email@e").Return(nil),
+			testRepo.mockUserR.EXPECT().CancelDeletion(ctx, user.ID).Return(notFound),
+		)
+
+		_, err := AuthService.LoginUser(authImpl, ctx, &authdto.LoginUserRequest{Email: user.Email, Password: "1234"})
+		assert.Equal(t, auth_constants.ErrAccountDeleted, err)
+	})
+}
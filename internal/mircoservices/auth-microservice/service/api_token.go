@@ -0,0 +1,167 @@
+package auth_service
+
+import (
+	"context"
+	"time"
+
+	authconstants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	authdb "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/db"
+	authcore "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	authdto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
+	authutils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/sirupsen/logrus"
+)
+
+type APITokenService interface {
+	CreateAPIToken(ctx context.Context, request *authdto.CreateAPITokenRequest) (*authdto.CreateAPITokenResponse, error)
+	ListAPITokens(ctx context.Context, request *authdto.ListAPITokensRequest) (*authdto.ListAPITokensResponse, error)
+	RevokeAPIToken(ctx context.Context, request *authdto.RevokeAPITokenRequest) error
+	CheckAPIToken(ctx context.Context, request *authdto.CheckAPITokenRequest) (*authdto.CheckAPITokenResponse, error)
+}
+
+type apiTokenServiceImpl struct {
+	log *logrus.Entry
+	db  *authdb.Repository
+}
+
+// CreateAPIToken creates the personal access token of the user with the given scopes.
+func (svc *apiTokenServiceImpl) CreateAPIToken(ctx context.Context, request *authdto.CreateAPITokenRequest) (*authdto.CreateAPITokenResponse, error) {
+	if err := validate.Struct(request); err != nil {
+		logger(ctx, svc.log).Errorf("Struct error: %s", err)
+		return nil, authconstants.ErrValidateRequest
+	}
+	if request.ExpiresAt != 0 && request.ExpiresAt <= time.Now().Unix() {
+		return nil, authconstants.ErrValidateRequest
+	}
+
+	scopes := make([]string, 0, len(request.Scopes))
+	seen := make(map[string]bool, len(request.Scopes))
+	for _, scope := range request.Scopes {
+		if !authconstants.APITokenScopes[scope] {
+			return nil, authconstants.ErrScopeInvalid
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if _, err := svc.db.AuthRepo.GetUserByID(ctx, request.UserID); err != nil {
+		logger(ctx, svc.log).Errorf("GetUserByID error: %s", err)
+		return nil, err
+	}
+
+	secret, _, err := authutils.GenerateOpaqueToken()
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GenerateOpaqueToken error: %s", err)
+		return nil, err
+	}
+	token := authconstants.APITokenPrefix + secret
+
+	apiToken := &authcore.APIToken{
+		UserID:    request.UserID,
+		Name:      request.Name,
+		Hash:      authutils.HashOpaqueToken(token),
+		Scopes:    scopes,
+		ExpiresAt: request.ExpiresAt,
+	}
+	if err := svc.db.APITokenRepo.CreateAPIToken(ctx, apiToken); err != nil {
+		logger(ctx, svc.log).Errorf("CreateAPIToken error: %s", err)
+		return nil, err
+	}
+
+	logger(ctx, svc.log).Infof("api token %s is created for user %s", apiToken.ID, request.UserID)
+	return &authdto.CreateAPITokenResponse{Token: token, APIToken: convertAPIToken(apiToken)}, nil
+}
+
+// ListAPITokens returns the tokens of the user, without the tokens themselves.
+func (svc *apiTokenServiceImpl) ListAPITokens(ctx context.Context, request *authdto.ListAPITokensRequest) (*authdto.ListAPITokensResponse, error) {
+	tokens, err := svc.db.APITokenRepo.GetUserAPITokens(ctx, request.UserID)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GetUserAPITokens error: %s", err)
+		return nil, err
+	}
+
+	response := &authdto.ListAPITokensResponse{Tokens: make([]authdto.APIToken, 0, len(tokens))}
+	for i := range tokens {
+		response.Tokens = append(response.Tokens, convertAPIToken(&tokens[i]))
+	}
+	return response, nil
+}
+
+func (svc *apiTokenServiceImpl) RevokeAPIToken(ctx context.Context, request *authdto.RevokeAPITokenRequest) error {
+	if err := validate.Struct(request); err != nil {
+		logger(ctx, svc.log).Errorf("Struct error: %s", err)
+		return authconstants.ErrValidateRequest
+	}
+
+	if err := svc.db.APITokenRepo.DeleteAPIToken(ctx, request.UserID, request.TokenID); err != nil {
+		if isNotFound(err) {
+			return authconstants.ErrAPITokenNotFound
+		}
+		logger(ctx, svc.log).Errorf("DeleteAPIToken error: %s", err)
+		return err
+	}
+	logger(ctx, svc.log).Infof("api token %s of user %s is revoked", request.TokenID, request.UserID)
+	return nil
+}
+
+// CheckAPIToken returns the user and the scopes of the token unless it is unknown or expired.
+func (svc *apiTokenServiceImpl) CheckAPIToken(ctx context.Context, request *authdto.CheckAPITokenRequest) (*authdto.CheckAPITokenResponse, error) {
+	if err := validate.Struct(request); err != nil {
+		logger(ctx, svc.log).Errorf("Struct error: %s", err)
+		return nil, authconstants.ErrAPITokenInvalid
+	}
+
+	token, err := svc.db.APITokenRepo.GetAPITokenByHash(ctx, authutils.HashOpaqueToken(request.Token))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, authconstants.ErrAPITokenInvalid
+		}
+		logger(ctx, svc.log).Errorf("GetAPITokenByHash error: %s", err)
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	if token.ExpiresAt != 0 && token.ExpiresAt <= now {
+		return nil, authconstants.ErrAPITokenInvalid
+	}
+
+	user, err := svc.db.AuthRepo.GetUserByID(ctx, token.UserID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, authconstants.ErrAPITokenInvalid
+		}
+		logger(ctx, svc.log).Errorf("GetUserByID error: %s", err)
+		return nil, err
+	}
+
+	// The last use time is only informational, so it is updated at most once a period and its errors are ignored.
+	if now-token.LastUsedAt >= authconstants.APITokenTouchPeriod {
+		if err := svc.db.APITokenRepo.TouchAPIToken(ctx, token.ID, now); err != nil {
+			logger(ctx, svc.log).Warnf("TouchAPIToken error: %s", err)
+		}
+	}
+
+	return &authdto.CheckAPITokenResponse{
+		UserID:     token.UserID,
+		TokenID:    token.ID,
+		Scopes:     token.Scopes,
+		Unverified: user.EmailUnverified,
+	}, nil
+}
+
+func convertAPIToken(token *authcore.APIToken) authdto.APIToken {
+	return authdto.APIToken{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     token.Scopes,
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+	}
+}
+
+func NewAPITokenService(log *logrus.Entry, db *authdb.Repository) APITokenService {
+	return &apiTokenServiceImpl{log: log, db: db}
+}
@@ -0,0 +1,135 @@
+package auth_service
+
+import (
+	"context"
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	authdto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateAPIToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	apiTokenImpl := NewAPITokenService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+
+	t.Run("Unknown scope", func(t *testing.T) {
+		_, err := APITokenService.CreateAPIToken(apiTokenImpl, ctx, &authdto.CreateAPITokenRequest{UserID: "1", Name: "bot", Scopes: []string{"admin"}})
+		assert.Equal(t, auth_constants.ErrScopeInvalid, err)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		_, err := APITokenService.CreateAPIToken(apiTokenImpl, ctx, &authdto.CreateAPITokenRequest{
+			UserID: "1", Name: "bot", Scopes: []string{"read:posts"}, ExpiresAt: time.Now().Add(-time.Hour).Unix(),
+		})
+		assert.Equal(t, auth_constants.ErrValidateRequest, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var hash string
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(&auth_core.User{ID: "1"}, nil),
+			testRepo.mockAPITokenR.EXPECT().CreateAPIToken(ctx, gomock.Any()).DoAndReturn(
+				func(_ context.Context, token *auth_core.APIToken) error {
+					assert.Equal(t, []string{"read:posts", "write:messages"}, token.Scopes)
+					hash = token.Hash
+					token.ID = "t1"
+					return nil
+				}),
+		)
+
+		res, err := APITokenService.CreateAPIToken(apiTokenImpl, ctx, &authdto.CreateAPITokenRequest{
+			UserID: "1", Name: "bot", Scopes: []string{"read:posts", "write:messages", "read:posts"},
+		})
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(res.Token, auth_constants.APITokenPrefix))
+		assert.Equal(t, auth_utils.HashOpaqueToken(res.Token), hash, "only the hash of the token must be stored")
+		assert.Equal(t, "t1", res.APIToken.ID)
+	})
+}
+
+func TestRevokeAPIToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	apiTokenImpl := NewAPITokenService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+
+	t.Run("Token of another user", func(t *testing.T) {
+		testRepo.mockAPITokenR.EXPECT().DeleteAPIToken(ctx, "1", "t2").Return(auth_constants.ErrDBNotFound)
+
+		err := APITokenService.RevokeAPIToken(apiTokenImpl, ctx, &authdto.RevokeAPITokenRequest{UserID: "1", TokenID: "t2"})
+		assert.Equal(t, auth_constants.ErrAPITokenNotFound, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		testRepo.mockAPITokenR.EXPECT().DeleteAPIToken(ctx, "1", "t1").Return(nil)
+
+		err := APITokenService.RevokeAPIToken(apiTokenImpl, ctx, &authdto.RevokeAPITokenRequest{UserID: "1", TokenID: "t1"})
+		assert.Nil(t, err)
+	})
+}
+
+func TestCheckAPIToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	apiTokenImpl := NewAPITokenService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+	token := auth_constants.APITokenPrefix + "secret"
+	hash := auth_utils.HashOpaqueToken(token)
+
+	t.Run("Unknown token", func(t *testing.T) {
+		testRepo.mockAPITokenR.EXPECT().GetAPITokenByHash(ctx, hash).Return(nil, auth_constants.ErrDBNotFound)
+
+		_, err := APITokenService.CheckAPIToken(apiTokenImpl, ctx, &authdto.CheckAPITokenRequest{Token: token})
+		assert.Equal(t, auth_constants.ErrAPITokenInvalid, err)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		testRepo.mockAPITokenR.EXPECT().GetAPITokenByHash(ctx, hash).Return(&auth_core.APIToken{
+			ID: "t1", UserID: "1", ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+		}, nil)
+
+		_, err := APITokenService.CheckAPIToken(apiTokenImpl, ctx, &authdto.CheckAPITokenRequest{Token: token})
+		assert.Equal(t, auth_constants.ErrAPITokenInvalid, err)
+	})
+
+	t.Run("Recently used token", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockAPITokenR.EXPECT().GetAPITokenByHash(ctx, hash).Return(&auth_core.APIToken{
+				ID: "t1", UserID: "1", Scopes: []string{"read:posts"}, LastUsedAt: time.Now().Unix(),
+			}, nil),
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(&auth_core.User{ID: "1", EmailUnverified: true}, nil),
+		)
+
+		res, err := APITokenService.CheckAPIToken(apiTokenImpl, ctx, &authdto.CheckAPITokenRequest{Token: token})
+		assert.Nil(t, err)
+		assert.Equal(t, &authdto.CheckAPITokenResponse{UserID: "1", TokenID: "t1", Scopes: []string{"read:posts"}, Unverified: true}, res)
+	})
+
+	t.Run("Touch", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockAPITokenR.EXPECT().GetAPITokenByHash(ctx, hash).Return(&auth_core.APIToken{ID: "t1", UserID: "1"}, nil),
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(&auth_core.User{ID: "1"}, nil),
+			testRepo.mockAPITokenR.EXPECT().TouchAPIToken(ctx, "t1", gomock.Any()).Return(nil),
+		)
+
+		res, err := APITokenService.CheckAPIToken(apiTokenImpl, ctx, &authdto.CheckAPITokenRequest{Token: token})
+		assert.Nil(t, err)
+		assert.Equal(t, "1", res.UserID)
+	})
+}
@@ -2,78 +2,590 @@ package auth_service
 
 import (
 	"context"
+	"errors"
+	"time"
+
 	authconstants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
 	authdb "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/db"
+	authmailer "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/mailer"
 	authcore "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
 	authdto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
 	authutils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
+var validate = validator.New()
+
 type AuthService interface {
 	SignupUser(ctx context.Context, request *authdto.SignupUserRequest) (*authdto.SignupUserResponse, error)
 	LoginUser(ctx context.Context, request *authdto.LoginUserRequest) (*authdto.LoginUserResponse, error)
+	Refresh(ctx context.Context, request *authdto.RefreshRequest) (*authdto.RefreshResponse, error)
+	VerifySecondFactor(ctx context.Context, request *authdto.VerifySecondFactorRequest) (*authdto.VerifySecondFactorResponse, error)
+	Logout(ctx context.Context, request *authdto.LogoutRequest) error
+	Check(ctx context.Context, request *authdto.CheckRequest) (*authdto.CheckResponse, error)
+	ListSessions(ctx context.Context, request *authdto.ListSessionsRequest) (*authdto.ListSessionsResponse, error)
+	RevokeSession(ctx context.Context, request *authdto.RevokeSessionRequest) error
+	ExternalLogin(ctx context.Context, request *authdto.ExternalLoginRequest) (*authdto.ExternalLoginResponse, error)
 }
 
 type AuthServiceImpl struct {
-	log *logrus.Entry
-	db  *authdb.Repository
+	log    *logrus.Entry
+	db     *authdb.Repository
+	mailer authmailer.Mailer
 }
 
 func (svc *AuthServiceImpl) LoginUser(ctx context.Context, request *authdto.LoginUserRequest) (*authdto.LoginUserResponse, error) {
+	scopes := loginScopes(request.Email, request.Client.IP)
+	if err := checkLoginAllowed(ctx, svc.log, svc.db, scopes); err != nil {
+		return nil, err
+	}
+
 	user, err := svc.db.AuthRepo.GetUserByEmail(ctx, request.Email)
 	if err != nil {
-		svc.log.Errorf("GetUserByEmail error: %s", err)
+		logger(ctx, svc.log).Errorf("GetUserByEmail error: %s", err)
+		if isNotFound(err) {
+			registerLoginFailure(ctx, svc.log, svc.db, scopes)
+		}
 		return nil, err
 	}
 
 	if err := user.Password.Validate(request.Password); err != nil {
-		svc.log.Errorf("Validate error: %s", err)
+		logger(ctx, svc.log).Errorf("Validate error: %s", err)
+		registerLoginFailure(ctx, svc.log, svc.db, scopes)
+		return nil, err
+	}
+
+	svc.upgradePassword(ctx, user, request.Password)
+
+	twoFactor, err := svc.db.TwoFactorRepo.GetTwoFactor(ctx, user.ID)
+	if err != nil && !isNotFound(err) {
+		logger(ctx, svc.log).Errorf("GetTwoFactor error: %s", err)
+		return nil, err
+	}
+	// The failures are forgotten once the second factor is verified too, the wrong codes are counted with them.
+	if err == nil && twoFactor.Enabled {
+		ticket, err := svc.issueLoginTicket(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &authdto.LoginUserResponse{TwoFactorTicket: ticket}, nil
+	}
+	resetLoginFailures(ctx, svc.log, svc.db, scopes)
+
+	if err := svc.cancelAccountDeletion(ctx, user); err != nil {
 		return nil, err
 	}
 
 	// AUTH
-	authToken, err := authutils.GenerateAuthToken(&authutils.AuthTokenWrapper{UserID: user.ID})
+	authToken, refreshToken, err := svc.startSession(ctx, user.ID, user.EmailUnverified, &request.Client)
 	if err != nil {
-		svc.log.Errorf("GenerateAuthToken error: %s", err)
 		return nil, err
 	}
 
-	return &authdto.LoginUserResponse{AuthToken: authToken, UserID: user.ID}, nil
+	return &authdto.LoginUserResponse{AuthToken: authToken, RefreshToken: refreshToken, UserID: user.ID}, nil
 }
 
+// SignupUser creates the account with an unverified email and sends the verification token to it.
 func (svc *AuthServiceImpl) SignupUser(ctx context.Context, request *authdto.SignupUserRequest) (*authdto.SignupUserResponse, error) {
+	if err := validate.Struct(request); err != nil {
+		logger(ctx, svc.log).Errorf("Struct error: %s", err)
+		return nil, authconstants.ErrValidateRequest
+	}
+
 	if exists, err := svc.db.AuthRepo.CheckUserEmailExistence(ctx, request.Email); err != nil {
 		return nil, err
 	} else if exists {
-		svc.log.Errorf("CheckUserEmailExistence error: %s", authconstants.ErrEmailAlreadyTaken)
-		return nil, status.Error(codes.Internal, authconstants.ErrEmailAlreadyTaken.Error())
+		logger(ctx, svc.log).Errorf("CheckUserEmailExistence error: %s", authconstants.ErrEmailAlreadyTaken)
+		return nil, authconstants.ErrEmailAlreadyTaken
 	}
 	user := &authcore.User{
-		Email: request.Email,
+		Email:           request.Email,
+		EmailUnverified: true,
 	}
 
 	if err := user.Password.Init(request.Password); err != nil {
-		svc.log.Errorf("Init password error: %s", err)
+		logger(ctx, svc.log).Errorf("Init password error: %s", err)
 		return nil, err
 	}
 
 	id, err := svc.db.AuthRepo.CreateUser(ctx, user)
 	if err != nil {
-		svc.log.Errorf("CreateUser error: %s", err)
+		logger(ctx, svc.log).Errorf("CreateUser error: %s", err)
 		return nil, err
 	}
+	user.ID = id
+
+	// The user can ask for another email, so the account is kept even if this one is not sent.
+	if err := sendEmailVerification(ctx, svc.log, svc.db, svc.mailer, user); err != nil {
+		logger(ctx, svc.log).Errorf("sendEmailVerification error: %s", err)
+	}
+
 	// AUTH
-	authToken, err := authutils.GenerateAuthToken(&authutils.AuthTokenWrapper{UserID: user.ID})
+	authToken, refreshToken, err := svc.startSession(ctx, id, true, &request.Client)
+	if err != nil {
+		return nil, err
+	}
+	return &authdto.SignupUserResponse{AuthToken: authToken, RefreshToken: refreshToken, UserID: id}, nil
+}
+
+// VerifySecondFactor exchanges the login ticket and a TOTP or recovery code for the session tokens.
+// The wrong codes are counted by the login protection like the wrong passwords, and by the user as well.
+func (svc *AuthServiceImpl) VerifySecondFactor(ctx context.Context, request *authdto.VerifySecondFactorRequest) (*authdto.VerifySecondFactorResponse, error) {
+	hash := authutils.HashOpaqueToken(request.Ticket)
+
+	ticket, err := svc.db.TwoFactorRepo.UseLoginTicketAttempt(ctx, hash, authconstants.LoginTicketMaxAttempts, time.Now().Unix())
+	if err != nil {
+		if isNotFound(err) {
+			return nil, authconstants.ErrTwoFactorTicketInvalid
+		}
+		logger(ctx, svc.log).Errorf("UseLoginTicketAttempt error: %s", err)
+		return nil, err
+	}
+
+	user, err := svc.db.AuthRepo.GetUserByID(ctx, ticket.UserID)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GetUserByID error: %s", err)
+		return nil, err
+	}
+	scopes := secondFactorScopes(user, request.Client.IP)
+	if err := checkLoginAllowed(ctx, svc.log, svc.db, scopes); err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := svc.db.TwoFactorRepo.GetTwoFactor(ctx, ticket.UserID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, authconstants.ErrTwoFactorTicketInvalid
+		}
+		logger(ctx, svc.log).Errorf("GetTwoFactor error: %s", err)
+		return nil, err
+	}
+	if !twoFactor.Enabled {
+		return nil, authconstants.ErrTwoFactorTicketInvalid
+	}
+
+	if err := checkSecondFactor(ctx, svc.db, twoFactor, request.Code); err != nil {
+		if errors.Is(err, authconstants.ErrTwoFactorCodeInvalid) {
+			registerLoginFailure(ctx, svc.log, svc.db, scopes)
+		}
+		return nil, err
+	}
+	resetLoginFailures(ctx, svc.log, svc.db, scopes)
+
+	if err := svc.db.TwoFactorRepo.DeleteLoginTicket(ctx, hash); err != nil {
+		logger(ctx, svc.log).Errorf("DeleteLoginTicket error: %s", err)
+		return nil, err
+	}
+
+	if err := svc.cancelAccountDeletion(ctx, user); err != nil {
+		return nil, err
+	}
+
+	authToken, refreshToken, err := svc.startSession(ctx, ticket.UserID, user.EmailUnverified, &request.Client)
+	if err != nil {
+		return nil, err
+	}
+	return &authdto.VerifySecondFactorResponse{AuthToken: authToken, RefreshToken: refreshToken, UserID: ticket.UserID}, nil
+}
+
+// Refresh rotates the refresh token of the session and issues a new access token.
+// Presenting an already rotated refresh token revokes the whole session, unless it was rotated
+// just now by a concurrent request of the same client.
+func (svc *AuthServiceImpl) Refresh(ctx context.Context, request *authdto.RefreshRequest) (*authdto.RefreshResponse, error) {
+	hash := authutils.HashOpaqueToken(request.RefreshToken)
+
+	session, err := svc.db.SessionRepo.GetSessionByRefreshHash(ctx, hash)
+	if err != nil {
+		if !isNotFound(err) {
+			logger(ctx, svc.log).Errorf("GetSessionByRefreshHash error: %s", err)
+			return nil, err
+		}
+		return nil, svc.checkReuse(ctx, hash)
+	}
+
+	if session.Revoked {
+		return nil, authconstants.ErrSessionRevoked
+	}
+	if session.ExpiresAt < time.Now().Unix() {
+		return nil, authconstants.ErrRefreshTokenInvalid
+	}
+
+	refreshToken, refreshHash, err := authutils.GenerateOpaqueToken()
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GenerateOpaqueToken error: %s", err)
+		return nil, err
+	}
+
+	rotated, err := svc.db.SessionRepo.RotateRefreshHash(ctx, session.ID, hash, refreshHash, authutils.RefreshTokenExpiration())
+	if err != nil {
+		logger(ctx, svc.log).Errorf("RotateRefreshHash error: %s", err)
+		return nil, err
+	}
+	if !rotated {
+		// Somebody has rotated the same token a moment ago.
+		return nil, svc.checkReuse(ctx, hash)
+	}
+
+	if err := svc.db.SessionRepo.TouchSession(ctx, session.ID, request.Client.IP, time.Now().Unix()); err != nil {
+		logger(ctx, svc.log).Errorf("TouchSession error: %s", err)
+	}
+
+	tw := &authutils.AuthTokenWrapper{UserID: session.UserID, SessionID: session.ID, Unverified: session.Unverified}
+	authToken, err := authutils.GenerateAuthToken(tw)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GenerateAuthToken error: %s", err)
+		return nil, err
+	}
+
+	return &authdto.RefreshResponse{
+		AuthToken:    authToken,
+		RefreshToken: refreshToken,
+		UserID:       session.UserID,
+		SessionID:    session.ID,
+		Unverified:   session.Unverified,
+	}, nil
+}
+
+// Logout revokes the session identified by the refresh token or, if there is none, by the access token.
+func (svc *AuthServiceImpl) Logout(ctx context.Context, request *authdto.LogoutRequest) error {
+	var sessionID string
+	if len(request.RefreshToken) != 0 {
+		session, err := svc.db.SessionRepo.GetSessionByRefreshHash(ctx, authutils.HashOpaqueToken(request.RefreshToken))
+		if err == nil {
+			sessionID = session.ID
+		} else if !isNotFound(err) {
+			logger(ctx, svc.log).Errorf("GetSessionByRefreshHash error: %s", err)
+			return err
+		}
+	}
+
+	if len(sessionID) == 0 && len(request.AuthToken) != 0 {
+		if tw, err := authutils.ParseAuthToken(request.AuthToken); err == nil {
+			sessionID = tw.SessionID
+		}
+	}
+
+	if len(sessionID) == 0 {
+		return nil
+	}
+
+	if err := svc.db.SessionRepo.RevokeSession(ctx, sessionID); err != nil {
+		logger(ctx, svc.log).Errorf("RevokeSession error: %s", err)
+		return err
+	}
+	return nil
+}
+
+// Check validates the access token and makes sure its session has not been revoked.
+func (svc *AuthServiceImpl) Check(ctx context.Context, request *authdto.CheckRequest) (*authdto.CheckResponse, error) {
+	tw, err := authutils.ParseAuthToken(request.AuthToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every login starts a session, a token without one can't be revoked and is never accepted.
+	if len(tw.SessionID) == 0 {
+		return nil, authconstants.ErrSessionRevoked
+	}
+
+	session, err := svc.db.SessionRepo.GetSessionByID(ctx, tw.SessionID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, authconstants.ErrSessionRevoked
+		}
+		logger(ctx, svc.log).Errorf("GetSessionByID error: %s", err)
+		return nil, err
+	}
+	if session.Revoked || session.UserID != tw.UserID {
+		return nil, authconstants.ErrSessionRevoked
+	}
+
+	if now := time.Now().Unix(); now-session.LastSeenAt >= authconstants.SessionTouchPeriod {
+		if err := svc.db.SessionRepo.TouchSession(ctx, session.ID, "", now); err != nil {
+			logger(ctx, svc.log).Errorf("TouchSession error: %s", err)
+		}
+	}
+
+	// The session rather than the token tells if the email is verified, since the token may predate the verification.
+	return &authdto.CheckResponse{UserID: tw.UserID, SessionID: tw.SessionID, Unverified: session.Unverified}, nil
+}
+
+// ListSessions returns active sessions of the user.
+func (svc *AuthServiceImpl) ListSessions(ctx context.Context, request *authdto.ListSessionsRequest) (*authdto.ListSessionsResponse, error) {
+	sessions, err := svc.db.SessionRepo.GetUserSessions(ctx, request.UserID)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GetUserSessions error: %s", err)
+		return nil, err
+	}
+
+	response := &authdto.ListSessionsResponse{Sessions: make([]authdto.SessionInfo, 0, len(sessions))}
+	for _, session := range sessions {
+		response.Sessions = append(response.Sessions, authdto.SessionInfo{
+			ID:         session.ID,
+			Device:     session.Device,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+		})
+	}
+	return response, nil
+}
+
+// RevokeSession signs the user out on the device of the session.
+func (svc *AuthServiceImpl) RevokeSession(ctx context.Context, request *authdto.RevokeSessionRequest) error {
+	session, err := svc.db.SessionRepo.GetSessionByID(ctx, request.SessionID)
+	if err != nil {
+		if isNotFound(err) {
+			return authconstants.ErrSessionNotFound
+		}
+		logger(ctx, svc.log).Errorf("GetSessionByID error: %s", err)
+		return err
+	}
+
+	// Sessions of other users must be indistinguishable from missing ones.
+	if session.UserID != request.UserID {
+		return authconstants.ErrSessionNotFound
+	}
+
+	if err := svc.db.SessionRepo.RevokeSession(ctx, session.ID); err != nil {
+		logger(ctx, svc.log).Errorf("RevokeSession error: %s", err)
+		return err
+	}
+	return nil
+}
+
+// ExternalLogin logs in the user of the external identity. The identity which is not linked yet
+// is linked to the user with the same verified email, or a new passwordless user is signed up for it.
+func (svc *AuthServiceImpl) ExternalLogin(ctx context.Context, request *authdto.ExternalLoginRequest) (*authdto.ExternalLoginResponse, error) {
+	if err := validate.Struct(request); err != nil {
+		logger(ctx, svc.log).Errorf("Struct error: %s", err)
+		return nil, authconstants.ErrValidateRequest
+	}
+
+	var user *authcore.User
+	created := false
+
+	identity, err := svc.db.IdentityRepo.GetIdentity(ctx, request.Identity.Provider, request.Identity.Subject)
+	switch {
+	case err == nil:
+		if user, err = svc.db.AuthRepo.GetUserByID(ctx, identity.UserID); err != nil {
+			logger(ctx, svc.log).Errorf("GetUserByID error: %s", err)
+			return nil, err
+		}
+	case isNotFound(err):
+		if user, created, err = svc.signupExternalUser(ctx, &request.Identity); err != nil {
+			return nil, err
+		}
+	default:
+		logger(ctx, svc.log).Errorf("GetIdentity error: %s", err)
+		return nil, err
+	}
+
+	twoFactor, err := svc.db.TwoFactorRepo.GetTwoFactor(ctx, user.ID)
+	if err != nil && !isNotFound(err) {
+		logger(ctx, svc.log).Errorf("GetTwoFactor error: %s", err)
+		return nil, err
+	}
+	if err == nil && twoFactor.Enabled {
+		ticket, err := svc.issueLoginTicket(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &authdto.ExternalLoginResponse{TwoFactorTicket: ticket, Created: created}, nil
+	}
+
+	if err := svc.cancelAccountDeletion(ctx, user); err != nil {
+		return nil, err
+	}
+
+	authToken, refreshToken, err := svc.startSession(ctx, user.ID, user.EmailUnverified, &request.Client)
 	if err != nil {
-		svc.log.Errorf("GenerateAuthToken error: %s", err)
 		return nil, err
 	}
-	return &authdto.SignupUserResponse{AuthToken: authToken, UserID: id}, nil
+	return &authdto.ExternalLoginResponse{AuthToken: authToken, RefreshToken: refreshToken, UserID: user.ID, Created: created}, nil
+}
+
+// signupExternalUser links the new external identity to the user with the same email or signs up a new user for it.
+// The identity is linked to the existing user only when both sides have verified the email: otherwise whoever
+// registered the email first could take over the account of the other.
+func (svc *AuthServiceImpl) signupExternalUser(ctx context.Context, external *authdto.ExternalIdentity) (*authcore.User, bool, error) {
+	if len(external.Email) != 0 {
+		exists, err := svc.db.AuthRepo.CheckUserEmailExistence(ctx, external.Email)
+		if err != nil {
+			logger(ctx, svc.log).Errorf("CheckUserEmailExistence error: %s", err)
+			return nil, false, err
+		}
+		if exists {
+			if !external.EmailVerified {
+				return nil, false, authconstants.ErrEmailAlreadyTaken
+			}
+			user, err := svc.db.AuthRepo.GetUserByEmail(ctx, external.Email)
+			if err != nil {
+				logger(ctx, svc.log).Errorf("GetUserByEmail error: %s", err)
+				return nil, false, err
+			}
+			if user.EmailUnverified {
+				return nil, false, authconstants.ErrEmailAlreadyTaken
+			}
+			if err := createIdentity(ctx, svc.log, svc.db, user.ID, external); err != nil {
+				return nil, false, err
+			}
+			logger(ctx, svc.log).Infof("%s identity is linked to user %s by email", external.Provider, user.ID)
+			return user, false, nil
+		}
+	}
+
+	user := &authcore.User{
+		Email:           external.Email,
+		EmailUnverified: len(external.Email) != 0 && !external.EmailVerified,
+	}
+	id, err := svc.db.AuthRepo.CreateUser(ctx, user)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("CreateUser error: %s", err)
+		return nil, false, err
+	}
+	user.ID = id
+
+	if err := createIdentity(ctx, svc.log, svc.db, user.ID, external); err != nil {
+		return nil, false, err
+	}
+
+	if user.EmailUnverified {
+		// The user can ask for another email, so the account is kept even if this one is not sent.
+		if err := sendEmailVerification(ctx, svc.log, svc.db, svc.mailer, user); err != nil {
+			logger(ctx, svc.log).Errorf("sendEmailVerification error: %s", err)
+		}
+	}
+	return user, true, nil
+}
+
+// cancelAccountDeletion restores the account which is scheduled for deletion. It is called right before
+// the session is started, so a password without the second factor can't take the deletion back.
+func (svc *AuthServiceImpl) cancelAccountDeletion(ctx context.Context, user *authcore.User) error {
+	if user.Deletion == nil {
+		return nil
+	}
+	if user.Deletion.Purging {
+		return authconstants.ErrAccountDeleted
+	}
+
+	if err := svc.db.AuthRepo.CancelDeletion(ctx, user.ID); err != nil {
+		if isNotFound(err) {
+			// Claimed for purging right after the user was read.
+			return authconstants.ErrAccountDeleted
+		}
+		logger(ctx, svc.log).Errorf("CancelDeletion error: %s", err)
+		return err
+	}
+	logger(ctx, svc.log).Infof("deletion of account %s is cancelled", user.ID)
+	return nil
+}
+
+// upgradePassword re-hashes the just validated password if it is stored with an outdated algorithm or cost.
+// Failure to do it must not prevent the user from logging in.
+func (svc *AuthServiceImpl) upgradePassword(ctx context.Context, user *authcore.User, password string) {
+	if !user.Password.NeedsRehash() {
+		return
+	}
+
+	var upgraded authcore.UserPassword
+	if err := upgraded.Init(password); err != nil {
+		logger(ctx, svc.log).Errorf("Init password error: %s", err)
+		return
+	}
+	if err := svc.db.AuthRepo.UpdatePassword(ctx, user.ID, upgraded); err != nil {
+		logger(ctx, svc.log).Errorf("UpdatePassword error: %s", err)
+		return
+	}
+	logger(ctx, svc.log).Infof("password of user %s is re-hashed with %s", user.ID, upgraded.Algorithm)
+}
+
+// issueLoginTicket creates a short-lived ticket which proves that the user has passed the first factor.
+func (svc *AuthServiceImpl) issueLoginTicket(ctx context.Context, userID string) (string, error) {
+	ticket, hash, err := authutils.GenerateOpaqueToken()
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GenerateOpaqueToken error: %s", err)
+		return "", err
+	}
+
+	loginTicket := &authcore.LoginTicket{Hash: hash, UserID: userID, ExpiresAt: authutils.LoginTicketExpiration()}
+	if err := svc.db.TwoFactorRepo.CreateLoginTicket(ctx, loginTicket); err != nil {
+		logger(ctx, svc.log).Errorf("CreateLoginTicket error: %s", err)
+		return "", err
+	}
+	return ticket, nil
+}
+
+// startSession creates a new session for the user and issues its first pair of tokens.
+// The tokens of the session carry the unverified flag until the user verifies the email.
+func (svc *AuthServiceImpl) startSession(ctx context.Context, userID string, unverified bool, client *authdto.ClientInfo) (string, string, error) {
+	refreshToken, refreshHash, err := authutils.GenerateOpaqueToken()
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GenerateOpaqueToken error: %s", err)
+		return "", "", err
+	}
+
+	session := &authcore.Session{
+		UserID:      userID,
+		RefreshHash: refreshHash,
+		Unverified:  unverified,
+		Device:      client.Device,
+		UserAgent:   client.UserAgent,
+		IP:          client.IP,
+		ExpiresAt:   authutils.RefreshTokenExpiration(),
+	}
+	if err := svc.db.SessionRepo.CreateSession(ctx, session); err != nil {
+		logger(ctx, svc.log).Errorf("CreateSession error: %s", err)
+		return "", "", err
+	}
+
+	authToken, err := authutils.GenerateAuthToken(&authutils.AuthTokenWrapper{UserID: userID, SessionID: session.ID, Unverified: unverified})
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GenerateAuthToken error: %s", err)
+		return "", "", err
+	}
+	return authToken, refreshToken, nil
+}
+
+// checkReuse is called for refresh token which is not the current one of any session.
+// The token rotated last within the grace period is only rejected: the client has sent it
+// with several requests at once and keeps the one issued to the first of them.
+// If the token was issued and rotated before, the token family is compromised and gets revoked.
+func (svc *AuthServiceImpl) checkReuse(ctx context.Context, hash string) error {
+	session, err := svc.db.SessionRepo.GetSessionByUsedHash(ctx, hash)
+	if err != nil {
+		if isNotFound(err) {
+			return authconstants.ErrRefreshTokenInvalid
+		}
+		logger(ctx, svc.log).Errorf("GetSessionByUsedHash error: %s", err)
+		return err
+	}
+
+	grace := configSeconds(authconstants.ViperRefreshReuseGraceKey, authconstants.DefaultRefreshReuseGrace)
+	if last := len(session.UsedHashes) - 1; last >= 0 && session.UsedHashes[last] == hash &&
+		session.RotatedAt >= time.Now().Unix()-grace {
+		return authconstants.ErrRefreshTokenInvalid
+	}
+
+	logger(ctx, svc.log).Warnf("refresh token reuse detected, revoking session %s of user %s", session.ID, session.UserID)
+	if err := svc.db.SessionRepo.RevokeSession(ctx, session.ID); err != nil {
+		logger(ctx, svc.log).Errorf("RevokeSession error: %s", err)
+		return err
+	}
+	return authconstants.ErrRefreshTokenReused
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, authconstants.ErrDBNotFound)
+}
+
+// logger is the log entry of the call which the service is used in, see controller.RequestIDInterceptor.
+func logger(ctx context.Context, log *logrus.Entry) *logrus.Entry {
+	return authutils.Logger(ctx, log)
 }
 
-func NewAuthService(log *logrus.Entry, db *authdb.Repository) AuthService {
-	return &AuthServiceImpl{log: log, db: db}
+func NewAuthService(log *logrus.Entry, db *authdb.Repository, mailer authmailer.Mailer) AuthService {
+	return &AuthServiceImpl{log: log, db: db, mailer: mailer}
 }
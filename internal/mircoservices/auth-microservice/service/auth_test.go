@@ -2,12 +2,19 @@ package auth_service
 
 import (
 	"context"
+	"encoding/base64"
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	mock_auth_db "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/mocks"
+	auth_common "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/common"
 	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
 	authdto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/status"
 	"testing"
+	"time"
 )
 
 func TestLoginUser(t *testing.T) {
@@ -15,7 +22,7 @@ func TestLoginUser(t *testing.T) {
 	defer ctrl.Finish()
 
 	TestBD, testRepo := TestRepositories(t, ctrl)
-	dbUserImpl := NewAuthService(TestLogger(t), TestBD)
+	dbUserImpl := NewAuthService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
 
 	ctx := context.Background()
 
@@ -56,6 +63,7 @@ func TestLoginUser(t *testing.T) {
 		},
 	}
 	gomock.InOrder(
+		testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "email:email@e").Return(nil, auth_constants.ErrDBNotFound),
 		testRepo.mockUserR.EXPECT().GetUserByEmail(ctx, tests[0].inputGetUserByEmail.email).Return(tests[0].outputGetUserByEmail.authUser, tests[0].outputGetUserByEmail.err),
 	)
 
@@ -73,12 +81,47 @@ func TestLoginUser(t *testing.T) {
 	}
 }
 
+func TestLoginUserUpgradesLegacyPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	dbUserImpl := NewAuthService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
+
+	ctx := context.Background()
+
+	salt, _ := auth_common.GetSalt()
+	hash, _ := auth_common.GetHash512("1234", salt)
+	user := &auth_core.User{ID: "1", Email: "email@e", Password: auth_core.UserPassword{
+		Hash: base64.URLEncoding.EncodeToString(hash),
+		Salt: base64.URLEncoding.EncodeToString(salt),
+	}}
+
+	gomock.InOrder(
+		testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "email:email@e").Return(nil, auth_constants.ErrDBNotFound),
+		testRepo.mockUserR.EXPECT().GetUserByEmail(ctx, user.Email).Return(user, nil),
+		testRepo.mockUserR.EXPECT().UpdatePassword(ctx, user.ID, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, password auth_core.UserPassword) error {
+				assert.Equal(t, auth_constants.PasswordAlgorithmArgon2id, password.Algorithm)
+				assert.Nil(t, password.Validate("1234"))
+				return nil
+			}),
+		testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, user.ID).Return(nil, auth_constants.ErrDBNotFound),
+		testRepo.mockLoginAttemptR.EXPECT().ResetLoginAttempts(ctx, "email:email@e").Return(nil),
+		testRepo.mockSessionR.EXPECT().CreateSession(ctx, gomock.Any()).Return(nil),
+	)
+
+	res, err := AuthService.LoginUser(dbUserImpl, ctx, &authdto.LoginUserRequest{Email: user.Email, Password: "1234"})
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, res.UserID)
+}
+
 func TestSignupUser(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	TestBD, testRepo := TestRepositories(t, ctrl)
-	dbUserImpl := NewAuthService(TestLogger(t), TestBD)
+	dbUserImpl := NewAuthService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
 
 	ctx := context.Background()
 
@@ -118,10 +161,10 @@ func TestSignupUser(t *testing.T) {
 		{
 			name: "GetUserByEmail error",
 			input: Input{info: &authdto.SignupUserRequest{
-				Email:    "email@e",
+				Email:    "email@example.com",
 				Password: "1234"}},
 			inputCheckUserEmailExistence: InputCheckUserEmailExistence{
-				email: "email@e",
+				email: "email@example.com",
 			},
 			outputCheckUserEmailExistence: OutputCheckUserEmailExistence{res: false,
 				err: err},
@@ -145,3 +188,269 @@ func TestSignupUser(t *testing.T) {
 		})
 	}
 }
+
+func TestRefresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	dbUserImpl := NewAuthService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
+
+	ctx := context.Background()
+	token := "refresh"
+	hash := auth_utils.HashOpaqueToken(token)
+	notFound := auth_constants.ErrDBNotFound
+	session := &auth_core.Session{ID: "1", UserID: "2", RefreshHash: "hash", ExpiresAt: time.Now().Unix() + 100}
+	// The session has rotated the token a moment ago.
+	rotated := &auth_core.Session{ID: "1", UserID: "2", RefreshHash: "next", UsedHashes: []string{"old", hash}, RotatedAt: time.Now().Unix()}
+
+	tests := []struct {
+		name    string
+		prepare func()
+		err     error
+	}{
+		{
+			name: "Unknown token",
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockSessionR.EXPECT().GetSessionByRefreshHash(ctx, hash).Return(nil, notFound),
+					testRepo.mockSessionR.EXPECT().GetSessionByUsedHash(ctx, hash).Return(nil, notFound),
+				)
+			},
+			err: auth_constants.ErrRefreshTokenInvalid,
+		},
+		{
+			name: "Reuse of rotated token",
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockSessionR.EXPECT().GetSessionByRefreshHash(ctx, hash).Return(nil, notFound),
+					testRepo.mockSessionR.EXPECT().GetSessionByUsedHash(ctx, hash).Return(session, nil),
+					testRepo.mockSessionR.EXPECT().RevokeSession(ctx, session.ID).Return(nil),
+				)
+			},
+			err: auth_constants.ErrRefreshTokenReused,
+		},
+		{
+			name: "Revoked session",
+			prepare: func() {
+				testRepo.mockSessionR.EXPECT().GetSessionByRefreshHash(ctx, hash).Return(&auth_core.Session{ID: "1", Revoked: true}, nil)
+			},
+			err: auth_constants.ErrSessionRevoked,
+		},
+		{
+			name: "Concurrent rotation",
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockSessionR.EXPECT().GetSessionByRefreshHash(ctx, hash).Return(session, nil),
+					testRepo.mockSessionR.EXPECT().RotateRefreshHash(ctx, session.ID, hash, gomock.Any(), gomock.Any()).Return(false, nil),
+					testRepo.mockSessionR.EXPECT().GetSessionByUsedHash(ctx, hash).Return(rotated, nil),
+				)
+			},
+			err: auth_constants.ErrRefreshTokenInvalid,
+		},
+		{
+			name: "Just rotated token",
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockSessionR.EXPECT().GetSessionByRefreshHash(ctx, hash).Return(nil, notFound),
+					testRepo.mockSessionR.EXPECT().GetSessionByUsedHash(ctx, hash).Return(rotated, nil),
+				)
+			},
+			err: auth_constants.ErrRefreshTokenInvalid,
+		},
+		{
+			name: "Reuse after the grace period",
+			prepare: func() {
+				stale := *rotated
+				stale.RotatedAt = time.Now().Add(-time.Minute).Unix()
+				gomock.InOrder(
+					testRepo.mockSessionR.EXPECT().GetSessionByRefreshHash(ctx, hash).Return(nil, notFound),
+					testRepo.mockSessionR.EXPECT().GetSessionByUsedHash(ctx, hash).Return(&stale, nil),
+					testRepo.mockSessionR.EXPECT().RevokeSession(ctx, stale.ID).Return(nil),
+				)
+			},
+			err: auth_constants.ErrRefreshTokenReused,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.prepare()
+			res, errRes := AuthService.Refresh(dbUserImpl, ctx, &authdto.RefreshRequest{RefreshToken: token})
+			assert.Nil(t, res)
+			if !assert.Equal(t, test.err.Error(), status.Convert(errRes).Message()) {
+				t.Error("got : ", errRes, " expected :", test.err)
+			}
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	dbUserImpl := NewAuthService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
+
+	ctx := context.Background()
+	token := "refresh"
+
+	gomock.InOrder(
+		testRepo.mockSessionR.EXPECT().GetSessionByRefreshHash(ctx, auth_utils.HashOpaqueToken(token)).Return(&auth_core.Session{ID: "1"}, nil),
+		testRepo.mockSessionR.EXPECT().RevokeSession(ctx, "1").Return(nil),
+	)
+
+	t.Run("Revoke by refresh token", func(t *testing.T) {
+		errRes := AuthService.Logout(dbUserImpl, ctx, &authdto.LogoutRequest{RefreshToken: token})
+		assert.Nil(t, errRes)
+	})
+
+	t.Run("Nothing to revoke", func(t *testing.T) {
+		errRes := AuthService.Logout(dbUserImpl, ctx, &authdto.LogoutRequest{})
+		assert.Nil(t, errRes)
+	})
+}
+
+func TestCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	dbUserImpl := NewAuthService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
+
+	ctx := context.Background()
+	notFound := auth_constants.ErrDBNotFound
+
+	token, err := auth_utils.GenerateAuthToken(&auth_utils.AuthTokenWrapper{UserID: "2", SessionID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		prepare func()
+		res     *authdto.CheckResponse
+		err     error
+	}{
+		{
+			name: "Active session",
+			prepare: func() {
+				testRepo.mockSessionR.EXPECT().GetSessionByID(ctx, "1").Return(&auth_core.Session{ID: "1", UserID: "2", LastSeenAt: time.Now().Unix()}, nil)
+			},
+			res: &authdto.CheckResponse{UserID: "2", SessionID: "1"},
+		},
+		{
+			name: "Stale last activity",
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockSessionR.EXPECT().GetSessionByID(ctx, "1").Return(&auth_core.Session{ID: "1", UserID: "2"}, nil),
+					testRepo.mockSessionR.EXPECT().TouchSession(ctx, "1", "", gomock.Any()).Return(nil),
+				)
+			},
+			res: &authdto.CheckResponse{UserID: "2", SessionID: "1"},
+		},
+		{
+			name: "Revoked session",
+			prepare: func() {
+				testRepo.mockSessionR.EXPECT().GetSessionByID(ctx, "1").Return(&auth_core.Session{ID: "1", UserID: "2", Revoked: true}, nil)
+			},
+			err: auth_constants.ErrSessionRevoked,
+		},
+		{
+			name: "Deleted session",
+			prepare: func() {
+				testRepo.mockSessionR.EXPECT().GetSessionByID(ctx, "1").Return(nil, notFound)
+			},
+			err: auth_constants.ErrSessionRevoked,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.prepare()
+			res, errRes := AuthService.Check(dbUserImpl, ctx, &authdto.CheckRequest{AuthToken: token})
+			assert.Equal(t, test.res, res)
+			if test.err == nil {
+				assert.Nil(t, errRes)
+			} else if !assert.Equal(t, test.err.Error(), status.Convert(errRes).Message()) {
+				t.Error("got : ", errRes, " expected :", test.err)
+			}
+		})
+	}
+
+	t.Run("Token without session", func(t *testing.T) {
+		token, err := auth_utils.GenerateAuthToken(&auth_utils.AuthTokenWrapper{UserID: "2"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, errRes := AuthService.Check(dbUserImpl, ctx, &authdto.CheckRequest{AuthToken: token})
+		assert.Nil(t, res)
+		assert.Equal(t, auth_constants.ErrSessionRevoked.Error(), status.Convert(errRes).Message())
+	})
+}
+
+func TestListSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	dbUserImpl := NewAuthService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
+
+	ctx := context.Background()
+	sessions := []auth_core.Session{
+		{ID: "1", UserID: "2", RefreshHash: "hash", Device: "Chrome on Android", IP: "127.0.0.1", CreatedAt: 10, LastSeenAt: 20},
+	}
+
+	testRepo.mockSessionR.EXPECT().GetUserSessions(ctx, "2").Return(sessions, nil)
+
+	res, err := AuthService.ListSessions(dbUserImpl, ctx, &authdto.ListSessionsRequest{UserID: "2"})
+	assert.Nil(t, err)
+	assert.Equal(t, &authdto.ListSessionsResponse{Sessions: []authdto.SessionInfo{
+		{ID: "1", Device: "Chrome on Android", IP: "127.0.0.1", CreatedAt: 10, LastSeenAt: 20},
+	}}, res)
+}
+
+func TestRevokeSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	dbUserImpl := NewAuthService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		prepare func()
+		err     error
+	}{
+		{
+			name: "Own session",
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockSessionR.EXPECT().GetSessionByID(ctx, "1").Return(&auth_core.Session{ID: "1", UserID: "2"}, nil),
+					testRepo.mockSessionR.EXPECT().RevokeSession(ctx, "1").Return(nil),
+				)
+			},
+		},
+		{
+			name: "Session of other user",
+			prepare: func() {
+				testRepo.mockSessionR.EXPECT().GetSessionByID(ctx, "1").Return(&auth_core.Session{ID: "1", UserID: "3"}, nil)
+			},
+			err: auth_constants.ErrSessionNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.prepare()
+			errRes := AuthService.RevokeSession(dbUserImpl, ctx, &authdto.RevokeSessionRequest{UserID: "2", SessionID: "1"})
+			if test.err == nil {
+				assert.Nil(t, errRes)
+			} else if !assert.Equal(t, test.err.Error(), status.Convert(errRes).Message()) {
+				t.Error("got : ", errRes, " expected :", test.err)
+			}
+		})
+	}
+}
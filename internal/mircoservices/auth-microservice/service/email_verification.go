@@ -0,0 +1,130 @@
+package auth_service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	authconstants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	authdb "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/db"
+	authmailer "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/mailer"
+	authcore "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	authdto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
+	authutils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type EmailVerificationService interface {
+	VerifyEmail(ctx context.Context, request *authdto.VerifyEmailRequest) (*authdto.VerifyEmailResponse, error)
+	ResendVerification(ctx context.Context, request *authdto.ResendVerificationRequest) error
+}
+
+type emailVerificationServiceImpl struct {
+	log    *logrus.Entry
+	db     *authdb.Repository
+	mailer authmailer.Mailer
+}
+
+// VerifyEmail confirms the email with the token from the verification email
+// and lifts the restrictions of the unverified email from the user's sessions.
+func (svc *emailVerificationServiceImpl) VerifyEmail(ctx context.Context, request *authdto.VerifyEmailRequest) (*authdto.VerifyEmailResponse, error) {
+	verification, err := svc.db.EmailVerificationRepo.ConsumeEmailVerification(ctx, authutils.HashOpaqueToken(request.Token))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, authconstants.ErrVerificationTokenInvalid
+		}
+		logger(ctx, svc.log).Errorf("ConsumeEmailVerification error: %s", err)
+		return nil, err
+	}
+
+	if verification.ExpiresAt < time.Now().Unix() {
+		return nil, authconstants.ErrVerificationTokenInvalid
+	}
+
+	if err := svc.db.AuthRepo.MarkEmailVerified(ctx, verification.UserID); err != nil {
+		if isNotFound(err) {
+			return nil, authconstants.ErrVerificationTokenInvalid
+		}
+		logger(ctx, svc.log).Errorf("MarkEmailVerified error: %s", err)
+		return nil, err
+	}
+
+	if err := svc.db.EmailVerificationRepo.DeleteUserEmailVerifications(ctx, verification.UserID); err != nil {
+		logger(ctx, svc.log).Errorf("DeleteUserEmailVerifications error: %s", err)
+		return nil, err
+	}
+
+	if err := svc.db.SessionRepo.MarkUserSessionsVerified(ctx, verification.UserID); err != nil {
+		logger(ctx, svc.log).Errorf("MarkUserSessionsVerified error: %s", err)
+		return nil, err
+	}
+
+	logger(ctx, svc.log).Infof("email of user %s is verified", verification.UserID)
+	return &authdto.VerifyEmailResponse{UserID: verification.UserID}, nil
+}
+
+// ResendVerification sends a new verification token to the user's email.
+// Emails are sent at most once per cooldown period.
+func (svc *emailVerificationServiceImpl) ResendVerification(ctx context.Context, request *authdto.ResendVerificationRequest) error {
+	user, err := svc.db.AuthRepo.GetUserByID(ctx, request.UserID)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GetUserByID error: %s", err)
+		return err
+	}
+	if !user.EmailUnverified {
+		return authconstants.ErrEmailAlreadyVerified
+	}
+
+	last, err := svc.db.EmailVerificationRepo.GetLastEmailVerification(ctx, user.ID)
+	if err != nil && !isNotFound(err) {
+		logger(ctx, svc.log).Errorf("GetLastEmailVerification error: %s", err)
+		return err
+	}
+	cooldown := configSeconds(authconstants.ViperEmailVerificationResendCooldownKey, authconstants.DefaultEmailVerificationResendCooldown)
+	if err == nil && time.Now().Unix()-last.CreatedAt < cooldown {
+		return authconstants.ErrVerificationResendTooSoon
+	}
+
+	return sendEmailVerification(ctx, svc.log, svc.db, svc.mailer, user)
+}
+
+// sendEmailVerification replaces the user's verification tokens with a new one and emails it.
+func sendEmailVerification(ctx context.Context, log *logrus.Entry, db *authdb.Repository, mailer authmailer.Mailer, user *authcore.User) error {
+	// Only the latest token is valid.
+	if err := db.EmailVerificationRepo.DeleteUserEmailVerifications(ctx, user.ID); err != nil {
+		logger(ctx, log).Errorf("DeleteUserEmailVerifications error: %s", err)
+		return err
+	}
+
+	token, hash, err := authutils.GenerateOpaqueToken()
+	if err != nil {
+		logger(ctx, log).Errorf("GenerateOpaqueToken error: %s", err)
+		return err
+	}
+
+	verification := &authcore.EmailVerification{Hash: hash, UserID: user.ID, ExpiresAt: authutils.EmailVerificationExpiration()}
+	if err := db.EmailVerificationRepo.CreateEmailVerification(ctx, verification); err != nil {
+		logger(ctx, log).Errorf("CreateEmailVerification error: %s", err)
+		return err
+	}
+
+	link := viper.GetString(authconstants.ViperEmailVerificationURLKey) + "?token=" + url.QueryEscape(token)
+	msg := &authmailer.Message{
+		To:      user.Email,
+		Subject: "Email verification",
+		Body:    fmt.Sprintf("Follow the link to confirm your email: %s\nIf you didn't sign up, just ignore this email.", link),
+	}
+	if err := mailer.Send(ctx, msg); err != nil {
+		logger(ctx, log).Errorf("Send error: %s", err)
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
+
+func NewEmailVerificationService(log *logrus.Entry, db *authdb.Repository, mailer authmailer.Mailer) EmailVerificationService {
+	return &emailVerificationServiceImpl{log: log, db: db, mailer: mailer}
+}
@@ -0,0 +1,202 @@
+package auth_service
+
+import (
+	"context"
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	auth_mailer "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/mailer"
+	mock_auth_db "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/mocks"
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	authdto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/status"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignupUserSendsVerification(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	mailer := mock_auth_db.NewMockMailer(ctrl)
+	authImpl := NewAuthService(TestLogger(t), TestBD, mailer)
+
+	ctx := context.Background()
+	request := &authdto.SignupUserRequest{Email: "mail@example.com", Password: "1234"}
+
+	t.Run("Invalid email", func(t *testing.T) {
+		res, err := AuthService.SignupUser(authImpl, ctx, &authdto.SignupUserRequest{Email: "not an email", Password: "1234"})
+		assert.Nil(t, res)
+		assert.Equal(t, auth_constants.ErrValidateRequest, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var verification *auth_core.EmailVerification
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().CheckUserEmailExistence(ctx, request.Email).Return(false, nil),
+			testRepo.mockUserR.EXPECT().CreateUser(ctx, gomock.Any()).DoAndReturn(
+				func(_ context.Context, user *auth_core.User) (string, error) {
+					assert.True(t, user.EmailUnverified)
+					return "1", nil
+				}),
+			testRepo.mockEmailVerificationR.EXPECT().DeleteUserEmailVerifications(ctx, "1").Return(nil),
+			testRepo.mockEmailVerificationR.EXPECT().CreateEmailVerification(ctx, gomock.Any()).DoAndReturn(
+				func(_ context.Context, v *auth_core.EmailVerification) error {
+					verification = v
+					return nil
+				}),
+			mailer.EXPECT().Send(ctx, gomock.Any()).DoAndReturn(
+				func(_ context.Context, msg *auth_mailer.Message) error {
+					i := strings.Index(msg.Body, "token=")
+					assert.NotEqual(t, -1, i)
+					token := strings.Fields(msg.Body[i+len("token="):])[0]
+					assert.Equal(t, verification.Hash, auth_utils.HashOpaqueToken(token))
+					assert.Equal(t, request.Email, msg.To)
+					return nil
+				}),
+			testRepo.mockSessionR.EXPECT().CreateSession(ctx, gomock.Any()).DoAndReturn(
+				func(_ context.Context, session *auth_core.Session) error {
+					assert.True(t, session.Unverified)
+					return nil
+				}),
+		)
+
+		res, err := AuthService.SignupUser(authImpl, ctx, request)
+		assert.Nil(t, err)
+		assert.Equal(t, "1", res.UserID)
+		assert.Equal(t, "1", verification.UserID)
+	})
+
+	t.Run("Mailer failure", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().CheckUserEmailExistence(ctx, request.Email).Return(false, nil),
+			testRepo.mockUserR.EXPECT().CreateUser(ctx, gomock.Any()).Return("1", nil),
+			testRepo.mockEmailVerificationR.EXPECT().DeleteUserEmailVerifications(ctx, "1").Return(nil),
+			testRepo.mockEmailVerificationR.EXPECT().CreateEmailVerification(ctx, gomock.Any()).Return(nil),
+			mailer.EXPECT().Send(ctx, gomock.Any()).Return(errors.New("smtp is down")),
+			testRepo.mockSessionR.EXPECT().CreateSession(ctx, gomock.Any()).Return(nil),
+		)
+
+		res, err := AuthService.SignupUser(authImpl, ctx, request)
+		assert.Nil(t, err)
+		assert.Equal(t, "1", res.UserID)
+	})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	verificationImpl := NewEmailVerificationService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
+
+	ctx := context.Background()
+	token := "token"
+	hash := auth_utils.HashOpaqueToken(token)
+
+	tests := []struct {
+		name    string
+		prepare func()
+		err     error
+	}{
+		{
+			name: "Used token",
+			prepare: func() {
+				testRepo.mockEmailVerificationR.EXPECT().ConsumeEmailVerification(ctx, hash).Return(nil, auth_constants.ErrDBNotFound)
+			},
+			err: auth_constants.ErrVerificationTokenInvalid,
+		},
+		{
+			name: "Expired token",
+			prepare: func() {
+				testRepo.mockEmailVerificationR.EXPECT().ConsumeEmailVerification(ctx, hash).Return(&auth_core.EmailVerification{Hash: hash, UserID: "1", ExpiresAt: time.Now().Unix() - 1}, nil)
+			},
+			err: auth_constants.ErrVerificationTokenInvalid,
+		},
+		{
+			name: "Success",
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockEmailVerificationR.EXPECT().ConsumeEmailVerification(ctx, hash).Return(&auth_core.EmailVerification{Hash: hash, UserID: "1", ExpiresAt: time.Now().Unix() + 100}, nil),
+					testRepo.mockUserR.EXPECT().MarkEmailVerified(ctx, "1").Return(nil),
+					testRepo.mockEmailVerificationR.EXPECT().DeleteUserEmailVerifications(ctx, "1").Return(nil),
+					testRepo.mockSessionR.EXPECT().MarkUserSessionsVerified(ctx, "1").Return(nil),
+				)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.prepare()
+			res, err := EmailVerificationService.VerifyEmail(verificationImpl, ctx, &authdto.VerifyEmailRequest{Token: token})
+			assert.Equal(t, test.err, err)
+			if test.err == nil {
+				assert.Equal(t, "1", res.UserID)
+			}
+		})
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	mailer := mock_auth_db.NewMockMailer(ctrl)
+	verificationImpl := NewEmailVerificationService(TestLogger(t), TestBD, mailer)
+
+	ctx := context.Background()
+	unverified := &auth_core.User{ID: "1", Email: "mail@example.com", EmailUnverified: true}
+
+	tests := []struct {
+		name    string
+		prepare func()
+		err     error
+	}{
+		{
+			name: "Already verified",
+			prepare: func() {
+				testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(&auth_core.User{ID: "1", Email: "mail@example.com"}, nil)
+			},
+			err: auth_constants.ErrEmailAlreadyVerified,
+		},
+		{
+			name: "Too soon",
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(unverified, nil),
+					testRepo.mockEmailVerificationR.EXPECT().GetLastEmailVerification(ctx, "1").Return(&auth_core.EmailVerification{UserID: "1", CreatedAt: time.Now().Unix()}, nil),
+				)
+			},
+			err: auth_constants.ErrVerificationResendTooSoon,
+		},
+		{
+			name: "Success",
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(unverified, nil),
+					testRepo.mockEmailVerificationR.EXPECT().GetLastEmailVerification(ctx, "1").Return(&auth_core.EmailVerification{UserID: "1", CreatedAt: time.Now().Unix() - 3600}, nil),
+					testRepo.mockEmailVerificationR.EXPECT().DeleteUserEmailVerifications(ctx, "1").Return(nil),
+					testRepo.mockEmailVerificationR.EXPECT().CreateEmailVerification(ctx, gomock.Any()).Return(nil),
+					mailer.EXPECT().Send(ctx, gomock.Any()).Return(nil),
+				)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.prepare()
+			err := EmailVerificationService.ResendVerification(verificationImpl, ctx, &authdto.ResendVerificationRequest{UserID: "1"})
+			assert.Equal(t, test.err, err)
+			if test.err != nil {
+				assert.Equal(t, status.Code(test.err), status.Code(err))
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package auth_service
+
+import (
+	"context"
+
+	authconstants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	authdb "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/db"
+	authcore "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	authdto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
+	"github.com/sirupsen/logrus"
+)
+
+type IdentityService interface {
+	LinkIdentity(ctx context.Context, request *authdto.LinkIdentityRequest) error
+	UnlinkIdentity(ctx context.Context, request *authdto.UnlinkIdentityRequest) error
+	ListIdentities(ctx context.Context, request *authdto.ListIdentitiesRequest) (*authdto.ListIdentitiesResponse, error)
+}
+
+type identityServiceImpl struct {
+	log *logrus.Entry
+	db  *authdb.Repository
+}
+
+// LinkIdentity links the external identity to the user. The user has at most one identity of every provider.
+func (svc *identityServiceImpl) LinkIdentity(ctx context.Context, request *authdto.LinkIdentityRequest) error {
+	if err := validate.Struct(request); err != nil {
+		logger(ctx, svc.log).Errorf("Struct error: %s", err)
+		return authconstants.ErrValidateRequest
+	}
+
+	identities, err := svc.db.IdentityRepo.GetUserIdentities(ctx, request.UserID)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GetUserIdentities error: %s", err)
+		return err
+	}
+	for _, identity := range identities {
+		if identity.Provider == request.Identity.Provider {
+			return authconstants.ErrIdentityAlreadyLinked
+		}
+	}
+
+	return createIdentity(ctx, svc.log, svc.db, request.UserID, &request.Identity)
+}
+
+// UnlinkIdentity removes the identity of the provider from the user
+// unless it is the only way for the user to log in.
+func (svc *identityServiceImpl) UnlinkIdentity(ctx context.Context, request *authdto.UnlinkIdentityRequest) error {
+	user, err := svc.db.AuthRepo.GetUserByID(ctx, request.UserID)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GetUserByID error: %s", err)
+		return err
+	}
+
+	identities, err := svc.db.IdentityRepo.GetUserIdentities(ctx, request.UserID)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GetUserIdentities error: %s", err)
+		return err
+	}
+
+	linked := false
+	for _, identity := range identities {
+		if identity.Provider == request.Provider {
+			linked = true
+		}
+	}
+	if !linked {
+		return authconstants.ErrIdentityNotFound
+	}
+	if !user.Password.IsSet() && len(identities) == 1 {
+		return authconstants.ErrLastLoginMethod
+	}
+
+	if err := svc.db.IdentityRepo.DeleteIdentity(ctx, request.UserID, request.Provider); err != nil {
+		if isNotFound(err) {
+			return authconstants.ErrIdentityNotFound
+		}
+		logger(ctx, svc.log).Errorf("DeleteIdentity error: %s", err)
+		return err
+	}
+	logger(ctx, svc.log).Infof("%s identity is unlinked from user %s", request.Provider, request.UserID)
+	return nil
+}
+
+func (svc *identityServiceImpl) ListIdentities(ctx context.Context, request *authdto.ListIdentitiesRequest) (*authdto.ListIdentitiesResponse, error) {
+	identities, err := svc.db.IdentityRepo.GetUserIdentities(ctx, request.UserID)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GetUserIdentities error: %s", err)
+		return nil, err
+	}
+
+	response := &authdto.ListIdentitiesResponse{Identities: make([]authdto.LinkedIdentity, 0, len(identities))}
+	for _, identity := range identities {
+		response.Identities = append(response.Identities, authdto.LinkedIdentity{
+			Provider: identity.Provider,
+			Email:    identity.Email,
+			LinkedAt: identity.LinkedAt,
+		})
+	}
+	return response, nil
+}
+
+// createIdentity links the external identity to the user.
+func createIdentity(ctx context.Context, log *logrus.Entry, db *authdb.Repository, userID string, external *authdto.ExternalIdentity) error {
+	identity := &authcore.ExternalIdentity{
+		Provider: external.Provider,
+		Subject:  external.Subject,
+		UserID:   userID,
+		Email:    external.Email,
+	}
+	if err := db.IdentityRepo.CreateIdentity(ctx, identity); err != nil {
+		logger(ctx, log).Errorf("CreateIdentity error: %s", err)
+		return err
+	}
+	return nil
+}
+
+func NewIdentityService(log *logrus.Entry, db *authdb.Repository) IdentityService {
+	return &identityServiceImpl{log: log, db: db}
+}
@@ -0,0 +1,198 @@
+package auth_service
+
+import (
+	"context"
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	mock_auth_db "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/mocks"
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	authdto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExternalLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	mailer := mock_auth_db.NewMockMailer(ctrl)
+	authImpl := NewAuthService(TestLogger(t), TestBD, mailer)
+
+	ctx := context.Background()
+	external := authdto.ExternalIdentity{Provider: "google", Subject: "42", Email: "mail@example.com", EmailVerified: true}
+	user := &auth_core.User{ID: "1", Email: "mail@example.com"}
+
+	t.Run("Linked identity", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockIdentityR.EXPECT().GetIdentity(ctx, "google", "42").Return(&auth_core.ExternalIdentity{UserID: "1"}, nil),
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil),
+			testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "1").Return(nil, auth_constants.ErrDBNotFound),
+			testRepo.mockSessionR.EXPECT().CreateSession(ctx, gomock.Any()).Return(nil),
+		)
+
+		res, err := AuthService.ExternalLogin(authImpl, ctx, &authdto.ExternalLoginRequest{Identity: external})
+		assert.Nil(t, err)
+		assert.Equal(t, "1", res.UserID)
+		assert.NotEmpty(t, res.AuthToken)
+		assert.False(t, res.Created)
+	})
+
+	t.Run("Linked by verified email", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockIdentityR.EXPECT().GetIdentity(ctx, "google", "42").Return(nil, auth_constants.ErrDBNotFound),
+			testRepo.mockUserR.EXPECT().CheckUserEmailExistence(ctx, user.Email).Return(true, nil),
+			testRepo.mockUserR.EXPECT().GetUserByEmail(ctx, user.Email).Return(user, nil),
+			testRepo.mockIdentityR.EXPECT().CreateIdentity(ctx, gomock.Any()).DoAndReturn(
+				func(_ context.Context, identity *auth_core.ExternalIdentity) error {
+					assert.Equal(t, "1", identity.UserID)
+					return nil
+				}),
+			testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "1").Return(&auth_core.TwoFactor{UserID: "1", Enabled: true}, nil),
+			testRepo.mockTwoFactorR.EXPECT().CreateLoginTicket(ctx, gomock.Any()).Return(nil),
+		)
+
+		res, err := AuthService.ExternalLogin(authImpl, ctx, &authdto.ExternalLoginRequest{Identity: external})
+		assert.Nil(t, err)
+		assert.NotEmpty(t, res.TwoFactorTicket)
+		assert.Empty(t, res.AuthToken)
+	})
+
+	t.Run("Email is not verified by the provider", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockIdentityR.EXPECT().GetIdentity(ctx, "google", "42").Return(nil, auth_constants.ErrDBNotFound),
+			testRepo.mockUserR.EXPECT().CheckUserEmailExistence(ctx, user.Email).Return(true, nil),
+		)
+
+		unverified := external
+		unverified.EmailVerified = false
+		_, err := AuthService.ExternalLogin(authImpl, ctx, &authdto.ExternalLoginRequest{Identity: unverified})
+		assert.Equal(t, auth_constants.ErrEmailAlreadyTaken, err)
+	})
+
+	t.Run("Email is not verified by the user", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockIdentityR.EXPECT().GetIdentity(ctx, "google", "42").Return(nil, auth_constants.ErrDBNotFound),
+			testRepo.mockUserR.EXPECT().CheckUserEmailExistence(ctx, user.Email).Return(true, nil),
+			testRepo.mockUserR.EXPECT().GetUserByEmail(ctx, user.Email).Return(&auth_core.User{ID: "1", Email: user.Email, EmailUnverified: true}, nil),
+		)
+
+		_, err := AuthService.ExternalLogin(authImpl, ctx, &authdto.ExternalLoginRequest{Identity: external})
+		assert.Equal(t, auth_constants.ErrEmailAlreadyTaken, err)
+	})
+
+	t.Run("New user without email", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockIdentityR.EXPECT().GetIdentity(ctx, "vk", "7").Return(nil, auth_constants.ErrDBNotFound),
+			testRepo.mockUserR.EXPECT().CreateUser(ctx, gomock.Any()).DoAndReturn(
+				func(_ context.Context, user *auth_core.User) (string, error) {
+					assert.False(t, user.EmailUnverified)
+					assert.Empty(t, user.Password.Hash)
+					return "2", nil
+				}),
+			testRepo.mockIdentityR.EXPECT().CreateIdentity(ctx, gomock.Any()).Return(nil),
+			testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "2").Return(nil, auth_constants.ErrDBNotFound),
+			testRepo.mockSessionR.EXPECT().CreateSession(ctx, gomock.Any()).Return(nil),
+		)
+
+		res, err := AuthService.ExternalLogin(authImpl, ctx, &authdto.ExternalLoginRequest{Identity: authdto.ExternalIdentity{Provider: "vk", Subject: "7"}})
+		assert.Nil(t, err)
+		assert.Equal(t, "2", res.UserID)
+		assert.True(t, res.Created)
+	})
+}
+
+func TestLinkIdentity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	identityImpl := NewIdentityService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+	request := &authdto.LinkIdentityRequest{UserID: "1", Identity: authdto.ExternalIdentity{Provider: "google", Subject: "42"}}
+
+	t.Run("Provider is already linked", func(t *testing.T) {
+		testRepo.mockIdentityR.EXPECT().GetUserIdentities(ctx, "1").Return([]auth_core.ExternalIdentity{{Provider: "google", Subject: "43"}}, nil)
+
+		err := IdentityService.LinkIdentity(identityImpl, ctx, request)
+		assert.Equal(t, auth_constants.ErrIdentityAlreadyLinked, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockIdentityR.EXPECT().GetUserIdentities(ctx, "1").Return([]auth_core.ExternalIdentity{{Provider: "vk"}}, nil),
+			testRepo.mockIdentityR.EXPECT().CreateIdentity(ctx, gomock.Any()).Return(nil),
+		)
+
+		err := IdentityService.LinkIdentity(identityImpl, ctx, request)
+		assert.Nil(t, err)
+	})
+}
+
+func TestUnlinkIdentity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	identityImpl := NewIdentityService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+	passwordless := &auth_core.User{ID: "1"}
+	request := &authdto.UnlinkIdentityRequest{UserID: "1", Provider: "google"}
+
+	tests := []struct {
+		name    string
+		prepare func()
+		err     error
+	}{
+		{
+			name: "Not linked",
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(passwordless, nil),
+					testRepo.mockIdentityR.EXPECT().GetUserIdentities(ctx, "1").Return([]auth_core.ExternalIdentity{{Provider: "vk"}}, nil),
+				)
+			},
+			err: auth_constants.ErrIdentityNotFound,
+		},
+		{
+			name: "Last login method",
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(passwordless, nil),
+					testRepo.mockIdentityR.EXPECT().GetUserIdentities(ctx, "1").Return([]auth_core.ExternalIdentity{{Provider: "google"}}, nil),
+				)
+			},
+			err: auth_constants.ErrLastLoginMethod,
+		},
+		{
+			name: "Another identity is left",
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(passwordless, nil),
+					testRepo.mockIdentityR.EXPECT().GetUserIdentities(ctx, "1").Return([]auth_core.ExternalIdentity{{Provider: "google"}, {Provider: "vk"}}, nil),
+					testRepo.mockIdentityR.EXPECT().DeleteIdentity(ctx, "1", "google").Return(nil),
+				)
+			},
+		},
+		{
+			name: "Password is left",
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(&auth_core.User{ID: "1", Password: auth_core.UserPassword{Hash: "hash"}}, nil),
+					testRepo.mockIdentityR.EXPECT().GetUserIdentities(ctx, "1").Return([]auth_core.ExternalIdentity{{Provider: "google"}}, nil),
+					testRepo.mockIdentityR.EXPECT().DeleteIdentity(ctx, "1", "google").Return(nil),
+				)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.prepare()
+			err := IdentityService.UnlinkIdentity(identityImpl, ctx, request)
+			assert.Equal(t, test.err, err)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	authconstants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	authdb "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/db"
+	authcore "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	authmonitoring "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/monitoring"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// loginProtection is a set of thresholds of brute-force protection, durations are in seconds.
+type loginProtection struct {
+	maxFailures map[string]int // by scope
+	delayAfter  int
+	baseDelay   int64
+	maxDelay    int64
+	lockout     int64
+	window      int64
+}
+
+type loginScope struct {
+	name string
+	key  string
+}
+
+func getLoginProtection() loginProtection {
+	return loginProtection{
+		maxFailures: map[string]int{
+			authconstants.LoginScopeEmail: configInt(authconstants.ViperLoginEmailMaxFailuresKey, authconstants.DefaultLoginEmailMaxFailures),
+			authconstants.LoginScopeIP:    configInt(authconstants.ViperLoginIPMaxFailuresKey, authconstants.DefaultLoginIPMaxFailures),
+			authconstants.LoginScopeUser:  configInt(authconstants.ViperLoginUserMaxFailuresKey, authconstants.DefaultLoginUserMaxFailures),
+		},
+		delayAfter: configInt(authconstants.ViperLoginDelayAfterKey, authconstants.DefaultLoginDelayAfter),
+		baseDelay:  configSeconds(authconstants.ViperLoginBaseDelayKey, authconstants.DefaultLoginBaseDelay),
+		maxDelay:   configSeconds(authconstants.ViperLoginMaxDelayKey, authconstants.DefaultLoginMaxDelay),
+		lockout:    configSeconds(authconstants.ViperLoginLockoutKey, authconstants.DefaultLoginLockout),
+		window:     configSeconds(authconstants.ViperLoginWindowKey, authconstants.DefaultLoginWindow),
+	}
+}
+
+func configInt(key string, def int) int {
+	if value := viper.GetInt(key); value > 0 {
+		return value
+	}
+	return def
+}
+
+// configSeconds reads the duration set in the config like "15m" and returns it in seconds.
+func configSeconds(key string, def time.Duration) int64 {
+	value := viper.GetDuration(key)
+	if value <= 0 {
+		value = def
+	}
+	return int64(value / time.Second)
+}
+
+// delay returns how long the next login is blocked after the given number of failures
+// and whether it is a lockout rather than a progressive delay.
+func (p loginProtection) delay(scope string, failures int) (int64, bool) {
+	if failures >= p.maxFailures[scope] {
+		return p.lockout, true
+	}
+	if failures <= p.delayAfter {
+		return 0, false
+	}
+
+	delay := p.baseDelay
+	for i := p.delayAfter + 1; i < failures && delay < p.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	return delay, false
+}
+
+func loginScopes(email, ip string) []loginScope {
+	scopes := []loginScope{{name: authconstants.LoginScopeEmail, key: authconstants.LoginScopeEmail + ":" + strings.ToLower(email)}}
+	if len(ip) != 0 {
+		scopes = append(scopes, loginScope{name: authconstants.LoginScopeIP, key: authconstants.LoginScopeIP + ":" + ip})
+	}
+	return scopes
+}
+
+// secondFactorScopes are the scopes the wrong second factor codes are counted in: the ones of the password
+// login and the user, since whoever knows the password can get any number of login tickets.
+func secondFactorScopes(user *authcore.User, ip string) []loginScope {
+	var scopes []loginScope
+	if len(user.Email) != 0 {
+		scopes = loginScopes(user.Email, ip)
+	} else if len(ip) != 0 {
+		scopes = append(scopes, loginScope{name: authconstants.LoginScopeIP, key: authconstants.LoginScopeIP + ":" + ip})
+	}
+	return append(scopes, userScope(user.ID))
+}
+
+func userScope(userID string) loginScope {
+	return loginScope{name: authconstants.LoginScopeUser, key: authconstants.LoginScopeUser + ":" + userID}
+}
+
+// checkLoginAllowed rejects the login while any of its scopes is delayed or locked out.
+func checkLoginAllowed(ctx context.Context, log *logrus.Entry, db *authdb.Repository, scopes []loginScope) error {
+	now := time.Now().Unix()
+	for _, scope := range scopes {
+		attempt, err := db.LoginAttemptRepo.GetLoginAttempt(ctx, scope.key)
+		if err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			logger(ctx, log).Errorf("GetLoginAttempt error: %s", err)
+			return err
+		}
+		if attempt.LockedUntil > now {
+			authmonitoring.LoginThrottled.WithLabelValues(scope.name).Inc()
+			return authconstants.ErrTooManyLoginAttempts
+		}
+	}
+	return nil
+}
+
+// registerLoginFailure counts the failed login in all its scopes and delays or locks out the next ones.
+func registerLoginFailure(ctx context.Context, log *logrus.Entry, db *authdb.Repository, scopes []loginScope) {
+	protection := getLoginProtection()
+	now := time.Now().Unix()
+	for _, scope := range scopes {
+		attempt, err := db.LoginAttemptRepo.RegisterLoginFailure(ctx, scope.key, now, now-protection.window)
+		if err != nil {
+			logger(ctx, log).Errorf("RegisterLoginFailure error: %s", err)
+			continue
+		}
+
+		delay, lockout := protection.delay(scope.name, attempt.Failures)
+		if delay == 0 {
+			continue
+		}
+		if err := db.LoginAttemptRepo.LockLogin(ctx, scope.key, now+delay); err != nil {
+			logger(ctx, log).Errorf("LockLogin error: %s", err)
+			continue
+		}
+		if lockout {
+			logger(ctx, log).Warnf("login locked out for %d seconds: %s", delay, scope.key)
+			authmonitoring.LoginLockouts.WithLabelValues(scope.name).Inc()
+		}
+	}
+}
+
+// resetLoginFailures forgets failures made with the email and by the user after the successful login.
+// Failures from the IP are kept, so one valid account can't be used to reset them.
+func resetLoginFailures(ctx context.Context, log *logrus.Entry, db *authdb.Repository, scopes []loginScope) {
+	for _, scope := range scopes {
+		if scope.name == authconstants.LoginScopeIP {
+			continue
+		}
+		if err := db.LoginAttemptRepo.ResetLoginAttempts(ctx, scope.key); err != nil {
+			logger(ctx, log).Errorf("ResetLoginAttempts error: %s", err)
+		}
+	}
+}
+
+// checkUserConfirmation runs the check of the password or the second factor code the signed in user
+// confirms a sensitive change with, unless the user is locked out. The wrong ones are counted in the user's
+// scope like the wrong codes of the logins, so that a stolen session can't be used to guess them.
+func checkUserConfirmation(ctx context.Context, log *logrus.Entry, db *authdb.Repository, userID string, check func() error) error {
+	scopes := []loginScope{userScope(userID)}
+	if err := checkLoginAllowed(ctx, log, db, scopes); err != nil {
+		return err
+	}
+	if err := check(); err != nil {
+		if errors.Is(err, authconstants.ErrPasswordMismatch) || errors.Is(err, authconstants.ErrTwoFactorCodeInvalid) {
+			registerLoginFailure(ctx, log, db, scopes)
+		}
+		return err
+	}
+	resetLoginFailures(ctx, log, db, scopes)
+	return nil
+}
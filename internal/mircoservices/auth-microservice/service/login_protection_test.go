@@ -0,0 +1,171 @@
+package auth_service
+
+import (
+	"context"
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	mock_auth_db "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/mocks"
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	authdto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
+	auth_monitoring "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/monitoring"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestLoginProtectionDelay(t *testing.T) {
+	protection := loginProtection{
+		maxFailures: map[string]int{auth_constants.LoginScopeEmail: 10, auth_constants.LoginScopeIP: 100},
+		delayAfter:  3,
+		baseDelay:   1,
+		maxDelay:    8,
+		lockout:     900,
+	}
+
+	tests := []struct {
+		scope    string
+		failures int
+		delay    int64
+		lockout  bool
+	}{
+		{auth_constants.LoginScopeEmail, 3, 0, false},
+		{auth_constants.LoginScopeEmail, 4, 1, false},
+		{auth_constants.LoginScopeEmail, 5, 2, false},
+		{auth_constants.LoginScopeEmail, 6, 4, false},
+		{auth_constants.LoginScopeEmail, 9, 8, false},
+		{auth_constants.LoginScopeEmail, 10, 900, true},
+		{auth_constants.LoginScopeIP, 10, 8, false},
+		{auth_constants.LoginScopeIP, 100, 900, true},
+	}
+
+	for _, test := range tests {
+		delay, lockout := protection.delay(test.scope, test.failures)
+		assert.Equal(t, test.delay, delay, "%s: %d failures", test.scope, test.failures)
+		assert.Equal(t, test.lockout, lockout, "%s: %d failures", test.scope, test.failures)
+	}
+}
+
+func TestLoginUserLocked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	dbUserImpl := NewAuthService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
+
+	ctx := context.Background()
+	notFound := auth_constants.ErrDBNotFound
+	throttled := testutil.ToFloat64(auth_monitoring.LoginThrottled.WithLabelValues(auth_constants.LoginScopeIP))
+
+	gomock.InOrder(
+		testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "email:email@e").Return(nil, notFound),
+		testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "ip:127.0.0.1").Return(&auth_core.LoginAttempt{
+			Key:         "ip:127.0.0.1",
+			Failures:    100,
+			LockedUntil: time.Now().Unix() + 60,
+		}, nil),
+	)
+
+	res, err := AuthService.LoginUser(dbUserImpl, ctx, &authdto.LoginUserRequest{
+		Email:    "email@e",
+		Password: "1234",
+		Client:   authdto.ClientInfo{IP: "127.0.0.1"},
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, auth_constants.ErrTooManyLoginAttempts.Error(), status.Convert(err).Message())
+	assert.Equal(t, throttled+1, testutil.ToFloat64(auth_monitoring.LoginThrottled.WithLabelValues(auth_constants.LoginScopeIP)))
+}
+
+func TestLoginUserLocksOut(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	dbUserImpl := NewAuthService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
+
+	ctx := context.Background()
+	notFound := auth_constants.ErrDBNotFound
+	lockouts := testutil.ToFloat64(auth_monitoring.LoginLockouts.WithLabelValues(auth_constants.LoginScopeEmail))
+
+	user := &auth_core.User{ID: "1", Email: "email@e"}
+	if err := user.Password.Init("1234"); err != nil {
+		t.Fatal(err)
+	}
+
+	gomock.InOrder(
+		testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "email:email@e").Return(nil, notFound),
+		testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "ip:127.0.0.1").Return(nil, notFound),
+		testRepo.mockUserR.EXPECT().GetUserByEmail(ctx, user.Email).Return(user, nil),
+		testRepo.mockLoginAttemptR.EXPECT().RegisterLoginFailure(ctx, "email:email@e", gomock.Any(), gomock.Any()).Return(&auth_core.LoginAttempt{
+			Key:      "email:email@e",
+			Failures: auth_constants.DefaultLoginEmailMaxFailures,
+		}, nil),
+		testRepo.mockLoginAttemptR.EXPECT().LockLogin(ctx, "email:email@e", gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, lockedUntil int64) error {
+				assert.InDelta(t, time.Now().Add(auth_constants.DefaultLoginLockout).Unix(), lockedUntil, 1)
+				return nil
+			}),
+		testRepo.mockLoginAttemptR.EXPECT().RegisterLoginFailure(ctx, "ip:127.0.0.1", gomock.Any(), gomock.Any()).Return(&auth_core.LoginAttempt{
+			Key:      "ip:127.0.0.1",
+			Failures: 1,
+		}, nil),
+	)
+
+	res, err := AuthService.LoginUser(dbUserImpl, ctx, &authdto.LoginUserRequest{
+		Email:    user.Email,
+		Password: "wrong",
+		Client:   authdto.ClientInfo{IP: "127.0.0.1"},
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, auth_constants.ErrPasswordMismatch.Error(), status.Convert(err).Message())
+	assert.Equal(t, lockouts+1, testutil.ToFloat64(auth_monitoring.LoginLockouts.WithLabelValues(auth_constants.LoginScopeEmail)))
+}
+
+func TestVerifySecondFactorLocksOutUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	dbUserImpl := NewAuthService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
+
+	ctx := context.Background()
+	notFound := auth_constants.ErrDBNotFound
+	lockouts := testutil.ToFloat64(auth_monitoring.LoginLockouts.WithLabelValues(auth_constants.LoginScopeUser))
+
+	hash := auth_utils.HashOpaqueToken("ticket")
+	secret, _ := auth_utils.GenerateTOTPSecret()
+
+	// The telegram users have no email, the codes are counted by the IP and the user only.
+	gomock.InOrder(
+		testRepo.mockTwoFactorR.EXPECT().UseLoginTicketAttempt(ctx, hash, auth_constants.LoginTicketMaxAttempts, gomock.Any()).Return(&auth_core.LoginTicket{Hash: hash, UserID: "1"}, nil),
+		testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(&auth_core.User{ID: "1"}, nil),
+		testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "ip:127.0.0.1").Return(nil, notFound),
+		testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "user:1").Return(nil, notFound),
+		testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "1").Return(&auth_core.TwoFactor{UserID: "1", Enabled: true, Secret: secret}, nil),
+		testRepo.mockTwoFactorR.EXPECT().UseRecoveryCode(ctx, "1", auth_utils.HashRecoveryCode("aaaaa-bbbbb")).Return(false, nil),
+		testRepo.mockLoginAttemptR.EXPECT().RegisterLoginFailure(ctx, "ip:127.0.0.1", gomock.Any(), gomock.Any()).Return(&auth_core.LoginAttempt{
+			Key:      "ip:127.0.0.1",
+			Failures: 1,
+		}, nil),
+		testRepo.mockLoginAttemptR.EXPECT().RegisterLoginFailure(ctx, "user:1", gomock.Any(), gomock.Any()).Return(&auth_core.LoginAttempt{
+			Key:      "user:1",
+			Failures: auth_constants.DefaultLoginUserMaxFailures,
+		}, nil),
+		testRepo.mockLoginAttemptR.EXPECT().LockLogin(ctx, "user:1", gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, lockedUntil int64) error {
+				assert.InDelta(t, time.Now().Add(auth_constants.DefaultLoginLockout).Unix(), lockedUntil, 1)
+				return nil
+			}),
+	)
+
+	res, err := AuthService.VerifySecondFactor(dbUserImpl, ctx, &authdto.VerifySecondFactorRequest{
+		Ticket: "ticket",
+		Code:   "aaaaa-bbbbb",
+		Client: authdto.ClientInfo{IP: "127.0.0.1"},
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, auth_constants.ErrTwoFactorCodeInvalid.Error(), status.Convert(err).Message())
+	assert.Equal(t, lockouts+1, testutil.ToFloat64(auth_monitoring.LoginLockouts.WithLabelValues(auth_constants.LoginScopeUser)))
+}
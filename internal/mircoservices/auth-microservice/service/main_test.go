@@ -0,0 +1,26 @@
+package auth_service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/golang-jwt/jwt"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	keyRing, err := auth_utils.NewKeyRing("test", []*auth_utils.SigningKey{
+		{ID: "test", Method: jwt.SigningMethodEdDSA, Private: private, Public: public},
+	})
+	if err != nil {
+		panic(err)
+	}
+	auth_utils.SetKeyRing(keyRing)
+
+	os.Exit(m.Run())
+}
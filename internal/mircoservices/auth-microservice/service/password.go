@@ -0,0 +1,153 @@
+package auth_service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	authconstants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	authdb "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/db"
+	authmailer "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/mailer"
+	authcore "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	authdto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
+	authutils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type PasswordService interface {
+	ChangePassword(ctx context.Context, request *authdto.ChangePasswordRequest) error
+	RequestPasswordReset(ctx context.Context, request *authdto.RequestPasswordResetRequest) error
+	ConfirmPasswordReset(ctx context.Context, request *authdto.ConfirmPasswordResetRequest) error
+}
+
+type passwordServiceImpl struct {
+	log    *logrus.Entry
+	db     *authdb.Repository
+	mailer authmailer.Mailer
+}
+
+// ChangePassword sets a new password of the user and signs out all other sessions.
+// The users without a password, signed up with OAuth or Telegram, set the first one with it.
+func (svc *passwordServiceImpl) ChangePassword(ctx context.Context, request *authdto.ChangePasswordRequest) error {
+	user, err := svc.db.AuthRepo.GetUserByID(ctx, request.UserID)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GetUserByID error: %s", err)
+		return err
+	}
+
+	if err := reauthenticate(ctx, svc.log, svc.db, user, request.SessionID, request.OldPassword, request.Code); err != nil {
+		return err
+	}
+
+	return svc.setPassword(ctx, user.ID, request.NewPassword, request.SessionID)
+}
+
+// RequestPasswordReset sends a one-time password reset token to the email, at most once in the cooldown.
+// Nothing is reported if there is no user with the email, the cooldown isn't over or the email isn't sent,
+// so registered emails can't be enumerated.
+func (svc *passwordServiceImpl) RequestPasswordReset(ctx context.Context, request *authdto.RequestPasswordResetRequest) error {
+	exists, err := svc.db.AuthRepo.CheckUserEmailExistence(ctx, request.Email)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("CheckUserEmailExistence error: %s", err)
+		return err
+	}
+	if !exists {
+		logger(ctx, svc.log).Warnf("password reset is requested for unknown email %s", request.Email)
+		return nil
+	}
+
+	user, err := svc.db.AuthRepo.GetUserByEmail(ctx, request.Email)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GetUserByEmail error: %s", err)
+		return err
+	}
+
+	last, err := svc.db.PasswordResetRepo.GetLastPasswordReset(ctx, user.ID)
+	if err != nil && !isNotFound(err) {
+		logger(ctx, svc.log).Errorf("GetLastPasswordReset error: %s", err)
+		return err
+	}
+	cooldown := configSeconds(authconstants.ViperPasswordResetCooldownKey, authconstants.DefaultPasswordResetCooldown)
+	if err == nil && time.Now().Unix()-last.CreatedAt < cooldown {
+		logger(ctx, svc.log).Warnf("password reset for user %s is requested again too soon", user.ID)
+		return nil
+	}
+
+	// Only the latest token is valid.
+	if err := svc.db.PasswordResetRepo.DeleteUserPasswordResets(ctx, user.ID); err != nil {
+		logger(ctx, svc.log).Errorf("DeleteUserPasswordResets error: %s", err)
+		return err
+	}
+
+	token, hash, err := authutils.GenerateOpaqueToken()
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GenerateOpaqueToken error: %s", err)
+		return err
+	}
+
+	reset := &authcore.PasswordReset{Hash: hash, UserID: user.ID, ExpiresAt: authutils.PasswordResetExpiration()}
+	if err := svc.db.PasswordResetRepo.CreatePasswordReset(ctx, reset); err != nil {
+		logger(ctx, svc.log).Errorf("CreatePasswordReset error: %s", err)
+		return err
+	}
+
+	link := viper.GetString(authconstants.ViperPasswordResetURLKey) + "?token=" + url.QueryEscape(token)
+	msg := &authmailer.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body:    fmt.Sprintf("Follow the link to set a new password: %s\nIf you didn't ask for it, just ignore this email.", link),
+	}
+	if err := svc.mailer.Send(ctx, msg); err != nil {
+		logger(ctx, svc.log).Errorf("Send error: %s", err)
+	}
+	return nil
+}
+
+// ConfirmPasswordReset sets a new password with the token from the email and signs out all sessions.
+func (svc *passwordServiceImpl) ConfirmPasswordReset(ctx context.Context, request *authdto.ConfirmPasswordResetRequest) error {
+	reset, err := svc.db.PasswordResetRepo.ConsumePasswordReset(ctx, authutils.HashOpaqueToken(request.Token))
+	if err != nil {
+		if isNotFound(err) {
+			return authconstants.ErrResetTokenInvalid
+		}
+		logger(ctx, svc.log).Errorf("ConsumePasswordReset error: %s", err)
+		return err
+	}
+
+	if reset.ExpiresAt < time.Now().Unix() {
+		return authconstants.ErrResetTokenInvalid
+	}
+
+	return svc.setPassword(ctx, reset.UserID, request.NewPassword, "")
+}
+
+// setPassword stores the new password and revokes all sessions except the current one.
+func (svc *passwordServiceImpl) setPassword(ctx context.Context, userID string, password string, currentSessionID string) error {
+	var newPassword authcore.UserPassword
+	if err := newPassword.Init(password); err != nil {
+		logger(ctx, svc.log).Errorf("Init password error: %s", err)
+		return err
+	}
+
+	if err := svc.db.AuthRepo.UpdatePassword(ctx, userID, newPassword); err != nil {
+		logger(ctx, svc.log).Errorf("UpdatePassword error: %s", err)
+		return err
+	}
+
+	if err := svc.db.PasswordResetRepo.DeleteUserPasswordResets(ctx, userID); err != nil {
+		logger(ctx, svc.log).Errorf("DeleteUserPasswordResets error: %s", err)
+		return err
+	}
+
+	if err := svc.db.SessionRepo.RevokeUserSessions(ctx, userID, currentSessionID); err != nil {
+		logger(ctx, svc.log).Errorf("RevokeUserSessions error: %s", err)
+		return err
+	}
+	return nil
+}
+
+func NewPasswordService(log *logrus.Entry, db *authdb.Repository, mailer authmailer.Mailer) PasswordService {
+	return &passwordServiceImpl{log: log, db: db, mailer: mailer}
+}
@@ -0,0 +1,262 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	auth_mailer "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/mailer"
+	mock_auth_db "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/mocks"
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	authdto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/status"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	passwordImpl := NewPasswordService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
+
+	ctx := context.Background()
+	user := &auth_core.User{ID: "1", Email: "mail@example.com"}
+	if err := user.Password.Init("old"); err != nil {
+		t.Fatal(err)
+	}
+	// signed up with OAuth or Telegram
+	passwordless := &auth_core.User{ID: "1"}
+
+	tests := []struct {
+		name    string
+		request *authdto.ChangePasswordRequest
+		prepare func()
+		err     error
+	}{
+		{
+			name:    "Wrong old password",
+			request: &authdto.ChangePasswordRequest{UserID: "1", SessionID: "2", OldPassword: "wrong", NewPassword: "new"},
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil),
+					testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "user:1").Return(nil, auth_constants.ErrDBNotFound),
+					testRepo.mockLoginAttemptR.EXPECT().RegisterLoginFailure(ctx, "user:1", gomock.Any(), gomock.Any()).Return(&auth_core.LoginAttempt{Key: "user:1", Failures: 1}, nil),
+				)
+			},
+			err: auth_constants.ErrPasswordMismatch,
+		},
+		{
+			name:    "Success",
+			request: &authdto.ChangePasswordRequest{UserID: "1", SessionID: "2", OldPassword: "old", NewPassword: "new"},
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil),
+					testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "user:1").Return(nil, auth_constants.ErrDBNotFound),
+					testRepo.mockLoginAttemptR.EXPECT().ResetLoginAttempts(ctx, "user:1").Return(nil),
+					testRepo.mockUserR.EXPECT().UpdatePassword(ctx, "1", gomock.Any()).Return(nil),
+					testRepo.mockPasswordResetR.EXPECT().DeleteUserPasswordResets(ctx, "1").Return(nil),
+					testRepo.mockSessionR.EXPECT().RevokeUserSessions(ctx, "1", "2").Return(nil),
+				)
+			},
+		},
+		{
+			name:    "First password with old login",
+			request: &authdto.ChangePasswordRequest{UserID: "1", SessionID: "2", NewPassword: "new"},
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(passwordless, nil),
+					testRepo.mockSessionR.EXPECT().GetSessionByID(ctx, "2").Return(&auth_core.Session{
+						ID:        "2",
+						UserID:    "1",
+						CreatedAt: time.Now().Add(-auth_constants.DefaultReauthMaxAge - time.Minute).Unix(),
+					}, nil),
+				)
+			},
+			err: auth_constants.ErrReauthRequired,
+		},
+		{
+			name:    "First password with recent login",
+			request: &authdto.ChangePasswordRequest{UserID: "1", SessionID: "2", NewPassword: "new"},
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(passwordless, nil),
+					testRepo.mockSessionR.EXPECT().GetSessionByID(ctx, "2").Return(&auth_core.Session{
+						ID:        "2",
+						UserID:    "1",
+						CreatedAt: time.Now().Add(-time.Minute).Unix(),
+					}, nil),
+					testRepo.mockUserR.EXPECT().UpdatePassword(ctx, "1", gomock.Any()).DoAndReturn(
+						func(_ context.Context, _ string, password auth_core.UserPassword) error {
+							assert.Nil(t, password.Validate("new"))
+							return nil
+						}),
+					testRepo.mockPasswordResetR.EXPECT().DeleteUserPasswordResets(ctx, "1").Return(nil),
+					testRepo.mockSessionR.EXPECT().RevokeUserSessions(ctx, "1", "2").Return(nil),
+				)
+			},
+		},
+		{
+			name:    "First password with second factor",
+			request: &authdto.ChangePasswordRequest{UserID: "1", SessionID: "2", NewPassword: "new", Code: "aaaaa-bbbbb"},
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(passwordless, nil),
+					testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "user:1").Return(nil, auth_constants.ErrDBNotFound),
+					testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "1").Return(&auth_core.TwoFactor{UserID: "1", Enabled: true, Secret: "SECRET"}, nil),
+					testRepo.mockTwoFactorR.EXPECT().UseRecoveryCode(ctx, "1", auth_utils.HashRecoveryCode("aaaaa-bbbbb")).Return(true, nil),
+					testRepo.mockLoginAttemptR.EXPECT().ResetLoginAttempts(ctx, "user:1").Return(nil),
+					testRepo.mockUserR.EXPECT().UpdatePassword(ctx, "1", gomock.Any()).Return(nil),
+					testRepo.mockPasswordResetR.EXPECT().DeleteUserPasswordResets(ctx, "1").Return(nil),
+					testRepo.mockSessionR.EXPECT().RevokeUserSessions(ctx, "1", "2").Return(nil),
+				)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.prepare()
+			errRes := PasswordService.ChangePassword(passwordImpl, ctx, test.request)
+			if test.err == nil {
+				assert.Nil(t, errRes)
+			} else if !assert.Equal(t, test.err.Error(), status.Convert(errRes).Message()) {
+				t.Error("got : ", errRes, " expected :", test.err)
+			}
+		})
+	}
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	mailer := mock_auth_db.NewMockMailer(ctrl)
+	passwordImpl := NewPasswordService(TestLogger(t), TestBD, mailer)
+
+	ctx := context.Background()
+	user := &auth_core.User{ID: "1", Email: "mail@example.com"}
+
+	t.Run("Unknown email", func(t *testing.T) {
+		testRepo.mockUserR.EXPECT().CheckUserEmailExistence(ctx, "unknown@example.com").Return(false, nil)
+
+		errRes := PasswordService.RequestPasswordReset(passwordImpl, ctx, &authdto.RequestPasswordResetRequest{Email: "unknown@example.com"})
+		assert.Nil(t, errRes)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var reset *auth_core.PasswordReset
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().CheckUserEmailExistence(ctx, user.Email).Return(true, nil),
+			testRepo.mockUserR.EXPECT().GetUserByEmail(ctx, user.Email).Return(user, nil),
+			testRepo.mockPasswordResetR.EXPECT().GetLastPasswordReset(ctx, user.ID).Return(nil, auth_constants.ErrDBNotFound),
+			testRepo.mockPasswordResetR.EXPECT().DeleteUserPasswordResets(ctx, user.ID).Return(nil),
+			testRepo.mockPasswordResetR.EXPECT().CreatePasswordReset(ctx, gomock.Any()).DoAndReturn(
+				func(_ context.Context, r *auth_core.PasswordReset) error {
+					reset = r
+					return nil
+				}),
+			mailer.EXPECT().Send(ctx, gomock.Any()).DoAndReturn(
+				func(_ context.Context, msg *auth_mailer.Message) error {
+					// The mail carries the token itself, while only its hash is stored.
+					i := strings.Index(msg.Body, "token=")
+					assert.NotEqual(t, -1, i)
+					token := strings.Fields(msg.Body[i+len("token="):])[0]
+					assert.Equal(t, reset.Hash, auth_utils.HashOpaqueToken(token))
+					assert.Equal(t, user.Email, msg.To)
+					return nil
+				}),
+		)
+
+		errRes := PasswordService.RequestPasswordReset(passwordImpl, ctx, &authdto.RequestPasswordResetRequest{Email: user.Email})
+		assert.Nil(t, errRes)
+		assert.Equal(t, user.ID, reset.UserID)
+	})
+
+	t.Run("Requested again too soon", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().CheckUserEmailExistence(ctx, user.Email).Return(true, nil),
+			testRepo.mockUserR.EXPECT().GetUserByEmail(ctx, user.Email).Return(user, nil),
+			testRepo.mockPasswordResetR.EXPECT().GetLastPasswordReset(ctx, user.ID).Return(&auth_core.PasswordReset{UserID: user.ID, CreatedAt: time.Now().Unix()}, nil),
+		)
+
+		errRes := PasswordService.RequestPasswordReset(passwordImpl, ctx, &authdto.RequestPasswordResetRequest{Email: user.Email})
+		assert.Nil(t, errRes, "the cooldown must not tell the email is registered")
+	})
+
+	t.Run("Mailer error", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().CheckUserEmailExistence(ctx, user.Email).Return(true, nil),
+			testRepo.mockUserR.EXPECT().GetUserByEmail(ctx, user.Email).Return(user, nil),
+			testRepo.mockPasswordResetR.EXPECT().GetLastPasswordReset(ctx, user.ID).Return(&auth_core.PasswordReset{UserID: user.ID, CreatedAt: time.Now().Unix() - 3600}, nil),
+			testRepo.mockPasswordResetR.EXPECT().DeleteUserPasswordResets(ctx, user.ID).Return(nil),
+			testRepo.mockPasswordResetR.EXPECT().CreatePasswordReset(ctx, gomock.Any()).Return(nil),
+			mailer.EXPECT().Send(ctx, gomock.Any()).Return(errors.New("smtp is down")),
+		)
+
+		errRes := PasswordService.RequestPasswordReset(passwordImpl, ctx, &authdto.RequestPasswordResetRequest{Email: user.Email})
+		assert.Nil(t, errRes, "the mailer error must not tell the email is registered")
+	})
+}
+
+func TestConfirmPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	passwordImpl := NewPasswordService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
+
+	ctx := context.Background()
+	token := "token"
+	hash := auth_utils.HashOpaqueToken(token)
+	notFound := auth_constants.ErrDBNotFound
+
+	tests := []struct {
+		name    string
+		prepare func()
+		err     error
+	}{
+		{
+			name: "Used token",
+			prepare: func() {
+				testRepo.mockPasswordResetR.EXPECT().ConsumePasswordReset(ctx, hash).Return(nil, notFound)
+			},
+			err: auth_constants.ErrResetTokenInvalid,
+		},
+		{
+			name: "Expired token",
+			prepare: func() {
+				testRepo.mockPasswordResetR.EXPECT().ConsumePasswordReset(ctx, hash).Return(&auth_core.PasswordReset{Hash: hash, UserID: "1", ExpiresAt: time.Now().Unix() - 1}, nil)
+			},
+			err: auth_constants.ErrResetTokenInvalid,
+		},
+		{
+			name: "Success",
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockPasswordResetR.EXPECT().ConsumePasswordReset(ctx, hash).Return(&auth_core.PasswordReset{Hash: hash, UserID: "1", ExpiresAt: time.Now().Unix() + 100}, nil),
+					testRepo.mockUserR.EXPECT().UpdatePassword(ctx, "1", gomock.Any()).Return(nil),
+					testRepo.mockPasswordResetR.EXPECT().DeleteUserPasswordResets(ctx, "1").Return(nil),
+					testRepo.mockSessionR.EXPECT().RevokeUserSessions(ctx, "1", "").Return(nil),
+				)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.prepare()
+			errRes := PasswordService.ConfirmPasswordReset(passwordImpl, ctx, &authdto.ConfirmPasswordResetRequest{Token: token, NewPassword: "new"})
+			if test.err == nil {
+				assert.Nil(t, errRes)
+			} else if !assert.Equal(t, test.err.Error(), status.Convert(errRes).Message()) {
+				t.Error("got : ", errRes, " expected :", test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package auth_service
+
+import (
+	"context"
+	"time"
+
+	authconstants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	authdb "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/db"
+	authcore "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	"github.com/sirupsen/logrus"
+)
+
+// reauthenticate confirms a sensitive change of the account by the user themselves.
+// The users with a password enter it. The users without one, signed up with OAuth or Telegram,
+// enter a second factor code if they have it enabled, or else log in with the provider again,
+// so that the session of the request is a recent one. The wrong passwords and codes count towards the lockout of the user.
+func reauthenticate(ctx context.Context, log *logrus.Entry, db *authdb.Repository, user *authcore.User, sessionID, password, code string) error {
+	if user.Password.IsSet() {
+		return checkUserConfirmation(ctx, log, db, user.ID, func() error {
+			if err := user.Password.Validate(password); err != nil {
+				logger(ctx, log).Errorf("Validate error: %s", err)
+				return err
+			}
+			return nil
+		})
+	}
+
+	if len(code) != 0 {
+		return checkUserConfirmation(ctx, log, db, user.ID, func() error {
+			twoFactor, err := db.TwoFactorRepo.GetTwoFactor(ctx, user.ID)
+			if err != nil && !isNotFound(err) {
+				logger(ctx, log).Errorf("GetTwoFactor error: %s", err)
+				return err
+			}
+			if err != nil || !twoFactor.Enabled {
+				return authconstants.ErrTwoFactorNotEnabled
+			}
+			return checkSecondFactor(ctx, db, twoFactor, code)
+		})
+	}
+
+	if len(sessionID) == 0 {
+		return authconstants.ErrReauthRequired
+	}
+	session, err := db.SessionRepo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		if isNotFound(err) {
+			return authconstants.ErrReauthRequired
+		}
+		logger(ctx, log).Errorf("GetSessionByID error: %s", err)
+		return err
+	}
+
+	maxAge := configSeconds(authconstants.ViperReauthMaxAgeKey, authconstants.DefaultReauthMaxAge)
+	if session.UserID != user.ID || session.Revoked || session.CreatedAt < time.Now().Unix()-maxAge {
+		return authconstants.ErrReauthRequired
+	}
+	return nil
+}
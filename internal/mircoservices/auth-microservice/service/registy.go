@@ -2,16 +2,30 @@ package auth_service
 
 import (
 	authdb "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/db"
+	authmailer "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/mailer"
 	"github.com/sirupsen/logrus"
 )
 
 type Registry struct {
-	AuthService AuthService
+	AuthService      AuthService
+	PasswordService  PasswordService
+	TwoFactorService TwoFactorService
+	AccountService   AccountService
+	IdentityService  IdentityService
+	APITokenService  APITokenService
+
+	EmailVerificationService EmailVerificationService
 }
 
-func NewRegistry(log *logrus.Entry, repository *authdb.Repository) *Registry {
+func NewRegistry(log *logrus.Entry, repository *authdb.Repository, mailer authmailer.Mailer) *Registry {
 	registry := new(Registry)
 
-	registry.AuthService = NewAuthService(log, repository)
+	registry.AuthService = NewAuthService(log, repository, mailer)
+	registry.PasswordService = NewPasswordService(log, repository, mailer)
+	registry.TwoFactorService = NewTwoFactorService(log, repository)
+	registry.AccountService = NewAccountService(log, repository)
+	registry.IdentityService = NewIdentityService(log, repository)
+	registry.APITokenService = NewAPITokenService(log, repository)
+	registry.EmailVerificationService = NewEmailVerificationService(log, repository, mailer)
 	return registry
 }
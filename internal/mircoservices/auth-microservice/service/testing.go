@@ -10,16 +10,41 @@ import (
 
 // TestRepository ...
 type TestRepository struct {
-	mockUserR *mock_auth_db.MockAuthRepository
+	mockUserR          *mock_auth_db.MockAuthRepository
+	mockSessionR       *mock_auth_db.MockSessionRepository
+	mockPasswordResetR *mock_auth_db.MockPasswordResetRepository
+	mockTwoFactorR     *mock_auth_db.MockTwoFactorRepository
+	mockLoginAttemptR  *mock_auth_db.MockLoginAttemptRepository
+
+	mockEmailVerificationR *mock_auth_db.MockEmailVerificationRepository
+	mockIdentityR          *mock_auth_db.MockIdentityRepository
+	mockAPITokenR          *mock_auth_db.MockAPITokenRepository
 }
 
 // TestRepositories ...
 func TestRepositories(t *testing.T, ctrl *gomock.Controller) (*auth_db.Repository, *TestRepository) {
 	MockRepo := &TestRepository{
 		mock_auth_db.NewMockAuthRepository(ctrl),
+		mock_auth_db.NewMockSessionRepository(ctrl),
+		mock_auth_db.NewMockPasswordResetRepository(ctrl),
+		mock_auth_db.NewMockTwoFactorRepository(ctrl),
+		mock_auth_db.NewMockLoginAttemptRepository(ctrl),
+		mock_auth_db.NewMockEmailVerificationRepository(ctrl),
+		mock_auth_db.NewMockIdentityRepository(ctrl),
+		mock_auth_db.NewMockAPITokenRepository(ctrl),
 	}
 	t.Helper()
-	return &auth_db.Repository{AuthRepo: MockRepo.mockUserR}, MockRepo
+	return &auth_db.Repository{
+		AuthRepo:          MockRepo.mockUserR,
+		SessionRepo:       MockRepo.mockSessionR,
+		PasswordResetRepo: MockRepo.mockPasswordResetR,
+		TwoFactorRepo:     MockRepo.mockTwoFactorR,
+		LoginAttemptRepo:  MockRepo.mockLoginAttemptR,
+
+		EmailVerificationRepo: MockRepo.mockEmailVerificationR,
+		IdentityRepo:          MockRepo.mockIdentityR,
+		APITokenRepo:          MockRepo.mockAPITokenR,
+	}, MockRepo
 }
 
 // TestLogger ...
@@ -0,0 +1,170 @@
+package auth_service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	authconstants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	authdb "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/db"
+	authcore "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	authdto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
+	authutils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type TwoFactorService interface {
+	EnrollTwoFactor(ctx context.Context, request *authdto.EnrollTwoFactorRequest) (*authdto.EnrollTwoFactorResponse, error)
+	ConfirmTwoFactor(ctx context.Context, request *authdto.ConfirmTwoFactorRequest) (*authdto.ConfirmTwoFactorResponse, error)
+	DisableTwoFactor(ctx context.Context, request *authdto.DisableTwoFactorRequest) error
+}
+
+type twoFactorServiceImpl struct {
+	log *logrus.Entry
+	db  *authdb.Repository
+}
+
+// EnrollTwoFactor generates a new TOTP secret. It takes effect only after ConfirmTwoFactor.
+func (svc *twoFactorServiceImpl) EnrollTwoFactor(ctx context.Context, request *authdto.EnrollTwoFactorRequest) (*authdto.EnrollTwoFactorResponse, error) {
+	if twoFactor, err := svc.getTwoFactor(ctx, request.UserID); err != nil {
+		return nil, err
+	} else if twoFactor != nil && twoFactor.Enabled {
+		return nil, authconstants.ErrTwoFactorAlreadyEnabled
+	}
+
+	user, err := svc.db.AuthRepo.GetUserByID(ctx, request.UserID)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GetUserByID error: %s", err)
+		return nil, err
+	}
+
+	secret, err := authutils.GenerateTOTPSecret()
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GenerateTOTPSecret error: %s", err)
+		return nil, err
+	}
+
+	if err := svc.db.TwoFactorRepo.SetPendingSecret(ctx, user.ID, secret); err != nil {
+		logger(ctx, svc.log).Errorf("SetPendingSecret error: %s", err)
+		return nil, err
+	}
+
+	uri := authutils.TOTPURI(viper.GetString(authconstants.ViperTOTPIssuerKey), user.Email, secret)
+	return &authdto.EnrollTwoFactorResponse{Secret: secret, URI: uri}, nil
+}
+
+// ConfirmTwoFactor enables the enrolled secret once the user proves the authenticator app is set up.
+// Recovery codes are returned only here and can't be viewed later.
+func (svc *twoFactorServiceImpl) ConfirmTwoFactor(ctx context.Context, request *authdto.ConfirmTwoFactorRequest) (*authdto.ConfirmTwoFactorResponse, error) {
+	twoFactor, err := svc.getTwoFactor(ctx, request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor != nil && twoFactor.Enabled {
+		return nil, authconstants.ErrTwoFactorAlreadyEnabled
+	}
+	if twoFactor == nil || len(twoFactor.PendingSecret) == 0 {
+		return nil, authconstants.ErrTwoFactorNotEnrolled
+	}
+
+	step, ok := authutils.ValidateTOTP(twoFactor.PendingSecret, strings.TrimSpace(request.Code), time.Now())
+	if !ok {
+		return nil, authconstants.ErrTwoFactorCodeInvalid
+	}
+
+	recoveryCodes, recoveryHashes, err := authutils.GenerateRecoveryCodes(authconstants.RecoveryCodesCount)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GenerateRecoveryCodes error: %s", err)
+		return nil, err
+	}
+
+	if err := svc.db.TwoFactorRepo.EnableTwoFactor(ctx, request.UserID, twoFactor.PendingSecret, recoveryHashes); err != nil {
+		logger(ctx, svc.log).Errorf("EnableTwoFactor error: %s", err)
+		return nil, err
+	}
+	if _, err := svc.db.TwoFactorRepo.UseTOTPStep(ctx, request.UserID, step); err != nil {
+		logger(ctx, svc.log).Errorf("UseTOTPStep error: %s", err)
+	}
+
+	return &authdto.ConfirmTwoFactorResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// DisableTwoFactor turns the second factor off. Both the password and a code are required for it,
+// the users without a password confirm it with the code only.
+func (svc *twoFactorServiceImpl) DisableTwoFactor(ctx context.Context, request *authdto.DisableTwoFactorRequest) error {
+	user, err := svc.db.AuthRepo.GetUserByID(ctx, request.UserID)
+	if err != nil {
+		logger(ctx, svc.log).Errorf("GetUserByID error: %s", err)
+		return err
+	}
+
+	err = checkUserConfirmation(ctx, svc.log, svc.db, user.ID, func() error {
+		if user.Password.IsSet() {
+			if err := user.Password.Validate(request.Password); err != nil {
+				logger(ctx, svc.log).Errorf("Validate error: %s", err)
+				return err
+			}
+		}
+
+		twoFactor, err := svc.getTwoFactor(ctx, request.UserID)
+		if err != nil {
+			return err
+		}
+		if twoFactor == nil || !twoFactor.Enabled {
+			return authconstants.ErrTwoFactorNotEnabled
+		}
+		return checkSecondFactor(ctx, svc.db, twoFactor, request.Code)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := svc.db.TwoFactorRepo.DisableTwoFactor(ctx, request.UserID); err != nil {
+		logger(ctx, svc.log).Errorf("DisableTwoFactor error: %s", err)
+		return err
+	}
+	return nil
+}
+
+// getTwoFactor returns nil without error if the user has never enrolled the second factor.
+func (svc *twoFactorServiceImpl) getTwoFactor(ctx context.Context, userID string) (*authcore.TwoFactor, error) {
+	twoFactor, err := svc.db.TwoFactorRepo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		logger(ctx, svc.log).Errorf("GetTwoFactor error: %s", err)
+		return nil, err
+	}
+	return twoFactor, nil
+}
+
+// checkSecondFactor accepts either a TOTP code which hasn't been used yet or one of the recovery codes.
+func checkSecondFactor(ctx context.Context, db *authdb.Repository, twoFactor *authcore.TwoFactor, code string) error {
+	code = strings.TrimSpace(code)
+
+	if step, ok := authutils.ValidateTOTP(twoFactor.Secret, code, time.Now()); ok {
+		used, err := db.TwoFactorRepo.UseTOTPStep(ctx, twoFactor.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return authconstants.ErrTwoFactorCodeInvalid
+		}
+		return nil
+	}
+
+	used, err := db.TwoFactorRepo.UseRecoveryCode(ctx, twoFactor.UserID, authutils.HashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return authconstants.ErrTwoFactorCodeInvalid
+	}
+	return nil
+}
+
+func NewTwoFactorService(log *logrus.Entry, db *authdb.Repository) TwoFactorService {
+	return &twoFactorServiceImpl{log: log, db: db}
+}
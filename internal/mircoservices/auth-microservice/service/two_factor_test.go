@@ -0,0 +1,330 @@
+package auth_service
+
+import (
+	"context"
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	mock_auth_db "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/mocks"
+	auth_core "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/core"
+	authdto "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/model/dto"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func currentTOTPCode(t *testing.T, secret string) (string, int64) {
+	t.Helper()
+
+	step := auth_utils.TOTPStep(time.Now())
+	code, err := auth_utils.TOTPCode(secret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code, step
+}
+
+func TestLoginUserWithTwoFactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	dbUserImpl := NewAuthService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
+
+	ctx := context.Background()
+	user := &auth_core.User{ID: "1", Email: "email@e"}
+	if err := user.Password.Init("1234"); err != nil {
+		t.Fatal(err)
+	}
+
+	var ticket *auth_core.LoginTicket
+	gomock.InOrder(
+		testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "email:email@e").Return(nil, auth_constants.ErrDBNotFound),
+		testRepo.mockUserR.EXPECT().GetUserByEmail(ctx, user.Email).Return(user, nil),
+		testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, user.ID).Return(&auth_core.TwoFactor{UserID: user.ID, Enabled: true, Secret: "SECRET"}, nil),
+		testRepo.mockTwoFactorR.EXPECT().CreateLoginTicket(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, t *auth_core.LoginTicket) error {
+				ticket = t
+				return nil
+			}),
+	)
+
+	res, err := AuthService.LoginUser(dbUserImpl, ctx, &authdto.LoginUserRequest{Email: user.Email, Password: "1234"})
+	assert.Nil(t, err)
+	assert.Empty(t, res.AuthToken)
+	assert.Empty(t, res.RefreshToken)
+	assert.Equal(t, ticket.Hash, auth_utils.HashOpaqueToken(res.TwoFactorTicket))
+	assert.Equal(t, user.ID, ticket.UserID)
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	dbUserImpl := NewAuthService(TestLogger(t), TestBD, mock_auth_db.NewMockMailer(ctrl))
+
+	ctx := context.Background()
+	notFound := auth_constants.ErrDBNotFound
+
+	secret, _ := auth_utils.GenerateTOTPSecret()
+	code, step := currentTOTPCode(t, secret)
+	hash := auth_utils.HashOpaqueToken("ticket")
+	ticket := &auth_core.LoginTicket{Hash: hash, UserID: "1", Attempts: 1}
+	twoFactor := &auth_core.TwoFactor{UserID: "1", Enabled: true, Secret: secret}
+	user := &auth_core.User{ID: "1", Email: "email@e"}
+	allowed := func() *gomock.Call {
+		return testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, gomock.Any()).Return(nil, notFound).Times(2)
+	}
+	failed := func() *gomock.Call {
+		return testRepo.mockLoginAttemptR.EXPECT().RegisterLoginFailure(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(&auth_core.LoginAttempt{Failures: 1}, nil).Times(2)
+	}
+
+	tests := []struct {
+		name    string
+		code    string
+		prepare func()
+		err     error
+	}{
+		{
+			name: "Unknown or exhausted ticket",
+			code: code,
+			prepare: func() {
+				testRepo.mockTwoFactorR.EXPECT().UseLoginTicketAttempt(ctx, hash, auth_constants.LoginTicketMaxAttempts, gomock.Any()).Return(nil, notFound)
+			},
+			err: auth_constants.ErrTwoFactorTicketInvalid,
+		},
+		{
+			name: "Replayed code",
+			code: code,
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockTwoFactorR.EXPECT().UseLoginTicketAttempt(ctx, hash, auth_constants.LoginTicketMaxAttempts, gomock.Any()).Return(ticket, nil),
+					testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil),
+					allowed(),
+					testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "1").Return(twoFactor, nil),
+					testRepo.mockTwoFactorR.EXPECT().UseTOTPStep(ctx, "1", step).Return(false, nil),
+					failed(),
+				)
+			},
+			err: auth_constants.ErrTwoFactorCodeInvalid,
+		},
+		{
+			name: "Wrong recovery code",
+			code: "aaaaa-bbbbb",
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockTwoFactorR.EXPECT().UseLoginTicketAttempt(ctx, hash, auth_constants.LoginTicketMaxAttempts, gomock.Any()).Return(ticket, nil),
+					testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil),
+					allowed(),
+					testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "1").Return(twoFactor, nil),
+					testRepo.mockTwoFactorR.EXPECT().UseRecoveryCode(ctx, "1", auth_utils.HashRecoveryCode("aaaaa-bbbbb")).Return(false, nil),
+					failed(),
+				)
+			},
+			err: auth_constants.ErrTwoFactorCodeInvalid,
+		},
+		{
+			name: "Locked out user",
+			code: code,
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockTwoFactorR.EXPECT().UseLoginTicketAttempt(ctx, hash, auth_constants.LoginTicketMaxAttempts, gomock.Any()).Return(ticket, nil),
+					testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil),
+					testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "email:email@e").Return(nil, notFound),
+					testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "user:1").Return(&auth_core.LoginAttempt{
+						Key:         "user:1",
+						Failures:    auth_constants.DefaultLoginUserMaxFailures,
+						LockedUntil: time.Now().Unix() + 60,
+					}, nil),
+				)
+			},
+			err: auth_constants.ErrTooManyLoginAttempts,
+		},
+		{
+			name: "Success",
+			code: code,
+			prepare: func() {
+				gomock.InOrder(
+					testRepo.mockTwoFactorR.EXPECT().UseLoginTicketAttempt(ctx, hash, auth_constants.LoginTicketMaxAttempts, gomock.Any()).Return(ticket, nil),
+					testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil),
+					allowed(),
+					testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "1").Return(twoFactor, nil),
+					testRepo.mockTwoFactorR.EXPECT().UseTOTPStep(ctx, "1", step).Return(true, nil),
+					testRepo.mockLoginAttemptR.EXPECT().ResetLoginAttempts(ctx, "email:email@e").Return(nil),
+					testRepo.mockLoginAttemptR.EXPECT().ResetLoginAttempts(ctx, "user:1").Return(nil),
+					testRepo.mockTwoFactorR.EXPECT().DeleteLoginTicket(ctx, hash).Return(nil),
+					testRepo.mockSessionR.EXPECT().CreateSession(ctx, gomock.Any()).Return(nil),
+				)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.prepare()
+			res, errRes := AuthService.VerifySecondFactor(dbUserImpl, ctx, &authdto.VerifySecondFactorRequest{Ticket: "ticket", Code: test.code})
+			if test.err == nil {
+				assert.Nil(t, errRes)
+				assert.Equal(t, "1", res.UserID)
+				assert.NotEmpty(t, res.AuthToken)
+			} else if !assert.Equal(t, test.err.Error(), status.Convert(errRes).Message()) {
+				t.Error("got : ", errRes, " expected :", test.err)
+			}
+		})
+	}
+}
+
+func TestEnrollTwoFactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	twoFactorImpl := NewTwoFactorService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+	notFound := auth_constants.ErrDBNotFound
+
+	t.Run("Already enabled", func(t *testing.T) {
+		testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "1").Return(&auth_core.TwoFactor{UserID: "1", Enabled: true}, nil)
+
+		res, err := TwoFactorService.EnrollTwoFactor(twoFactorImpl, ctx, &authdto.EnrollTwoFactorRequest{UserID: "1"})
+		assert.Nil(t, res)
+		assert.Equal(t, auth_constants.ErrTwoFactorAlreadyEnabled.Error(), status.Convert(err).Message())
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var pending string
+		gomock.InOrder(
+			testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "1").Return(nil, notFound),
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(&auth_core.User{ID: "1", Email: "mail@example.com"}, nil),
+			testRepo.mockTwoFactorR.EXPECT().SetPendingSecret(ctx, "1", gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ string, secret string) error {
+					pending = secret
+					return nil
+				}),
+		)
+
+		res, err := TwoFactorService.EnrollTwoFactor(twoFactorImpl, ctx, &authdto.EnrollTwoFactorRequest{UserID: "1"})
+		assert.Nil(t, err)
+		assert.Equal(t, pending, res.Secret)
+		assert.Contains(t, res.URI, "secret="+pending)
+	})
+}
+
+func TestConfirmTwoFactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	twoFactorImpl := NewTwoFactorService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+	secret, _ := auth_utils.GenerateTOTPSecret()
+	code, step := currentTOTPCode(t, secret)
+
+	t.Run("Wrong code", func(t *testing.T) {
+		testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "1").Return(&auth_core.TwoFactor{UserID: "1", PendingSecret: secret}, nil)
+
+		res, err := TwoFactorService.ConfirmTwoFactor(twoFactorImpl, ctx, &authdto.ConfirmTwoFactorRequest{UserID: "1", Code: "000000x"})
+		assert.Nil(t, res)
+		assert.Equal(t, auth_constants.ErrTwoFactorCodeInvalid.Error(), status.Convert(err).Message())
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var hashes []string
+		gomock.InOrder(
+			testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "1").Return(&auth_core.TwoFactor{UserID: "1", PendingSecret: secret}, nil),
+			testRepo.mockTwoFactorR.EXPECT().EnableTwoFactor(ctx, "1", secret, gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ string, _ string, recoveryHashes []string) error {
+					hashes = recoveryHashes
+					return nil
+				}),
+			testRepo.mockTwoFactorR.EXPECT().UseTOTPStep(ctx, "1", step).Return(true, nil),
+		)
+
+		res, err := TwoFactorService.ConfirmTwoFactor(twoFactorImpl, ctx, &authdto.ConfirmTwoFactorRequest{UserID: "1", Code: code})
+		assert.Nil(t, err)
+		assert.Len(t, res.RecoveryCodes, auth_constants.RecoveryCodesCount)
+		for i, recoveryCode := range res.RecoveryCodes {
+			assert.Equal(t, hashes[i], auth_utils.HashRecoveryCode(recoveryCode))
+		}
+	})
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	twoFactorImpl := NewTwoFactorService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+	user := &auth_core.User{ID: "1", Email: "mail@example.com"}
+	if err := user.Password.Init("1234"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Locked out", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil),
+			testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "user:1").Return(&auth_core.LoginAttempt{Key: "user:1", LockedUntil: time.Now().Unix() + 60}, nil),
+		)
+
+		err := TwoFactorService.DisableTwoFactor(twoFactorImpl, ctx, &authdto.DisableTwoFactorRequest{UserID: "1", Password: "1234", Code: "aaaaa-bbbbb"})
+		assert.Equal(t, auth_constants.ErrTooManyLoginAttempts.Error(), status.Convert(err).Message())
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil),
+			testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "user:1").Return(nil, auth_constants.ErrDBNotFound),
+			testRepo.mockLoginAttemptR.EXPECT().RegisterLoginFailure(ctx, "user:1", gomock.Any(), gomock.Any()).Return(&auth_core.LoginAttempt{Key: "user:1", Failures: 1}, nil),
+		)
+
+		err := TwoFactorService.DisableTwoFactor(twoFactorImpl, ctx, &authdto.DisableTwoFactorRequest{UserID: "1", Password: "wrong", Code: "aaaaa-bbbbb"})
+		assert.Equal(t, auth_constants.ErrPasswordMismatch.Error(), status.Convert(err).Message())
+	})
+
+	t.Run("Wrong code", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil),
+			testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "user:1").Return(nil, auth_constants.ErrDBNotFound),
+			testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "1").Return(&auth_core.TwoFactor{UserID: "1", Enabled: true, Secret: "SECRET"}, nil),
+			testRepo.mockTwoFactorR.EXPECT().UseRecoveryCode(ctx, "1", auth_utils.HashRecoveryCode("aaaaa-bbbbb")).Return(false, nil),
+			testRepo.mockLoginAttemptR.EXPECT().RegisterLoginFailure(ctx, "user:1", gomock.Any(), gomock.Any()).Return(&auth_core.LoginAttempt{Key: "user:1", Failures: 1}, nil),
+		)
+
+		err := TwoFactorService.DisableTwoFactor(twoFactorImpl, ctx, &authdto.DisableTwoFactorRequest{UserID: "1", Password: "1234", Code: "aaaaa-bbbbb"})
+		assert.Equal(t, auth_constants.ErrTwoFactorCodeInvalid.Error(), status.Convert(err).Message())
+	})
+
+	t.Run("Success with recovery code", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil),
+			testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "user:1").Return(nil, auth_constants.ErrDBNotFound),
+			testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "1").Return(&auth_core.TwoFactor{UserID: "1", Enabled: true, Secret: "SECRET"}, nil),
+			testRepo.mockTwoFactorR.EXPECT().UseRecoveryCode(ctx, "1", auth_utils.HashRecoveryCode("aaaaa-bbbbb")).Return(true, nil),
+			testRepo.mockLoginAttemptR.EXPECT().ResetLoginAttempts(ctx, "user:1").Return(nil),
+			testRepo.mockTwoFactorR.EXPECT().DisableTwoFactor(ctx, "1").Return(nil),
+		)
+
+		err := TwoFactorService.DisableTwoFactor(twoFactorImpl, ctx, &authdto.DisableTwoFactorRequest{UserID: "1", Password: "1234", Code: "aaaaa-bbbbb"})
+		assert.Nil(t, err)
+	})
+
+	t.Run("Passwordless with recovery code", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(&auth_core.User{ID: "1"}, nil),
+			testRepo.mockLoginAttemptR.EXPECT().GetLoginAttempt(ctx, "user:1").Return(nil, auth_constants.ErrDBNotFound),
+			testRepo.mockTwoFactorR.EXPECT().GetTwoFactor(ctx, "1").Return(&auth_core.TwoFactor{UserID: "1", Enabled: true, Secret: "SECRET"}, nil),
+			testRepo.mockTwoFactorR.EXPECT().UseRecoveryCode(ctx, "1", auth_utils.HashRecoveryCode("aaaaa-bbbbb")).Return(true, nil),
+			testRepo.mockLoginAttemptR.EXPECT().ResetLoginAttempts(ctx, "user:1").Return(nil),
+			testRepo.mockTwoFactorR.EXPECT().DisableTwoFactor(ctx, "1").Return(nil),
+		)
+
+		err := TwoFactorService.DisableTwoFactor(twoFactorImpl, ctx, &authdto.DisableTwoFactorRequest{UserID: "1", Code: "aaaaa-bbbbb"})
+		assert.Nil(t, err)
+	})
+}
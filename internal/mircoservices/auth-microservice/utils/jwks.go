@@ -0,0 +1,85 @@
+package auth_utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"` // OKP
+	X         string `json:"x,omitempty"`   // OKP
+	N         string `json:"n,omitempty"`   // RSA
+	E         string `json:"e,omitempty"`   // RSA
+}
+
+// JWKSet is a document served at the JWKS endpoint.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns public parts of all the keys in the ring.
+func (kr *KeyRing) JWKS() *JWKSet {
+	set := &JWKSet{Keys: make([]JWK, 0, len(kr.keys))}
+	for _, key := range kr.keys {
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
+
+// NewKeyRingFromJWKS builds a verification only key ring from the published keys.
+// Keys of unknown types are skipped.
+func NewKeyRingFromJWKS(set *JWKSet) (*KeyRing, error) {
+	keys := make([]*SigningKey, 0, len(set.Keys))
+	for _, jwk := range set.Keys {
+		key, err := jwk.verificationKey()
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyRing("", keys)
+}
+
+func (jwk JWK) verificationKey() (*SigningKey, error) {
+	switch {
+	case jwk.KeyType == "OKP" && jwk.Curve == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key %q", jwk.KeyID)
+		}
+		return NewVerificationKey(jwk.KeyID, ed25519.PublicKey(x))
+	case jwk.KeyType == "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA key %q", jwk.KeyID)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("invalid RSA key %q", jwk.KeyID)
+		}
+		return NewVerificationKey(jwk.KeyID, &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())})
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.KeyType)
+	}
+}
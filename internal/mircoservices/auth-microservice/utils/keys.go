@@ -0,0 +1,232 @@
+package auth_utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	"github.com/golang-jwt/jwt"
+	"github.com/spf13/viper"
+)
+
+// SigningKey is a key of access tokens. Private is nil for retired keys which only verify tokens issued before.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+}
+
+// KeyRing holds the active key which signs new tokens and all the keys tokens are still accepted with.
+// Rotation: add a new key and make it active, keep the old one until its last token expires.
+type KeyRing struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+var keyRing = &KeyRing{keys: map[string]*SigningKey{}}
+
+// InitKeyRing loads the keys set in the config, the tokens are signed and verified with them since then.
+// Without keys the service doesn't start, unless the ephemeral key is allowed in the config: then a key
+// is generated and ephemeral is true.
+func InitKeyRing() (ephemeral bool, err error) {
+	files := viper.GetStringMapString(auth_constants.ViperJWTKeysKey)
+	var kr *KeyRing
+	switch {
+	case len(files) != 0:
+		kr, err = LoadKeyRing(viper.GetString(auth_constants.ViperJWTActiveKeyKey), files)
+	case viper.GetBool(auth_constants.ViperJWTEphemeralKeyKey):
+		kr, err = NewEphemeralKeyRing()
+		ephemeral = true
+	default:
+		err = errors.New("no keys are set, mount them and list in jwt.keys")
+	}
+	if err != nil {
+		return false, err
+	}
+	SetKeyRing(kr)
+	return ephemeral, nil
+}
+
+// NewEphemeralKeyRing generates the Ed25519 key which lives as long as the process.
+// The key id is random, so the clients which cache the keys reload them after a restart.
+func NewEphemeralKeyRing() (*KeyRing, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	id := "ephemeral-" + hex.EncodeToString(suffix)
+	return NewKeyRing(id, []*SigningKey{{ID: id, Method: jwt.SigningMethodEdDSA, Private: private, Public: public}})
+}
+
+func SetKeyRing(kr *KeyRing) {
+	keyRing = kr
+}
+
+// JWKS returns public keys of access tokens.
+func JWKS() *JWKSet {
+	return keyRing.JWKS()
+}
+
+// NewKeyRing builds the key ring. activeID may be empty if the ring is only used for verification.
+func NewKeyRing(activeID string, keys []*SigningKey) (*KeyRing, error) {
+	kr := &KeyRing{keys: make(map[string]*SigningKey, len(keys))}
+	for _, key := range keys {
+		if _, ok := kr.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		kr.keys[key.ID] = key
+	}
+
+	if len(activeID) != 0 {
+		active, ok := kr.keys[activeID]
+		if !ok {
+			return nil, fmt.Errorf("active key %q is not found", activeID)
+		}
+		if active.Private == nil {
+			return nil, fmt.Errorf("active key %q has no private part", activeID)
+		}
+		kr.active = active
+	}
+	return kr, nil
+}
+
+// LoadKeyRing reads PEM files of the keys by their ids.
+func LoadKeyRing(activeID string, files map[string]string) (*KeyRing, error) {
+	keys := make([]*SigningKey, 0, len(files))
+	for id, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read key %q: %w", id, err)
+		}
+		key, err := ParseKeyPEM(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("parse key %q: %w", id, err)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyRing(activeID, keys)
+}
+
+// ParseKeyPEM parses a PKCS#8 or PKCS#1 private key or a PKIX public key, either RSA or Ed25519.
+func ParseKeyPEM(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, jwt.ErrKeyMustBePEMEncoded
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &SigningKey{ID: id, Method: jwt.SigningMethodRS256, Private: key, Public: &key.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, Private: key, Public: key.Public()}, nil
+	default:
+		return NewVerificationKey(id, parsed)
+	}
+}
+
+// NewVerificationKey wraps a public key which is only used to verify tokens.
+func NewVerificationKey(id string, public crypto.PublicKey) (*SigningKey, error) {
+	switch public.(type) {
+	case *rsa.PublicKey:
+		return &SigningKey{ID: id, Method: jwt.SigningMethodRS256, Public: public}, nil
+	case ed25519.PublicKey:
+		return &SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, Public: public}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", public)
+	}
+}
+
+// Sign signs the claims with the active key.
+func (kr *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	if kr.active == nil {
+		return "", auth_constants.ErrSignToken
+	}
+
+	token := jwt.NewWithClaims(kr.active.Method, claims)
+	token.Header["kid"] = kr.active.ID
+	signed, err := token.SignedString(kr.active.Private)
+	if err != nil {
+		return "", auth_constants.ErrSignToken
+	}
+	return signed, nil
+}
+
+// Parse verifies the access token with the key named in its header.
+func (kr *KeyRing) Parse(authToken string) (*AuthTokenWrapper, error) {
+	t, err := jwt.ParseWithClaims(authToken, &AuthTokenWrapper{}, kr.keyFunc)
+
+	var codedErr *auth_constants.CodedError
+	if ve, ok := err.(*jwt.ValidationError); ok {
+		// check if Expiration error was set
+		if ve.Errors&jwt.ValidationErrorExpired == jwt.ValidationErrorExpired {
+			return nil, auth_constants.ErrAuthTokenExpired
+		} else if errors.As(ve.Inner, &codedErr) {
+			return nil, codedErr
+		} else {
+			return nil, auth_constants.ErrAuthTokenInvalid
+		}
+	} else if err != nil {
+		return nil, auth_constants.ErrParseAuthToken
+	}
+
+	atw, ok := t.Claims.(*AuthTokenWrapper)
+	if !ok {
+		return nil, auth_constants.ErrAuthTokenInvalid
+	}
+	return atw, nil
+}
+
+// ParseClaims verifies the token of any issuer whose keys are in the ring and decodes its claims.
+// Unlike Parse, the claims other than expiration are left to the caller to check.
+func (kr *KeyRing) ParseClaims(token string, claims jwt.Claims) error {
+	_, err := jwt.ParseWithClaims(token, claims, kr.keyFunc)
+	return err
+}
+
+func (kr *KeyRing) keyFunc(token *jwt.Token) (interface{}, error) {
+	id, _ := token.Header["kid"].(string)
+	key, ok := kr.keys[id]
+	if !ok {
+		return nil, auth_constants.ErrAuthTokenInvalid
+	}
+	// The key decides the algorithm, not the token.
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, auth_constants.ErrUnexpectedSigningMethod
+	}
+	return key.Public, nil
+}
+
+// Has reports whether the ring knows the key with such id.
+func (kr *KeyRing) Has(id string) bool {
+	_, ok := kr.keys[id]
+	return ok
+}
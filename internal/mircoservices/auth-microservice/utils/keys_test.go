@@ -0,0 +1,177 @@
+package auth_utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	auth_constants "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	"github.com/golang-jwt/jwt"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/status"
+)
+
+func testEdKey(t *testing.T, id string) *SigningKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return &SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, Private: private, Public: public}
+}
+
+func testRSAKey(t *testing.T, id string) *SigningKey {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return &SigningKey{ID: id, Method: jwt.SigningMethodRS256, Private: private, Public: &private.PublicKey}
+}
+
+func claims() *AuthTokenWrapper {
+	return &AuthTokenWrapper{UserID: "1", SessionID: "2", StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Minute).Unix()}}
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	old, current := testRSAKey(t, "old"), testEdKey(t, "current")
+
+	before, err := NewKeyRing("old", []*SigningKey{old})
+	require.NoError(t, err)
+	oldToken, err := before.Sign(claims())
+	require.NoError(t, err)
+
+	// The old key is retired: only its public part is kept.
+	retired, err := NewVerificationKey("old", old.Public)
+	require.NoError(t, err)
+	after, err := NewKeyRing("current", []*SigningKey{current, retired})
+	require.NoError(t, err)
+	newToken, err := after.Sign(claims())
+	require.NoError(t, err)
+
+	for _, token := range []string{oldToken, newToken} {
+		atw, err := after.Parse(token)
+		require.NoError(t, err)
+		assert.Equal(t, "1", atw.UserID)
+		assert.Equal(t, "2", atw.SessionID)
+	}
+
+	_, err = before.Parse(newToken)
+	assert.Equal(t, auth_constants.ErrAuthTokenInvalid.Error(), status.Convert(err).Message())
+}
+
+func TestKeyRingRejectsAlgorithmMismatch(t *testing.T) {
+	key := testEdKey(t, "key")
+	kr, err := NewKeyRing("key", []*SigningKey{key})
+	require.NoError(t, err)
+
+	// HS256 signed with the public key bytes must not pass as EdDSA.
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	token.Header["kid"] = "key"
+	signed, err := token.SignedString([]byte(key.Public.(ed25519.PublicKey)))
+	require.NoError(t, err)
+
+	_, err = kr.Parse(signed)
+	assert.Equal(t, auth_constants.ErrUnexpectedSigningMethod.Error(), status.Convert(err).Message())
+}
+
+func TestKeyRingExpired(t *testing.T) {
+	kr, err := NewKeyRing("key", []*SigningKey{testEdKey(t, "key")})
+	require.NoError(t, err)
+
+	expired := claims()
+	expired.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	token, err := kr.Sign(expired)
+	require.NoError(t, err)
+
+	_, err = kr.Parse(token)
+	assert.Equal(t, auth_constants.ErrAuthTokenExpired.Error(), status.Convert(err).Message())
+}
+
+func TestNewKeyRingActiveKey(t *testing.T) {
+	key := testEdKey(t, "key")
+	_, err := NewKeyRing("missing", []*SigningKey{key})
+	assert.Error(t, err)
+
+	public, _ := NewVerificationKey("public", key.Public)
+	_, err = NewKeyRing("public", []*SigningKey{public})
+	assert.Error(t, err)
+
+	verifier, err := NewKeyRing("", []*SigningKey{public})
+	require.NoError(t, err)
+	_, err = verifier.Sign(claims())
+	assert.Equal(t, auth_constants.ErrSignToken.Error(), status.Convert(err).Message())
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey := testRSAKey(t, "rsa")
+	edKey := testEdKey(t, "ed")
+	privateDER, err := x509.MarshalPKCS8PrivateKey(edKey.Private)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(rsaKey.Public)
+	require.NoError(t, err)
+
+	files := map[string]string{
+		"ed":  filepath.Join(dir, "ed.pem"),
+		"rsa": filepath.Join(dir, "rsa.pub.pem"),
+	}
+	require.NoError(t, os.WriteFile(files["ed"], pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600))
+	require.NoError(t, os.WriteFile(files["rsa"], pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0600))
+
+	kr, err := LoadKeyRing("ed", files)
+	require.NoError(t, err)
+	assert.Equal(t, jwt.SigningMethodEdDSA, kr.active.Method)
+	assert.Nil(t, kr.keys["rsa"].Private)
+	assert.Equal(t, jwt.SigningMethodRS256, kr.keys["rsa"].Method)
+}
+
+func TestInitKeyRing(t *testing.T) {
+	defer SetKeyRing(keyRing)
+	defer viper.Reset()
+
+	t.Run("No keys", func(t *testing.T) {
+		_, err := InitKeyRing()
+		assert.Error(t, err, "the service must not start without keys")
+	})
+
+	t.Run("Ephemeral key", func(t *testing.T) {
+		viper.Set(auth_constants.ViperJWTEphemeralKeyKey, true)
+		ephemeral, err := InitKeyRing()
+		require.NoError(t, err)
+		assert.True(t, ephemeral)
+
+		token, err := GenerateAuthToken(claims())
+		require.NoError(t, err)
+		_, err = ParseAuthToken(token)
+		assert.Nil(t, err)
+	})
+}
+
+func TestJWKSRoundTrip(t *testing.T) {
+	signer, err := NewKeyRing("ed", []*SigningKey{testEdKey(t, "ed"), testRSAKey(t, "rsa")})
+	require.NoError(t, err)
+
+	set := signer.JWKS()
+	require.Len(t, set.Keys, 2)
+	assert.Equal(t, "ed", set.Keys[0].KeyID)
+	assert.Equal(t, "EdDSA", set.Keys[0].Algorithm)
+	assert.Equal(t, "RSA", set.Keys[1].KeyType)
+	assert.Equal(t, "AQAB", set.Keys[1].E)
+
+	verifier, err := NewKeyRingFromJWKS(set)
+	require.NoError(t, err)
+	assert.True(t, verifier.Has("ed"))
+	assert.True(t, verifier.Has("rsa"))
+
+	token, err := signer.Sign(claims())
+	require.NoError(t, err)
+	atw, err := verifier.Parse(token)
+	require.NoError(t, err)
+	assert.Equal(t, "1", atw.UserID)
+}
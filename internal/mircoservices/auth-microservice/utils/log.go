@@ -0,0 +1,22 @@
+package auth_utils
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type logKey struct{}
+
+// WithLogger returns the context carrying the log entry of the call.
+func WithLogger(ctx context.Context, log *logrus.Entry) context.Context {
+	return context.WithValue(ctx, logKey{}, log)
+}
+
+// Logger returns the log entry of the call, or fallback outside of a call.
+func Logger(ctx context.Context, fallback *logrus.Entry) *logrus.Entry {
+	if log, ok := ctx.Value(logKey{}).(*logrus.Entry); ok {
+		return log
+	}
+	return fallback
+}
@@ -0,0 +1,42 @@
+package auth_utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	recoveryCodeAlphabet = "abcdefghijkmnpqrstuvwxyz23456789" // 32 symbols, so every byte maps without bias
+	recoveryCodeHalf     = 5
+)
+
+// GenerateRecoveryCodes returns one-time recovery codes like "abcde-12345" and their hashes.
+// Only the hashes are meant to be stored.
+func GenerateRecoveryCodes(count int) ([]string, []string, error) {
+	codesList := make([]string, 0, count)
+	hashes := make([]string, 0, count)
+
+	raw := make([]byte, 2*recoveryCodeHalf)
+	for i := 0; i < count; i++ {
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+		}
+		for j := range raw {
+			raw[j] = recoveryCodeAlphabet[int(raw[j])%len(recoveryCodeAlphabet)]
+		}
+		code := string(raw[:recoveryCodeHalf]) + "-" + string(raw[recoveryCodeHalf:])
+		codesList = append(codesList, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codesList, hashes, nil
+}
+
+// HashRecoveryCode hashes the code ignoring case, spaces and dashes which users type inconsistently.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return HashOpaqueToken(normalized)
+}
@@ -0,0 +1,54 @@
+package auth_utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	"github.com/spf13/viper"
+)
+
+const opaqueTokenSize = 32
+
+// GenerateOpaqueToken returns a new random token (refresh, password reset etc.) and its hash.
+// Only the hash is meant to be stored.
+func GenerateOpaqueToken() (string, string, error) {
+	raw := make([]byte, opaqueTokenSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", auth_constants.ErrSignToken
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashOpaqueToken(token), nil
+}
+
+func HashOpaqueToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// RefreshTokenExpiration returns expiration time of the refresh token issued now.
+func RefreshTokenExpiration() int64 {
+	return expiration(auth_constants.ViperRefreshTTLKey)
+}
+
+// PasswordResetExpiration returns expiration time of the password reset token issued now.
+func PasswordResetExpiration() int64 {
+	return expiration(auth_constants.ViperPasswordResetTTLKey)
+}
+
+// LoginTicketExpiration returns expiration time of the two-factor login ticket issued now.
+func LoginTicketExpiration() int64 {
+	return expiration(auth_constants.ViperLoginTicketTTLKey)
+}
+
+// EmailVerificationExpiration returns expiration time of the email verification token issued now.
+func EmailVerificationExpiration() int64 {
+	return expiration(auth_constants.ViperEmailVerificationTTLKey)
+}
+
+func expiration(ttlKey string) int64 {
+	return time.Now().Add(viper.GetDuration(ttlKey)).Unix()
+}
@@ -0,0 +1,90 @@
+package auth_utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/constants"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const totpSecretSize = 20
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new base32 encoded secret for RFC 6238 one-time passwords.
+func GenerateTOTPSecret() (string, error) {
+	raw := make([]byte, totpSecretSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", status.Error(codes.Internal, fmt.Errorf("%s", err).Error())
+	}
+	return totpEncoding.EncodeToString(raw), nil
+}
+
+// TOTPURI returns otpauth:// URI which authenticator apps read from the QR code.
+func TOTPURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(auth_constants.TOTPDigits))
+	query.Set("period", fmt.Sprint(auth_constants.TOTPPeriod))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// TOTPStep returns number of the TOTP period the moment belongs to.
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / auth_constants.TOTPPeriod
+}
+
+// TOTPCode returns one-time password of the secret for the given period.
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", status.Error(codes.Internal, fmt.Errorf("decode totp secret: %s", err).Error())
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, RFC 4226 section 5.3.
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < auth_constants.TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", auth_constants.TOTPDigits, value%mod), nil
+}
+
+// ValidateTOTP checks the code against the periods around the moment.
+// Returns the period the code belongs to, so the caller can forbid its reuse.
+func ValidateTOTP(secret, code string, t time.Time) (int64, bool) {
+	current := TOTPStep(t)
+	for step := current - auth_constants.TOTPSkew; step <= current+auth_constants.TOTPSkew; step++ {
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
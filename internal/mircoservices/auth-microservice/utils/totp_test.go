@@ -0,0 +1,66 @@
+package auth_utils
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors of RFC 6238 appendix B (SHA-1), truncated to 6 digits.
+func TestTOTPCode(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{20000000000, "353130"},
+	}
+
+	for _, test := range tests {
+		code, err := TOTPCode(secret, TOTPStep(time.Unix(test.unix, 0)))
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	assert.Nil(t, err)
+
+	now := time.Now()
+	previous, _ := TOTPCode(secret, TOTPStep(now)-1)
+	old, _ := TOTPCode(secret, TOTPStep(now)-5)
+
+	step, ok := ValidateTOTP(secret, previous, now)
+	assert.True(t, ok)
+	assert.Equal(t, TOTPStep(now)-1, step)
+
+	_, ok = ValidateTOTP(secret, old, now)
+	assert.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("CJ", "mail@example.com", "SECRET")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/CJ:mail@example.com?"))
+	assert.Contains(t, uri, "secret=SECRET")
+	assert.Contains(t, uri, "issuer=CJ")
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes(3)
+	assert.Nil(t, err)
+	assert.Len(t, codes, 3)
+	assert.Len(t, hashes, 3)
+
+	for i, code := range codes {
+		assert.Len(t, code, 11)
+		assert.Equal(t, hashes[i], HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", " "))))
+	}
+}
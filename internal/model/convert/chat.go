@@ -1,28 +1,12 @@
 package convert
 
 import (
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
 )
 
 func Dialog2DTO(dialog *core.Dialog, userID string) dto.Dialog {
-	nonRead := int64(0)
-	for _, message := range dialog.Messages {
-		if message.AuthorID != userID {
-			for _, id := range message.IsRead {
-				if id.Participant == userID {
-					if !id.IsRead {
-						nonRead += 1
-					} else {
-						break
-					}
-				}
-			}
-		} else {
-			break
-		}
-	}
-
 	var participants []string
 	for _, id := range dialog.Participants {
 		if id != userID {
@@ -34,28 +18,135 @@ func Dialog2DTO(dialog *core.Dialog, userID string) dto.Dialog {
 		DialogID:     dialog.ID,
 		Name:         dialog.Name,
 		Participants: participants,
-		NonRead:      nonRead,
+		Image:        dialog.Image,
+		Group:        dialog.IsGroup(),
+		AdminIDs:     dialog.Admins(),
 	}
 }
 
 func Message2DTO(message core.Message, userID string) dto.MessageInfo {
 	if userID == message.AuthorID {
 		return dto.MessageInfo{
+			ID:          message.ID,
 			AuthorID:    message.AuthorID,
 			Body:        message.Body,
 			IsRead:      message.IsRead,
 			CreatedAt:   message.CreatedAt,
 			Attachments: message.Attachments,
 			Images:      message.Images,
+			Edited:      message.Edited,
+			EditedAt:    message.EditedAt,
+			Reactions:   Reactions2DTO(message.Reactions, userID),
+			Action:      message.Action,
+			TargetID:    message.TargetID,
 		}
 	}
 	return dto.MessageInfo{
+		ID:          message.ID,
 		AuthorID:    message.AuthorID,
 		Body:        message.Body,
 		CreatedAt:   message.CreatedAt,
 		Attachments: message.Attachments,
 		Images:      message.Images,
+		Edited:      message.Edited,
+		EditedAt:    message.EditedAt,
+		Reactions:   Reactions2DTO(message.Reactions, userID),
+		Action:      message.Action,
+		TargetID:    message.TargetID,
+	}
+}
+
+// DialogOverview2DTO returns the entry of the dialog list.
+func DialogOverview2DTO(overview *core.DialogOverview, userID string) dto.Dialog {
+	dialog := Dialog2DTO(&overview.Dialog, userID)
+	dialog.NonRead = overview.Unread
+	dialog.LastActivity = overview.LastActivity
+	if overview.LastMessage != nil {
+		dialog.LastMessage = &dto.MessagePreview{
+			ID:             overview.LastMessage.ID,
+			AuthorID:       overview.LastMessage.AuthorID,
+			Body:           previewBody(overview.LastMessage.Body),
+			AttachmentType: attachmentType(overview.LastMessage),
+			Action:         overview.LastMessage.Action,
+			CreatedAt:      overview.LastMessage.CreatedAt,
+		}
+	}
+	return dialog
+}
+
+// SystemMessage2DTO returns the event of the system message about the change of the group chat.
+func SystemMessage2DTO(message *core.Message) dto.Message {
+	return dto.Message{
+		ID:        message.ID,
+		DialogID:  message.DialogID,
+		Event:     message.Action,
+		AuthorID:  message.AuthorID,
+		DestinID:  message.TargetID,
+		Body:      message.Body,
+		CreatedAt: message.CreatedAt,
+	}
+}
+
+// MessageQuote2DTO returns the preview of the message which is replied to.
+// The message which is deleted or unknown is quoted as deleted.
+func MessageQuote2DTO(messageID string, message *core.Message, userID string) *dto.MessageQuote {
+	if message == nil || message.Deleted || isDeletedFor(message, userID) {
+		return &dto.MessageQuote{ID: messageID, Deleted: true}
+	}
+
+	return &dto.MessageQuote{
+		ID:             message.ID,
+		AuthorID:       message.AuthorID,
+		Body:           previewBody(message.Body),
+		AttachmentType: attachmentType(message),
+	}
+}
+
+// previewBody truncates the body of the message to constants.QuoteBodyLength characters.
+func previewBody(body string) string {
+	if runes := []rune(body); len(runes) > constants.QuoteBodyLength {
+		return string(runes[:constants.QuoteBodyLength]) + "…"
+	}
+	return body
+}
+
+func attachmentType(message *core.Message) string {
+	if len(message.Images) != 0 {
+		return constants.AttachmentImage
+	}
+	if len(message.Attachments) != 0 {
+		return constants.AttachmentFile
+	}
+	return constants.Empty
+}
+
+func isDeletedFor(message *core.Message, userID string) bool {
+	for _, id := range message.DeletedFor {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Reactions2DTO groups the reactions by emoji in the order they were first made.
+func Reactions2DTO(reactions []core.Reaction, userID string) []dto.Reaction {
+	var result []dto.Reaction
+	index := make(map[string]int)
+	for _, reaction := range reactions {
+		i, ok := index[reaction.Emoji]
+		if !ok {
+			i = len(result)
+			index[reaction.Emoji] = i
+			result = append(result, dto.Reaction{Emoji: reaction.Emoji})
+		}
+		result[i].Count++
+		result[i].UserIDs = append(result[i].UserIDs, reaction.UserID)
+		if reaction.UserID == userID {
+			result[i].Mine = true
+		}
 	}
+	return result
 }
 
 func Messages2DTO(messages []core.Message, userID string) []dto.MessageInfo {
@@ -0,0 +1,48 @@
+package convert
+
+import (
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
+)
+
+// Message2ChatEvent makes the event of the message in the event log of the user.
+func Message2ChatEvent(msg *dto.Message, userID string, seq int64, expiresAt time.Time) core.ChatEvent {
+	return core.ChatEvent{
+		UserID:      userID,
+		Seq:         seq,
+		DialogID:    msg.DialogID,
+		Event:       msg.Event,
+		MessageID:   msg.ID,
+		AuthorID:    msg.AuthorID,
+		DestinID:    msg.DestinID,
+		Body:        msg.Body,
+		Attachments: msg.Attachments,
+		Images:      msg.Images,
+		CreatedAt:   msg.CreatedAt,
+		EditedAt:    msg.EditedAt,
+		ForEveryone: msg.ForEveryone,
+		ReplyTo:     msg.ReplyTo,
+		ExpiresAt:   expiresAt,
+	}
+}
+
+// ChatEvent2DTO makes the message the event was made of, as the user has received it.
+func ChatEvent2DTO(event core.ChatEvent) dto.Message {
+	return dto.Message{
+		ID:          event.MessageID,
+		DialogID:    event.DialogID,
+		Event:       event.Event,
+		AuthorID:    event.AuthorID,
+		DestinID:    event.DestinID,
+		Body:        event.Body,
+		Attachments: event.Attachments,
+		Images:      event.Images,
+		CreatedAt:   event.CreatedAt,
+		EditedAt:    event.EditedAt,
+		ForEveryone: event.ForEveryone,
+		ReplyTo:     event.ReplyTo,
+		Seq:         event.Seq,
+	}
+}
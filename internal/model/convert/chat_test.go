@@ -1,9 +1,11 @@
 package convert
 
 import (
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -17,12 +19,46 @@ func TestDialog2DTO(t *testing.T) {
 	})
 }
 
+func TestGroupDialog2DTO(t *testing.T) {
+	legacy := &core.Dialog{ID: "123", Name: "bestChat", Participants: []string{"1", "2", "3"}}
+	dialogDTO := Dialog2DTO(legacy, "2")
+	assert.True(t, dialogDTO.Group)
+	assert.Equal(t, []string{"1"}, dialogDTO.AdminIDs, "the group chat without admins is administered by its creator")
+
+	group := &core.Dialog{ID: "123", Group: true, Participants: []string{"1", "2"}, AdminIDs: []string{"2"}, Image: "chat.png"}
+	dialogDTO = Dialog2DTO(group, "1")
+	assert.True(t, dialogDTO.Group, "the group chat of two participants is a group chat still")
+	assert.Equal(t, []string{"2"}, dialogDTO.AdminIDs)
+	assert.Equal(t, "chat.png", dialogDTO.Image)
+}
+
+func TestDialogOverview2DTO(t *testing.T) {
+	overview := &core.DialogOverview{
+		Dialog:       core.Dialog{ID: "123", Name: "bestChat", Participants: []string{"1", "2", "3"}},
+		LastMessage:  &core.Message{ID: "m1", AuthorID: "2", Images: []string{"cat.png"}, CreatedAt: 200},
+		Unread:       3,
+		LastActivity: 200,
+	}
+	dialogDTO := DialogOverview2DTO(overview, "1")
+	assert.Equal(t, int64(3), dialogDTO.NonRead)
+	assert.Equal(t, int64(200), dialogDTO.LastActivity)
+	assert.Equal(t, &dto.MessagePreview{ID: "m1", AuthorID: "2", AttachmentType: constants.AttachmentImage, CreatedAt: 200}, dialogDTO.LastMessage)
+
+	overview.LastMessage = nil
+	assert.Nil(t, DialogOverview2DTO(overview, "1").LastMessage, "the dialog without messages has no preview")
+}
+
+func TestSystemMessage2DTO(t *testing.T) {
+	message := &core.Message{ID: "m1", DialogID: "d1", AuthorID: "1", Body: "Alice added Bob", CreatedAt: 123, Action: constants.AddMember, TargetID: "2"}
+	assert.Equal(t, dto.Message{ID: "m1", DialogID: "d1", Event: constants.AddMember, AuthorID: "1", DestinID: "2", Body: "Alice added Bob", CreatedAt: 123}, SystemMessage2DTO(message))
+}
+
 func TestMessage2DTO(t *testing.T) {
 	messageCore := core.Message{ID: "123", Body: "someBody", AuthorID: "1234", CreatedAt: 123}
 	messageDTO := Message2DTO(messageCore, "5")
 	t.Run("Check equals", func(t *testing.T) {
-		if !assert.Equal(t, messageDTO, dto.MessageInfo{AuthorID: "1234", Body: "someBody", CreatedAt: 123}) {
-			t.Error("got : ", messageDTO, " expected :", dto.MessageInfo{AuthorID: "1234", Body: "someBody", CreatedAt: 123})
+		if !assert.Equal(t, messageDTO, dto.MessageInfo{ID: "123", AuthorID: "1234", Body: "someBody", CreatedAt: 123}) {
+			t.Error("got : ", messageDTO, " expected :", dto.MessageInfo{ID: "123", AuthorID: "1234", Body: "someBody", CreatedAt: 123})
 		}
 	})
 }
@@ -31,11 +67,30 @@ func TestMessages2DTO(t *testing.T) {
 	messagesCore := []core.Message{{ID: "123", Body: "someBody", AuthorID: "1234", CreatedAt: 123}, {ID: "1234", Body: "someBody", AuthorID: "123", CreatedAt: 1235}}
 	messagesDTO := Messages2DTO(messagesCore, "5")
 	t.Run("Check equals", func(t *testing.T) {
-		if !assert.Equal(t, messagesDTO[0], dto.MessageInfo{AuthorID: "1234", Body: "someBody", CreatedAt: 123}) {
-			t.Error("got : ", messagesDTO[0], " expected :", dto.MessageInfo{AuthorID: "1234", Body: "someBody", CreatedAt: 123})
+		if !assert.Equal(t, messagesDTO[0], dto.MessageInfo{ID: "123", AuthorID: "1234", Body: "someBody", CreatedAt: 123}) {
+			t.Error("got : ", messagesDTO[0], " expected :", dto.MessageInfo{ID: "123", AuthorID: "1234", Body: "someBody", CreatedAt: 123})
 		}
-		if !assert.Equal(t, messagesDTO[1], dto.MessageInfo{AuthorID: "123", Body: "someBody", CreatedAt: 1235}) {
-			t.Error("got : ", messagesDTO[1], " expected :", dto.MessageInfo{AuthorID: "123", Body: "someBody", CreatedAt: 1235})
+		if !assert.Equal(t, messagesDTO[1], dto.MessageInfo{ID: "1234", AuthorID: "123", Body: "someBody", CreatedAt: 1235}) {
+			t.Error("got : ", messagesDTO[1], " expected :", dto.MessageInfo{ID: "1234", AuthorID: "123", Body: "someBody", CreatedAt: 1235})
 		}
 	})
 }
+
+func TestReactions2DTO(t *testing.T) {
+	reactions := []core.Reaction{{UserID: "1", Emoji: "👍"}, {UserID: "2", Emoji: "❤️"}, {UserID: "3", Emoji: "👍"}}
+	assert.Equal(t, []dto.Reaction{
+		{Emoji: "👍", Count: 2, UserIDs: []string{"1", "3"}, Mine: true},
+		{Emoji: "❤️", Count: 1, UserIDs: []string{"2"}},
+	}, Reactions2DTO(reactions, "3"))
+	assert.Nil(t, Reactions2DTO(nil, "3"))
+}
+
+func TestMessageQuote2DTO(t *testing.T) {
+	long := strings.Repeat("я", constants.QuoteBodyLength+1)
+	message := &core.Message{ID: "1", AuthorID: "2", Body: long, Images: []string{"img.png"}, DeletedFor: []string{"3"}}
+
+	assert.Equal(t, &dto.MessageQuote{ID: "1", AuthorID: "2", Body: long[:len(long)-len("я")] + "…", AttachmentType: constants.AttachmentImage}, MessageQuote2DTO("1", message, "2"))
+	assert.Equal(t, &dto.MessageQuote{ID: "1", Deleted: true}, MessageQuote2DTO("1", message, "3"), "the message is deleted for the viewer")
+	assert.Equal(t, &dto.MessageQuote{ID: "1", Deleted: true}, MessageQuote2DTO("1", &core.Message{ID: "1", Deleted: true}, "2"))
+	assert.Equal(t, &dto.MessageQuote{ID: "1", Deleted: true}, MessageQuote2DTO("1", nil, "2"))
+}
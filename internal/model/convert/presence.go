@@ -0,0 +1,17 @@
+package convert
+
+import (
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
+)
+
+// Presence2DTO tells the user is online if they have open connections which are still alive at now.
+func Presence2DTO(presence *core.Presence, now int64) dto.Presence {
+	return dto.Presence{
+		Online:     presence.Connections > 0 && now-presence.LastSeenAt <= int64(constants.PresenceTimeout/time.Second),
+		LastSeenAt: presence.LastSeenAt,
+	}
+}
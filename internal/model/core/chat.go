@@ -5,20 +5,97 @@ type IsRead struct {
 	IsRead      bool   `bson:"is_read" json:"is_read"`
 }
 
+// Reaction is an emoji a user has reacted to a message with.
+type Reaction struct {
+	UserID string `bson:"user_id"`
+	Emoji  string `bson:"emoji"`
+}
+
 type Message struct {
 	ID          string   `bson:"_id"`
+	DialogID    string   `bson:"dialog_id"`
 	Body        string   `bson:"body"`
 	AuthorID    string   `bson:"author_id"`
 	IsRead      []IsRead `bson:"is_participants_read,omitempty"`
 	Attachments []string `json:"attachments"`
 	Images      []string `json:"images"`
-	CreatedAt   int64    `bson:"created_at"` // unix timestamp
+	CreatedAt   int64    `bson:"created_at"`         // unix timestamp
+	ReplyTo     string   `bson:"reply_to,omitempty"` // id of the message of the same dialog
+
+	// Action is set for the system messages about the changes of the group chat, such as "X added Y".
+	Action   string `bson:"action,omitempty"`
+	TargetID string `bson:"target_id,omitempty"` // the participant the action is about
+
+	Edited     bool     `bson:"edited,omitempty"`
+	EditedAt   int64    `bson:"edited_at,omitempty"`
+	Deleted    bool     `bson:"deleted,omitempty"` // for everyone, the body is erased
+	DeletedAt  int64    `bson:"deleted_at,omitempty"`
+	DeletedFor []string `bson:"deleted_for,omitempty"` // the users who have deleted the message for themselves
+
+	Reactions []Reaction `bson:"reactions,omitempty"`
 }
 
 type Dialog struct {
-	ID           string    `bson:"_id"`
-	Name         string    `bson:"name"`
-	Participants []string  `bson:"participants"`
-	Messages     []Message `bson:"messages,omitempty"`
-	CreatedAt    int64     `bson:"created_at"`
+	ID           string   `bson:"_id"`
+	Name         string   `bson:"name"`
+	Participants []string `bson:"participants"`
+	CreatedAt    int64    `bson:"created_at"`
+
+	Group    bool     `bson:"group,omitempty"`
+	Image    string   `bson:"image,omitempty"`
+	AdminIDs []string `bson:"admin_ids,omitempty"`
+}
+
+// DialogOverview is an entry of the dialog list of a user.
+type DialogOverview struct {
+	Dialog      `bson:",inline"`
+	LastMessage *Message `bson:"last_message,omitempty"` // the last message the user sees
+	Unread      int64    `bson:"unread"`                 // the number of the messages the user hasn't read
+	// LastActivity is the time of the last message, or of the creation of the dialog without messages.
+	LastActivity int64 `bson:"last_activity"`
+	// Interlocutor is the other participant of a personal dialog.
+	Interlocutor *User `bson:"interlocutor,omitempty"`
+}
+
+// DialogsOverview is a page of the dialog list of a user.
+type DialogsOverview struct {
+	Dialogs []DialogOverview `bson:"dialogs"`
+	Total   int64            `bson:"total"`
+	Unread  int64            `bson:"unread"` // of all the dialogs
+}
+
+// IsGroup tells if the dialog is a group chat rather than a personal dialog.
+// The group chats created before the flag have more than two participants.
+func (d *Dialog) IsGroup() bool {
+	return d.Group || len(d.Participants) > 2
+}
+
+func (d *Dialog) HasParticipant(userID string) bool {
+	for _, id := range d.Participants {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Admins returns the participants who administer the group chat.
+// The group chats created before the admins were introduced are administered by their creator.
+func (d *Dialog) Admins() []string {
+	if !d.IsGroup() {
+		return nil
+	}
+	if len(d.AdminIDs) == 0 && len(d.Participants) != 0 {
+		return d.Participants[:1]
+	}
+	return d.AdminIDs
+}
+
+func (d *Dialog) IsAdmin(userID string) bool {
+	for _, id := range d.Admins() {
+		if id == userID {
+			return d.HasParticipant(userID)
+		}
+	}
+	return false
 }
@@ -0,0 +1,179 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Broker delivers the messages of the dialogs to every instance of the service,
+// each instance passes them on to its own connections.
+type Broker interface {
+	// Publish sends the message to all subscribers of its dialog.
+	Publish(ctx context.Context, msg *dto.Message) error
+	// Subscribe calls handle for every message published to the dialog until unsubscribe is called.
+	Subscribe(ctx context.Context, dialogID string, handle func(msg *dto.Message)) (unsubscribe func(), err error)
+	Close() error
+}
+
+// DialogBroker is the broker Dialog.Emit publishes through, it is replaced by the configured one on start.
+var DialogBroker Broker = NewMemoryBroker()
+
+// NewBroker creates the broker chosen in the config.
+func NewBroker(log *logrus.Entry) (Broker, error) {
+	switch brokerType := viper.GetString(constants.ConfigChatBrokerType); brokerType {
+	case constants.ChatBrokerMemory, "":
+		return NewMemoryBroker(), nil
+	case constants.ChatBrokerRedis:
+		return NewRedisBroker(log, &redis.Options{
+			Addr:     viper.GetString(constants.ConfigChatBrokerAddress),
+			Password: viper.GetString(constants.ConfigChatBrokerPassword),
+		})
+	default:
+		return nil, fmt.Errorf("unknown chat broker type: %s", brokerType)
+	}
+}
+
+// MemoryBroker delivers the messages within the process, it is enough for a single instance.
+type MemoryBroker struct {
+	sync.Mutex
+	lastID   int
+	handlers map[string]map[int]func(msg *dto.Message)
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{handlers: make(map[string]map[int]func(msg *dto.Message))}
+}
+
+func (b *MemoryBroker) Publish(_ context.Context, msg *dto.Message) error {
+	b.Lock()
+	handlers := make([]func(msg *dto.Message), 0, len(b.handlers[msg.DialogID]))
+	for _, handle := range b.handlers[msg.DialogID] {
+		handlers = append(handlers, handle)
+	}
+	b.Unlock()
+
+	// The handlers may block, so they are called without the lock.
+	for _, handle := range handlers {
+		handle(msg)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(_ context.Context, dialogID string, handle func(msg *dto.Message)) (func(), error) {
+	b.Lock()
+	defer b.Unlock()
+	b.lastID++
+	id := b.lastID
+	if b.handlers[dialogID] == nil {
+		b.handlers[dialogID] = make(map[int]func(msg *dto.Message))
+	}
+	b.handlers[dialogID][id] = handle
+
+	return func() {
+		b.Lock()
+		defer b.Unlock()
+		delete(b.handlers[dialogID], id)
+		if len(b.handlers[dialogID]) == 0 {
+			delete(b.handlers, dialogID)
+		}
+	}, nil
+}
+
+// subscribers returns how many handlers the dialog has.
+func (b *MemoryBroker) subscribers(dialogID string) int {
+	b.Lock()
+	defer b.Unlock()
+	return len(b.handlers[dialogID])
+}
+
+func (b *MemoryBroker) Close() error {
+	return nil
+}
+
+// RedisBroker delivers the messages through Redis pub/sub, so the instances of the service
+// behind a load balancer reach each other's connections. Every instance subscribes only
+// to the dialogs its connections have joined.
+type RedisBroker struct {
+	sync.Mutex
+	client *redis.Client
+	pubsub *redis.PubSub
+	local  *MemoryBroker
+	log    *logrus.Entry
+}
+
+func NewRedisBroker(log *logrus.Entry, opts *redis.Options) (*RedisBroker, error) {
+	client := redis.NewClient(opts)
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	b := &RedisBroker{
+		client: client,
+		pubsub: client.Subscribe(context.Background()),
+		local:  NewMemoryBroker(),
+		log:    log,
+	}
+	go b.receive()
+	return b, nil
+}
+
+func (b *RedisBroker) receive() {
+	for m := range b.pubsub.Channel() {
+		msg := new(dto.Message)
+		if err := json.Unmarshal([]byte(m.Payload), msg); err != nil {
+			b.log.Errorf("failed to decode message of %s: %s", m.Channel, err)
+			continue
+		}
+		_ = b.local.Publish(context.Background(), msg)
+	}
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, msg *dto.Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, redisChannel(msg.DialogID), payload).Err()
+}
+
+func (b *RedisBroker) Subscribe(ctx context.Context, dialogID string, handle func(msg *dto.Message)) (func(), error) {
+	b.Lock()
+	defer b.Unlock()
+	if b.local.subscribers(dialogID) == 0 {
+		if err := b.pubsub.Subscribe(ctx, redisChannel(dialogID)); err != nil {
+			return nil, err
+		}
+	}
+	unsubscribe, _ := b.local.Subscribe(ctx, dialogID, handle)
+
+	return func() {
+		b.Lock()
+		defer b.Unlock()
+		unsubscribe()
+		if b.local.subscribers(dialogID) == 0 {
+			if err := b.pubsub.Unsubscribe(context.Background(), redisChannel(dialogID)); err != nil {
+				b.log.Errorf("failed to unsubscribe from dialog %s: %s", dialogID, err)
+			}
+		}
+	}, nil
+}
+
+func (b *RedisBroker) Close() error {
+	if err := b.pubsub.Close(); err != nil {
+		return err
+	}
+	return b.client.Close()
+}
+
+func redisChannel(dialogID string) string {
+	return constants.ChatBrokerChannelPrefix + dialogID
+}
@@ -0,0 +1,108 @@
+package chat
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMemoryBroker(t *testing.T) {
+	broker := NewMemoryBroker()
+	ctx := context.Background()
+
+	var received []*dto.Message
+	unsubscribe, err := broker.Subscribe(ctx, "1", func(msg *dto.Message) { received = append(received, msg) })
+	require.Nil(t, err)
+
+	msg := ConstructMessage("1", constants.SendChat, "u1", constants.Empty, "hi")
+	assert.Nil(t, broker.Publish(ctx, msg))
+	assert.Nil(t, broker.Publish(ctx, ConstructMessage("2", constants.SendChat, "u1", constants.Empty, "hi")))
+	assert.Equal(t, []*dto.Message{msg}, received, "only the messages of the dialog must be received")
+
+	unsubscribe()
+	assert.Nil(t, broker.Publish(ctx, msg))
+	assert.Len(t, received, 1)
+	assert.Equal(t, 0, broker.subscribers("1"))
+}
+
+// TestRedisBroker runs two brokers as if they were two instances of the app.
+func TestRedisBroker(t *testing.T) {
+	server := miniredis.RunT(t)
+	log := logrus.NewEntry(logrus.New())
+
+	first, err := NewRedisBroker(log, &redis.Options{Addr: server.Addr()})
+	require.Nil(t, err)
+	defer first.Close()
+	second, err := NewRedisBroker(log, &redis.Options{Addr: server.Addr()})
+	require.Nil(t, err)
+	defer second.Close()
+
+	ctx := context.Background()
+	received := make(chan *dto.Message, 1)
+	unsubscribe, err := first.Subscribe(ctx, "1", func(msg *dto.Message) { received <- msg })
+	require.Nil(t, err)
+
+	msg := ConstructMessage("1", constants.SendChat, "u1", constants.Empty, "hi")
+	assert.Equal(t, msg, publishUntilReceived(t, second, msg, received))
+
+	unsubscribe()
+	assert.Eventually(t, func() bool { return len(server.PubSubChannels("")) == 0 }, time.Second, 10*time.Millisecond,
+		"the instance must leave the channel of the dialog nobody listens to")
+}
+
+// TestDialogEmit checks that a message sent on one instance reaches the members of the dialog connected to another one.
+func TestDialogEmit(t *testing.T) {
+	server := miniredis.RunT(t)
+	log := logrus.NewEntry(logrus.New())
+
+	local, err := NewRedisBroker(log, &redis.Options{Addr: server.Addr()})
+	require.Nil(t, err)
+	defer local.Close()
+	remote, err := NewRedisBroker(log, &redis.Options{Addr: server.Addr()})
+	require.Nil(t, err)
+	defer remote.Close()
+
+	DialogBroker = local
+	defer func() { DialogBroker = NewMemoryBroker() }()
+
+	room, err := NewRoom("1")
+	require.Nil(t, err)
+	defer room.Stop()
+
+	conn := &Conn{ID: "u2", Send: make(chan dto.Message, 1), Dialogs: make(map[string]string), log: log}
+	registerConn(conn)
+	defer unregisterConn(conn)
+	room.Join(conn)
+	<-conn.Send
+
+	received := make(chan *dto.Message, 1)
+	go func() {
+		msg := <-conn.Send
+		received <- &msg
+	}()
+
+	msg := ConstructMessage("1", constants.SendChat, "u1", constants.Empty, "hi")
+	assert.Equal(t, msg, publishUntilReceived(t, remote, msg, received))
+}
+
+// publishUntilReceived publishes the message until it is received: a subscription takes effect
+// only after redis has handled it, so the first messages may be missed.
+func publishUntilReceived(t *testing.T, broker Broker, msg *dto.Message, received <-chan *dto.Message) *dto.Message {
+	for i := 0; i < 50; i++ {
+		require.Nil(t, broker.Publish(context.Background(), msg))
+		select {
+		case got := <-received:
+			return got
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	t.Fatal("the message is not received")
+	return nil
+}
@@ -15,7 +15,7 @@ import (
 	"github.com/gorilla/websocket"
 )
 
-// The Conn type represents a single client.
+// The Conn type represents a single client. A user has a Conn for every device or tab.
 type Conn struct {
 	sync.Mutex
 	Socket  *websocket.Conn
@@ -25,57 +25,231 @@ type Conn struct {
 	reg     *service.Registry
 	log     *logrus.Entry
 	ctx     echo.Context
+	closed  bool
+	typing  map[string]*typingTimer
+
+	// syncing is set while the missed events are being caught up on, the live ones are held back in pending.
+	syncing bool
+	pending []dto.Message
+}
+
+// typingTimer stops typing in a dialog when it expires.
+type typingTimer struct {
+	timer *time.Timer
 }
 
+// typingTimeout is a variable to be shortened in tests.
+var typingTimeout = constants.TypingTimeout
+
 var (
-	// ConnManager Stores all Conn types by their uuid.
+	// ConnManager Stores all Conn types by the uuid of their user.
 	ConnManager = struct {
 		sync.Mutex
-		Conns map[string]*Conn
+		Conns map[string]map[*Conn]struct{}
 	}{
-		Conns: make(map[string]*Conn),
+		Conns: make(map[string]map[*Conn]struct{}),
 	}
 )
 
+func registerConn(c *Conn) {
+	ConnManager.Lock()
+	defer ConnManager.Unlock()
+	if ConnManager.Conns[c.ID] == nil {
+		ConnManager.Conns[c.ID] = make(map[*Conn]struct{})
+	}
+	ConnManager.Conns[c.ID][c] = struct{}{}
+}
+
+func unregisterConn(c *Conn) {
+	ConnManager.Lock()
+	defer ConnManager.Unlock()
+	delete(ConnManager.Conns[c.ID], c)
+	if len(ConnManager.Conns[c.ID]) == 0 {
+		delete(ConnManager.Conns, c.ID)
+	}
+}
+
+// userConns returns all connections of the user.
+func userConns(userID string) []*Conn {
+	ConnManager.Lock()
+	defer ConnManager.Unlock()
+	conns := make([]*Conn, 0, len(ConnManager.Conns[userID]))
+	for c := range ConnManager.Conns[userID] {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
 // HandleData Handles incoming, error free messages.
 func HandleData(c *Conn, msg *dto.Message) {
 	if c.IsDialogExist(msg.DialogID) {
 		switch msg.Event {
 		case constants.JoinChat:
 			c.Join(msg.DialogID)
-		case constants.LeaveChat:
+		case constants.LeaveChat, constants.LeftChat:
 			c.Leave(msg.DialogID)
 		case constants.JoinedChat:
 			c.Emit(msg)
-		case constants.LeftChat:
-			c.LeftChat(msg)
 		case constants.ReadChat:
 			c.ReadMessage(msg)
+		case constants.TypingStart:
+			c.StartTyping(msg.DialogID)
+		case constants.TypingStop:
+			c.StopTyping(msg.DialogID)
 		case constants.SendChat:
 			c.SendMessage(msg)
 		case constants.SendFile:
 			c.SendFile(msg)
 		case constants.SendSticker:
 			c.SendSticker(msg)
+		case constants.EditChat:
+			c.EditMessage(msg)
+		case constants.DeleteChat:
+			c.DeleteMessage(msg)
+		case constants.ReactChat:
+			c.ReactMessage(msg)
+		case constants.UnreactChat:
+			c.UnreactMessage(msg)
+		case constants.AddMember, constants.RemoveMember, constants.RenameChat, constants.SetChatImage,
+			constants.LeaveGroup, constants.AddAdmin, constants.RemoveAdmin:
+			c.ManageChat(msg)
 		default:
-			c.Send <- *ConstructMessage(msg.DialogID, constants.ErrChat, c.ID, constants.Empty, constants.ErrRequest)
+			c.send(*ConstructMessage(msg.DialogID, constants.ErrChat, c.ID, constants.Empty, constants.ErrRequest))
+		}
+	}
+}
+
+// send queues the message to the client. The Conn which can't keep up is closed.
+func (c *Conn) send(msg dto.Message) {
+	c.Lock()
+	defer c.Unlock()
+	if c.closed {
+		return
+	}
+	if c.syncing {
+		if len(c.pending) == constants.SendBufferSize {
+			c.tooSlow()
+			return
 		}
+		c.pending = append(c.pending, msg)
+		return
 	}
+	c.enqueue(msg)
 }
+
+// enqueue passes the message to the writer, the Conn must be locked.
+func (c *Conn) enqueue(msg dto.Message) {
+	select {
+	case c.Send <- msg:
+	default:
+		c.tooSlow()
+	}
+}
+
+func (c *Conn) tooSlow() {
+	c.log.Warnf("connection of user %s is too slow, closing it", c.ID)
+	c.closed = true
+	close(c.Send)
+}
+
+// sync writes the events the user has missed since the seq before the live events resume. The live events
+// which come meanwhile are held back, the ones which have been caught up on already are dropped.
+// The client is told the seq it has caught up to with the "synced" event, or to load the dialogs anew with
+// the "resync" event if some events are gone.
+func (c *Conn) sync(since int64, write func(v interface{}) error) error {
+	last, reset := since, false
+	for {
+		response, err := c.reg.ChatEventService.GetEvents(context.Background(), &dto.GetChatEventsRequest{
+			UserID: c.ID,
+			Since:  last,
+			Limit:  constants.ChatSyncBatchSize,
+		})
+		if err != nil {
+			c.log.Errorf("GetEvents error: %s", err)
+			reset = true
+			break
+		}
+		for _, event := range response.Events {
+			if err := write(event); err != nil {
+				return err
+			}
+		}
+		if response.HasMore && response.LastSeq == last {
+			// The events after the last one are being added yet.
+			time.Sleep(constants.ChatSyncRetryDelay)
+		}
+		last, reset = response.LastSeq, response.Reset
+		if reset || !response.HasMore {
+			break
+		}
+	}
+
+	marker := ConstructMessage(constants.Empty, constants.SyncedChat, c.ID, constants.Empty, constants.Empty)
+	if reset {
+		marker.Event = constants.ResyncChat
+	}
+	marker.Seq = last
+	if err := write(*marker); err != nil {
+		return err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	pending := c.pending
+	c.syncing, c.pending = false, nil
+	for _, msg := range pending {
+		if c.closed {
+			break
+		}
+		if msg.Seq == 0 || msg.Seq > last {
+			c.enqueue(msg)
+		}
+	}
+	return nil
+}
+
+// disconnect removes the Conn from its Dialogs and from the connections of the user,
+// the other connections of the user stay where they are.
+func (c *Conn) disconnect() {
+	c.Lock()
+	typing := make([]string, 0, len(c.typing))
+	for name := range c.typing {
+		typing = append(typing, name)
+	}
+	c.Unlock()
+	for _, name := range typing {
+		c.StopTyping(name)
+	}
+
+	c.Lock()
+	dialogs := c.Dialogs
+	c.Dialogs = make(map[string]string)
+	c.Unlock()
+
+	for name := range dialogs {
+		DialogManager.Lock()
+		room, ok := DialogManager.Rooms[name]
+		DialogManager.Unlock()
+		if ok {
+			room.Drop(c)
+		}
+	}
+	unregisterConn(c)
+
+	c.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.Send)
+	}
+	c.Unlock()
+}
+
 func (c *Conn) readPump() {
 	defer func() {
-		c.Lock()
-		for name := range c.Dialogs {
-			c.Unlock()
-			DialogManager.Lock()
-			room, ok := DialogManager.Rooms[name]
-			DialogManager.Unlock()
-			if ok {
-				room.Leave(c)
-			}
-			c.Lock()
+		c.disconnect()
+		if err := c.reg.PresenceService.Disconnect(context.Background(), c.ID); err != nil {
+			c.log.Errorf("presence Disconnect error: %s", err)
 		}
-		c.Unlock()
 		_ = c.Socket.Close()
 	}()
 	c.Socket.SetReadLimit(constants.MaxMessageSize)
@@ -86,36 +260,12 @@ func (c *Conn) readPump() {
 	})
 	for {
 		data := new(dto.Message)
-		err := c.Socket.ReadJSON(&data)
-		data.AuthorID = c.ID
-
-		if err != nil {
-			if _, wok := err.(*websocket.CloseError); !wok {
-				break
-			}
-			c.Lock()
-			for name := range c.Dialogs {
-				c.Unlock()
-				DialogManager.Lock()
-				room, rok := DialogManager.Rooms[name]
-				DialogManager.Unlock()
-				if !rok {
-					c.Lock()
-					continue
-				}
-				room.Emit(c, ConstructMessage(name, constants.LeftChat, c.ID, constants.Empty, c.ID))
-				room.Lock()
-				delete(room.Members, c.ID)
-				members := len(room.Members)
-				room.Unlock()
-				if members == 0 {
-					room.Stop()
-				}
-				c.Lock()
-			}
-			c.Unlock()
+		if err := c.Socket.ReadJSON(&data); err != nil {
 			break
 		}
+		data.AuthorID = c.ID
+		// The seqs are given by the event log only.
+		data.Seq, data.Seqs = 0, nil
 		HandleData(c, data)
 	}
 }
@@ -125,12 +275,21 @@ func (c *Conn) write(mt int, payload []byte) error {
 	return c.Socket.WriteMessage(mt, payload)
 }
 
-func (c *Conn) writePump() {
+// writePump writes the events to the client. If since isn't constants.NoSync,
+// the events missed since that seq are written first.
+func (c *Conn) writePump(since int64) {
 	ticker := time.NewTicker(constants.PingPeriod)
+	heartbeat := time.NewTicker(constants.PresenceHeartbeat)
 	defer func() {
 		ticker.Stop()
+		heartbeat.Stop()
 		_ = c.Socket.Close()
 	}()
+	if since != constants.NoSync {
+		if err := c.sync(since, c.Socket.WriteJSON); err != nil {
+			return
+		}
+	}
 	for {
 		select {
 		case msg, ok := <-c.Send:
@@ -145,28 +304,47 @@ func (c *Conn) writePump() {
 			if err := c.write(websocket.PingMessage, []byte{}); err != nil {
 				return
 			}
+		case <-heartbeat.C:
+			if err := c.reg.PresenceService.Touch(context.Background(), c.ID); err != nil {
+				c.log.Errorf("presence Touch error: %s", err)
+			}
 		}
 	}
 }
 
 // Join Adds the Conn to a Dialog. If the Dialog does not exist, it is created.
 func (c *Conn) Join(name string) {
-	DialogManager.Lock()
-	room, ok := DialogManager.Rooms[name]
-	DialogManager.Unlock()
-	if !ok {
-		room = NewRoom(name)
-	}
 	c.Lock()
 	c.Dialogs[name] = name
 	c.Unlock()
-	room.Join(c)
+	for {
+		DialogManager.Lock()
+		room, ok := DialogManager.Rooms[name]
+		DialogManager.Unlock()
+		if !ok {
+			var err error
+			if room, err = NewRoom(name); err != nil {
+				c.log.Errorf("failed to create room %s: %s", name, err)
+				c.Lock()
+				delete(c.Dialogs, name)
+				c.Unlock()
+				c.send(*ConstructMessage(name, constants.ErrChat, c.ID, constants.Empty, constants.ErrChatUnavailable))
+				return
+			}
+		}
+		// The Dialog may have stopped since it was found, then a new one is created.
+		if room.Join(c) {
+			return
+		}
+	}
 }
 
 // SendMessage ...
 func (c *Conn) SendMessage(msg *dto.Message) {
 	msgID, err := core.GenUUID()
 	if err != nil {
+		c.log.Errorf("GenUUID error: %s", err)
+		c.send(*ConstructMessage(msg.DialogID, constants.ErrChat, c.ID, constants.Empty, err.Error()))
 		return
 	}
 	msg.ID = msgID
@@ -175,10 +353,12 @@ func (c *Conn) SendMessage(msg *dto.Message) {
 	_, err = c.reg.ChatService.SendMessage(context.Background(), &dto.SendMessageRequest{Message: *msg})
 	if err != nil {
 		c.log.Errorf("don't send message: %s", err)
+		c.send(*ConstructMessage(msg.DialogID, constants.ErrChat, c.ID, constants.Empty, err.Error()))
 		return
 	}
 	c.log.Info("send message")
 	c.Emit(msg)
+	c.StopTyping(msg.DialogID)
 }
 
 func (c *Conn) SendFile(msg *dto.Message) {
@@ -208,6 +388,7 @@ func (c *Conn) SendFile(msg *dto.Message) {
 	}
 	c.log.Infof("send message")
 	c.Emit(msg)
+	c.StopTyping(msg.DialogID)
 }
 
 // SendMessage ...
@@ -226,60 +407,121 @@ func (c *Conn) SendSticker(msg *dto.Message) {
 	}
 	c.log.Info("send message")
 	c.Emit(msg)
+	c.StopTyping(msg.DialogID)
+}
+
+// ReadMessage marks the message as read and lets its author (msg.DestinID) and the other devices of the reader know.
+func (c *Conn) ReadMessage(msg *dto.Message) {
+	if msg.DestinID != constants.Empty && msg.Body != constants.Empty {
+		_, err := c.reg.ChatService.ReadMessage(context.Background(), &dto.ReadMessageRequest{Message: *msg})
+		if err != nil {
+			c.log.Errorf("don't read message in db")
+			return
+		}
+		c.log.Infof("read message")
+		c.Emit(msg)
+	}
 }
 
-// LeftChat ...
-func (c *Conn) LeftChat(msg *dto.Message) {
+// EditMessage replaces the body of the message msg.ID and lets the Dialog know.
+func (c *Conn) EditMessage(msg *dto.Message) {
+	response, err := c.reg.ChatService.EditMessage(context.Background(), &dto.EditMessageRequest{
+		UserID:    c.ID,
+		DialogID:  msg.DialogID,
+		MessageID: msg.ID,
+		Body:      msg.Body,
+	})
+	if err != nil {
+		c.log.Errorf("don't edit message: %s", err)
+		c.send(*ConstructMessage(msg.DialogID, constants.ErrChat, c.ID, constants.Empty, err.Error()))
+		return
+	}
+	msg.EditedAt = response.EditedAt
 	c.Emit(msg)
-	DialogManager.Lock()
-	room, ok := DialogManager.Rooms[msg.DialogID]
-	DialogManager.Unlock()
-	if !ok {
+}
+
+// DeleteMessage deletes the message msg.ID for the user or, if msg.ForEveryone is set, for everyone.
+// The message deleted for the user only is synced between the devices of the user.
+func (c *Conn) DeleteMessage(msg *dto.Message) {
+	_, err := c.reg.ChatService.DeleteMessage(context.Background(), &dto.DeleteMessageRequest{
+		UserID:      c.ID,
+		DialogID:    msg.DialogID,
+		MessageID:   msg.ID,
+		ForEveryone: msg.ForEveryone,
+	})
+	if err != nil {
+		c.log.Errorf("don't delete message: %s", err)
+		c.send(*ConstructMessage(msg.DialogID, constants.ErrChat, c.ID, constants.Empty, err.Error()))
 		return
 	}
-	room.Lock()
-	delete(room.Members, c.ID)
-	members := len(room.Members)
-	room.Unlock()
-	if members == 0 {
-		room.Stop()
+	msg.Body = constants.Empty
+	c.Emit(msg)
+}
+
+// ReactMessage reacts to the message msg.ID with the emoji msg.Body and lets the Dialog know.
+func (c *Conn) ReactMessage(msg *dto.Message) {
+	_, err := c.reg.ChatService.ReactMessage(context.Background(), reactRequest(c.ID, msg))
+	if err != nil {
+		c.log.Errorf("don't react to message: %s", err)
+		c.send(*ConstructMessage(msg.DialogID, constants.ErrChat, c.ID, constants.Empty, err.Error()))
+		return
 	}
+	c.Emit(msg)
 }
 
-// ReadMessage ...
-func (c *Conn) ReadMessage(msg *dto.Message) {
-	if msg.DestinID != constants.Empty {
-		DialogManager.Lock()
-		room, rok := DialogManager.Rooms[msg.DialogID]
-		DialogManager.Unlock()
-		if !rok {
-			return
-		}
-		room.Lock()
-		id, mok := room.Members[msg.DestinID]
-		room.Unlock()
-		if !mok {
-			return
-		}
-		ConnManager.Lock()
-		dst, cok := ConnManager.Conns[id]
-		ConnManager.Unlock()
-		if !cok {
-			return
-		}
-		if msg.Body != constants.Empty {
+// UnreactMessage takes the reaction msg.Body to the message msg.ID back and lets the Dialog know.
+func (c *Conn) UnreactMessage(msg *dto.Message) {
+	_, err := c.reg.ChatService.UnreactMessage(context.Background(), reactRequest(c.ID, msg))
+	if err != nil {
+		c.log.Errorf("don't unreact to message: %s", err)
+		c.send(*ConstructMessage(msg.DialogID, constants.ErrChat, c.ID, constants.Empty, err.Error()))
+		return
+	}
+	c.Emit(msg)
+}
 
-			_, err := c.reg.ChatService.ReadMessage(context.Background(), &dto.ReadMessageRequest{Message: *msg})
-			if err != nil {
-				c.log.Errorf("don't read message in db")
-				return
-			}
-			c.log.Infof("read message")
-			dst.Send <- *msg
+// ManageChat changes the group chat and lets the Dialog know with the events of the system messages.
+// The member is msg.DestinID, the new name or image of the chat is msg.Body.
+func (c *Conn) ManageChat(msg *dto.Message) {
+	ctx := context.Background()
+	var response *dto.ChatActionResponse
+	var err error
+	switch msg.Event {
+	case constants.AddMember:
+		response, err = c.reg.ChatService.AddMembers(ctx, &dto.AddMembersRequest{UserID: c.ID, DialogID: msg.DialogID, MemberIDs: []string{msg.DestinID}})
+	case constants.RemoveMember:
+		response, err = c.reg.ChatService.RemoveMember(ctx, &dto.RemoveMemberRequest{UserID: c.ID, DialogID: msg.DialogID, MemberID: msg.DestinID})
+	case constants.RenameChat:
+		response, err = c.reg.ChatService.EditChat(ctx, &dto.EditChatRequest{UserID: c.ID, DialogID: msg.DialogID, Name: msg.Body})
+	case constants.SetChatImage:
+		response, err = c.reg.ChatService.EditChat(ctx, &dto.EditChatRequest{UserID: c.ID, DialogID: msg.DialogID, Image: msg.Body})
+	case constants.LeaveGroup:
+		response, err = c.reg.ChatService.LeaveChat(ctx, &dto.LeaveChatRequest{UserID: c.ID, DialogID: msg.DialogID})
+	case constants.AddAdmin, constants.RemoveAdmin:
+		response, err = c.reg.ChatService.SetChatAdmin(ctx, &dto.SetChatAdminRequest{
+			UserID:   c.ID,
+			DialogID: msg.DialogID,
+			MemberID: msg.DestinID,
+			Admin:    msg.Event == constants.AddAdmin,
+		})
+	}
+	if err != nil {
+		c.log.Errorf("don't change chat: %s", err)
+		c.send(*ConstructMessage(msg.DialogID, constants.ErrChat, c.ID, constants.Empty, err.Error()))
+		return
+	}
+
+	for i := range response.Messages {
+		if err := Broadcast(ctx, &response.Messages[i]); err != nil {
+			c.log.Errorf("Broadcast error: %s", err)
 		}
 	}
 }
 
+func reactRequest(userID string, msg *dto.Message) *dto.ReactMessageRequest {
+	return &dto.ReactMessageRequest{UserID: userID, DialogID: msg.DialogID, MessageID: msg.ID, Emoji: msg.Body}
+}
+
 // Leave Removes the Conn from a Dialog.
 func (c *Conn) Leave(name string) {
 	DialogManager.Lock()
@@ -300,12 +542,49 @@ func (c *Conn) Leave(name string) {
 	room.Leave(c)
 }
 
+// StartTyping tells the Dialog that the user is typing, until they stop or typingTimeout passes.
+func (c *Conn) StartTyping(name string) {
+	c.Lock()
+	previous, typing := c.typing[name]
+	if typing {
+		previous.timer.Stop()
+	}
+	expiry := new(typingTimer)
+	expiry.timer = time.AfterFunc(typingTimeout, func() { c.stopTyping(name, expiry) })
+	c.typing[name] = expiry
+	c.Unlock()
+
+	if !typing {
+		c.Emit(ConstructMessage(name, constants.TypingStart, c.ID, constants.Empty, constants.Empty))
+	}
+}
+
+// StopTyping tells the Dialog that the user has stopped typing.
+func (c *Conn) StopTyping(name string) {
+	c.stopTyping(name, nil)
+}
+
+// stopTyping stops typing in the Dialog. If expiry is set, typing is stopped only if it hasn't been started again since.
+func (c *Conn) stopTyping(name string, expiry *typingTimer) {
+	c.Lock()
+	current, ok := c.typing[name]
+	if !ok || (expiry != nil && current != expiry) {
+		c.Unlock()
+		return
+	}
+	current.timer.Stop()
+	delete(c.typing, name)
+	c.Unlock()
+
+	c.Emit(ConstructMessage(name, constants.TypingStop, c.ID, constants.Empty, constants.Empty))
+}
+
 // IsDialogExist CheckDialog ...
 func (c *Conn) IsDialogExist(name string) bool {
 
 	err := c.reg.ChatService.CheckDialog(context.Background(), &dto.CheckDialogRequest{UserID: c.ID, DialogID: name})
 	if err != nil {
-		c.Send <- *ConstructMessage(name, constants.ErrChat, c.ID, constants.Empty, constants.ErrChatDoNotExist)
+		c.send(*ConstructMessage(name, constants.ErrChat, c.ID, constants.Empty, constants.ErrChatDoNotExist))
 		c.log.Debug("room doesn't exist")
 		return false
 	}
@@ -323,7 +602,7 @@ func (c *Conn) Emit(msg *dto.Message) {
 }
 
 // NewConnection Upgrades an HTTP connection and creates a new Conn type.
-func NewConnection(ctx *echo.Context, log *logrus.Entry, registry *service.Registry, userID string) (*Conn, error) {
+func NewConnection(ctx *echo.Context, log *logrus.Entry, registry *service.Registry, userID string, since int64) (*Conn, error) {
 	socket, err := constants.Upgrader.Upgrade((*ctx).Response(), (*ctx).Request(), nil)
 	if err != nil {
 		return nil, err
@@ -331,26 +610,30 @@ func NewConnection(ctx *echo.Context, log *logrus.Entry, registry *service.Regis
 	conn := &Conn{
 		Socket:  socket,
 		ID:      userID,
-		Send:    make(chan dto.Message),
+		Send:    make(chan dto.Message, constants.SendBufferSize),
 		Dialogs: make(map[string]string),
 		log:     log,
 		reg:     registry,
 		ctx:     *ctx,
+		typing:  make(map[string]*typingTimer),
+		syncing: since != constants.NoSync,
+	}
+	registerConn(conn)
+	if err := registry.PresenceService.Connect(context.Background(), conn.ID); err != nil {
+		log.Errorf("presence Connect error: %s", err)
 	}
-	ConnManager.Lock()
-	ConnManager.Conns[conn.ID] = conn
-	ConnManager.Unlock()
 	return conn, nil
 }
 
 // SocketHandler Calls NewConnection, starts the returned Conn's writer, joins the root room, and finally starts the Conn's reader.
-func SocketHandler(ctx *echo.Context, log *logrus.Entry, registry *service.Registry, userID string) error {
-	conn, err := NewConnection(ctx, log, registry, userID)
+// The events missed since the seq are caught up on first, unless it is constants.NoSync.
+func SocketHandler(ctx *echo.Context, log *logrus.Entry, registry *service.Registry, userID string, since int64) error {
+	conn, err := NewConnection(ctx, log, registry, userID, since)
 	if err != nil {
 		return err
 	}
 
-	go conn.writePump()
+	go conn.writePump(since)
 	go conn.readPump()
 	conn.log.Infof("new user: %s", conn.ID)
 
@@ -0,0 +1,284 @@
+package chat
+
+import (
+	"context"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/service"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestConn(userID string) *Conn {
+	conn := &Conn{
+		ID:      userID,
+		Send:    make(chan dto.Message, constants.SendBufferSize),
+		Dialogs: make(map[string]string),
+		log:     logrus.NewEntry(logrus.New()),
+		typing:  make(map[string]*typingTimer),
+	}
+	registerConn(conn)
+	return conn
+}
+
+func receive(t *testing.T, conn *Conn) dto.Message {
+	select {
+	case msg := <-conn.Send:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatalf("connection of user %s receives nothing", conn.ID)
+		return dto.Message{}
+	}
+}
+
+func TestSeveralDevices(t *testing.T) {
+	phone, laptop, friend := newTestConn("u1"), newTestConn("u1"), newTestConn("u2")
+	defer friend.disconnect()
+
+	phone.Join("d1")
+	receive(t, phone)
+	laptop.Join("d1")
+	receive(t, laptop)
+	friend.Join("d1")
+	receive(t, friend)
+
+	msg := ConstructMessage("d1", constants.SendChat, "u2", constants.Empty, "hi")
+	friend.Emit(msg)
+	assert.Equal(t, *msg, receive(t, phone))
+	assert.Equal(t, *msg, receive(t, laptop), "every device of the user must receive the message")
+	assert.Equal(t, *msg, receive(t, friend))
+
+	read := ConstructMessage("d1", constants.ReadChat, "u1", "u2", "m1")
+	phone.Emit(read)
+	assert.Equal(t, *read, receive(t, laptop), "the read state must be synced between the devices")
+	assert.Equal(t, *read, receive(t, friend))
+	receive(t, phone)
+
+	phone.disconnect()
+	assert.Len(t, userConns("u1"), 1)
+	friend.Emit(msg)
+	assert.Equal(t, *msg, receive(t, laptop), "the user is in the dialog while one of their devices is connected")
+	assert.Equal(t, *msg, receive(t, friend))
+
+	laptop.disconnect()
+	assert.Empty(t, userConns("u1"))
+	left := receive(t, friend)
+	assert.Equal(t, constants.LeftChat, left.Event)
+	assert.Equal(t, "u1", left.Body)
+}
+
+func TestDialogStopsWithoutConnections(t *testing.T) {
+	conn := newTestConn("u1")
+	conn.Join("d2")
+	receive(t, conn)
+
+	DialogManager.Lock()
+	room := DialogManager.Rooms["d2"]
+	DialogManager.Unlock()
+	require.NotNil(t, room)
+
+	conn.disconnect()
+	<-room.done
+	DialogManager.Lock()
+	_, ok := DialogManager.Rooms["d2"]
+	DialogManager.Unlock()
+	assert.False(t, ok)
+
+	_, open := <-conn.Send
+	assert.False(t, open, "the writer of the connection must be stopped")
+}
+
+func TestTyping(t *testing.T) {
+	typingTimeout = 50 * time.Millisecond
+	defer func() { typingTimeout = constants.TypingTimeout }()
+
+	writer, reader := newTestConn("u1"), newTestConn("u2")
+	defer writer.disconnect()
+	defer reader.disconnect()
+	writer.Join("d3")
+	receive(t, writer)
+	reader.Join("d3")
+	receive(t, reader)
+
+	writer.StartTyping("d3")
+	assert.Equal(t, constants.TypingStart, receive(t, reader).Event)
+	writer.StartTyping("d3")
+	writer.StopTyping("d3")
+	stop := receive(t, reader)
+	assert.Equal(t, constants.TypingStop, stop.Event, "typing must be started once until it is stopped")
+	assert.Equal(t, "u1", stop.AuthorID)
+
+	writer.StartTyping("d3")
+	assert.Equal(t, constants.TypingStart, receive(t, reader).Event)
+	assert.Equal(t, constants.TypingStop, receive(t, reader).Event, "typing must expire by itself")
+	select {
+	case msg := <-reader.Send:
+		t.Fatalf("unexpected %s", msg.Event)
+	case <-time.After(2 * typingTimeout):
+	}
+}
+
+func TestDeleteForMe(t *testing.T) {
+	phone, laptop, friend := newTestConn("u1"), newTestConn("u1"), newTestConn("u2")
+	defer phone.disconnect()
+	defer laptop.disconnect()
+	defer friend.disconnect()
+	phone.Join("d4")
+	receive(t, phone)
+	friend.Join("d4")
+	receive(t, friend)
+
+	hide := ConstructMessage("d4", constants.DeleteChat, "u1", constants.Empty, constants.Empty)
+	hide.ID = "m1"
+	phone.Emit(hide)
+	assert.Equal(t, *hide, receive(t, phone))
+	assert.Equal(t, *hide, receive(t, laptop), "the message must be hidden on every device of the user")
+
+	deleted := *hide
+	deleted.ForEveryone = true
+	phone.Emit(&deleted)
+	assert.Equal(t, deleted, receive(t, friend), "the message deleted for the user only must not reach the others")
+	receive(t, phone)
+	receive(t, laptop)
+}
+
+func TestRemoveMember(t *testing.T) {
+	admin, member, newcomer := newTestConn("u1"), newTestConn("u2"), newTestConn("u3")
+	defer admin.disconnect()
+	defer member.disconnect()
+	defer newcomer.disconnect()
+	admin.Join("d7")
+	receive(t, admin)
+	member.Join("d7")
+	receive(t, member)
+
+	added := ConstructMessage("d7", constants.AddMember, "u1", "u3", "Alice added Carol")
+	admin.Emit(added)
+	assert.Equal(t, *added, receive(t, newcomer), "the added member must be told though they haven't joined the dialog")
+	receive(t, admin)
+	receive(t, member)
+
+	removed := ConstructMessage("d7", constants.RemoveMember, "u1", "u2", "Alice removed Bob")
+	admin.Emit(removed)
+	assert.Equal(t, *removed, receive(t, member), "the removed member must be told")
+	receive(t, admin)
+
+	admin.Emit(ConstructMessage("d7", constants.SendChat, "u1", constants.Empty, "hi"))
+	receive(t, admin)
+	select {
+	case msg := <-member.Send:
+		t.Fatalf("the removed member receives %s", msg.Event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// testEvents gives every receiver of a message the next seq of their own and the events set to it.
+type testEvents struct {
+	seqs   map[string]int64
+	events []dto.Message
+}
+
+func (e *testEvents) Record(_ context.Context, msg *dto.Message) error {
+	msg.Seqs = make(map[string]int64)
+	for userID := range e.seqs {
+		e.seqs[userID]++
+		msg.Seqs[userID] = e.seqs[userID]
+	}
+	return nil
+}
+
+func (e *testEvents) GetEvents(_ context.Context, request *dto.GetChatEventsRequest) (*dto.GetChatEventsResponse, error) {
+	response := &dto.GetChatEventsResponse{LastSeq: request.Since}
+	for _, event := range e.events {
+		if event.Seq > request.Since && int64(len(response.Events)) < request.Limit {
+			response.Events = append(response.Events, event)
+			response.LastSeq = event.Seq
+		}
+	}
+	response.HasMore = response.LastSeq < e.events[len(e.events)-1].Seq
+	return response, nil
+}
+
+func TestEventSeqs(t *testing.T) {
+	DialogEvents = &testEvents{seqs: map[string]int64{"u1": 10, "u2": 3}}
+	defer func() { DialogEvents = nil }()
+
+	writer, reader := newTestConn("u1"), newTestConn("u2")
+	defer writer.disconnect()
+	defer reader.disconnect()
+	writer.Join("d5")
+	receive(t, writer)
+	reader.Join("d5")
+	receive(t, reader)
+
+	writer.Emit(ConstructMessage("d5", constants.SendChat, "u1", constants.Empty, "hi"))
+	mine, theirs := receive(t, writer), receive(t, reader)
+	assert.Equal(t, int64(11), mine.Seq)
+	assert.Equal(t, int64(4), theirs.Seq, "every receiver must get the seq of their own event log")
+	assert.Nil(t, theirs.Seqs)
+
+	writer.StartTyping("d5")
+	assert.Zero(t, receive(t, reader).Seq, "typing isn't caught up on")
+}
+
+func TestSync(t *testing.T) {
+	events := &testEvents{}
+	for seq := int64(1); seq <= constants.ChatSyncBatchSize+2; seq++ {
+		events.events = append(events.events, dto.Message{DialogID: "d6", Event: constants.SendChat, Seq: seq})
+	}
+
+	conn := newTestConn("u1")
+	defer conn.disconnect()
+	conn.reg = &service.Registry{ChatEventService: events}
+	conn.syncing = true
+
+	// The live events which come while the missed ones are caught up on.
+	conn.send(dto.Message{DialogID: "d6", Event: constants.SendChat, Seq: 2})
+	conn.send(dto.Message{DialogID: "d6", Event: constants.TypingStart})
+	conn.send(dto.Message{DialogID: "d6", Event: constants.SendChat, Seq: constants.ChatSyncBatchSize + 3})
+	select {
+	case msg := <-conn.Send:
+		t.Fatalf("%s is sent before the missed events", msg.Event)
+	default:
+	}
+
+	var written []dto.Message
+	err := conn.sync(1, func(v interface{}) error {
+		written = append(written, v.(dto.Message))
+		return nil
+	})
+	require.Nil(t, err)
+	require.Len(t, written, constants.ChatSyncBatchSize+2)
+	assert.Equal(t, int64(2), written[0].Seq)
+	assert.Equal(t, int64(constants.ChatSyncBatchSize+2), written[len(written)-2].Seq)
+	synced := written[len(written)-1]
+	assert.Equal(t, constants.SyncedChat, synced.Event)
+	assert.Equal(t, int64(constants.ChatSyncBatchSize+2), synced.Seq)
+
+	assert.Equal(t, constants.TypingStart, receive(t, conn).Event, "the live event which has been caught up on must be dropped")
+	assert.Equal(t, int64(constants.ChatSyncBatchSize+3), receive(t, conn).Seq)
+}
+
+// failingChat fails to send every message.
+type failingChat struct {
+	service.ChatService
+}
+
+func (failingChat) SendMessage(context.Context, *dto.SendMessageRequest) (*dto.SendMessageResponse, error) {
+	return nil, constants.ErrReplyNotFound
+}
+
+func TestSendMessageError(t *testing.T) {
+	conn := newTestConn("u1")
+	defer conn.disconnect()
+	conn.reg = &service.Registry{ChatService: failingChat{}}
+
+	conn.SendMessage(ConstructMessage("d8", constants.SendChat, "u1", constants.Empty, "hi"))
+	msg := receive(t, conn)
+	assert.Equal(t, constants.ErrChat, msg.Event, "the author must be told the message isn't sent")
+	assert.Equal(t, "d8", msg.DialogID)
+	assert.Equal(t, constants.ErrReplyNotFound.Error(), msg.Body)
+}
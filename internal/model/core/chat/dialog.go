@@ -1,21 +1,30 @@
 package chat
 
 import (
+	"context"
 	"sync"
 
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/service"
 )
 
 // The Dialog type represents a communication channel.
 type Dialog struct {
 	sync.Mutex
-	Name      string
-	Members   map[string]string
+	Name string
+	// Members are the users in the Dialog with their connections which have joined it.
+	Members   map[string]map[*Conn]struct{}
 	stopchan  chan bool
 	joinchan  chan *Conn
 	leavechan chan *Conn
+	dropchan  chan *Conn
 	Send      chan *DialogMessage
+
+	broker      Broker
+	events      service.ChatEventService
+	done        chan struct{}
+	unsubscribe func()
 }
 
 // Stores all Dialog types by their name.
@@ -26,8 +35,13 @@ var DialogManager = struct {
 	Rooms: make(map[string]*Dialog, 0),
 }
 
+// DialogEvents records the events for the users to catch up on after reconnecting, it is set on start.
+// The events aren't recorded without it.
+var DialogEvents service.ChatEventService
+
 // Message protocol used only with a room's Send channel.
 type DialogMessage struct {
+	// Sender is nil for the messages which come through the broker.
 	Sender *Conn
 	Data   *dto.Message
 }
@@ -43,104 +57,216 @@ func ConstructMessage(room, event, src, dst string, body string) *dto.Message {
 	}
 }
 
-// Starts the Dialog.
+// Starts the Dialog. The Dialog stops by itself once its last connection is gone.
 func (r *Dialog) Start() {
+	defer r.close()
 	for {
 		select {
 		case c := <-r.joinchan:
 			var membersString string
 			r.Lock()
 			for id := range r.Members {
-				r.Unlock()
 				membersString += id + ", "
-				r.Lock()
 			}
-			r.Members[c.ID] = c.ID
+			if r.Members[c.ID] == nil {
+				r.Members[c.ID] = make(map[*Conn]struct{})
+			}
+			r.Members[c.ID][c] = struct{}{}
 			r.Unlock()
 
-			c.Send <- *ConstructMessage(r.Name, constants.JoinedChat, c.ID, constants.Empty, membersString)
+			c.send(*ConstructMessage(r.Name, constants.JoinedChat, c.ID, constants.Empty, membersString))
 
 		case c := <-r.leavechan:
-			r.Lock()
-			id, ok := r.Members[c.ID]
-			r.Unlock()
-			if !ok {
-				break
+			if r.remove(c) {
+				c.send(*ConstructMessage(r.Name, constants.LeftChat, c.ID, constants.Empty, c.ID))
+			}
+			if r.empty() {
+				return
+			}
+
+		case c := <-r.dropchan:
+			r.remove(c)
+			if r.empty() {
+				return
 			}
-			r.Lock()
-			delete(r.Members, id)
-			r.Unlock()
-			c.Send <- *ConstructMessage(r.Name, constants.LeftChat, id, constants.Empty, c.ID)
 
 		case rmsg := <-r.Send:
+			member := service.ChangedMember(rmsg.Data)
 			r.Lock()
-
+			users := make([]string, 0, len(r.Members)+1)
 			for id := range r.Members {
-				r.Unlock()
-
-				ConnManager.Lock()
-				c, ok := ConnManager.Conns[id]
-				ConnManager.Unlock()
-				if !ok {
-					r.Lock()
-					continue
+				if service.IsMessageFor(rmsg.Data, id) && id != member {
+					users = append(users, id)
 				}
+			}
+			r.Unlock()
+			if len(member) != 0 {
+				users = append(users, member)
+			}
 
-				select {
-				case c.Send <- *rmsg.Data:
-				default:
-					r.Lock()
-					delete(r.Members, id)
-					r.Unlock()
-					close(c.Send)
+			// Every device of the members receives the message, even the ones which haven't joined the Dialog.
+			for _, id := range users {
+				msg := *rmsg.Data
+				msg.Seq, msg.Seqs = rmsg.Data.Seqs[id], nil
+				for _, c := range userConns(id) {
+					c.send(msg)
 				}
-				r.Lock()
 			}
 
-			r.Unlock()
+			// The member who is removed or has left doesn't receive the messages of the Dialog anymore.
+			if rmsg.Data.Event == constants.RemoveMember || rmsg.Data.Event == constants.LeaveGroup {
+				r.Lock()
+				delete(r.Members, member)
+				r.Unlock()
+				if r.empty() {
+					return
+				}
+			}
 
 		case <-r.stopchan:
-			DialogManager.Lock()
-			delete(DialogManager.Rooms, r.Name)
-			DialogManager.Unlock()
 			return
 		}
 	}
 }
 
+// remove removes the connection from the Dialog. If it was the last connection of its user,
+// the other members are told that the user has left.
+func (r *Dialog) remove(c *Conn) bool {
+	r.Lock()
+	conns, ok := r.Members[c.ID]
+	if ok {
+		_, ok = conns[c]
+		delete(conns, c)
+	}
+	left := ok && len(conns) == 0
+	if left {
+		delete(r.Members, c.ID)
+	}
+	r.Unlock()
+
+	if left {
+		// Published asynchronously, as the message comes back to this very loop.
+		go r.Emit(c, ConstructMessage(r.Name, constants.LeftChat, c.ID, constants.Empty, c.ID))
+	}
+	return ok
+}
+
+func (r *Dialog) empty() bool {
+	r.Lock()
+	defer r.Unlock()
+	return len(r.Members) == 0
+}
+
+func (r *Dialog) close() {
+	close(r.done)
+	r.unsubscribe()
+	DialogManager.Lock()
+	if DialogManager.Rooms[r.Name] == r {
+		delete(DialogManager.Rooms, r.Name)
+	}
+	DialogManager.Unlock()
+}
+
 // Stops the Dialog.
 func (r *Dialog) Stop() {
-	r.stopchan <- true
+	select {
+	case r.stopchan <- true:
+	case <-r.done:
+	}
 }
 
-// Adds a Conn to the Dialog.
-func (r *Dialog) Join(c *Conn) {
-	r.joinchan <- c
+// Adds a Conn to the Dialog. It returns false if the Dialog has stopped meanwhile.
+func (r *Dialog) Join(c *Conn) bool {
+	select {
+	case r.joinchan <- c:
+		return true
+	case <-r.done:
+		return false
+	}
 }
 
 // Removes a Conn from the Dialog.
 func (r *Dialog) Leave(c *Conn) {
-	r.leavechan <- c
+	select {
+	case r.leavechan <- c:
+	case <-r.done:
+	}
+}
+
+// Drop removes the Conn which is closed from the Dialog.
+func (r *Dialog) Drop(c *Conn) {
+	select {
+	case r.dropchan <- c:
+	case <-r.done:
+	}
 }
 
-// Broadcasts data to all members of the Dialog.
+// Broadcasts data to all members of the Dialog on every instance of the service.
 func (r *Dialog) Emit(c *Conn, msg *dto.Message) {
-	r.Send <- &DialogMessage{c, msg}
+	if err := record(context.Background(), r.events, msg); err != nil {
+		c.log.Errorf("failed to record message to dialog %s: %s", r.Name, err)
+	}
+	if err := r.broker.Publish(context.Background(), msg); err != nil {
+		c.log.Errorf("failed to publish message to dialog %s: %s", r.Name, err)
+	}
+}
+
+// Broadcast publishes the message to the members of its Dialog on every instance of the service.
+// It's used for the changes which are made without a websocket connection.
+func Broadcast(ctx context.Context, msg *dto.Message) error {
+	recordErr := record(ctx, DialogEvents, msg)
+	if err := DialogBroker.Publish(ctx, msg); err != nil {
+		return err
+	}
+	return recordErr
+}
+
+// record adds the message to the event logs of its receivers. The message is delivered all the same if it fails,
+// the receivers who are online don't miss it.
+func record(ctx context.Context, events service.ChatEventService, msg *dto.Message) error {
+	if events == nil || !constants.LoggedChatEvents[msg.Event] {
+		return nil
+	}
+	return events.Record(ctx, msg)
+}
+
+// deliver passes the message published to the Dialog to its members on this instance.
+func (r *Dialog) deliver(msg *dto.Message) {
+	select {
+	case r.Send <- &DialogMessage{Data: msg}:
+	case <-r.done:
+	}
 }
 
-// Creates a new Dialog type and starts it.
-func NewRoom(name string) *Dialog {
+// Creates a new Dialog type, subscribes it to its messages and starts it.
+// If the Dialog has been created by another connection meanwhile, that one is returned.
+func NewRoom(name string) (*Dialog, error) {
 	r := &Dialog{
 		Name:      name,
-		Members:   make(map[string]string),
+		Members:   make(map[string]map[*Conn]struct{}),
 		stopchan:  make(chan bool),
 		joinchan:  make(chan *Conn),
 		leavechan: make(chan *Conn),
+		dropchan:  make(chan *Conn),
 		Send:      make(chan *DialogMessage),
+		broker:    DialogBroker,
+		events:    DialogEvents,
+		done:      make(chan struct{}),
 	}
+	unsubscribe, err := r.broker.Subscribe(context.Background(), name, r.deliver)
+	if err != nil {
+		return nil, err
+	}
+	r.unsubscribe = unsubscribe
+
 	DialogManager.Lock()
+	if existing, ok := DialogManager.Rooms[name]; ok {
+		DialogManager.Unlock()
+		unsubscribe()
+		return existing, nil
+	}
 	DialogManager.Rooms[name] = r
 	DialogManager.Unlock()
 	go r.Start()
-	return r
+	return r, nil
 }
@@ -0,0 +1,29 @@
+package core
+
+import "time"
+
+// ChatEvent is an event of a dialog a user has received, it's kept for the user to catch up on after reconnecting.
+type ChatEvent struct {
+	UserID      string    `bson:"user_id"`
+	Seq         int64     `bson:"seq"` // grows with every event of the user
+	DialogID    string    `bson:"dialog_id"`
+	Event       string    `bson:"event"`
+	MessageID   string    `bson:"message_id,omitempty"`
+	AuthorID    string    `bson:"author_id"`
+	DestinID    string    `bson:"dst,omitempty"`
+	Body        string    `bson:"body,omitempty"`
+	Attachments []string  `bson:"attachments,omitempty"`
+	Images      []string  `bson:"images,omitempty"`
+	CreatedAt   int64     `bson:"created_at,omitempty"`
+	EditedAt    int64     `bson:"edited_at,omitempty"`
+	ForEveryone bool      `bson:"for_everyone,omitempty"`
+	ReplyTo     string    `bson:"reply_to,omitempty"`
+	ExpiresAt   time.Time `bson:"expires_at"` // the event is removed by the database then
+}
+
+// ChatEventCounter is the last seq given to an event of the user.
+type ChatEventCounter struct {
+	UserID    string `bson:"_id"`
+	Seq       int64  `bson:"seq"`
+	UpdatedAt int64  `bson:"updated_at"` // unix timestamp
+}
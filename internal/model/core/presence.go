@@ -0,0 +1,8 @@
+package core
+
+// Presence describes whether the user is connected to the messenger.
+type Presence struct {
+	UserID      string `bson:"_id"`
+	Connections int64  `bson:"connections"`  // open websockets on all instances
+	LastSeenAt  int64  `bson:"last_seen_at"` // unix timestamp
+}
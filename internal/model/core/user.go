@@ -17,6 +17,8 @@ type User struct {
 	Posts        []string        `bson:"posts,omitempty"`
 	DialogIDs    []string        `bson:"dialog_ids,omitempty"`
 	CommunityIDs []string        `bson:"community_ids,omitempty"`
+
+	PresenceVisibility string `bson:"presence_visibility,omitempty"` // everyone if empty
 }
 
 type EditInfo struct {
@@ -16,3 +16,152 @@ type LoginUserRequest struct {
 }
 
 type LoginUserResponse AuthTokenResponse
+
+type RefreshSessionResponse AuthTokenResponse
+
+type Session struct {
+	ID         string `json:"id"`
+	Device     string `json:"device"`
+	UserAgent  string `json:"user_agent"`
+	IP         string `json:"ip"`
+	CreatedAt  int64  `json:"created_at"`
+	LastSeenAt int64  `json:"last_seen_at"`
+	Current    bool   `json:"current"`
+}
+
+type GetSessionsRequest struct {
+	UserID    string `header:"User-Id" validate:"required"`
+	SessionID string `header:"Session-Id"`
+}
+
+type GetSessionsResponse struct {
+	Sessions []Session `json:"sessions"`
+}
+
+type RevokeSessionRequest struct {
+	UserID    string `header:"User-Id"   validate:"required"`
+	SessionID string `query:"session_id" validate:"required"`
+}
+
+type RevokeSessionResponse BasicResponse
+
+type APIToken struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  int64    `json:"created_at"`
+	ExpiresAt  int64    `json:"expires_at,omitempty"`
+	LastUsedAt int64    `json:"last_used_at,omitempty"`
+}
+
+type CreateAPITokenRequest struct {
+	UserID    string   `header:"User-Id"  validate:"required"`
+	Name      string   `json:"name"       validate:"required,max=64"`
+	Scopes    []string `json:"scopes"     validate:"required,min=1"`
+	ExpiresAt int64    `json:"expires_at"` // unix timestamp, the token never expires if omitted
+}
+
+// CreateAPITokenResponse contains the token itself, it is shown only once.
+type CreateAPITokenResponse struct {
+	Token    string   `json:"token"`
+	APIToken APIToken `json:"api_token"`
+}
+
+type GetAPITokensRequest struct {
+	UserID string `header:"User-Id" validate:"required"`
+}
+
+type GetAPITokensResponse struct {
+	Tokens []APIToken `json:"tokens"`
+}
+
+type RevokeAPITokenRequest struct {
+	UserID  string `header:"User-Id" validate:"required"`
+	TokenID string `query:"id"       validate:"required"`
+}
+
+type RevokeAPITokenResponse BasicResponse
+
+type ChangePasswordRequest struct {
+	UserID      string `header:"User-Id"    validate:"required"`
+	SessionID   string `header:"Session-Id"`
+	OldPassword string `json:"old_password"` // empty for the users without a password, they set the first one
+	NewPassword string `json:"new_password" validate:"required"`
+	Code        string `json:"code"`
+}
+
+type ChangePasswordResponse BasicResponse
+
+type RequestPasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type RequestPasswordResetResponse BasicResponse
+
+type ConfirmPasswordResetRequest struct {
+	Token       string `json:"token"        validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+type ConfirmPasswordResetResponse BasicResponse
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type VerifyEmailResponse BasicResponse
+
+type ResendVerificationRequest struct {
+	UserID string `header:"User-Id" validate:"required"`
+}
+
+type ResendVerificationResponse BasicResponse
+
+type TwoFactorRequiredResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	Ticket            string `json:"ticket"`
+}
+
+type VerifySecondFactorRequest struct {
+	Ticket string `json:"ticket" validate:"required"`
+	Code   string `json:"code"   validate:"required"`
+}
+
+type VerifySecondFactorResponse AuthTokenResponse
+
+type EnrollTwoFactorRequest struct {
+	UserID string `header:"User-Id" validate:"required"`
+}
+
+type EnrollTwoFactorResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type ConfirmTwoFactorRequest struct {
+	UserID string `header:"User-Id" validate:"required"`
+	Code   string `json:"code"      validate:"required"`
+}
+
+type ConfirmTwoFactorResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type DisableTwoFactorRequest struct {
+	UserID   string `header:"User-Id" validate:"required"`
+	Password string `json:"password"`
+	Code     string `json:"code"      validate:"required"`
+}
+
+type DisableTwoFactorResponse BasicResponse
+
+type DeleteAccountRequest struct {
+	UserID    string `header:"User-Id"    validate:"required"`
+	SessionID string `header:"Session-Id"`
+	Password  string `json:"password"`
+	Code      string `json:"code"`
+}
+
+type DeleteAccountResponse struct {
+	PurgeAt int64 `json:"purge_at"` // unix timestamp, logging in before it cancels the deletion
+}
@@ -23,16 +23,57 @@ type Message struct {
 	Attachments []string `json:"attachments"`
 	Images      []string `json:"images"`
 	CreatedAt   int64    `json:"created_at"`
+	EditedAt    int64    `json:"edited_at,omitempty"`
+	ForEveryone bool     `json:"for_everyone,omitempty"` // event="delete"
+	ReplyTo     string   `json:"reply_to,omitempty"`     // id of the message of the dialog which is replied to
+	Seq         int64    `json:"seq,omitempty"`          // seq of the event in the event log of the receiver
+	// Seqs are the seqs of the event for every receiver, they are used on the way to the receivers only.
+	Seqs map[string]int64 `json:"seqs,omitempty"`
 }
 
 // Message for chat for giving
 type MessageInfo struct {
+	ID          string        `json:"id"`
 	AuthorID    string        `json:"author_id"`
 	Body        string        `json:"body"`
 	IsRead      []core.IsRead `json:"is_read,omitempty"`
 	Attachments []string      `json:"attachments"`
 	Images      []string      `json:"images"`
 	CreatedAt   int64         `json:"created_at"`
+	Edited      bool          `json:"edited,omitempty"`
+	EditedAt    int64         `json:"edited_at,omitempty"`
+	Reactions   []Reaction    `json:"reactions,omitempty"`
+	ReplyTo     *MessageQuote `json:"reply_to,omitempty"`
+	// Action is set for the system messages about the changes of the group chat, e.g. "add_member".
+	Action   string `json:"action,omitempty"`
+	TargetID string `json:"target_id,omitempty"`
+}
+
+// MessageQuote is a short preview of the message which is replied to.
+type MessageQuote struct {
+	ID             string `json:"id"`
+	AuthorID       string `json:"author_id,omitempty"`
+	Body           string `json:"body,omitempty"`            // truncated
+	AttachmentType string `json:"attachment_type,omitempty"` // "image" or "file"
+	Deleted        bool   `json:"deleted,omitempty"`         // the rest is empty then
+}
+
+// MessagePreview is a short preview of the last message of the dialog.
+type MessagePreview struct {
+	ID             string `json:"id"`
+	AuthorID       string `json:"author_id"`
+	Body           string `json:"body,omitempty"`            // truncated
+	AttachmentType string `json:"attachment_type,omitempty"` // "image" or "file"
+	Action         string `json:"action,omitempty"`          // of the system message
+	CreatedAt      int64  `json:"created_at"`
+}
+
+// Reaction is an emoji with the users who have reacted to the message with it.
+type Reaction struct {
+	Emoji   string   `json:"emoji"`
+	Count   int      `json:"count"`
+	UserIDs []string `json:"user_ids"`
+	Mine    bool     `json:"mine"`
 }
 
 type Dialog struct {
@@ -41,6 +82,13 @@ type Dialog struct {
 	Participants []string `json:"participants"`
 	NonRead      int64    `json:"non_read"`
 	Image        string   `json:"image"`
+	Group        bool     `json:"group"`
+	AdminIDs     []string `json:"admin_ids,omitempty"`
+	// LastMessage and LastActivity are set in the dialog list.
+	LastMessage  *MessagePreview `json:"last_message,omitempty"`
+	LastActivity int64           `json:"last_activity,omitempty"`
+	// Presence of the other participant of a personal dialog.
+	Presence *Presence `json:"presence,omitempty"`
 }
 
 type SendMessageRequest struct {
@@ -61,24 +109,32 @@ type GetDialogsRequest struct { //
 	Page   int64  `query:"page,omitempty"`
 }
 
+// GetDialogsResponse has a page of the dialogs, the ones with the latest activity first.
 type GetDialogsResponse struct {
 	Dialogs     []Dialog `json:"dialogs"`
 	Total       int64    `json:"total"`
 	AmountPages int64    `json:"amount_pages"`
+	Unread      int64    `json:"unread"` // of all the dialogs of the user
 }
 
+// GetDialogRequest asks for a page of messages, the newest first.
+// The next page is the one before the last message of the previous page.
 type GetDialogRequest struct { //
 	UserID   string
 	DialogID string `query:"dialog_id"`
 	Limit    int64  `query:"limit,omitempty"`
-	Page     int64  `query:"page,omitempty"`
+	Before   string `query:"before,omitempty"`
+	// Deprecated: the pages by number are kept until the clients use before.
+	Page int64 `query:"page,omitempty"`
 }
 
 type GetDialogResponse struct {
-	Dialog      Dialog        `json:"dialog"`
-	Messages    []MessageInfo `json:"messages"`
-	Total       int64         `json:"total"`
-	AmountPages int64         `json:"amount_pages"`
+	Dialog   Dialog        `json:"dialog"`
+	Messages []MessageInfo `json:"messages"`
+	HasMore  bool          `json:"has_more"`
+	// Deprecated: they are set only if the page is asked for by number.
+	Total       int64 `json:"total,omitempty"`
+	AmountPages int64 `json:"amount_pages,omitempty"`
 }
 
 type GetDialogByUserIDRequest struct {
@@ -89,6 +145,86 @@ type GetDialogByUserIDResponse struct {
 	DialogID string `json:"dialog_id"`
 }
 
+type EditMessageRequest struct {
+	UserID    string `header:"User-Id" validate:"required"`
+	DialogID  string `json:"dialog_id" validate:"required"`
+	MessageID string `json:"message_id" validate:"required"`
+	Body      string `json:"body" validate:"required"`
+}
+
+type EditMessageResponse struct {
+	EditedAt int64 `json:"edited_at"`
+}
+
+type DeleteMessageRequest struct {
+	UserID      string `header:"User-Id" validate:"required"`
+	DialogID    string `query:"dialog_id" validate:"required"`
+	MessageID   string `query:"message_id" validate:"required"`
+	ForEveryone bool   `query:"for_everyone"`
+}
+
+type DeleteMessageResponse struct{}
+
+type ReactMessageRequest struct {
+	UserID    string `header:"User-Id" validate:"required"`
+	DialogID  string `json:"dialog_id" validate:"required"`
+	MessageID string `json:"message_id" validate:"required"`
+	Emoji     string `json:"emoji" validate:"required"`
+}
+
+type ReactMessageResponse struct{}
+
+type GetChatEventsRequest struct {
+	UserID string `header:"User-Id" validate:"required"`
+	Since  int64  `query:"since"`
+	Limit  int64  `query:"limit"`
+}
+
+type GetChatEventsResponse struct {
+	Events  []Message `json:"events"`
+	LastSeq int64     `json:"last_seq"`
+	HasMore bool      `json:"has_more"`
+	// Reset tells that the events since the seq are gone, the dialogs have to be loaded anew.
+	Reset bool `json:"reset"`
+}
+
+type AddMembersRequest struct {
+	UserID    string   `header:"User-Id" validate:"required"`
+	DialogID  string   `json:"dialog_id" validate:"required"`
+	MemberIDs []string `json:"member_ids" validate:"required,min=1"`
+}
+
+type RemoveMemberRequest struct {
+	UserID   string `header:"User-Id" validate:"required"`
+	DialogID string `query:"dialog_id" validate:"required"`
+	MemberID string `query:"member_id" validate:"required"`
+}
+
+// EditChatRequest changes the name and the image of the group chat, the empty ones are left as they are.
+type EditChatRequest struct {
+	UserID   string `header:"User-Id" validate:"required"`
+	DialogID string `json:"dialog_id" validate:"required"`
+	Name     string `json:"name"`
+	Image    string `json:"image"`
+}
+
+type SetChatAdminRequest struct {
+	UserID   string `header:"User-Id" validate:"required"`
+	DialogID string `json:"dialog_id" validate:"required"`
+	MemberID string `json:"member_id" validate:"required"`
+	Admin    bool   `json:"admin"`
+}
+
+type LeaveChatRequest struct {
+	UserID   string `header:"User-Id" validate:"required"`
+	DialogID string `json:"dialog_id" validate:"required"`
+}
+
+// ChatActionResponse has the events of the changes of the group chat, they are the system messages of its history too.
+type ChatActionResponse struct {
+	Messages []Message `json:"messages"`
+}
+
 type CheckDialogRequest struct {
 	UserID   string `json:"user_id"`
 	DialogID string `json:"dialog_id"`
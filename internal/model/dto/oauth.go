@@ -1,8 +1,56 @@
 package dto
 
+// AuthenticateThroughTelergamRequest is the user data of the Telegram login widget, checked by OAuthTelegramMiddleware.
 type AuthenticateThroughTelergamRequest struct {
 	ID        string `query:"id" validate:"required"`
 	FirstName string `query:"first_name" validate:"required"`
-	LastName  string `query:"last_name" validate:"required"`
+	LastName  string `query:"last_name"`
 	PhotoURL  string `query:"photo_url"`
 }
+
+type LinkTelegramRequest struct {
+	UserID string `header:"User-Id" validate:"required"`
+	ID     string `query:"id" validate:"required"`
+}
+
+type GetOAuthProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+type OAuthLoginRequest struct {
+	Provider string `param:"provider" validate:"required"`
+}
+
+type OAuthLinkRequest struct {
+	UserID   string `header:"User-Id"  validate:"required"`
+	Provider string `param:"provider" validate:"required"`
+}
+
+// OAuthCallbackRequest is where the provider redirects the user back to with either the code or the error.
+type OAuthCallbackRequest struct {
+	Provider string `param:"provider" validate:"required"`
+	Code     string `query:"code"`
+	State    string `query:"state"`
+	Error    string `query:"error"`
+}
+
+type LinkedIdentity struct {
+	Provider string `json:"provider"`
+	Email    string `json:"email,omitempty"`
+	LinkedAt int64  `json:"linked_at"`
+}
+
+type GetIdentitiesRequest struct {
+	UserID string `header:"User-Id" validate:"required"`
+}
+
+type GetIdentitiesResponse struct {
+	Identities []LinkedIdentity `json:"identities"`
+}
+
+type UnlinkIdentityRequest struct {
+	UserID   string `header:"User-Id"  validate:"required"`
+	Provider string `query:"provider" validate:"required"`
+}
+
+type UnlinkIdentityResponse BasicResponse
@@ -4,10 +4,17 @@ import "github.com/go-park-mail-ru/2022_1_CJ/internal/model/common"
 
 // Only used in responses! Does not need validation.
 type User struct {
-	ID    string          `json:"id"`
-	Email string          `json:"email"`
-	Name  common.UserName `json:"name"`
-	Image string          `json:"image"`
+	ID       string          `json:"id"`
+	Email    string          `json:"email"`
+	Name     common.UserName `json:"name"`
+	Image    string          `json:"image"`
+	Presence *Presence       `json:"presence,omitempty"`
+}
+
+// Presence is left out if the user doesn't let the viewer see it.
+type Presence struct {
+	Online     bool  `json:"online"`
+	LastSeenAt int64 `json:"last_seen_at,omitempty"` // unix timestamp, unset if the user has never been online
 }
 
 // Add status
@@ -19,6 +26,9 @@ type UserProfile struct {
 	Phone    string          `json:"phone"`
 	Location string          `json:"location"`
 	BirthDay string          `json:"birth_day"`
+	Presence *Presence       `json:"presence,omitempty"`
+	// PresenceVisibility is shown to the user themself only.
+	PresenceVisibility string `json:"presence_visibility,omitempty"`
 }
 
 type EditProfile struct {
@@ -66,7 +76,8 @@ type GetUserFeedResponse struct {
 }
 
 type GetProfileRequest struct {
-	UserID string `query:"user_id"`
+	ViewerID string `header:"User-Id"`
+	UserID   string `query:"user_id"`
 }
 
 type GetProfileResponse struct {
@@ -79,6 +90,8 @@ type EditProfileRequest struct {
 	Phone    string          `json:"phone"`
 	Location string          `json:"location"`
 	BirthDay string          `json:"birth_day"`
+	// PresenceVisibility is who sees whether the user is online: everyone, friends or nobody.
+	PresenceVisibility string `json:"presence_visibility"`
 }
 
 type EditProfileResponse BasicResponse
@@ -90,6 +103,7 @@ type UpdatePhotoResponse struct {
 }
 
 type SearchUsersRequest struct {
+	ViewerID string `header:"User-Id"`
 	Selector string `query:"selector" validate:"required"`
 	Limit    int64  `query:"limit,omitempty"`
 	Page     int64  `query:"page,omitempty"`
@@ -9,6 +9,8 @@ import (
 type PrometheusMetrics struct {
 	Hits     *prometheus.CounterVec
 	Duration *prometheus.HistogramVec
+	// TokenCache counts the lookups of the access token cache by result: hit, miss or stale.
+	TokenCache *prometheus.CounterVec
 }
 
 func RegisterMonitoring(server *echo.Echo) *PrometheusMetrics {
@@ -23,7 +25,12 @@ func RegisterMonitoring(server *echo.Echo) *PrometheusMetrics {
 		Help: "help",
 	}, []string{"status", "path", "method"})
 
-	prometheus.MustRegister(metrics.Hits, metrics.Duration)
+	metrics.TokenCache = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_token_cache_requests",
+		Help: "Lookups of the access token cache by result",
+	}, []string{"result"})
+
+	prometheus.MustRegister(metrics.Hits, metrics.Duration, metrics.TokenCache)
 
 	server.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
 
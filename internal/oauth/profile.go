@@ -0,0 +1,103 @@
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Profile is the user's account at the provider.
+type Profile struct {
+	Subject       string // id of the account, unique within the provider
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+	Picture       string
+}
+
+// Claims tells where the profile fields are found in the provider's responses. Each one is a dotted path
+// starting with the document it is taken from: "token" is the token response, "id_token" are the claims of
+// the ID token and "userinfo" is the userinfo response. Array elements are addressed by index, e.g.
+// "userinfo.response.0.id".
+type Claims struct {
+	Subject       string `mapstructure:"subject"`
+	Email         string `mapstructure:"email"`
+	EmailVerified string `mapstructure:"email_verified"`
+	FirstName     string `mapstructure:"first_name"`
+	LastName      string `mapstructure:"last_name"`
+	Picture       string `mapstructure:"picture"`
+}
+
+// merge fills the empty paths from the defaults.
+func (c Claims) merge(defaults Claims) Claims {
+	pick := func(value, def string) string {
+		if len(value) != 0 {
+			return value
+		}
+		return def
+	}
+	return Claims{
+		Subject:       pick(c.Subject, defaults.Subject),
+		Email:         pick(c.Email, defaults.Email),
+		EmailVerified: pick(c.EmailVerified, defaults.EmailVerified),
+		FirstName:     pick(c.FirstName, defaults.FirstName),
+		LastName:      pick(c.LastName, defaults.LastName),
+		Picture:       pick(c.Picture, defaults.Picture),
+	}
+}
+
+// mapProfile builds the profile from the provider's responses. trustEmail marks the email as
+// verified for the providers which only give out verified emails but don't say it.
+func mapProfile(claims Claims, docs map[string]interface{}, trustEmail bool) (*Profile, error) {
+	profile := &Profile{
+		Subject:   lookupString(docs, claims.Subject),
+		Email:     lookupString(docs, claims.Email),
+		FirstName: lookupString(docs, claims.FirstName),
+		LastName:  lookupString(docs, claims.LastName),
+		Picture:   lookupString(docs, claims.Picture),
+	}
+	if len(profile.Subject) == 0 {
+		return nil, fmt.Errorf("no subject at %q", claims.Subject)
+	}
+	if len(profile.Email) != 0 {
+		verified, _ := strconv.ParseBool(lookupString(docs, claims.EmailVerified))
+		profile.EmailVerified = trustEmail || verified
+	}
+	return profile, nil
+}
+
+// lookupString returns the value at the path as a string, or an empty string if there is no such value.
+func lookupString(doc interface{}, path string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			doc = node[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return ""
+			}
+			doc = node[i]
+		default:
+			return ""
+		}
+	}
+
+	switch value := doc.(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		return strconv.FormatBool(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,474 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/golang-jwt/jwt"
+	"github.com/spf13/viper"
+)
+
+const (
+	// TypeOIDC providers are discovered from the issuer and identify the user with the ID token.
+	TypeOIDC = "oidc"
+	// TypeOAuth2 providers identify the user with their userinfo API only.
+	TypeOAuth2 = "oauth2"
+
+	requestTimeout = 10 * time.Second
+	// keysRefreshPeriod is how often the keys of the ID tokens are reloaded.
+	keysRefreshPeriod = time.Hour
+	// keysMissRefreshPeriod limits reloads caused by ID tokens with unknown key ids.
+	keysMissRefreshPeriod = 10 * time.Second
+)
+
+// Config describes the identity provider. The providers with the known names (see presets)
+// only need the client credentials, any other OpenID Connect provider also needs its issuer.
+type Config struct {
+	Type         string   `mapstructure:"type"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	Scopes       []string `mapstructure:"scopes"`
+
+	// Issuer of the ID tokens, the endpoints of oidc providers are discovered from it.
+	Issuer string `mapstructure:"issuer"`
+
+	// Endpoints of oauth2 providers. For oidc providers they override the discovered ones.
+	AuthURL     string `mapstructure:"auth_url"`
+	TokenURL    string `mapstructure:"token_url"`
+	UserinfoURL string `mapstructure:"userinfo_url"`
+	JWKSURL     string `mapstructure:"jwks_url"`
+
+	// UserinfoTokenParam passes the access token to the userinfo API in the query parameter
+	// with this name instead of the Authorization header.
+	UserinfoTokenParam string `mapstructure:"userinfo_token_param"`
+	// UserinfoParams are added to the query of the userinfo request.
+	UserinfoParams map[string]string `mapstructure:"userinfo_params"`
+
+	// TrustEmail marks emails as verified for the providers which only give out verified emails.
+	TrustEmail bool   `mapstructure:"trust_email"`
+	Claims     Claims `mapstructure:"claims"`
+}
+
+// presets are the configs of the well-known providers.
+var presets = map[string]Config{
+	"google": {
+		Type:   TypeOIDC,
+		Issuer: "https://accounts.google.com",
+		Scopes: []string{"openid", "email", "profile"},
+	},
+	"yandex": {
+		Type:               TypeOAuth2,
+		AuthURL:            "https://oauth.yandex.ru/authorize",
+		TokenURL:           "https://oauth.yandex.ru/token",
+		UserinfoURL:        "https://login.yandex.ru/info",
+		UserinfoTokenParam: "oauth_token",
+		UserinfoParams:     map[string]string{"format": "json"},
+		Scopes:             []string{"login:email", "login:info"},
+		TrustEmail:         true,
+		Claims: Claims{
+			Subject:   "userinfo.id",
+			Email:     "userinfo.default_email",
+			FirstName: "userinfo.first_name",
+			LastName:  "userinfo.last_name",
+		},
+	},
+	"vk": {
+		Type:               TypeOAuth2,
+		AuthURL:            "https://oauth.vk.com/authorize",
+		TokenURL:           "https://oauth.vk.com/access_token",
+		UserinfoURL:        "https://api.vk.com/method/users.get",
+		UserinfoTokenParam: "access_token",
+		UserinfoParams:     map[string]string{"v": "5.131", "fields": "photo_200"},
+		Scopes:             []string{"email"},
+		Claims: Claims{
+			Subject:   "token.user_id",
+			Email:     "token.email",
+			FirstName: "userinfo.response.0.first_name",
+			LastName:  "userinfo.response.0.last_name",
+			Picture:   "userinfo.response.0.photo_200",
+		},
+	},
+}
+
+// oidcClaims are where the standard claims are. Claims missing in the ID token are taken from userinfo.
+var oidcClaims = Claims{
+	Subject:       "id_token.sub",
+	Email:         "id_token.email",
+	EmailVerified: "id_token.email_verified",
+	FirstName:     "id_token.given_name",
+	LastName:      "id_token.family_name",
+	Picture:       "id_token.picture",
+}
+
+// endpoints are the urls of the provider. JSON names are the ones of the OpenID discovery document.
+type endpoints struct {
+	Issuer      string `json:"issuer"`
+	AuthURL     string `json:"authorization_endpoint"`
+	TokenURL    string `json:"token_endpoint"`
+	UserinfoURL string `json:"userinfo_endpoint"`
+	JWKSURL     string `json:"jwks_uri"`
+}
+
+// Provider runs the authorization code flow with PKCE at the identity provider.
+type Provider struct {
+	Name   string
+	cfg    Config
+	client *http.Client
+
+	mu            sync.Mutex
+	endpoints     *endpoints
+	keyRing       *auth_utils.KeyRing
+	keysFetchedAt time.Time
+}
+
+// LoadProviders creates the providers set in the config. Providers without client id are disabled.
+func LoadProviders() (map[string]*Provider, error) {
+	configs := map[string]Config{}
+	if err := viper.UnmarshalKey(constants.ViperOAuthProvidersKey, &configs); err != nil {
+		return nil, err
+	}
+
+	providers := make(map[string]*Provider, len(configs))
+	for name, cfg := range configs {
+		if len(cfg.ClientID) == 0 {
+			continue
+		}
+		provider, err := NewProvider(name, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("provider %s: %w", name, err)
+		}
+		providers[name] = provider
+	}
+	return providers, nil
+}
+
+// NewProvider creates the provider. The settings missing in cfg are taken from the preset of the same name.
+func NewProvider(name string, cfg Config) (*Provider, error) {
+	if preset, ok := presets[name]; ok {
+		cfg = cfg.merge(preset)
+	}
+	if len(cfg.Type) == 0 {
+		cfg.Type = TypeOIDC
+	}
+
+	switch cfg.Type {
+	case TypeOIDC:
+		if len(cfg.Issuer) == 0 {
+			return nil, fmt.Errorf("issuer is required")
+		}
+		if len(cfg.Scopes) == 0 {
+			cfg.Scopes = []string{"openid", "email", "profile"}
+		}
+		cfg.Claims = cfg.Claims.merge(oidcClaims)
+	case TypeOAuth2:
+		if len(cfg.AuthURL) == 0 || len(cfg.TokenURL) == 0 {
+			return nil, fmt.Errorf("auth_url and token_url are required")
+		}
+		if len(cfg.Claims.Subject) == 0 {
+			return nil, fmt.Errorf("claims.subject is required")
+		}
+	default:
+		return nil, fmt.Errorf("unknown type %q", cfg.Type)
+	}
+
+	provider := &Provider{Name: name, cfg: cfg, client: &http.Client{Timeout: requestTimeout}}
+	if cfg.Type == TypeOAuth2 {
+		provider.endpoints = &endpoints{AuthURL: cfg.AuthURL, TokenURL: cfg.TokenURL, UserinfoURL: cfg.UserinfoURL}
+	}
+	return provider, nil
+}
+
+// merge fills the empty settings from the preset.
+func (cfg Config) merge(preset Config) Config {
+	pick := func(value, def string) string {
+		if len(value) != 0 {
+			return value
+		}
+		return def
+	}
+
+	merged := cfg
+	merged.Type = pick(cfg.Type, preset.Type)
+	merged.Issuer = pick(cfg.Issuer, preset.Issuer)
+	merged.AuthURL = pick(cfg.AuthURL, preset.AuthURL)
+	merged.TokenURL = pick(cfg.TokenURL, preset.TokenURL)
+	merged.UserinfoURL = pick(cfg.UserinfoURL, preset.UserinfoURL)
+	merged.JWKSURL = pick(cfg.JWKSURL, preset.JWKSURL)
+	merged.UserinfoTokenParam = pick(cfg.UserinfoTokenParam, preset.UserinfoTokenParam)
+	if len(cfg.Scopes) == 0 {
+		merged.Scopes = preset.Scopes
+	}
+	if len(cfg.UserinfoParams) == 0 {
+		merged.UserinfoParams = preset.UserinfoParams
+	}
+	merged.TrustEmail = cfg.TrustEmail || preset.TrustEmail
+	merged.Claims = cfg.Claims.merge(preset.Claims)
+	return merged
+}
+
+// AuthCodeURL is where the user is sent to authorize at the provider.
+func (p *Provider) AuthCodeURL(ctx context.Context, state *State, redirectURL string) (string, error) {
+	ep, err := p.resolve(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {redirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state.Value},
+		"code_challenge":        {state.challenge()},
+		"code_challenge_method": {"S256"},
+	}
+	if p.cfg.Type == TypeOIDC {
+		query.Set("nonce", state.Nonce)
+	}
+
+	sep := "?"
+	if strings.Contains(ep.AuthURL, "?") {
+		sep = "&"
+	}
+	return ep.AuthURL + sep + query.Encode(), nil
+}
+
+// Exchange redeems the authorization code and returns the profile of the user who has authorized.
+func (p *Provider) Exchange(ctx context.Context, code string, state *State, redirectURL string) (*Profile, error) {
+	ep, err := p.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.requestToken(ctx, ep, code, state, redirectURL)
+	if err != nil {
+		return nil, err
+	}
+	docs := map[string]interface{}{"token": token}
+
+	accessToken, _ := token["access_token"].(string)
+	var idClaims jwt.MapClaims
+	if p.cfg.Type == TypeOIDC {
+		rawIDToken, _ := token["id_token"].(string)
+		if idClaims, err = p.verifyIDToken(ctx, ep, rawIDToken, state.Nonce); err != nil {
+			return nil, err
+		}
+		docs["id_token"] = map[string]interface{}(idClaims)
+	}
+
+	if len(ep.UserinfoURL) != 0 && len(accessToken) != 0 {
+		userinfo, err := p.requestUserinfo(ctx, ep, accessToken)
+		if err != nil {
+			return nil, err
+		}
+		docs["userinfo"] = userinfo
+
+		if idClaims != nil {
+			if sub, ok := userinfo["sub"]; ok && sub != idClaims["sub"] {
+				return nil, fmt.Errorf("%w: userinfo is of another user", constants.ErrOAuthIDTokenInvalid)
+			}
+			for key, value := range userinfo {
+				if _, ok := idClaims[key]; !ok {
+					idClaims[key] = value
+				}
+			}
+		}
+	}
+
+	profile, err := mapProfile(p.cfg.Claims, docs, p.cfg.TrustEmail)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", constants.ErrOAuthProvider, err)
+	}
+	return profile, nil
+}
+
+// resolve returns the endpoints of the provider, discovering them on the first call for oidc providers.
+func (p *Provider) resolve(ctx context.Context) (*endpoints, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.endpoints != nil {
+		return p.endpoints, nil
+	}
+
+	ep := new(endpoints)
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", ep); err != nil {
+		return nil, fmt.Errorf("%w: discovery: %s", constants.ErrOAuthProvider, err)
+	}
+	// The document is trusted only for the issuer it was asked for.
+	if ep.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("%w: discovery: issuer %q doesn't match", constants.ErrOAuthProvider, ep.Issuer)
+	}
+
+	override := func(value *string, configured string) {
+		if len(configured) != 0 {
+			*value = configured
+		}
+	}
+	override(&ep.AuthURL, p.cfg.AuthURL)
+	override(&ep.TokenURL, p.cfg.TokenURL)
+	override(&ep.UserinfoURL, p.cfg.UserinfoURL)
+	override(&ep.JWKSURL, p.cfg.JWKSURL)
+	if len(ep.AuthURL) == 0 || len(ep.TokenURL) == 0 || len(ep.JWKSURL) == 0 {
+		return nil, fmt.Errorf("%w: discovery: endpoints are missing", constants.ErrOAuthProvider)
+	}
+
+	p.endpoints = ep
+	return ep, nil
+}
+
+func (p *Provider) requestToken(ctx context.Context, ep *endpoints, code string, state *State, redirectURL string) (map[string]interface{}, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"code_verifier": {state.Verifier},
+		"client_id":     {p.cfg.ClientID},
+		"client_secret": {p.cfg.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	token := map[string]interface{}{}
+	if err := p.doJSON(req, &token); err != nil {
+		return nil, fmt.Errorf("%w: token: %s", constants.ErrOAuthProvider, err)
+	}
+	if _, ok := token["access_token"].(string); !ok {
+		return nil, fmt.Errorf("%w: token: no access token", constants.ErrOAuthProvider)
+	}
+	return token, nil
+}
+
+func (p *Provider) requestUserinfo(ctx context.Context, ep *endpoints, accessToken string) (map[string]interface{}, error) {
+	userinfoURL, err := url.Parse(ep.UserinfoURL)
+	if err != nil {
+		return nil, err
+	}
+	query := userinfoURL.Query()
+	for key, value := range p.cfg.UserinfoParams {
+		query.Set(key, value)
+	}
+	if len(p.cfg.UserinfoTokenParam) != 0 {
+		query.Set(p.cfg.UserinfoTokenParam, accessToken)
+	}
+	userinfoURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, userinfoURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.cfg.UserinfoTokenParam) == 0 {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	userinfo := map[string]interface{}{}
+	if err := p.doJSON(req, &userinfo); err != nil {
+		return nil, fmt.Errorf("%w: userinfo: %s", constants.ErrOAuthProvider, err)
+	}
+	return userinfo, nil
+}
+
+// verifyIDToken checks that the ID token is signed by the provider and issued to us for this login.
+func (p *Provider) verifyIDToken(ctx context.Context, ep *endpoints, rawIDToken, nonce string) (jwt.MapClaims, error) {
+	if len(rawIDToken) == 0 {
+		return nil, fmt.Errorf("%w: no id token", constants.ErrOAuthIDTokenInvalid)
+	}
+	unverified, _, err := new(jwt.Parser).ParseUnverified(rawIDToken, jwt.MapClaims{})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", constants.ErrOAuthIDTokenInvalid, err)
+	}
+	kid, _ := unverified.Header["kid"].(string)
+
+	keyRing, err := p.keys(ctx, ep, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	if err := keyRing.ParseClaims(rawIDToken, claims); err != nil {
+		return nil, fmt.Errorf("%w: %s", constants.ErrOAuthIDTokenInvalid, err)
+	}
+
+	now := time.Now().Unix()
+	switch {
+	case !claims.VerifyIssuer(p.cfg.Issuer, true):
+		return nil, fmt.Errorf("%w: wrong issuer", constants.ErrOAuthIDTokenInvalid)
+	case !claims.VerifyAudience(p.cfg.ClientID, true):
+		return nil, fmt.Errorf("%w: wrong audience", constants.ErrOAuthIDTokenInvalid)
+	case !claims.VerifyExpiresAt(now, true):
+		return nil, fmt.Errorf("%w: expired", constants.ErrOAuthIDTokenInvalid)
+	case claims["nonce"] != nonce:
+		return nil, fmt.Errorf("%w: wrong nonce", constants.ErrOAuthIDTokenInvalid)
+	}
+	// The token issued to several clients must name us as the authorized party.
+	if azp, ok := claims["azp"]; ok && azp != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: wrong authorized party", constants.ErrOAuthIDTokenInvalid)
+	}
+	return claims, nil
+}
+
+// keys returns the keys of the ID tokens, reloading them when they are stale or the key id is not known yet.
+func (p *Provider) keys(ctx context.Context, ep *endpoints, kid string) (*auth_utils.KeyRing, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	since := time.Since(p.keysFetchedAt)
+	if p.keyRing != nil && since < keysRefreshPeriod && (p.keyRing.Has(kid) || since < keysMissRefreshPeriod) {
+		return p.keyRing, nil
+	}
+
+	set := new(auth_utils.JWKSet)
+	err := p.getJSON(ctx, ep.JWKSURL, set)
+	p.keysFetchedAt = time.Now()
+	if err != nil {
+		// Keys loaded before are kept if the provider is unavailable.
+		if p.keyRing != nil {
+			return p.keyRing, nil
+		}
+		return nil, fmt.Errorf("%w: jwks: %s", constants.ErrOAuthProvider, err)
+	}
+	keyRing, err := auth_utils.NewKeyRingFromJWKS(set)
+	if err != nil {
+		return nil, fmt.Errorf("%w: jwks: %s", constants.ErrOAuthProvider, err)
+	}
+	p.keyRing = keyRing
+	return keyRing, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	return p.doJSON(req, v)
+}
+
+func (p *Provider) doJSON(req *http.Request, v interface{}) error {
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	decoder := json.NewDecoder(res.Body)
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
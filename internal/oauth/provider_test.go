@@ -0,0 +1,324 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	auth_utils "github.com/go-park-mail-ru/2022_1_CJ/internal/mircoservices/auth-microservice/utils"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testClientID     = "client"
+	testClientSecret = "secret"
+	testRedirectURL  = "http://localhost/api/oauth/test/callback"
+)
+
+// mockIdP is an identity provider which authorizes everyone who comes as the same user.
+type mockIdP struct {
+	t      *testing.T
+	server *httptest.Server
+	signer *auth_utils.KeyRing
+
+	// What the authorization request has left for the token request.
+	code      string
+	challenge string
+	nonce     string
+
+	// idClaims are changed by the tests to issue broken ID tokens.
+	idClaims func(claims jwt.MapClaims)
+	userinfo map[string]interface{}
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	signer, err := auth_utils.NewKeyRing("idp", []*auth_utils.SigningKey{
+		{ID: "idp", Method: jwt.SigningMethodRS256, Private: private, Public: &private.PublicKey},
+	})
+	require.NoError(t, err)
+
+	idp := &mockIdP{t: t, signer: signer, idClaims: func(jwt.MapClaims) {}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(idp.signer.JWKS())
+	})
+	mux.HandleFunc("/userinfo", idp.userinfoHandler)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *mockIdP) discovery(w http.ResponseWriter, _ *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 idp.server.URL,
+		"authorization_endpoint": idp.server.URL + "/authorize",
+		"token_endpoint":         idp.server.URL + "/token",
+		"userinfo_endpoint":      idp.server.URL + "/userinfo",
+		"jwks_uri":               idp.server.URL + "/jwks",
+	})
+}
+
+// authorize does what the user's browser does at the authorization endpoint and returns the code.
+func (idp *mockIdP) authorize(authURL string) string {
+	u, err := url.Parse(authURL)
+	require.NoError(idp.t, err)
+	query := u.Query()
+	require.Equal(idp.t, "S256", query.Get("code_challenge_method"))
+	require.Equal(idp.t, testClientID, query.Get("client_id"))
+	require.Equal(idp.t, testRedirectURL, query.Get("redirect_uri"))
+
+	idp.code = "code"
+	idp.challenge = query.Get("code_challenge")
+	idp.nonce = query.Get("nonce")
+	return idp.code
+}
+
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	require.NoError(idp.t, r.ParseForm())
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if r.PostForm.Get("code") != idp.code ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != idp.challenge ||
+		r.PostForm.Get("client_id") != testClientID ||
+		r.PostForm.Get("client_secret") != testClientSecret {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"aud":            testClientID,
+		"sub":            "42",
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"nonce":          idp.nonce,
+		"email":          "mail@example.com",
+		"email_verified": true,
+	}
+	idp.idClaims(claims)
+	idToken, err := idp.signer.Sign(claims)
+	require.NoError(idp.t, err)
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+		"user_id":      7,
+		"email":        "vk@example.com",
+	})
+}
+
+func (idp *mockIdP) userinfoHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer access" && r.URL.Query().Get("access_token") != "access" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(idp.userinfo)
+}
+
+func (idp *mockIdP) oidcProvider(t *testing.T) *Provider {
+	provider, err := NewProvider("test", Config{
+		Type:         TypeOIDC,
+		Issuer:       idp.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+	})
+	require.NoError(t, err)
+	return provider
+}
+
+// login runs the whole flow and returns the result of the exchange.
+func login(t *testing.T, idp *mockIdP, provider *Provider, tamper func(state *State)) (*Profile, error) {
+	ctx := context.Background()
+	state, err := NewState(provider.Name, "", time.Minute)
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, state, testRedirectURL)
+	require.NoError(t, err)
+	code := idp.authorize(authURL)
+
+	if tamper != nil {
+		tamper(state)
+	}
+	return provider.Exchange(ctx, code, state, testRedirectURL)
+}
+
+func TestOIDCLogin(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.userinfo = map[string]interface{}{"sub": "42", "given_name": "Ivan", "family_name": "Ivanov"}
+	provider := idp.oidcProvider(t)
+
+	profile, err := login(t, idp, provider, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &Profile{
+		Subject:       "42",
+		Email:         "mail@example.com",
+		EmailVerified: true,
+		FirstName:     "Ivan",
+		LastName:      "Ivanov",
+	}, profile)
+}
+
+func TestOIDCLoginRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		idClaims func(claims jwt.MapClaims)
+		userinfo map[string]interface{}
+		tamper   func(state *State)
+		err      error
+	}{
+		{
+			name:   "Wrong PKCE verifier",
+			tamper: func(state *State) { state.Verifier = "forged" },
+			err:    constants.ErrOAuthProvider,
+		},
+		{
+			name:   "Wrong nonce",
+			tamper: func(state *State) { state.Nonce = "forged" },
+			err:    constants.ErrOAuthIDTokenInvalid,
+		},
+		{
+			name:     "Wrong audience",
+			idClaims: func(claims jwt.MapClaims) { claims["aud"] = "other" },
+			err:      constants.ErrOAuthIDTokenInvalid,
+		},
+		{
+			name:     "Wrong issuer",
+			idClaims: func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+			err:      constants.ErrOAuthIDTokenInvalid,
+		},
+		{
+			name:     "Expired",
+			idClaims: func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+			err:      constants.ErrOAuthIDTokenInvalid,
+		},
+		{
+			name:     "Userinfo of another user",
+			userinfo: map[string]interface{}{"sub": "43"},
+			err:      constants.ErrOAuthIDTokenInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			idp := newMockIdP(t)
+			if test.idClaims != nil {
+				idp.idClaims = test.idClaims
+			}
+			idp.userinfo = test.userinfo
+			provider := idp.oidcProvider(t)
+
+			profile, err := login(t, idp, provider, test.tamper)
+			assert.Nil(t, profile)
+			assert.True(t, errors.Is(err, test.err), "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	idp := newMockIdP(t)
+	provider, err := NewProvider("test", Config{Issuer: idp.server.URL + "/", ClientID: testClientID})
+	require.NoError(t, err)
+
+	state, err := NewState("test", "", time.Minute)
+	require.NoError(t, err)
+	_, err = provider.AuthCodeURL(context.Background(), state, testRedirectURL)
+	assert.True(t, errors.Is(err, constants.ErrOAuthProvider))
+}
+
+func TestOAuth2Login(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.userinfo = map[string]interface{}{
+		"response": []map[string]interface{}{{"id": 7, "first_name": "Ivan", "photo_200": "https://example.com/7.jpg"}},
+	}
+
+	// The VK preset with the endpoints of the mock.
+	provider, err := NewProvider("vk", Config{
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		AuthURL:      idp.server.URL + "/authorize",
+		TokenURL:     idp.server.URL + "/token",
+		UserinfoURL:  idp.server.URL + "/userinfo",
+	})
+	require.NoError(t, err)
+
+	profile, err := login(t, idp, provider, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &Profile{
+		Subject:   "7",
+		Email:     "vk@example.com",
+		FirstName: "Ivan",
+		Picture:   "https://example.com/7.jpg",
+	}, profile)
+}
+
+func TestNewProvider(t *testing.T) {
+	_, err := NewProvider("custom", Config{ClientID: testClientID})
+	assert.Error(t, err, "oidc provider without issuer")
+
+	_, err = NewProvider("custom", Config{Type: TypeOAuth2, ClientID: testClientID, AuthURL: "a", TokenURL: "t"})
+	assert.Error(t, err, "oauth2 provider without subject claim")
+
+	google, err := NewProvider("google", Config{ClientID: testClientID})
+	require.NoError(t, err)
+	assert.Equal(t, "https://accounts.google.com", google.cfg.Issuer)
+	assert.Equal(t, "id_token.sub", google.cfg.Claims.Subject)
+}
+
+func TestState(t *testing.T) {
+	secret := []byte("secret")
+	state, err := NewState("google", "1", time.Minute)
+	require.NoError(t, err)
+
+	encoded, err := state.Encode(secret)
+	require.NoError(t, err)
+
+	t.Run("Round trip", func(t *testing.T) {
+		decoded, err := DecodeState(encoded, secret)
+		require.NoError(t, err)
+		assert.Equal(t, state, decoded)
+		assert.NoError(t, decoded.Check("google", state.Value))
+		assert.Equal(t, constants.ErrOAuthStateInvalid, decoded.Check("google", "forged"))
+		assert.Equal(t, constants.ErrOAuthStateInvalid, decoded.Check("vk", state.Value))
+	})
+
+	t.Run("Wrong secret", func(t *testing.T) {
+		_, err := DecodeState(encoded, []byte("other"))
+		assert.Equal(t, constants.ErrOAuthStateInvalid, err)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		forged := *state
+		forged.UserID = "2"
+		payload, err := json.Marshal(&forged)
+		require.NoError(t, err)
+		_, signature, _ := strings.Cut(encoded, ".")
+		_, err = DecodeState(base64.RawURLEncoding.EncodeToString(payload)+"."+signature, secret)
+		assert.Equal(t, constants.ErrOAuthStateInvalid, err)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		expired, err := NewState("google", "", -time.Minute)
+		require.NoError(t, err)
+		encoded, err := expired.Encode(secret)
+		require.NoError(t, err)
+		_, err = DecodeState(encoded, secret)
+		assert.Equal(t, constants.ErrOAuthStateInvalid, err)
+	})
+}
@@ -0,0 +1,105 @@
+package oauth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+)
+
+// State is what the login flow keeps between the redirect to the provider and the callback.
+// It is stored in the signed cookie of the user's browser.
+type State struct {
+	Provider string `json:"p"`
+	UserID   string `json:"u,omitempty"` // set when the identity is linked to the logged in user
+	Value    string `json:"s"`           // state parameter, protects the callback from CSRF
+	Nonce    string `json:"n"`           // binds the ID token to this login
+	Verifier string `json:"v"`           // PKCE code verifier
+	Expires  int64  `json:"e"`           // unix timestamp
+}
+
+// NewState starts the login flow at the provider. Pass userID to link the identity to the user instead.
+func NewState(provider, userID string, ttl time.Duration) (*State, error) {
+	values := make([]string, 3)
+	for i := range values {
+		value, err := randomString()
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+
+	return &State{
+		Provider: provider,
+		UserID:   userID,
+		Value:    values[0],
+		Nonce:    values[1],
+		Verifier: values[2],
+		Expires:  time.Now().Add(ttl).Unix(),
+	}, nil
+}
+
+// Encode signs the state to be stored on the client.
+func (s *State) Encode(secret []byte) (string, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + sign(payload, secret), nil
+}
+
+// DecodeState verifies the state returned by the client. The state must be signed with the secret and not expired.
+func DecodeState(value string, secret []byte) (*State, error) {
+	payload, signature, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(sign(payload, secret))) {
+		return nil, constants.ErrOAuthStateInvalid
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, constants.ErrOAuthStateInvalid
+	}
+	state := new(State)
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, constants.ErrOAuthStateInvalid
+	}
+	if state.Expires < time.Now().Unix() {
+		return nil, constants.ErrOAuthStateInvalid
+	}
+	return state, nil
+}
+
+// Check compares the state parameter returned by the provider with the stored one.
+func (s *State) Check(provider, value string) error {
+	if s.Provider != provider || subtle.ConstantTimeCompare([]byte(s.Value), []byte(value)) != 1 {
+		return constants.ErrOAuthStateInvalid
+	}
+	return nil
+}
+
+// challenge is the PKCE code challenge of the verifier (S256).
+func (s *State) challenge() string {
+	sum := sha256.Sum256([]byte(s.Verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func sign(payload string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
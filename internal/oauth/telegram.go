@@ -0,0 +1,56 @@
+package oauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+)
+
+// telegramSkew is how far into the future auth_date may be because of the clock difference.
+const telegramSkew = time.Minute
+
+// telegramFields are the fields of the user signed by the Telegram login widget.
+// Other query parameters, like the CSRF token, are not part of the signed data.
+var telegramFields = []string{"auth_date", "first_name", "id", "last_name", "photo_url", "username"}
+
+// CheckTelegramAuth verifies the data of the Telegram login widget: it must be signed with the bot
+// token and not older than ttl, otherwise an intercepted login url could be replayed forever.
+// See https://core.telegram.org/widgets/login#checking-authorization.
+func CheckTelegramAuth(query url.Values, botToken string, ttl time.Duration) error {
+	hash, err := hex.DecodeString(query.Get("hash"))
+	if err != nil || len(hash) == 0 {
+		return constants.ErrHashInvalid
+	}
+
+	lines := make([]string, 0, len(telegramFields))
+	for _, field := range telegramFields {
+		if values, ok := query[field]; ok {
+			lines = append(lines, field+"="+values[0])
+		}
+	}
+	sort.Strings(lines)
+
+	secret := sha256.Sum256([]byte(botToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	if !hmac.Equal(hash, mac.Sum(nil)) {
+		return constants.ErrHashInvalid
+	}
+
+	authDate, err := strconv.ParseInt(query.Get("auth_date"), 10, 64)
+	if err != nil {
+		return constants.ErrHashInvalid
+	}
+	signedAt := time.Unix(authDate, 0)
+	if time.Since(signedAt) > ttl || time.Until(signedAt) > telegramSkew {
+		return constants.ErrTelegramAuthExpired
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package oauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+const testBotToken = "bot-token"
+
+// telegramQuery is what the login widget redirects with, signed as of authDate.
+func telegramQuery(authDate time.Time) url.Values {
+	query := url.Values{
+		"id":         {"42"},
+		"first_name": {"Ivan"},
+		"username":   {"ivan"},
+		"auth_date":  {strconv.FormatInt(authDate.Unix(), 10)},
+	}
+	secret := sha256.Sum256([]byte(testBotToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte("auth_date=" + query.Get("auth_date") + "\nfirst_name=Ivan\nid=42\nusername=ivan"))
+	query.Set("hash", hex.EncodeToString(mac.Sum(nil)))
+	return query
+}
+
+func TestCheckTelegramAuth(t *testing.T) {
+	ttl := 5 * time.Minute
+
+	tests := []struct {
+		name  string
+		query func() url.Values
+		err   error
+	}{
+		{
+			name:  "Fresh",
+			query: func() url.Values { return telegramQuery(time.Now()) },
+		},
+		{
+			name: "Extra query parameters",
+			query: func() url.Values {
+				query := telegramQuery(time.Now())
+				query.Set(constants.CookieKeyCSRFToken, "csrf")
+				return query
+			},
+		},
+		{
+			name:  "Replayed",
+			query: func() url.Values { return telegramQuery(time.Now().Add(-ttl - time.Minute)) },
+			err:   constants.ErrTelegramAuthExpired,
+		},
+		{
+			name:  "From the future",
+			query: func() url.Values { return telegramQuery(time.Now().Add(time.Hour)) },
+			err:   constants.ErrTelegramAuthExpired,
+		},
+		{
+			name: "Tampered",
+			query: func() url.Values {
+				query := telegramQuery(time.Now())
+				query.Set("id", "43")
+				return query
+			},
+			err: constants.ErrHashInvalid,
+		},
+		{
+			name: "No hash",
+			query: func() url.Values {
+				query := telegramQuery(time.Now())
+				query.Del("hash")
+				return query
+			},
+			err: constants.ErrHashInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.err, CheckTelegramAuth(test.query(), testBotToken, ttl))
+		})
+	}
+}
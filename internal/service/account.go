@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/db"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+)
+
+type AccountService interface {
+	PurgeAccount(ctx context.Context, userID string) error
+}
+
+type accountServiceImpl struct {
+	log *logrus.Entry
+	db  *db.Repository
+}
+
+// PurgeAccount removes everything the user has left: the profile, posts, comments, likes, friends,
+// dialog memberships and community roles. The communities left without an admin are handed off
+// to their oldest follower or deleted if nobody follows them anymore.
+// The profile goes last, so the purge can be simply repeated if it fails halfway.
+func (svc *accountServiceImpl) PurgeAccount(ctx context.Context, userID string) error {
+	user, err := svc.db.UserRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			svc.log.Warnf("profile of purged account %s is not found", userID)
+			return nil
+		}
+		svc.log.Errorf("GetUserByID error: %s", err)
+		return err
+	}
+
+	for _, postID := range user.Posts {
+		if err := svc.purgePost(ctx, postID); err != nil {
+			return err
+		}
+	}
+
+	commentIDs, err := svc.db.CommentRepo.GetUserCommentIDs(ctx, userID)
+	if err != nil {
+		svc.log.Errorf("GetUserCommentIDs error: %s", err)
+		return err
+	}
+
+	if err := svc.db.PostRepo.PostsDeleteComments(ctx, commentIDs); err != nil {
+		svc.log.Errorf("PostsDeleteComments error: %s", err)
+		return err
+	}
+
+	if err := svc.db.CommentRepo.DeleteComments(ctx, commentIDs); err != nil {
+		svc.log.Errorf("DeleteComments error: %s", err)
+		return err
+	}
+
+	if err := svc.db.LikeRepo.DeleteUserLikes(ctx, userID); err != nil {
+		svc.log.Errorf("DeleteUserLikes error: %s", err)
+		return err
+	}
+
+	if err := svc.db.FriendsRepo.DeleteUserFriends(ctx, userID); err != nil {
+		svc.log.Errorf("DeleteUserFriends error: %s", err)
+		return err
+	}
+
+	if err := svc.db.ChatRepo.LeaveDialogs(ctx, userID); err != nil {
+		svc.log.Errorf("LeaveDialogs error: %s", err)
+		return err
+	}
+
+	if err := svc.db.ChatEventRepo.DeleteUserEvents(ctx, userID); err != nil {
+		svc.log.Errorf("DeleteUserEvents error: %s", err)
+		return err
+	}
+
+	if err := svc.db.PresenceRepo.DeletePresence(ctx, userID); err != nil {
+		svc.log.Errorf("DeletePresence error: %s", err)
+		return err
+	}
+
+	for _, communityID := range user.CommunityIDs {
+		if err := svc.leaveCommunity(ctx, communityID, userID); err != nil {
+			return err
+		}
+	}
+
+	if err := svc.db.UserRepo.DeleteUser(ctx, user); err != nil {
+		svc.log.Errorf("DeleteUser error: %s", err)
+		return err
+	}
+
+	svc.log.Infof("data of account %s is purged", userID)
+	return nil
+}
+
+// purgePost deletes the post with its comments and likes.
+func (svc *accountServiceImpl) purgePost(ctx context.Context, postID string) error {
+	post, err := svc.db.PostRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil
+		}
+		svc.log.Errorf("GetPostByID error: %s", err)
+		return err
+	}
+
+	if err := svc.db.CommentRepo.DeleteComments(ctx, post.CommentsIDs); err != nil {
+		svc.log.Errorf("DeleteComments error: %s", err)
+		return err
+	}
+
+	if err := svc.db.LikeRepo.DeleteLike(ctx, postID); err != nil {
+		svc.log.Errorf("DeleteLike error: %s", err)
+		return err
+	}
+
+	if err := svc.db.PostRepo.DeletePost(ctx, postID); err != nil {
+		svc.log.Errorf("DeletePost error: %s", err)
+		return err
+	}
+	return nil
+}
+
+// leaveCommunity removes the user from the community. If the user is its last admin, the community is
+// handed off first, so a failure in between never leaves it without an admin.
+func (svc *accountServiceImpl) leaveCommunity(ctx context.Context, communityID string, userID string) error {
+	community, err := svc.db.CommunityRepo.GetCommunityByID(ctx, communityID)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil
+		}
+		svc.log.Errorf("GetCommunityByID error: %s", err)
+		return err
+	}
+
+	admins := without(community.AdminIDs, userID)
+	if len(admins) == 0 && len(community.AdminIDs) != 0 {
+		followers := without(community.FollowerIDs, userID)
+		if len(followers) == 0 {
+			return svc.purgeCommunity(ctx, community)
+		}
+
+		// Followers are appended on join, so the first one has been there the longest.
+		if err := svc.db.CommunityRepo.AddAdmin(ctx, communityID, followers[0]); err != nil {
+			svc.log.Errorf("AddAdmin error: %s", err)
+			return err
+		}
+		svc.log.Infof("community %s is handed off to %s", communityID, followers[0])
+	}
+
+	if err := svc.db.CommunityRepo.RemoveMember(ctx, communityID, userID); err != nil {
+		svc.log.Errorf("RemoveMember error: %s", err)
+		return err
+	}
+	return nil
+}
+
+func (svc *accountServiceImpl) purgeCommunity(ctx context.Context, community *core.Community) error {
+	for _, postID := range community.PostIDs {
+		if err := svc.purgePost(ctx, postID); err != nil {
+			return err
+		}
+	}
+
+	if err := svc.db.CommunityRepo.DeleteCommunity(ctx, community.ID); err != nil {
+		svc.log.Errorf("DeleteCommunity error: %s", err)
+		return err
+	}
+	svc.log.Infof("community %s is deleted since it has no followers left", community.ID)
+	return nil
+}
+
+func without(ids []string, id string) []string {
+	result := make([]string, 0, len(ids))
+	for _, item := range ids {
+		if item != id {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func NewAccountService(log *logrus.Entry, db *db.Repository) AccountService {
+	return &accountServiceImpl{log: log, db: db}
+}
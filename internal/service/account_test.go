@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	accountImpl := NewAccountService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+
+	t.Run("Already purged", func(t *testing.T) {
+		testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(&core.User{}, constants.ErrDBNotFound)
+
+		err := AccountService.PurgeAccount(accountImpl, ctx, "1")
+		assert.Nil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		user := &core.User{ID: "1", Email: "mail@example.com", Posts: []string{"p1"}, CommunityIDs: []string{"c1", "c2", "c3"}}
+		handedOff := &core.Community{ID: "c1", AdminIDs: []string{"1"}, FollowerIDs: []string{"1", "2", "3"}}
+		shared := &core.Community{ID: "c2", AdminIDs: []string{"1", "4"}, FollowerIDs: []string{"1", "4"}}
+		abandoned := &core.Community{ID: "c3", AdminIDs: []string{"1"}, FollowerIDs: []string{"1"}, PostIDs: []string{"p2"}}
+
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(user, nil),
+
+			testRepo.mockPostR.EXPECT().GetPostByID(ctx, "p1").Return(&core.Post{ID: "p1", CommentsIDs: []string{"k1"}}, nil),
+			testRepo.mockCommentR.EXPECT().DeleteComments(ctx, []string{"k1"}).Return(nil),
+			testRepo.mockLikeR.EXPECT().DeleteLike(ctx, "p1").Return(nil),
+			testRepo.mockPostR.EXPECT().DeletePost(ctx, "p1").Return(nil),
+
+			testRepo.mockCommentR.EXPECT().GetUserCommentIDs(ctx, "1").Return([]string{"k2"}, nil),
+			testRepo.mockPostR.EXPECT().PostsDeleteComments(ctx, []string{"k2"}).Return(nil),
+			testRepo.mockCommentR.EXPECT().DeleteComments(ctx, []string{"k2"}).Return(nil),
+			testRepo.mockLikeR.EXPECT().DeleteUserLikes(ctx, "1").Return(nil),
+			testRepo.mockFriendsR.EXPECT().DeleteUserFriends(ctx, "1").Return(nil),
+			testRepo.mockChatR.EXPECT().LeaveDialogs(ctx, "1").Return(nil),
+			testRepo.mockChatEventR.EXPECT().DeleteUserEvents(ctx, "1").Return(nil),
+			testRepo.mockPresenceR.EXPECT().DeletePresence(ctx, "1").Return(nil),
+
+			testRepo.mockCommunityR.EXPECT().GetCommunityByID(ctx, "c1").Return(handedOff, nil),
+			testRepo.mockCommunityR.EXPECT().AddAdmin(ctx, "c1", "2").Return(nil),
+			testRepo.mockCommunityR.EXPECT().RemoveMember(ctx, "c1", "1").Return(nil),
+
+			testRepo.mockCommunityR.EXPECT().GetCommunityByID(ctx, "c2").Return(shared, nil),
+			testRepo.mockCommunityR.EXPECT().RemoveMember(ctx, "c2", "1").Return(nil),
+
+			testRepo.mockCommunityR.EXPECT().GetCommunityByID(ctx, "c3").Return(abandoned, nil),
+			testRepo.mockPostR.EXPECT().GetPostByID(ctx, "p2").Return(&core.Post{ID: "p2"}, nil),
+			testRepo.mockCommentR.EXPECT().DeleteComments(ctx, gomock.Nil()).Return(nil),
+			testRepo.mockLikeR.EXPECT().DeleteLike(ctx, "p2").Return(nil),
+			testRepo.mockPostR.EXPECT().DeletePost(ctx, "p2").Return(nil),
+			testRepo.mockCommunityR.EXPECT().DeleteCommunity(ctx, "c3").Return(nil),
+
+			testRepo.mockUserR.EXPECT().DeleteUser(ctx, user).Return(nil),
+		)
+
+		err := AccountService.PurgeAccount(accountImpl, ctx, "1")
+		assert.Nil(t, err)
+	})
+}
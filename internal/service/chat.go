@@ -2,15 +2,19 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/db"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/common"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/convert"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/utils"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 )
 
 type ChatService interface {
@@ -21,12 +25,25 @@ type ChatService interface {
 
 	SendMessage(ctx context.Context, request *dto.SendMessageRequest) (*dto.SendMessageResponse, error)
 	ReadMessage(ctx context.Context, request *dto.ReadMessageRequest) (*dto.ReadMessageResponse, error)
+	EditMessage(ctx context.Context, request *dto.EditMessageRequest) (*dto.EditMessageResponse, error)
+	DeleteMessage(ctx context.Context, request *dto.DeleteMessageRequest) (*dto.DeleteMessageResponse, error)
+	ReactMessage(ctx context.Context, request *dto.ReactMessageRequest) (*dto.ReactMessageResponse, error)
+	UnreactMessage(ctx context.Context, request *dto.ReactMessageRequest) (*dto.ReactMessageResponse, error)
 	CheckDialog(ctx context.Context, request *dto.CheckDialogRequest) error
+
+	AddMembers(ctx context.Context, request *dto.AddMembersRequest) (*dto.ChatActionResponse, error)
+	RemoveMember(ctx context.Context, request *dto.RemoveMemberRequest) (*dto.ChatActionResponse, error)
+	EditChat(ctx context.Context, request *dto.EditChatRequest) (*dto.ChatActionResponse, error)
+	SetChatAdmin(ctx context.Context, request *dto.SetChatAdminRequest) (*dto.ChatActionResponse, error)
+	LeaveChat(ctx context.Context, request *dto.LeaveChatRequest) (*dto.ChatActionResponse, error)
 }
 
 type chatServiceImpl struct {
 	log *logrus.Entry
 	db  *db.Repository
+
+	// editTimeLimit is how long the author may change a message, 0 is unlimited.
+	editTimeLimit time.Duration
 }
 
 func (svc *chatServiceImpl) CreateChat(ctx context.Context, request *dto.CreateChatRequest) (*dto.CreateChatResponse, error) {
@@ -72,7 +89,18 @@ func (svc *chatServiceImpl) SendMessage(ctx context.Context, request *dto.SendMe
 		}
 	}
 
-	message := core.Message{
+	if len(request.Message.ReplyTo) != 0 {
+		reply, err := svc.db.MessageRepo.GetMessage(ctx, request.Message.DialogID, request.Message.ReplyTo)
+		if errors.Is(err, constants.ErrDBNotFound) || (err == nil && reply.Deleted) {
+			return nil, constants.ErrReplyNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("GetMessage: %w", err)
+		}
+	}
+
+	message := &core.Message{
+		DialogID:    request.Message.DialogID,
 		Body:        request.Message.Body,
 		AuthorID:    request.Message.AuthorID,
 		IsRead:      isRead,
@@ -80,10 +108,11 @@ func (svc *chatServiceImpl) SendMessage(ctx context.Context, request *dto.SendMe
 		Attachments: request.Message.Attachments,
 		Images:      request.Message.Images,
 		CreatedAt:   request.Message.CreatedAt,
+		ReplyTo:     request.Message.ReplyTo,
 	}
 
-	if err := svc.db.ChatRepo.SendMessage(ctx, message, request.Message.DialogID); err != nil {
-		return nil, fmt.Errorf("SendMessage: %w", err)
+	if err := svc.db.MessageRepo.CreateMessage(ctx, message); err != nil {
+		return nil, fmt.Errorf("CreateMessage: %w", err)
 	}
 
 	return &dto.SendMessageResponse{}, nil
@@ -96,7 +125,7 @@ func (svc *chatServiceImpl) ReadMessage(ctx context.Context, request *dto.ReadMe
 		return nil, err
 	}
 
-	if err := svc.db.ChatRepo.ReadMessage(ctx, request.Message.AuthorID, request.Message.Body, request.Message.DialogID); err != nil {
+	if err := svc.db.MessageRepo.ReadMessage(ctx, request.Message.AuthorID, request.Message.Body, request.Message.DialogID); err != nil {
 		svc.log.Errorf("SendMessage error: %s", err)
 		return nil, err
 	}
@@ -105,37 +134,165 @@ func (svc *chatServiceImpl) ReadMessage(ctx context.Context, request *dto.ReadMe
 	return &dto.ReadMessageResponse{}, nil
 }
 
-func (svc *chatServiceImpl) GetDialogs(ctx context.Context, request *dto.GetDialogsRequest) (*dto.GetDialogsResponse, error) {
-	ids, err := svc.db.UserRepo.GetUserDialogs(ctx, request.UserID)
+func (svc *chatServiceImpl) EditMessage(ctx context.Context, request *dto.EditMessageRequest) (*dto.EditMessageResponse, error) {
+	message, err := svc.authorMessage(ctx, request.UserID, request.DialogID, request.MessageID)
 	if err != nil {
-		svc.log.Errorf("GetUserDialogs error: %s", err)
 		return nil, err
 	}
 
-	ids, total, page := utils.GetLimitArray(&ids, request.Limit, request.Page)
+	editedAt := time.Now().Unix()
+	if err := svc.db.MessageRepo.EditMessage(ctx, message.ID, request.Body, editedAt); err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.ErrMessageNotFound
+		}
+		svc.log.Errorf("EditMessage error: %s", err)
+		return nil, err
+	}
 
-	var dialogs []dto.Dialog
-	for _, id := range ids {
-		dInf, err := svc.db.ChatRepo.GetDialogByID(ctx, id)
-		if err != nil {
-			svc.log.Errorf("GetDialogInfo error: %s", err)
+	svc.log.Debug("EditMessage success")
+	return &dto.EditMessageResponse{EditedAt: editedAt}, nil
+}
+
+func (svc *chatServiceImpl) DeleteMessage(ctx context.Context, request *dto.DeleteMessageRequest) (*dto.DeleteMessageResponse, error) {
+	if !request.ForEveryone {
+		if _, err := svc.dialogMessage(ctx, request.UserID, request.DialogID, request.MessageID); err != nil {
+			return nil, err
+		}
+		if err := svc.db.MessageRepo.HideMessage(ctx, request.MessageID, request.UserID); err != nil {
+			svc.log.Errorf("HideMessage error: %s", err)
+			return nil, err
+		}
+		return &dto.DeleteMessageResponse{}, nil
+	}
+
+	message, err := svc.authorMessage(ctx, request.UserID, request.DialogID, request.MessageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.db.MessageRepo.DeleteMessage(ctx, message.ID, time.Now().Unix()); err != nil {
+		svc.log.Errorf("DeleteMessage error: %s", err)
+		return nil, err
+	}
+
+	svc.log.Debug("DeleteMessage success")
+	return &dto.DeleteMessageResponse{}, nil
+}
+
+func (svc *chatServiceImpl) ReactMessage(ctx context.Context, request *dto.ReactMessageRequest) (*dto.ReactMessageResponse, error) {
+	if !utils.IsEmoji(request.Emoji) {
+		return nil, constants.ErrReactionInvalid
+	}
+	if _, err := svc.dialogMessage(ctx, request.UserID, request.DialogID, request.MessageID); err != nil {
+		return nil, err
+	}
+
+	reaction := core.Reaction{UserID: request.UserID, Emoji: request.Emoji}
+	if err := svc.db.MessageRepo.AddReaction(ctx, request.MessageID, reaction); err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.ErrMessageNotFound
+		}
+		svc.log.Errorf("AddReaction error: %s", err)
+		return nil, err
+	}
+	return &dto.ReactMessageResponse{}, nil
+}
+
+func (svc *chatServiceImpl) UnreactMessage(ctx context.Context, request *dto.ReactMessageRequest) (*dto.ReactMessageResponse, error) {
+	if _, err := svc.dialogMessage(ctx, request.UserID, request.DialogID, request.MessageID); err != nil {
+		return nil, err
+	}
+
+	reaction := core.Reaction{UserID: request.UserID, Emoji: request.Emoji}
+	if err := svc.db.MessageRepo.RemoveReaction(ctx, request.MessageID, reaction); err != nil {
+		svc.log.Errorf("RemoveReaction error: %s", err)
+		return nil, err
+	}
+	return &dto.ReactMessageResponse{}, nil
+}
+
+// dialogMessage returns the message of the dialog the user is a participant of.
+func (svc *chatServiceImpl) dialogMessage(ctx context.Context, userID string, dialogID string, messageID string) (*core.Message, error) {
+	if _, err := svc.dialogFor(ctx, dialogID, userID, false); err != nil {
+		return nil, err
+	}
+
+	message, err := svc.db.MessageRepo.GetMessage(ctx, dialogID, messageID)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.ErrMessageNotFound
 		}
+		svc.log.Errorf("GetMessage error: %s", err)
+		return nil, err
+	}
+	if message.Deleted {
+		return nil, constants.ErrMessageNotFound
+	}
+	return message, nil
+}
 
-		dialogs = append(dialogs, convert.Dialog2DTO(dInf, request.UserID))
+// authorMessage returns the message the user may still change for everyone.
+func (svc *chatServiceImpl) authorMessage(ctx context.Context, userID string, dialogID string, messageID string) (*core.Message, error) {
+	message, err := svc.dialogMessage(ctx, userID, dialogID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.AuthorID != userID {
+		return nil, constants.ErrNotMessageAuthor
+	}
+	if svc.editTimeLimit > 0 && time.Since(time.Unix(message.CreatedAt, 0)) > svc.editTimeLimit {
+		return nil, constants.ErrMessageEditExpired
+	}
+	return message, nil
+}
+
+// GetDialogs returns a page of the dialogs of the user, the ones with the latest activity first,
+// with their last messages and unread counters. The limit -1 is all of them.
+func (svc *chatServiceImpl) GetDialogs(ctx context.Context, request *dto.GetDialogsRequest) (*dto.GetDialogsResponse, error) {
+	var skip, limit int64
+	if request.Limit > 0 {
+		skip, limit = (request.Page-1)*request.Limit, request.Limit
 	}
 
-	for i, dialog := range dialogs {
-		if len(dialog.Participants) == 1 {
-			participant, err := svc.db.UserRepo.GetUserByID(ctx, dialog.Participants[0])
-			if err != nil {
-				return nil, err
+	overview, err := svc.db.ChatRepo.GetDialogsOverview(ctx, request.UserID, skip, limit)
+	if err != nil {
+		svc.log.Errorf("GetDialogsOverview error: %s", err)
+		return nil, err
+	}
+
+	pages := int64(1)
+	if limit > 0 && limit <= overview.Total {
+		pages = overview.Total/limit + utils.IsLarge(overview.Total%limit > 0)
+	}
+
+	dialogs := make([]dto.Dialog, 0, len(overview.Dialogs))
+	var participants []*core.User
+	for i := range overview.Dialogs {
+		dialog := convert.DialogOverview2DTO(&overview.Dialogs[i], request.UserID)
+		if participant := overview.Dialogs[i].Interlocutor; participant != nil {
+			dialog.Name = participant.Name.Full()
+			dialog.Image = participant.Image
+			participants = append(participants, participant)
+		}
+		dialogs = append(dialogs, dialog)
+	}
+
+	if len(participants) != 0 {
+		presence, err := getPresence(ctx, svc.db, request.UserID, participants)
+		if err != nil {
+			svc.log.Errorf("getPresence error: %s", err)
+		}
+		for i := range overview.Dialogs {
+			participant := overview.Dialogs[i].Interlocutor
+			if participant == nil {
+				continue
+			}
+			if participantPresence, ok := presence[participant.ID]; ok {
+				dialogs[i].Presence = &participantPresence
 			}
-			dialogs[i].Name = participant.Name.Full()
-			dialogs[i].Image = participant.Image
 		}
 	}
 
-	return &dto.GetDialogsResponse{Dialogs: dialogs, Total: total, AmountPages: page}, err
+	return &dto.GetDialogsResponse{Dialogs: dialogs, Total: overview.Total, AmountPages: pages, Unread: overview.Unread}, nil
 }
 
 func (svc *chatServiceImpl) GetDialogByUserID(ctx context.Context, request *dto.GetDialogByUserIDRequest, currentUserID string) (*dto.GetDialogByUserIDResponse, error) {
@@ -148,37 +305,75 @@ func (svc *chatServiceImpl) GetDialogByUserID(ctx context.Context, request *dto.
 }
 
 func (svc *chatServiceImpl) CheckDialog(ctx context.Context, request *dto.CheckDialogRequest) error {
-	dialog, err := svc.db.ChatRepo.GetDialogByID(ctx, request.DialogID)
+	_, err := svc.dialogFor(ctx, request.DialogID, request.UserID, false)
+	return err
+}
+
+// dialogFor returns the dialog the user is a participant of. If admin is set,
+// the dialog must be a group chat the user administers.
+func (svc *chatServiceImpl) dialogFor(ctx context.Context, dialogID string, userID string, admin bool) (*core.Dialog, error) {
+	dialog, err := svc.db.ChatRepo.GetDialogByID(ctx, dialogID)
 	if err != nil {
-		svc.log.Errorf("GetDialogInfo error: %s", err)
-		return err
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.ErrDBNotFound
+		}
+		svc.log.Errorf("GetDialogByID error: %s", err)
+		return nil, err
+	}
+	if !dialog.HasParticipant(userID) {
+		return nil, constants.ErrDBNotFound
 	}
 
-	err = svc.db.UserRepo.UserCheckDialog(ctx, dialog.ID, request.UserID)
-	if err != nil {
-		svc.log.Errorf("Don't found in db")
-		return constants.ErrDBNotFound
+	if admin {
+		if !dialog.IsGroup() {
+			return nil, constants.ErrNotGroupChat
+		}
+		if !dialog.IsAdmin(userID) {
+			return nil, constants.ErrNotChatAdmin
+		}
 	}
-	return nil
+	return dialog, nil
 }
 
 func (svc *chatServiceImpl) GetDialog(ctx context.Context, request *dto.GetDialogRequest) (*dto.GetDialogResponse, error) {
-	err := svc.db.UserRepo.UserCheckDialog(ctx, request.DialogID, request.UserID)
-	if err != nil {
-		return nil, constants.ErrDBNotFound
-	}
-
-	dialogCore, err := svc.db.ChatRepo.GetDialogByID(ctx, request.DialogID)
+	dialogCore, err := svc.dialogFor(ctx, request.DialogID, request.UserID, false)
 	if err != nil {
 		return nil, err
 	}
 
-	var total int64
-	var page int64
-	dialogCore.Messages, total, page = utils.GetLimitMessage(&dialogCore.Messages, request.Limit, request.Page)
+	response := &dto.GetDialogResponse{}
+	var messages []core.Message
+	if request.Page > 0 {
+		if len(request.Before) != 0 {
+			return nil, constants.ErrPageWithBefore
+		}
+		var page *common.PageResponse
+		messages, page, err = svc.db.MessageRepo.GetMessagesPage(ctx, request.DialogID, request.UserID, request.Limit, request.Page)
+		if err != nil {
+			svc.log.Errorf("GetMessagesPage error: %s", err)
+			return nil, err
+		}
+		response.Total, response.AmountPages = page.Total, page.AmountPages
+		response.HasMore = request.Page < page.AmountPages
+	} else {
+		// One more message tells if there is a page before this one.
+		limit := request.Limit
+		if limit > 0 {
+			limit++
+		}
+		messages, err = svc.db.MessageRepo.GetMessages(ctx, request.DialogID, request.UserID, request.Before, limit)
+		if err != nil {
+			svc.log.Errorf("GetMessages error: %s", err)
+			return nil, err
+		}
+		response.HasMore = request.Limit > 0 && int64(len(messages)) > request.Limit
+		if response.HasMore {
+			messages = messages[:request.Limit]
+		}
+	}
 
 	dialog := convert.Dialog2DTO(dialogCore, request.UserID)
-	if len(dialog.Participants) == 1 {
+	if !dialog.Group && len(dialog.Participants) == 1 {
 		participant, err := svc.db.UserRepo.GetUserByID(ctx, dialog.Participants[0])
 		if err != nil {
 			return nil, err
@@ -187,9 +382,301 @@ func (svc *chatServiceImpl) GetDialog(ctx context.Context, request *dto.GetDialo
 		dialog.Image = participant.Image
 	}
 
-	return &dto.GetDialogResponse{Dialog: dialog, Messages: convert.Messages2DTO(dialogCore.Messages, request.UserID), Total: total, AmountPages: page}, err
+	messagesDTO := convert.Messages2DTO(messages, request.UserID)
+	if err := svc.quoteReplies(ctx, request.DialogID, request.UserID, messages, messagesDTO); err != nil {
+		svc.log.Errorf("quoteReplies error: %s", err)
+		return nil, err
+	}
+
+	response.Dialog, response.Messages = dialog, messagesDTO
+	return response, nil
+}
+
+// quoteReplies sets the previews of the messages which are replied to, they are got at once.
+func (svc *chatServiceImpl) quoteReplies(ctx context.Context, dialogID string, userID string, messages []core.Message, messagesDTO []dto.MessageInfo) error {
+	var ids []string
+	for _, message := range messages {
+		if len(message.ReplyTo) != 0 {
+			ids = append(ids, message.ReplyTo)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	replies, err := svc.db.MessageRepo.GetMessagesByIDs(ctx, dialogID, ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*core.Message, len(replies))
+	for i := range replies {
+		byID[replies[i].ID] = &replies[i]
+	}
+
+	for i, message := range messages {
+		if len(message.ReplyTo) != 0 {
+			messagesDTO[i].ReplyTo = convert.MessageQuote2DTO(message.ReplyTo, byID[message.ReplyTo], userID)
+		}
+	}
+	return nil
+}
+
+// AddMembers adds the users to the group chat, every one of them is announced by a system message.
+func (svc *chatServiceImpl) AddMembers(ctx context.Context, request *dto.AddMembersRequest) (*dto.ChatActionResponse, error) {
+	if len(request.MemberIDs) == 0 {
+		return nil, constants.ErrNoMembersToAdd
+	}
+
+	dialog, err := svc.dialogFor(ctx, request.DialogID, request.UserID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var memberIDs []string
+	added := make(map[string]bool, len(request.MemberIDs))
+	for _, id := range request.MemberIDs {
+		if dialog.HasParticipant(id) {
+			return nil, constants.ErrAlreadyParticipant
+		}
+		if added[id] {
+			continue
+		}
+		if _, err := svc.db.UserRepo.GetUserByID(ctx, id); err != nil {
+			svc.log.Errorf("GetUserByID error: %s", err)
+			return nil, err
+		}
+		added[id] = true
+		memberIDs = append(memberIDs, id)
+	}
+
+	if err := svc.db.ChatRepo.AddParticipants(ctx, dialog.ID, memberIDs); err != nil {
+		svc.log.Errorf("AddParticipants error: %s", err)
+		return nil, err
+	}
+
+	response := &dto.ChatActionResponse{}
+	for _, id := range memberIDs {
+		if err := svc.db.UserRepo.AddDialog(ctx, dialog.ID, id); err != nil {
+			svc.log.Errorf("AddDialog error: %s", err)
+			return nil, err
+		}
+		if err := svc.systemMessage(ctx, response, dialog.ID, request.UserID, constants.AddMember, id, constants.Empty); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// RemoveMember removes the participant from the group chat. The admin who removes themselves leaves the chat.
+func (svc *chatServiceImpl) RemoveMember(ctx context.Context, request *dto.RemoveMemberRequest) (*dto.ChatActionResponse, error) {
+	if request.MemberID == request.UserID {
+		return svc.LeaveChat(ctx, &dto.LeaveChatRequest{UserID: request.UserID, DialogID: request.DialogID})
+	}
+
+	dialog, err := svc.dialogFor(ctx, request.DialogID, request.UserID, true)
+	if err != nil {
+		return nil, err
+	}
+	if !dialog.HasParticipant(request.MemberID) {
+		return nil, constants.ErrNotChatParticipant
+	}
+
+	if err := svc.removeParticipant(ctx, dialog.ID, request.MemberID); err != nil {
+		return nil, err
+	}
+
+	response := &dto.ChatActionResponse{}
+	if err := svc.systemMessage(ctx, response, dialog.ID, request.UserID, constants.RemoveMember, request.MemberID, constants.Empty); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// EditChat renames the group chat and sets its image.
+func (svc *chatServiceImpl) EditChat(ctx context.Context, request *dto.EditChatRequest) (*dto.ChatActionResponse, error) {
+	if len(request.Name) == 0 && len(request.Image) == 0 {
+		return nil, constants.ErrChatEditEmpty
+	}
+
+	dialog, err := svc.dialogFor(ctx, request.DialogID, request.UserID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := svc.db.ChatRepo.EditDialog(ctx, dialog.ID, request.Name, request.Image); err != nil {
+		svc.log.Errorf("EditDialog error: %s", err)
+		return nil, err
+	}
+
+	response := &dto.ChatActionResponse{}
+	if len(request.Name) != 0 {
+		if err := svc.systemMessage(ctx, response, dialog.ID, request.UserID, constants.RenameChat, constants.Empty, request.Name); err != nil {
+			return nil, err
+		}
+	}
+	if len(request.Image) != 0 {
+		if err := svc.systemMessage(ctx, response, dialog.ID, request.UserID, constants.SetChatImage, constants.Empty, request.Image); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// SetChatAdmin makes the participant an admin of the group chat or takes it back. The last admin stays.
+func (svc *chatServiceImpl) SetChatAdmin(ctx context.Context, request *dto.SetChatAdminRequest) (*dto.ChatActionResponse, error) {
+	dialog, err := svc.dialogFor(ctx, request.DialogID, request.UserID, true)
+	if err != nil {
+		return nil, err
+	}
+	if !dialog.HasParticipant(request.MemberID) {
+		return nil, constants.ErrNotChatParticipant
+	}
+
+	response := &dto.ChatActionResponse{Messages: []dto.Message{}}
+	if dialog.IsAdmin(request.MemberID) == request.Admin {
+		return response, nil
+	}
+
+	var admins []string
+	for _, id := range dialog.Admins() {
+		if id != request.MemberID {
+			admins = append(admins, id)
+		}
+	}
+	action := constants.RemoveAdmin
+	if request.Admin {
+		admins = append(admins, request.MemberID)
+		action = constants.AddAdmin
+	} else if len(admins) == 0 {
+		return nil, constants.ErrLastChatAdmin
+	}
+
+	if err := svc.db.ChatRepo.SetAdmins(ctx, dialog.ID, admins); err != nil {
+		svc.log.Errorf("SetAdmins error: %s", err)
+		return nil, err
+	}
+	if err := svc.systemMessage(ctx, response, dialog.ID, request.UserID, action, request.MemberID, constants.Empty); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// LeaveChat removes the user from the group chat. If the last admin leaves, the first participant left becomes an admin.
+func (svc *chatServiceImpl) LeaveChat(ctx context.Context, request *dto.LeaveChatRequest) (*dto.ChatActionResponse, error) {
+	dialog, err := svc.dialogFor(ctx, request.DialogID, request.UserID, false)
+	if err != nil {
+		return nil, err
+	}
+	if !dialog.IsGroup() {
+		return nil, constants.ErrNotGroupChat
+	}
+
+	if err := svc.removeParticipant(ctx, dialog.ID, request.UserID); err != nil {
+		return nil, err
+	}
+
+	response := &dto.ChatActionResponse{}
+	if err := svc.systemMessage(ctx, response, dialog.ID, request.UserID, constants.LeaveGroup, constants.Empty, constants.Empty); err != nil {
+		return nil, err
+	}
+
+	var left, admins []string
+	for _, id := range dialog.Participants {
+		if id != request.UserID {
+			left = append(left, id)
+		}
+	}
+	for _, id := range dialog.Admins() {
+		if id != request.UserID {
+			admins = append(admins, id)
+		}
+	}
+	if len(admins) != 0 || len(left) == 0 {
+		return response, nil
+	}
+
+	if err := svc.db.ChatRepo.SetAdmins(ctx, dialog.ID, left[:1]); err != nil {
+		svc.log.Errorf("SetAdmins error: %s", err)
+		return nil, err
+	}
+	if err := svc.systemMessage(ctx, response, dialog.ID, request.UserID, constants.AddAdmin, left[0], constants.Empty); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (svc *chatServiceImpl) removeParticipant(ctx context.Context, dialogID string, userID string) error {
+	if err := svc.db.ChatRepo.RemoveParticipant(ctx, dialogID, userID); err != nil {
+		svc.log.Errorf("RemoveParticipant error: %s", err)
+		return err
+	}
+	if err := svc.db.UserRepo.RemoveDialog(ctx, dialogID, userID); err != nil {
+		svc.log.Errorf("RemoveDialog error: %s", err)
+		return err
+	}
+	return nil
+}
+
+// systemMessages are the texts of the system messages, they are formatted with the names of the author and the target.
+var systemMessages = map[string]string{
+	constants.AddMember:    "%s added %s",
+	constants.RemoveMember: "%s removed %s",
+	constants.RenameChat:   "%s renamed the chat to %s",
+	constants.SetChatImage: "%s changed the chat image",
+	constants.LeaveGroup:   "%s left the chat",
+	constants.AddAdmin:     "%s made %s an admin",
+	constants.RemoveAdmin:  "%s removed %s from the admins",
+}
+
+// systemMessage stores the system message about the change of the group chat and adds its event to the response.
+// The event has the new name or image of the chat in its body.
+func (svc *chatServiceImpl) systemMessage(ctx context.Context, response *dto.ChatActionResponse, dialogID string, userID string,
+	action string, targetID string, value string) error {
+	msgID, err := core.GenUUID()
+	if err != nil {
+		return constants.ErrGenerateUUID
+	}
+
+	author, err := svc.db.UserRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		svc.log.Errorf("GetUserByID error: %s", err)
+		return err
+	}
+	args := []interface{}{author.Name.Full()}
+	if len(targetID) != 0 {
+		target, err := svc.db.UserRepo.GetUserByID(ctx, targetID)
+		if err != nil {
+			svc.log.Errorf("GetUserByID error: %s", err)
+			return err
+		}
+		args = append(args, target.Name.Full())
+	}
+	if action == constants.RenameChat {
+		args = append(args, value)
+	}
+
+	message := &core.Message{
+		ID:        msgID,
+		DialogID:  dialogID,
+		Body:      fmt.Sprintf(systemMessages[action], args...),
+		AuthorID:  userID,
+		CreatedAt: time.Now().Unix(),
+		Action:    action,
+		TargetID:  targetID,
+	}
+	if err := svc.db.MessageRepo.CreateMessage(ctx, message); err != nil {
+		svc.log.Errorf("CreateMessage error: %s", err)
+		return err
+	}
+
+	event := convert.SystemMessage2DTO(message)
+	if len(value) != 0 {
+		event.Body = value
+	}
+	response.Messages = append(response.Messages, event)
+	return nil
 }
 
 func NewChatService(log *logrus.Entry, db *db.Repository) ChatService {
-	return &chatServiceImpl{log: log, db: db}
+	return &chatServiceImpl{log: log, db: db, editTimeLimit: viper.GetDuration(constants.ConfigChatEditTimeLimit)}
 }
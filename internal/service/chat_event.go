@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/db"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/convert"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// ChatEventService keeps the event log of every user, so that the events missed while the user was offline
+// can be caught up on. The events of a user are ordered by their seq.
+type ChatEventService interface {
+	Record(ctx context.Context, msg *dto.Message) error
+	GetEvents(ctx context.Context, request *dto.GetChatEventsRequest) (*dto.GetChatEventsResponse, error)
+}
+
+type chatEventServiceImpl struct {
+	log *logrus.Entry
+	db  *db.Repository
+
+	// ttl is how long the events are kept.
+	ttl time.Duration
+}
+
+// Record adds the message to the event logs of the participants of its dialog who receive it
+// and sets the seq of the event of every receiver to msg.Seqs. The member who is removed or has left receives it too.
+func (svc *chatEventServiceImpl) Record(ctx context.Context, msg *dto.Message) error {
+	dialog, err := svc.db.ChatRepo.GetDialogByID(ctx, msg.DialogID)
+	if err != nil {
+		return fmt.Errorf("GetDialogByID: %w", err)
+	}
+
+	receivers := dialog.Participants
+	if member := ChangedMember(msg); len(member) != 0 && !dialog.HasParticipant(member) {
+		receivers = append(receivers[:len(receivers):len(receivers)], member)
+	}
+
+	userIDs := make([]string, 0, len(receivers))
+	for _, userID := range receivers {
+		if IsMessageFor(msg, userID) {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	seqs, err := svc.db.ChatEventRepo.NextSeqs(ctx, userIDs, now.Unix())
+	if err != nil {
+		return fmt.Errorf("NextSeqs: %w", err)
+	}
+	events := make([]core.ChatEvent, 0, len(userIDs))
+	for _, userID := range userIDs {
+		events = append(events, convert.Message2ChatEvent(msg, userID, seqs[userID], now.Add(svc.ttl)))
+	}
+
+	if err := svc.db.ChatEventRepo.AddEvents(ctx, events); err != nil {
+		return fmt.Errorf("AddEvents: %w", err)
+	}
+	msg.Seqs = seqs
+	return nil
+}
+
+// GetEvents returns the events of the user after the seq request.Since. If some of them are gone,
+// the response is reset and has no events, the user catches up from the last seq next time.
+// The seqs are taken before the events are added, so an event may be in the log before the previous one.
+// The events are returned up to such a gap, HasMore tells to ask for them again a bit later.
+func (svc *chatEventServiceImpl) GetEvents(ctx context.Context, request *dto.GetChatEventsRequest) (*dto.GetChatEventsResponse, error) {
+	counter, err := svc.db.ChatEventRepo.GetCounter(ctx, request.UserID)
+	if errors.Is(err, constants.ErrDBNotFound) {
+		counter, err = &core.ChatEventCounter{UserID: request.UserID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetCounter: %w", err)
+	}
+
+	response := &dto.GetChatEventsResponse{Events: []dto.Message{}, LastSeq: counter.Seq}
+	if request.Since > counter.Seq {
+		response.Reset = true
+		return response, nil
+	}
+
+	// One more event tells if there are more of them.
+	limit := request.Limit
+	if limit > 0 {
+		limit++
+	}
+	events, err := svc.db.ChatEventRepo.GetEvents(ctx, request.UserID, request.Since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("GetEvents: %w", err)
+	}
+	response.HasMore = request.Limit > 0 && int64(len(events)) > request.Limit
+	if response.HasMore {
+		events = events[:request.Limit]
+	}
+
+	// The events which are being recorded aren't in the log yet, the old gap at the start tells they have expired.
+	expired := time.Since(time.Unix(counter.UpdatedAt, 0)) > svc.ttl
+	if request.Since < counter.Seq && ((len(events) != 0 && events[0].Seq > request.Since+1 && svc.isGapLost(events[0])) || (len(events) == 0 && expired)) {
+		response.Reset = true
+		return response, nil
+	}
+
+	// The cursor moves to the last event given, the events being recorded come after it.
+	response.LastSeq = request.Since
+	for _, event := range events {
+		if event.Seq > response.LastSeq+1 && !svc.isGapLost(event) {
+			response.HasMore = true
+			break
+		}
+		response.Events = append(response.Events, convert.ChatEvent2DTO(event))
+		response.LastSeq = event.Seq
+	}
+	return response, nil
+}
+
+// isGapLost tells if the events missing before the event won't be added anymore.
+func (svc *chatEventServiceImpl) isGapLost(event core.ChatEvent) bool {
+	recordedAt := event.ExpiresAt.Add(-svc.ttl)
+	return time.Since(recordedAt) > constants.ChatEventGapTimeout
+}
+
+// IsMessageFor tells if the participant of the dialog receives the message. The read state is shared by the
+// reader and the author of the message only, the message deleted for its author only is hidden for them only.
+func IsMessageFor(msg *dto.Message, userID string) bool {
+	switch {
+	case msg.Event == constants.ReadChat:
+		return userID == msg.DestinID || userID == msg.AuthorID
+	case msg.Event == constants.DeleteChat && !msg.ForEveryone:
+		return userID == msg.AuthorID
+	}
+	return true
+}
+
+// ChangedMember returns the member of the group chat who is added, removed or has left with the event.
+// They receive the event though they aren't its participant yet or anymore.
+func ChangedMember(msg *dto.Message) string {
+	switch msg.Event {
+	case constants.AddMember, constants.RemoveMember:
+		return msg.DestinID
+	case constants.LeaveGroup:
+		return msg.AuthorID
+	}
+	return constants.Empty
+}
+
+func NewChatEventService(log *logrus.Entry, db *db.Repository) ChatEventService {
+	return &chatEventServiceImpl{log: log, db: db, ttl: viper.GetDuration(constants.ConfigChatEventLogTTL)}
+}
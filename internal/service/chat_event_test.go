@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordChatEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	eventImpl := &chatEventServiceImpl{log: TestLogger(t), db: TestBD, ttl: time.Hour}
+
+	ctx := context.Background()
+	dialog := &core.Dialog{ID: "d1", Participants: []string{"1", "2", "3"}}
+
+	t.Run("Message", func(t *testing.T) {
+		msg := &dto.Message{ID: "m1", DialogID: "d1", Event: constants.SendChat, AuthorID: "1", Body: "hi"}
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockChatEventR.EXPECT().NextSeqs(ctx, []string{"1", "2", "3"}, gomock.Any()).Return(map[string]int64{"1": 4, "2": 10, "3": 1}, nil),
+			testRepo.mockChatEventR.EXPECT().AddEvents(ctx, gomock.Len(3)).Return(nil),
+		)
+
+		assert.Nil(t, ChatEventService.Record(eventImpl, ctx, msg))
+		assert.Equal(t, map[string]int64{"1": 4, "2": 10, "3": 1}, msg.Seqs)
+	})
+
+	t.Run("Read", func(t *testing.T) {
+		msg := &dto.Message{DialogID: "d1", Event: constants.ReadChat, AuthorID: "2", DestinID: "1", Body: "m1"}
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockChatEventR.EXPECT().NextSeqs(ctx, []string{"1", "2"}, gomock.Any()).Return(map[string]int64{"1": 5, "2": 11}, nil),
+			testRepo.mockChatEventR.EXPECT().AddEvents(ctx, gomock.Len(2)).Return(nil),
+		)
+
+		assert.Nil(t, ChatEventService.Record(eventImpl, ctx, msg))
+		assert.Equal(t, map[string]int64{"1": 5, "2": 11}, msg.Seqs, "the read state is shared by the reader and the author only")
+	})
+
+	t.Run("Removed member", func(t *testing.T) {
+		msg := &dto.Message{ID: "m2", DialogID: "d1", Event: constants.RemoveMember, AuthorID: "1", DestinID: "4"}
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockChatEventR.EXPECT().NextSeqs(ctx, []string{"1", "2", "3", "4"}, gomock.Any()).Return(map[string]int64{"1": 6, "2": 12, "3": 2, "4": 7}, nil),
+			testRepo.mockChatEventR.EXPECT().AddEvents(ctx, gomock.Len(4)).Return(nil),
+		)
+
+		assert.Nil(t, ChatEventService.Record(eventImpl, ctx, msg))
+		assert.Equal(t, int64(7), msg.Seqs["4"], "the removed member is told too")
+		assert.Len(t, dialog.Participants, 3, "the participants of the dialog are left as they are")
+	})
+}
+
+func TestGetChatEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	eventImpl := &chatEventServiceImpl{log: TestLogger(t), db: TestBD, ttl: time.Hour}
+
+	ctx := context.Background()
+	now := time.Now().Unix()
+	counter := &core.ChatEventCounter{UserID: "1", Seq: 7, UpdatedAt: now}
+	// The events are kept for an hour, so the ones expiring in an hour are just recorded.
+	recorded, old := time.Now().Add(time.Hour), time.Now().Add(time.Hour-time.Minute)
+
+	t.Run("Has more", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatEventR.EXPECT().GetCounter(ctx, "1").Return(counter, nil),
+			testRepo.mockChatEventR.EXPECT().GetEvents(ctx, "1", int64(4), int64(3)).Return([]core.ChatEvent{
+				{Seq: 5, MessageID: "m1", ExpiresAt: recorded}, {Seq: 6, ExpiresAt: recorded}, {Seq: 7, ExpiresAt: recorded},
+			}, nil),
+		)
+
+		res, err := ChatEventService.GetEvents(eventImpl, ctx, &dto.GetChatEventsRequest{UserID: "1", Since: 4, Limit: 2})
+		assert.Nil(t, err)
+		assert.True(t, res.HasMore)
+		assert.False(t, res.Reset)
+		assert.Equal(t, int64(6), res.LastSeq)
+		assert.Equal(t, []dto.Message{{ID: "m1", Seq: 5}, {Seq: 6}}, res.Events)
+	})
+
+	t.Run("Up to date", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatEventR.EXPECT().GetCounter(ctx, "1").Return(counter, nil),
+			testRepo.mockChatEventR.EXPECT().GetEvents(ctx, "1", int64(7), int64(3)).Return([]core.ChatEvent{}, nil),
+		)
+
+		res, err := ChatEventService.GetEvents(eventImpl, ctx, &dto.GetChatEventsRequest{UserID: "1", Since: 7, Limit: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, &dto.GetChatEventsResponse{Events: []dto.Message{}, LastSeq: 7}, res)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatEventR.EXPECT().GetCounter(ctx, "1").Return(counter, nil),
+			testRepo.mockChatEventR.EXPECT().GetEvents(ctx, "1", int64(1), int64(3)).Return([]core.ChatEvent{{Seq: 6, ExpiresAt: old}}, nil),
+		)
+
+		res, err := ChatEventService.GetEvents(eventImpl, ctx, &dto.GetChatEventsRequest{UserID: "1", Since: 1, Limit: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, &dto.GetChatEventsResponse{Events: []dto.Message{}, LastSeq: 7, Reset: true}, res,
+			"the events 2-5 are gone, the dialogs have to be loaded anew")
+	})
+
+	t.Run("Being recorded", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatEventR.EXPECT().GetCounter(ctx, "1").Return(counter, nil),
+			testRepo.mockChatEventR.EXPECT().GetEvents(ctx, "1", int64(3), int64(4)).Return([]core.ChatEvent{
+				{Seq: 4, ExpiresAt: recorded}, {Seq: 6, ExpiresAt: recorded}, {Seq: 7, ExpiresAt: recorded},
+			}, nil),
+		)
+
+		res, err := ChatEventService.GetEvents(eventImpl, ctx, &dto.GetChatEventsRequest{UserID: "1", Since: 3, Limit: 3})
+		assert.Nil(t, err)
+		assert.False(t, res.Reset)
+		assert.True(t, res.HasMore, "the event 5 must be asked for again")
+		assert.Equal(t, int64(4), res.LastSeq, "the events after the one being recorded must not be given before it")
+		assert.Len(t, res.Events, 1)
+	})
+
+	t.Run("Being recorded first", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatEventR.EXPECT().GetCounter(ctx, "1").Return(counter, nil),
+			testRepo.mockChatEventR.EXPECT().GetEvents(ctx, "1", int64(4), int64(3)).Return([]core.ChatEvent{{Seq: 6, ExpiresAt: recorded}}, nil),
+		)
+
+		res, err := ChatEventService.GetEvents(eventImpl, ctx, &dto.GetChatEventsRequest{UserID: "1", Since: 4, Limit: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, &dto.GetChatEventsResponse{Events: []dto.Message{}, LastSeq: 4, HasMore: true}, res,
+			"the event 5 being recorded isn't expired")
+	})
+
+	t.Run("Lost", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatEventR.EXPECT().GetCounter(ctx, "1").Return(counter, nil),
+			testRepo.mockChatEventR.EXPECT().GetEvents(ctx, "1", int64(3), int64(4)).Return([]core.ChatEvent{
+				{Seq: 4, ExpiresAt: old}, {Seq: 6, ExpiresAt: old}, {Seq: 7, ExpiresAt: old},
+			}, nil),
+		)
+
+		res, err := ChatEventService.GetEvents(eventImpl, ctx, &dto.GetChatEventsRequest{UserID: "1", Since: 3, Limit: 3})
+		assert.Nil(t, err)
+		assert.False(t, res.HasMore)
+		assert.Equal(t, int64(7), res.LastSeq, "the event 5 which has failed to be recorded must be skipped")
+		assert.Len(t, res.Events, 3)
+	})
+
+	t.Run("All expired", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatEventR.EXPECT().GetCounter(ctx, "1").Return(&core.ChatEventCounter{UserID: "1", Seq: 7, UpdatedAt: now - 7200}, nil),
+			testRepo.mockChatEventR.EXPECT().GetEvents(ctx, "1", int64(1), int64(3)).Return([]core.ChatEvent{}, nil),
+		)
+
+		res, err := ChatEventService.GetEvents(eventImpl, ctx, &dto.GetChatEventsRequest{UserID: "1", Since: 1, Limit: 2})
+		assert.Nil(t, err)
+		assert.True(t, res.Reset)
+	})
+
+	t.Run("Unknown seq", func(t *testing.T) {
+		testRepo.mockChatEventR.EXPECT().GetCounter(ctx, "2").Return(nil, constants.ErrDBNotFound)
+
+		res, err := ChatEventService.GetEvents(eventImpl, ctx, &dto.GetChatEventsRequest{UserID: "2", Since: 3, Limit: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, &dto.GetChatEventsResponse{Events: []dto.Message{}, LastSeq: 0, Reset: true}, res)
+	})
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/common"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCreateDialog(t *testing.T) {
@@ -178,8 +181,7 @@ func TestSendMessage(t *testing.T) {
 	}
 
 	type InputSendMessage struct {
-		message  core.Message
-		dialogID string
+		message *core.Message
 	}
 	type OutputSendMessage struct {
 		err error
@@ -231,13 +233,13 @@ func TestSendMessage(t *testing.T) {
 				err: nil,
 			},
 			inputSendMessage: InputSendMessage{
-				message: core.Message{
+				message: &core.Message{
 					ID:       "1",
+					DialogID: "1",
 					AuthorID: "1",
 					Body:     "hi",
 					IsRead:   []core.IsRead{{Participant: "2", IsRead: false}},
 				},
-				dialogID: "1",
 			},
 			outputSendMessage: OutputSendMessage{err: nil},
 			output:            Output{&dto.SendMessageResponse{}, nil},
@@ -250,7 +252,7 @@ func TestSendMessage(t *testing.T) {
 
 		testRepo.mockChatR.EXPECT().GetDialogByID(ctx, tests[1].inputGetDialogByID.dialogID).Return(tests[1].outputGetDialogByID.Dialog,
 			tests[1].outputGetDialogByID.err),
-		testRepo.mockChatR.EXPECT().SendMessage(ctx, tests[1].inputSendMessage.message, tests[1].inputSendMessage.dialogID).Return(tests[1].outputSendMessage.err),
+		testRepo.mockMessageR.EXPECT().CreateMessage(ctx, tests[1].inputSendMessage.message).Return(tests[1].outputSendMessage.err),
 	)
 
 	for _, test := range tests {
@@ -354,7 +356,7 @@ func TestReadMessage(t *testing.T) {
 		testRepo.mockChatR.EXPECT().IsChatExist(ctx, tests[0].inputIsChatExist.dialogID).Return(tests[0].outputIsChatExist.err),
 
 		testRepo.mockChatR.EXPECT().IsChatExist(ctx, tests[1].inputIsChatExist.dialogID).Return(tests[1].outputIsChatExist.err),
-		testRepo.mockChatR.EXPECT().ReadMessage(ctx, tests[1].inputReadMessage.userID, tests[1].inputReadMessage.messageID, tests[1].inputReadMessage.dialogID).Return(tests[1].outputReadMessage.err),
+		testRepo.mockMessageR.EXPECT().ReadMessage(ctx, tests[1].inputReadMessage.userID, tests[1].inputReadMessage.messageID, tests[1].inputReadMessage.dialogID).Return(tests[1].outputReadMessage.err),
 	)
 
 	for _, test := range tests {
@@ -380,66 +382,56 @@ func TestGetDialogs(t *testing.T) {
 
 	ctx := context.Background()
 
-	type Input struct {
-		info *dto.GetDialogsRequest
-	}
-
-	type InputGetUserDialogs struct {
-		postID string
-	}
-	type OutputGetUserDialogs struct {
-		ids []string
-		err error
-	}
-
-	type InputGetDialogByID struct {
-		//dialogID string
-	}
-	type OutputGetDialogByID struct {
-		//dialog *core.Dialog
-		//err    error
-	}
-
-	type Output struct {
-		res *dto.GetDialogsResponse
-		err error
-	}
-
-	tests := []struct {
-		name                 string
-		input                Input
-		inputGetUserDialogs  InputGetUserDialogs
-		outputGetUserDialogs OutputGetUserDialogs
-		inputGetDialogByID   InputGetDialogByID
-		outputGetDialogByID  OutputGetDialogByID
-		output               Output
-	}{
-		{
-			name:                 "Don't found in DB",
-			input:                Input{info: &dto.GetDialogsRequest{UserID: "0"}},
-			inputGetUserDialogs:  InputGetUserDialogs{postID: "0"},
-			outputGetUserDialogs: OutputGetUserDialogs{ids: nil, err: mongo.ErrNoDocuments},
-			output:               Output{nil, mongo.ErrNoDocuments},
-		},
-	}
-
-	gomock.InOrder(
-		testRepo.mockUserR.EXPECT().GetUserDialogs(ctx, tests[0].inputGetUserDialogs.postID).Return(tests[0].outputGetUserDialogs.ids,
-			tests[0].outputGetUserDialogs.err),
-	)
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-
-			res, errRes := ChatService.GetDialogs(dbUserImpl, ctx, test.input.info)
-			if !assert.Equal(t, test.output.res, res) {
-				t.Error("got : ", res, " expected :", test.output.res)
-			}
-			if !assert.Equal(t, test.output.err, errRes) {
-				t.Error("got : ", errRes, " expected :", test.output.err)
-			}
-		})
-	}
+	t.Run("Don't found in DB", func(t *testing.T) {
+		testRepo.mockChatR.EXPECT().GetDialogsOverview(ctx, "0", int64(0), int64(10)).Return(nil, mongo.ErrNoDocuments)
+
+		res, err := ChatService.GetDialogs(dbUserImpl, ctx, &dto.GetDialogsRequest{UserID: "0", Limit: 10, Page: 1})
+		assert.Nil(t, res)
+		assert.Equal(t, mongo.ErrNoDocuments, err)
+	})
+
+	t.Run("Overview", func(t *testing.T) {
+		bob := &core.User{ID: "2", Name: common.UserName{First: "Bob", Last: "B"}, Image: "bob.png", PresenceVisibility: constants.PresenceVisibilityNobody}
+		overview := &core.DialogsOverview{
+			Dialogs: []core.DialogOverview{
+				{
+					Dialog:       core.Dialog{ID: "d2", Participants: []string{"1", "2"}},
+					LastMessage:  &core.Message{ID: "m9", AuthorID: "2", Body: strings.Repeat("a", constants.QuoteBodyLength+1), CreatedAt: 200},
+					Unread:       2,
+					LastActivity: 200,
+					Interlocutor: bob,
+				},
+				{
+					Dialog:       core.Dialog{ID: "d1", Name: "chat", Group: true, Participants: []string{"1", "3"}, AdminIDs: []string{"1"}},
+					LastActivity: 100,
+				},
+			},
+			Total:  5,
+			Unread: 7,
+		}
+		testRepo.mockChatR.EXPECT().GetDialogsOverview(ctx, "1", int64(2), int64(2)).Return(overview, nil)
+
+		res, err := ChatService.GetDialogs(dbUserImpl, ctx, &dto.GetDialogsRequest{UserID: "1", Limit: 2, Page: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(5), res.Total)
+		assert.Equal(t, int64(3), res.AmountPages)
+		assert.Equal(t, int64(7), res.Unread, "the badge counts the messages of all the dialogs")
+		assert.Len(t, res.Dialogs, 2)
+
+		personal := res.Dialogs[0]
+		assert.Equal(t, "Bob B", personal.Name)
+		assert.Equal(t, "bob.png", personal.Image)
+		assert.Equal(t, int64(2), personal.NonRead)
+		assert.Equal(t, int64(200), personal.LastActivity)
+		assert.Equal(t, "m9", personal.LastMessage.ID)
+		assert.Equal(t, strings.Repeat("a", constants.QuoteBodyLength)+"…", personal.LastMessage.Body)
+		assert.Nil(t, personal.Presence, "the presence of the user who hides it isn't shown")
+
+		group := res.Dialogs[1]
+		assert.Equal(t, "chat", group.Name)
+		assert.True(t, group.Group)
+		assert.Nil(t, group.LastMessage)
+	})
 }
 
 func TestCheckDialog(t *testing.T) {
@@ -463,27 +455,16 @@ func TestCheckDialog(t *testing.T) {
 		err    error
 	}
 
-	type InputUserCheckDialog struct {
-		dialogID string
-		userID   string
-	}
-
-	type OutputUserCheckDialog struct {
-		err error
-	}
-
 	type Output struct {
 		err error
 	}
 
 	tests := []struct {
-		name                  string
-		input                 Input
-		inputGetDialogByID    InputGetDialogByID
-		outputGetDialogByID   OutputGetDialogByID
-		inputUserCheckDialog  InputUserCheckDialog
-		outputUserCheckDialog OutputUserCheckDialog
-		output                Output
+		name                string
+		input               Input
+		inputGetDialogByID  InputGetDialogByID
+		outputGetDialogByID OutputGetDialogByID
+		output              Output
 	}{
 		{
 			name:               "Don't found in DB",
@@ -501,17 +482,13 @@ func TestCheckDialog(t *testing.T) {
 			inputGetDialogByID: InputGetDialogByID{dialogID: "1"},
 			outputGetDialogByID: OutputGetDialogByID{
 				dialog: &core.Dialog{
-					ID:   "1",
-					Name: "chat",
+					ID:           "1",
+					Name:         "chat",
+					Participants: []string{"2", "3"},
 				},
 				err: nil,
 			},
-			inputUserCheckDialog: InputUserCheckDialog{
-				dialogID: "1",
-				userID:   "1",
-			},
-			outputUserCheckDialog: OutputUserCheckDialog{err: mongo.ErrNoDocuments},
-			output:                Output{constants.ErrDBNotFound},
+			output: Output{constants.ErrDBNotFound},
 		},
 		{
 			name:               "success",
@@ -519,17 +496,13 @@ func TestCheckDialog(t *testing.T) {
 			inputGetDialogByID: InputGetDialogByID{dialogID: "2"},
 			outputGetDialogByID: OutputGetDialogByID{
 				dialog: &core.Dialog{
-					ID:   "2",
-					Name: "chat",
+					ID:           "2",
+					Name:         "chat",
+					Participants: []string{"1", "2"},
 				},
 				err: nil,
 			},
-			inputUserCheckDialog: InputUserCheckDialog{
-				dialogID: "2",
-				userID:   "2",
-			},
-			outputUserCheckDialog: OutputUserCheckDialog{err: nil},
-			output:                Output{nil},
+			output: Output{nil},
 		},
 	}
 
@@ -537,10 +510,8 @@ func TestCheckDialog(t *testing.T) {
 		testRepo.mockChatR.EXPECT().GetDialogByID(ctx, tests[0].inputGetDialogByID.dialogID).Return(tests[0].outputGetDialogByID.dialog, tests[0].outputGetDialogByID.err),
 
 		testRepo.mockChatR.EXPECT().GetDialogByID(ctx, tests[1].inputGetDialogByID.dialogID).Return(tests[1].outputGetDialogByID.dialog, tests[1].outputGetDialogByID.err),
-		testRepo.mockUserR.EXPECT().UserCheckDialog(ctx, tests[1].inputUserCheckDialog.dialogID, tests[1].inputUserCheckDialog.userID).Return(tests[1].outputUserCheckDialog.err),
 
 		testRepo.mockChatR.EXPECT().GetDialogByID(ctx, tests[2].inputGetDialogByID.dialogID).Return(tests[2].outputGetDialogByID.dialog, tests[2].outputGetDialogByID.err),
-		testRepo.mockUserR.EXPECT().UserCheckDialog(ctx, tests[2].inputUserCheckDialog.dialogID, tests[2].inputUserCheckDialog.userID).Return(tests[2].outputUserCheckDialog.err),
 	)
 
 	for _, test := range tests {
@@ -567,23 +538,13 @@ func TestGetDialog(t *testing.T) {
 		info *dto.GetDialogRequest
 	}
 
-	type InputUserCheckDialog struct {
-		dialogID string
-		postID   string
-	}
-
-	type OutputUserCheckDialog struct {
-		err error
-	}
-
 	type InputGetDialogByID struct {
-		//dialogID string
-		//err      error
+		dialogID string
 	}
 
 	type OutputGetDialogByID struct {
-		//dialog *core.Dialog
-		//err    error
+		dialog *core.Dialog
+		err    error
 	}
 
 	type Output struct {
@@ -592,27 +553,35 @@ func TestGetDialog(t *testing.T) {
 	}
 
 	tests := []struct {
-		name                  string
-		input                 Input
-		inputUserCheckDialog  InputUserCheckDialog
-		outputUserCheckDialog OutputUserCheckDialog
-		inputGetDialogByID    InputGetDialogByID
-		outputGetDialogByID   OutputGetDialogByID
-		output                Output
+		name                string
+		input               Input
+		inputGetDialogByID  InputGetDialogByID
+		outputGetDialogByID OutputGetDialogByID
+		output              Output
 	}{
 		{
-			name:                 "Don't found in DB",
-			input:                Input{info: &dto.GetDialogRequest{UserID: "0", DialogID: "0"}},
-			inputUserCheckDialog: InputUserCheckDialog{dialogID: "0", postID: "0"},
-			outputUserCheckDialog: OutputUserCheckDialog{
+			name:               "Don't found in DB",
+			input:              Input{info: &dto.GetDialogRequest{UserID: "0", DialogID: "0"}},
+			inputGetDialogByID: InputGetDialogByID{dialogID: "0"},
+			outputGetDialogByID: OutputGetDialogByID{
 				err: constants.ErrDBNotFound,
 			},
 			output: Output{res: nil, err: constants.ErrDBNotFound},
 		},
+		{
+			name:               "Not a participant",
+			input:              Input{info: &dto.GetDialogRequest{UserID: "0", DialogID: "1"}},
+			inputGetDialogByID: InputGetDialogByID{dialogID: "1"},
+			outputGetDialogByID: OutputGetDialogByID{
+				dialog: &core.Dialog{ID: "1", Participants: []string{"1", "2"}},
+			},
+			output: Output{res: nil, err: constants.ErrDBNotFound},
+		},
 	}
 
 	gomock.InOrder(
-		testRepo.mockUserR.EXPECT().UserCheckDialog(ctx, tests[0].inputUserCheckDialog.dialogID, tests[0].inputUserCheckDialog.postID).Return(tests[0].outputUserCheckDialog.err),
+		testRepo.mockChatR.EXPECT().GetDialogByID(ctx, tests[0].inputGetDialogByID.dialogID).Return(tests[0].outputGetDialogByID.dialog, tests[0].outputGetDialogByID.err),
+		testRepo.mockChatR.EXPECT().GetDialogByID(ctx, tests[1].inputGetDialogByID.dialogID).Return(tests[1].outputGetDialogByID.dialog, tests[1].outputGetDialogByID.err),
 	)
 
 	for _, test := range tests {
@@ -628,3 +597,379 @@ func TestGetDialog(t *testing.T) {
 		})
 	}
 }
+
+func TestGetDialogPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	dbUserImpl := NewChatService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+	dialog := &core.Dialog{ID: "1", Name: "chat", Participants: []string{"1", "2", "3"}}
+	messages := []core.Message{{ID: "m3", AuthorID: "2"}, {ID: "m2", AuthorID: "1"}, {ID: "m1", AuthorID: "2"}}
+
+	t.Run("Has more", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessages(ctx, "1", "1", "m4", int64(3)).Return(messages, nil),
+		)
+
+		res, err := ChatService.GetDialog(dbUserImpl, ctx, &dto.GetDialogRequest{UserID: "1", DialogID: "1", Limit: 2, Before: "m4"})
+		assert.Nil(t, err)
+		assert.True(t, res.HasMore)
+		assert.Len(t, res.Messages, 2)
+		assert.Equal(t, "m2", res.Messages[1].ID)
+	})
+
+	t.Run("Last page", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessages(ctx, "1", "1", "m2", int64(3)).Return(messages[2:], nil),
+		)
+
+		res, err := ChatService.GetDialog(dbUserImpl, ctx, &dto.GetDialogRequest{UserID: "1", DialogID: "1", Limit: 2, Before: "m2"})
+		assert.Nil(t, err)
+		assert.False(t, res.HasMore)
+		assert.Len(t, res.Messages, 1)
+	})
+
+	t.Run("Page by number", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessagesPage(ctx, "1", "1", int64(2), int64(1)).Return(messages[:2], &common.PageResponse{Total: 3, AmountPages: 2}, nil),
+		)
+
+		res, err := ChatService.GetDialog(dbUserImpl, ctx, &dto.GetDialogRequest{UserID: "1", DialogID: "1", Limit: 2, Page: 1})
+		assert.Nil(t, err)
+		assert.True(t, res.HasMore)
+		assert.Len(t, res.Messages, 2)
+		assert.Equal(t, int64(3), res.Total, "the old clients still use the total")
+		assert.Equal(t, int64(2), res.AmountPages)
+	})
+
+	t.Run("Page with before", func(t *testing.T) {
+		testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "1").Return(dialog, nil)
+
+		_, err := ChatService.GetDialog(dbUserImpl, ctx, &dto.GetDialogRequest{UserID: "1", DialogID: "1", Limit: 2, Page: 2, Before: "m2"})
+		assert.Equal(t, constants.ErrPageWithBefore, err)
+	})
+}
+
+func TestEditMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	chatImpl := &chatServiceImpl{log: TestLogger(t), db: TestBD, editTimeLimit: time.Hour}
+
+	ctx := context.Background()
+	dialog := &core.Dialog{ID: "d1", Name: "chat", Participants: []string{"1", "2"}}
+	now := time.Now().Unix()
+	request := &dto.EditMessageRequest{UserID: "1", DialogID: "d1", MessageID: "m1", Body: "edited"}
+
+	t.Run("Success", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessage(ctx, "d1", "m1").Return(&core.Message{ID: "m1", AuthorID: "1", CreatedAt: now}, nil),
+			testRepo.mockMessageR.EXPECT().EditMessage(ctx, "m1", "edited", gomock.Any()).Return(nil),
+		)
+
+		res, err := ChatService.EditMessage(chatImpl, ctx, request)
+		assert.Nil(t, err)
+		assert.NotZero(t, res.EditedAt)
+	})
+
+	t.Run("Not author", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessage(ctx, "d1", "m1").Return(&core.Message{ID: "m1", AuthorID: "2", CreatedAt: now}, nil),
+		)
+
+		_, err := ChatService.EditMessage(chatImpl, ctx, request)
+		assert.Equal(t, constants.ErrNotMessageAuthor, err)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessage(ctx, "d1", "m1").Return(&core.Message{ID: "m1", AuthorID: "1", CreatedAt: now - 7200}, nil),
+		)
+
+		_, err := ChatService.EditMessage(chatImpl, ctx, request)
+		assert.Equal(t, constants.ErrMessageEditExpired, err)
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessage(ctx, "d1", "m1").Return(&core.Message{ID: "m1", AuthorID: "1", CreatedAt: now, Deleted: true}, nil),
+		)
+
+		_, err := ChatService.EditMessage(chatImpl, ctx, request)
+		assert.Equal(t, constants.ErrMessageNotFound, err)
+	})
+}
+
+func TestDeleteMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	chatImpl := &chatServiceImpl{log: TestLogger(t), db: TestBD, editTimeLimit: time.Hour}
+
+	ctx := context.Background()
+	dialog := &core.Dialog{ID: "d1", Name: "chat", Participants: []string{"1", "2"}}
+	old := &core.Message{ID: "m1", AuthorID: "2", CreatedAt: time.Now().Unix() - 7200}
+
+	t.Run("For me", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessage(ctx, "d1", "m1").Return(old, nil),
+			testRepo.mockMessageR.EXPECT().HideMessage(ctx, "m1", "1").Return(nil),
+		)
+
+		_, err := ChatService.DeleteMessage(chatImpl, ctx, &dto.DeleteMessageRequest{UserID: "1", DialogID: "d1", MessageID: "m1"})
+		assert.Nil(t, err, "anyone may delete any message for themselves")
+	})
+
+	t.Run("For everyone", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessage(ctx, "d1", "m1").Return(&core.Message{ID: "m1", AuthorID: "2", CreatedAt: time.Now().Unix()}, nil),
+			testRepo.mockMessageR.EXPECT().DeleteMessage(ctx, "m1", gomock.Any()).Return(nil),
+		)
+
+		_, err := ChatService.DeleteMessage(chatImpl, ctx, &dto.DeleteMessageRequest{UserID: "2", DialogID: "d1", MessageID: "m1", ForEveryone: true})
+		assert.Nil(t, err)
+	})
+
+	t.Run("For everyone not author", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessage(ctx, "d1", "m1").Return(old, nil),
+		)
+
+		_, err := ChatService.DeleteMessage(chatImpl, ctx, &dto.DeleteMessageRequest{UserID: "1", DialogID: "d1", MessageID: "m1", ForEveryone: true})
+		assert.Equal(t, constants.ErrNotMessageAuthor, err)
+	})
+
+	t.Run("Unknown message", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessage(ctx, "d1", "m2").Return(nil, constants.ErrDBNotFound),
+		)
+
+		_, err := ChatService.DeleteMessage(chatImpl, ctx, &dto.DeleteMessageRequest{UserID: "1", DialogID: "d1", MessageID: "m2"})
+		assert.Equal(t, constants.ErrMessageNotFound, err)
+	})
+}
+
+func TestReactMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	chatImpl := NewChatService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+	dialog := &core.Dialog{ID: "d1", Name: "chat", Participants: []string{"1", "2"}}
+	message := &core.Message{ID: "m1", AuthorID: "2"}
+
+	t.Run("Success", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessage(ctx, "d1", "m1").Return(message, nil),
+			testRepo.mockMessageR.EXPECT().AddReaction(ctx, "m1", core.Reaction{UserID: "1", Emoji: "👍"}).Return(nil),
+		)
+
+		_, err := ChatService.ReactMessage(chatImpl, ctx, &dto.ReactMessageRequest{UserID: "1", DialogID: "d1", MessageID: "m1", Emoji: "👍"})
+		assert.Nil(t, err)
+	})
+
+	t.Run("Not emoji", func(t *testing.T) {
+		_, err := ChatService.ReactMessage(chatImpl, ctx, &dto.ReactMessageRequest{UserID: "1", DialogID: "d1", MessageID: "m1", Emoji: "<b>"})
+		assert.Equal(t, constants.ErrReactionInvalid, err)
+	})
+
+	t.Run("Not participant", func(t *testing.T) {
+		testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil)
+
+		_, err := ChatService.ReactMessage(chatImpl, ctx, &dto.ReactMessageRequest{UserID: "3", DialogID: "d1", MessageID: "m1", Emoji: "👍"})
+		assert.Equal(t, constants.ErrDBNotFound, err)
+	})
+
+	t.Run("Unreact", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessage(ctx, "d1", "m1").Return(message, nil),
+			testRepo.mockMessageR.EXPECT().RemoveReaction(ctx, "m1", core.Reaction{UserID: "1", Emoji: "👍"}).Return(nil),
+		)
+
+		_, err := ChatService.UnreactMessage(chatImpl, ctx, &dto.ReactMessageRequest{UserID: "1", DialogID: "d1", MessageID: "m1", Emoji: "👍"})
+		assert.Nil(t, err)
+	})
+}
+
+func TestReplies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	chatImpl := NewChatService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+	dialog := &core.Dialog{ID: "d1", Name: "chat", Participants: []string{"1", "2", "3"}}
+
+	t.Run("Reply to deleted message", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessage(ctx, "d1", "m1").Return(&core.Message{ID: "m1", Deleted: true}, nil),
+		)
+
+		_, err := ChatService.SendMessage(chatImpl, ctx, &dto.SendMessageRequest{Message: dto.Message{DialogID: "d1", AuthorID: "1", Body: "hi", ReplyTo: "m1"}})
+		assert.Equal(t, constants.ErrReplyNotFound, err)
+	})
+
+	t.Run("Reply to message of another dialog", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessage(ctx, "d1", "m9").Return(nil, constants.ErrDBNotFound),
+		)
+
+		_, err := ChatService.SendMessage(chatImpl, ctx, &dto.SendMessageRequest{Message: dto.Message{DialogID: "d1", AuthorID: "1", Body: "hi", ReplyTo: "m9"}})
+		assert.Equal(t, constants.ErrReplyNotFound, err)
+	})
+
+	t.Run("Quotes", func(t *testing.T) {
+		messages := []core.Message{{ID: "m3", AuthorID: "1", ReplyTo: "m2"}, {ID: "m2", AuthorID: "2", Body: "hi", ReplyTo: "m1"}}
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(dialog, nil),
+			testRepo.mockMessageR.EXPECT().GetMessages(ctx, "d1", "1", "", int64(-1)).Return(messages, nil),
+			testRepo.mockMessageR.EXPECT().GetMessagesByIDs(ctx, "d1", []string{"m2", "m1"}).Return(messages[1:], nil),
+		)
+
+		res, err := ChatService.GetDialog(chatImpl, ctx, &dto.GetDialogRequest{UserID: "1", DialogID: "d1", Limit: -1})
+		assert.Nil(t, err)
+		assert.Equal(t, &dto.MessageQuote{ID: "m2", AuthorID: "2", Body: "hi"}, res.Messages[0].ReplyTo)
+		assert.Equal(t, &dto.MessageQuote{ID: "m1", Deleted: true}, res.Messages[1].ReplyTo, "the message removed from the database is quoted as deleted")
+	})
+}
+
+func TestGroupChat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	chatImpl := NewChatService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+	group := &core.Dialog{ID: "d1", Name: "chat", Group: true, Participants: []string{"1", "2", "3"}, AdminIDs: []string{"1"}}
+	alice := &core.User{ID: "1", Name: common.UserName{First: "Alice", Last: "A"}}
+	bob := &core.User{ID: "4", Name: common.UserName{First: "Bob", Last: "B"}}
+
+	t.Run("Add member", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(group, nil),
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "4").Return(bob, nil),
+			testRepo.mockChatR.EXPECT().AddParticipants(ctx, "d1", []string{"4"}).Return(nil),
+			testRepo.mockUserR.EXPECT().AddDialog(ctx, "d1", "4").Return(nil),
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(alice, nil),
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "4").Return(bob, nil),
+			testRepo.mockMessageR.EXPECT().CreateMessage(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, message *core.Message) error {
+				assert.Equal(t, "Alice A added Bob B", message.Body)
+				assert.Equal(t, constants.AddMember, message.Action)
+				assert.Empty(t, message.IsRead, "the system messages aren't unread")
+				return nil
+			}),
+		)
+
+		res, err := ChatService.AddMembers(chatImpl, ctx, &dto.AddMembersRequest{UserID: "1", DialogID: "d1", MemberIDs: []string{"4", "4"}})
+		assert.Nil(t, err)
+		assert.Len(t, res.Messages, 1)
+		assert.Equal(t, constants.AddMember, res.Messages[0].Event)
+		assert.Equal(t, "4", res.Messages[0].DestinID)
+	})
+
+	t.Run("Add participant", func(t *testing.T) {
+		testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(group, nil)
+
+		_, err := ChatService.AddMembers(chatImpl, ctx, &dto.AddMembersRequest{UserID: "1", DialogID: "d1", MemberIDs: []string{"2"}})
+		assert.Equal(t, constants.ErrAlreadyParticipant, err)
+	})
+
+	t.Run("No members", func(t *testing.T) {
+		_, err := ChatService.AddMembers(chatImpl, ctx, &dto.AddMembersRequest{UserID: "1", DialogID: "d1", MemberIDs: []string{}})
+		assert.Equal(t, constants.ErrNoMembersToAdd, err)
+	})
+
+	t.Run("Not admin", func(t *testing.T) {
+		testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(group, nil)
+
+		_, err := ChatService.RemoveMember(chatImpl, ctx, &dto.RemoveMemberRequest{UserID: "2", DialogID: "d1", MemberID: "3"})
+		assert.Equal(t, constants.ErrNotChatAdmin, err)
+	})
+
+	t.Run("Personal dialog", func(t *testing.T) {
+		testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d2").Return(&core.Dialog{ID: "d2", Participants: []string{"1", "2"}}, nil)
+
+		_, err := ChatService.EditChat(chatImpl, ctx, &dto.EditChatRequest{UserID: "1", DialogID: "d2", Name: "chat"})
+		assert.Equal(t, constants.ErrNotGroupChat, err)
+	})
+
+	t.Run("Rename", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(group, nil),
+			testRepo.mockChatR.EXPECT().EditDialog(ctx, "d1", "friends", "").Return(nil),
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(alice, nil),
+			testRepo.mockMessageR.EXPECT().CreateMessage(ctx, gomock.Any()).Return(nil),
+		)
+
+		res, err := ChatService.EditChat(chatImpl, ctx, &dto.EditChatRequest{UserID: "1", DialogID: "d1", Name: "friends"})
+		assert.Nil(t, err)
+		assert.Equal(t, "friends", res.Messages[0].Body, "the event has the new name")
+	})
+
+	t.Run("Last admin", func(t *testing.T) {
+		testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(group, nil)
+
+		_, err := ChatService.SetChatAdmin(chatImpl, ctx, &dto.SetChatAdminRequest{UserID: "1", DialogID: "d1", MemberID: "1", Admin: false})
+		assert.Equal(t, constants.ErrLastChatAdmin, err)
+	})
+
+	t.Run("Legacy group admin", func(t *testing.T) {
+		legacy := &core.Dialog{ID: "d3", Name: "chat", Participants: []string{"2", "1", "3"}}
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d3").Return(legacy, nil),
+			testRepo.mockChatR.EXPECT().SetAdmins(ctx, "d3", []string{"2", "1"}).Return(nil),
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "2").Return(&core.User{ID: "2"}, nil),
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(alice, nil),
+			testRepo.mockMessageR.EXPECT().CreateMessage(ctx, gomock.Any()).Return(nil),
+		)
+
+		res, err := ChatService.SetChatAdmin(chatImpl, ctx, &dto.SetChatAdminRequest{UserID: "2", DialogID: "d3", MemberID: "1", Admin: true})
+		assert.Nil(t, err)
+		assert.Equal(t, constants.AddAdmin, res.Messages[0].Event)
+	})
+
+	t.Run("Last admin leaves", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockChatR.EXPECT().GetDialogByID(ctx, "d1").Return(group, nil),
+			testRepo.mockChatR.EXPECT().RemoveParticipant(ctx, "d1", "1").Return(nil),
+			testRepo.mockUserR.EXPECT().RemoveDialog(ctx, "d1", "1").Return(nil),
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(alice, nil),
+			testRepo.mockMessageR.EXPECT().CreateMessage(ctx, gomock.Any()).Return(nil),
+			testRepo.mockChatR.EXPECT().SetAdmins(ctx, "d1", []string{"2"}).Return(nil),
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "1").Return(alice, nil),
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, "2").Return(&core.User{ID: "2"}, nil),
+			testRepo.mockMessageR.EXPECT().CreateMessage(ctx, gomock.Any()).Return(nil),
+		)
+
+		res, err := ChatService.LeaveChat(chatImpl, ctx, &dto.LeaveChatRequest{UserID: "1", DialogID: "d1"})
+		assert.Nil(t, err)
+		assert.Len(t, res.Messages, 2)
+		assert.Equal(t, constants.LeaveGroup, res.Messages[0].Event)
+		assert.Equal(t, constants.AddAdmin, res.Messages[1].Event)
+		assert.Equal(t, "2", res.Messages[1].DestinID, "the first participant left becomes an admin")
+	})
+}
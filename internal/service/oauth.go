@@ -10,11 +10,11 @@ import (
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/db"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/common"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
-	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/oauth"
 )
 
 type OAuthService interface {
-	AuthenticateThroughTelergam(ctx context.Context, request *dto.AuthenticateThroughTelergamRequest) error
+	CreateExternalUser(ctx context.Context, userID string, profile *oauth.Profile) error
 }
 
 type OAuthServiceImpl struct {
@@ -22,28 +22,29 @@ type OAuthServiceImpl struct {
 	db  *db.Repository
 }
 
-func (svc *OAuthServiceImpl) AuthenticateThroughTelergam(ctx context.Context, request *dto.AuthenticateThroughTelergamRequest) error {
-	exists, err := svc.db.UserRepo.CheckUserIDExistence(ctx, request.ID)
+// CreateExternalUser creates the profile of the user signed up by the external identity provider.
+func (svc *OAuthServiceImpl) CreateExternalUser(ctx context.Context, userID string, profile *oauth.Profile) error {
+	exists, err := svc.db.UserRepo.CheckUserIDExistence(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("CheckUserIDExistence: %w", err)
 	}
+	if exists {
+		return nil
+	}
 
-	if !exists {
-		user := &core.User{
-			ID:    request.ID,
-			Name:  common.UserName{First: request.FirstName, Last: request.LastName},
-			Image: request.PhotoURL,
-		}
-
-		if err := svc.db.UserRepo.InsertUser(ctx, user); err != nil {
-			return fmt.Errorf("CreateUser: %w", err)
-		}
-
-		if err := svc.db.FriendsRepo.CreateFriends(ctx, user.ID); err != nil {
-			return fmt.Errorf("CreateFriends: %w", err)
-		}
+	user := &core.User{
+		ID:    userID,
+		Name:  common.UserName{First: profile.FirstName, Last: profile.LastName},
+		Image: profile.Picture,
+		Email: profile.Email,
+	}
+	if err := svc.db.UserRepo.InsertUser(ctx, user); err != nil {
+		return fmt.Errorf("InsertUser: %w", err)
 	}
 
+	if err := svc.db.FriendsRepo.CreateFriends(ctx, user.ID); err != nil {
+		return fmt.Errorf("CreateFriends: %w", err)
+	}
 	return nil
 }
 
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/common"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/oauth"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateExternalUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	oauthImpl := NewOAuthService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+	profile := &oauth.Profile{Subject: "42", Email: "mail@example.com", FirstName: "Ivan", LastName: "Ivanov", Picture: "photo"}
+
+	t.Run("Already created", func(t *testing.T) {
+		testRepo.mockUserR.EXPECT().CheckUserIDExistence(ctx, "1").Return(true, nil)
+
+		err := OAuthService.CreateExternalUser(oauthImpl, ctx, "1", profile)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		gomock.InOrder(
+			testRepo.mockUserR.EXPECT().CheckUserIDExistence(ctx, "1").Return(false, nil),
+			testRepo.mockUserR.EXPECT().InsertUser(ctx, &core.User{
+				ID:    "1",
+				Name:  common.UserName{First: "Ivan", Last: "Ivanov"},
+				Image: "photo",
+				Email: "mail@example.com",
+			}).Return(nil),
+			testRepo.mockFriendsR.EXPECT().CreateFriends(ctx, "1").Return(nil),
+		)
+
+		err := OAuthService.CreateExternalUser(oauthImpl, ctx, "1", profile)
+		assert.Nil(t, err)
+	})
+}
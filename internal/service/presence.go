@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/db"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/convert"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
+	"github.com/sirupsen/logrus"
+)
+
+// PresenceService tracks who is online by their websocket connections.
+type PresenceService interface {
+	Connect(ctx context.Context, userID string) error
+	Disconnect(ctx context.Context, userID string) error
+	Touch(ctx context.Context, userID string) error
+	GetPresence(ctx context.Context, viewerID string, users []*core.User) (map[string]dto.Presence, error)
+}
+
+type presenceServiceImpl struct {
+	log *logrus.Entry
+	db  *db.Repository
+}
+
+func (svc *presenceServiceImpl) Connect(ctx context.Context, userID string) error {
+	return svc.db.PresenceRepo.Connect(ctx, userID, time.Now().Unix())
+}
+
+func (svc *presenceServiceImpl) Disconnect(ctx context.Context, userID string) error {
+	return svc.db.PresenceRepo.Disconnect(ctx, userID, time.Now().Unix())
+}
+
+func (svc *presenceServiceImpl) Touch(ctx context.Context, userID string) error {
+	return svc.db.PresenceRepo.Touch(ctx, userID, time.Now().Unix())
+}
+
+// GetPresence returns the presence of the users the viewer is allowed to see.
+func (svc *presenceServiceImpl) GetPresence(ctx context.Context, viewerID string, users []*core.User) (map[string]dto.Presence, error) {
+	return getPresence(ctx, svc.db, viewerID, users)
+}
+
+// getPresence returns the presence of the users the viewer is allowed to see, the others are left out.
+func getPresence(ctx context.Context, repo *db.Repository, viewerID string, users []*core.User) (map[string]dto.Presence, error) {
+	var friends map[string]bool
+	ids := make([]string, 0, len(users))
+	for _, user := range users {
+		if user.ID != viewerID {
+			switch user.PresenceVisibility {
+			case constants.PresenceVisibilityNobody:
+				continue
+			case constants.PresenceVisibilityFriends:
+				if friends == nil {
+					friendIDs, err := repo.FriendsRepo.GetFriends(ctx, viewerID)
+					if err != nil {
+						return nil, err
+					}
+					friends = make(map[string]bool, len(friendIDs))
+					for _, id := range friendIDs {
+						friends[id] = true
+					}
+				}
+				if !friends[user.ID] {
+					continue
+				}
+			}
+		}
+		ids = append(ids, user.ID)
+	}
+
+	result := make(map[string]dto.Presence, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	presence, err := repo.PresenceRepo.GetPresence(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	// The users who have never connected are offline.
+	for _, id := range ids {
+		result[id] = dto.Presence{}
+	}
+	now := time.Now().Unix()
+	for i := range presence {
+		result[presence[i].UserID] = convert.Presence2DTO(&presence[i], now)
+	}
+	return result, nil
+}
+
+func NewPresenceService(log *logrus.Entry, db *db.Repository) PresenceService {
+	return &presenceServiceImpl{log: log, db: db}
+}
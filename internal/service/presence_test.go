@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGetPresence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	presenceImpl := NewPresenceService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+	now := time.Now().Unix()
+
+	users := []*core.User{
+		{ID: "everyone"},
+		{ID: "friend", PresenceVisibility: constants.PresenceVisibilityFriends},
+		{ID: "stranger", PresenceVisibility: constants.PresenceVisibilityFriends},
+		{ID: "nobody", PresenceVisibility: constants.PresenceVisibilityNobody},
+		{ID: "viewer", PresenceVisibility: constants.PresenceVisibilityNobody},
+	}
+
+	gomock.InOrder(
+		testRepo.mockFriendsR.EXPECT().GetFriends(ctx, "viewer").Return([]string{"friend"}, nil),
+		testRepo.mockPresenceR.EXPECT().GetPresence(ctx, []string{"everyone", "friend", "viewer"}).Return([]core.Presence{
+			{UserID: "everyone", Connections: 1, LastSeenAt: now},
+			{UserID: "friend", Connections: 0, LastSeenAt: now - 60},
+		}, nil),
+	)
+
+	res, err := PresenceService.GetPresence(presenceImpl, ctx, "viewer", users)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]dto.Presence{
+		"everyone": {Online: true, LastSeenAt: now},
+		"friend":   {Online: false, LastSeenAt: now - 60},
+		"viewer":   {},
+	}, res, "the users must see their own presence only if they hide it")
+}
+
+func TestGetPresenceStale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	TestBD, testRepo := TestRepositories(t, ctrl)
+	presenceImpl := NewPresenceService(TestLogger(t), TestBD)
+
+	ctx := context.Background()
+	lastSeen := time.Now().Add(-constants.PresenceTimeout - time.Minute).Unix()
+
+	testRepo.mockPresenceR.EXPECT().GetPresence(ctx, []string{"1"}).Return([]core.Presence{
+		{UserID: "1", Connections: 1, LastSeenAt: lastSeen},
+	}, nil)
+
+	res, err := PresenceService.GetPresence(presenceImpl, ctx, "2", []*core.User{{ID: "1"}})
+	assert.Nil(t, err)
+	assert.Equal(t, dto.Presence{Online: false, LastSeenAt: lastSeen}, res["1"],
+		"the connections without heartbeats must be considered dead")
+}
@@ -16,6 +16,9 @@ type Registry struct {
 	LikeService      LikeService
 	CommunityService CommunityService
 	CommentService   CommentService
+	AccountService   AccountService
+	PresenceService  PresenceService
+	ChatEventService ChatEventService
 }
 
 func NewRegistry(log *logrus.Entry, repository *db.Repository) *Registry {
@@ -31,6 +34,9 @@ func NewRegistry(log *logrus.Entry, repository *db.Repository) *Registry {
 	registry.LikeService = NewLikeService(log, repository)
 	registry.CommunityService = NewCommunityService(log, repository)
 	registry.CommentService = NewCommentService(log, repository)
+	registry.AccountService = NewAccountService(log, repository)
+	registry.PresenceService = NewPresenceService(log, repository)
+	registry.ChatEventService = NewChatEventService(log, repository)
 
 	return registry
 }
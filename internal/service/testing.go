@@ -15,9 +15,12 @@ type TestRepository struct {
 	mockFriendsR   *mockDB.MockFriendsRepository
 	mockPostR      *mockDB.MockPostRepository
 	mockChatR      *mockDB.MockChatRepository
+	mockMessageR   *mockDB.MockMessageRepository
 	mockLikeR      *mockDB.MockLikeRepository
 	mockCommunityR *mockDB.MockCommunityRepository
 	mockCommentR   *mockDB.MockCommentRepository
+	mockPresenceR  *mockDB.MockPresenceRepository
+	mockChatEventR *mockDB.MockChatEventRepository
 }
 
 // TestRepositories ...
@@ -27,18 +30,24 @@ func TestRepositories(t *testing.T, ctrl *gomock.Controller) (*db.Repository, *T
 		mockDB.NewMockFriendsRepository(ctrl),
 		mockDB.NewMockPostRepository(ctrl),
 		mockDB.NewMockChatRepository(ctrl),
+		mockDB.NewMockMessageRepository(ctrl),
 		mockDB.NewMockLikeRepository(ctrl),
 		mockDB.NewMockCommunityRepository(ctrl),
 		mockDB.NewMockCommentRepository(ctrl),
+		mockDB.NewMockPresenceRepository(ctrl),
+		mockDB.NewMockChatEventRepository(ctrl),
 	}
 	t.Helper()
 	return &db.Repository{UserRepo: MockRepo.mockUserR,
 		FriendsRepo:   MockRepo.mockFriendsR,
 		PostRepo:      MockRepo.mockPostR,
 		ChatRepo:      MockRepo.mockChatR,
+		MessageRepo:   MockRepo.mockMessageR,
 		LikeRepo:      MockRepo.mockLikeR,
 		CommunityRepo: MockRepo.mockCommunityR,
 		CommentRepo:   MockRepo.mockCommentR,
+		PresenceRepo:  MockRepo.mockPresenceR,
+		ChatEventRepo: MockRepo.mockChatEventR,
 	}, MockRepo
 }
 
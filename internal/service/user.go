@@ -10,11 +10,12 @@ import (
 
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/db"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/convert"
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
 	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/dto"
 )
 
 type UserService interface {
-	GetUserData(ctx context.Context, userID string) (*dto.GetUserResponse, error)
+	GetUserData(ctx context.Context, viewerID string, userID string) (*dto.GetUserResponse, error)
 	GetUserPosts(ctx context.Context, request *dto.GetUserPostsRequest) (*dto.GetUserPostsResponse, error)
 	GetFeed(ctx context.Context, userID string, request *dto.GetUserFeedRequest) (*dto.GetUserFeedResponse, error)
 	GetProfile(ctx context.Context, request *dto.GetProfileRequest) (*dto.GetProfileResponse, error)
@@ -28,14 +29,19 @@ type userServiceImpl struct {
 	db  *db.Repository
 }
 
-func (svc *userServiceImpl) GetUserData(ctx context.Context, userID string) (*dto.GetUserResponse, error) {
+func (svc *userServiceImpl) GetUserData(ctx context.Context, viewerID string, userID string) (*dto.GetUserResponse, error) {
 	user, err := svc.db.UserRepo.GetUserByID(ctx, userID)
 	if err != nil {
 		svc.log.Errorf("GetUserByID error: %s", err)
 		return nil, err
 	}
 	svc.log.Debug("GetUserData success")
-	return &dto.GetUserResponse{User: convert.User2DTO(user)}, nil
+
+	userDTO := convert.User2DTO(user)
+	if presence, ok := svc.presence(ctx, viewerID, user)[user.ID]; ok {
+		userDTO.Presence = &presence
+	}
+	return &dto.GetUserResponse{User: userDTO}, nil
 }
 
 func (svc *userServiceImpl) GetUserPosts(ctx context.Context, request *dto.GetUserPostsRequest) (*dto.GetUserPostsResponse, error) {
@@ -118,7 +124,18 @@ func (svc *userServiceImpl) GetProfile(ctx context.Context, request *dto.GetProf
 	}
 
 	svc.log.Debug("GetProfile success")
-	return &dto.GetProfileResponse{UserProfile: convert.Profile2DTO(user)}, nil
+
+	profile := convert.Profile2DTO(user)
+	if presence, ok := svc.presence(ctx, request.ViewerID, user)[user.ID]; ok {
+		profile.Presence = &presence
+	}
+	if request.ViewerID == user.ID {
+		profile.PresenceVisibility = user.PresenceVisibility
+		if len(profile.PresenceVisibility) == 0 {
+			profile.PresenceVisibility = constants.PresenceVisibilityEveryone
+		}
+	}
+	return &dto.GetProfileResponse{UserProfile: profile}, nil
 }
 
 func (svc *userServiceImpl) EditProfile(ctx context.Context, request *dto.EditProfileRequest, userID string) (*dto.EditProfileResponse, error) {
@@ -151,6 +168,14 @@ func (svc *userServiceImpl) EditProfile(ctx context.Context, request *dto.EditPr
 		user.Name.Last = request.Name.Last
 	}
 
+	switch request.PresenceVisibility {
+	case "":
+	case constants.PresenceVisibilityEveryone, constants.PresenceVisibilityFriends, constants.PresenceVisibilityNobody:
+		user.PresenceVisibility = request.PresenceVisibility
+	default:
+		return nil, constants.ErrPresenceVisibilityInvalid
+	}
+
 	if err = svc.db.UserRepo.UpdateUser(ctx, user); err != nil {
 		return nil, err
 	}
@@ -179,13 +204,30 @@ func (svc *userServiceImpl) SearchUsers(ctx context.Context, request *dto.Search
 		return nil, err
 	}
 
+	presence := svc.presence(ctx, request.ViewerID, usersCore...)
+
 	var users []dto.User
 	for _, userCore := range usersCore {
-		users = append(users, convert.User2DTO(userCore))
+		user := convert.User2DTO(userCore)
+		if userPresence, ok := presence[userCore.ID]; ok {
+			user.Presence = &userPresence
+		}
+		users = append(users, user)
 	}
 	return &dto.SearchUsersResponse{Users: users, Total: pages.Total, AmountPages: pages.Total}, nil
 }
 
+// presence returns the presence of the users the viewer can see. It is not worth failing
+// the request, so it's left out if it can't be got.
+func (svc *userServiceImpl) presence(ctx context.Context, viewerID string, users ...*core.User) map[string]dto.Presence {
+	presence, err := getPresence(ctx, svc.db, viewerID, users)
+	if err != nil {
+		svc.log.Errorf("getPresence error: %s", err)
+		return nil
+	}
+	return presence
+}
+
 func NewUserService(log *logrus.Entry, db *db.Repository) UserService {
 	return &userServiceImpl{log: log, db: db}
 }
@@ -43,10 +43,11 @@ func TestGetUserData(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			gomock.InOrder(
-				testRepo.mockUserR.EXPECT().GetUserByID(ctx, test.input).Return(&core.User{}, test.resultGetUserByID),
-			)
-			_, res := UserService.GetUserData(dbUserImpl, ctx, test.input)
+			testRepo.mockUserR.EXPECT().GetUserByID(ctx, test.input).Return(&core.User{ID: test.input}, test.resultGetUserByID)
+			if test.resultGetUserByID == nil {
+				testRepo.mockPresenceR.EXPECT().GetPresence(ctx, []string{test.input}).Return([]core.Presence{}, nil)
+			}
+			_, res := UserService.GetUserData(dbUserImpl, ctx, test.input, test.input)
 			if !assert.Equal(t, test.output, res) {
 				t.Error("got : ", res, " expected :", test.output)
 			}
@@ -359,6 +360,9 @@ func TestGetProfile(t *testing.T) {
 		err error
 	}
 
+	profileWithPresence := convert.Profile2DTO(&core.User{ID: "123", Email: "123@bk", Phone: "891"})
+	profileWithPresence.Presence = &dto.Presence{LastSeenAt: 100}
+
 	tests := []struct {
 		name              string
 		input             Input
@@ -378,7 +382,7 @@ func TestGetProfile(t *testing.T) {
 			input:             Input{info: &dto.GetProfileRequest{UserID: "123"}},
 			inputGetUserByID:  InputGetUserByID{userID: "123"},
 			outputGetUserByID: OutputGetUserByID{user: &core.User{ID: "123", Email: "123@bk", Phone: "891"}, err: nil},
-			output:            Output{res: &dto.GetProfileResponse{UserProfile: convert.Profile2DTO(&core.User{ID: "123", Email: "123@bk", Phone: "891"})}, err: nil},
+			output:            Output{res: &dto.GetProfileResponse{UserProfile: profileWithPresence}, err: nil},
 		},
 	}
 
@@ -388,6 +392,7 @@ func TestGetProfile(t *testing.T) {
 
 		//second
 		testRepo.mockUserR.EXPECT().GetUserByID(ctx, tests[1].inputGetUserByID.userID).Return(tests[1].outputGetUserByID.user, tests[1].outputGetUserByID.err),
+		testRepo.mockPresenceR.EXPECT().GetPresence(ctx, []string{"123"}).Return([]core.Presence{{UserID: "123", LastSeenAt: 100}}, nil),
 	)
 
 	for _, test := range tests {
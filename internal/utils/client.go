@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/spf13/viper"
+)
+
+var (
+	userAgentBrowsers = []struct{ marker, name string }{
+		{"YaBrowser", "Yandex Browser"},
+		{"Edg", "Edge"},
+		{"OPR", "Opera"},
+		{"Firefox", "Firefox"},
+		{"Chrome", "Chrome"},
+		{"Safari", "Safari"},
+	}
+	userAgentPlatforms = []struct{ marker, name string }{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Macintosh", "macOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// GetIP returns ip address of the client. The proxy headers are taken into account only when
+// the request comes from one of the trusted proxies, anyone else could set them to any address.
+func GetIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil || net.ParseIP(ip) == nil {
+		return ""
+	}
+
+	proxies := trustedProxies()
+	if !isTrustedProxy(proxies, ip) {
+		return ip
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-REAL-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+
+	// Every proxy appends the address it got the request from, the last one not of a trusted proxy is the client.
+	forwarded := strings.Split(r.Header.Get("X-FORWARDED-FOR"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		forwardedIP := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(forwardedIP) == nil {
+			break
+		}
+		ip = forwardedIP
+		if !isTrustedProxy(proxies, forwardedIP) {
+			break
+		}
+	}
+	return ip
+}
+
+func trustedProxies() []*net.IPNet {
+	var proxies []*net.IPNet
+	for _, cidr := range viper.GetStringSlice(constants.ViperTrustedProxiesKey) {
+		if _, proxy, err := net.ParseCIDR(cidr); err == nil {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+func isTrustedProxy(proxies []*net.IPNet, ip string) bool {
+	netIP := net.ParseIP(ip)
+	for _, proxy := range proxies {
+		if proxy.Contains(netIP) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDevice returns human-readable name of the client device, like "Firefox on Linux".
+func GetDevice(userAgent string) string {
+	browser := findUserAgentMarker(userAgent, userAgentBrowsers)
+	platform := findUserAgentMarker(userAgent, userAgentPlatforms)
+
+	switch {
+	case len(browser) != 0 && len(platform) != 0:
+		return browser + " on " + platform
+	case len(browser) != 0:
+		return browser
+	case len(platform) != 0:
+		return platform
+	default:
+		return "Unknown device"
+	}
+}
+
+func findUserAgentMarker(userAgent string, markers []struct{ marker, name string }) string {
+	for _, m := range markers {
+		if strings.Contains(userAgent, m.marker) {
+			return m.name
+		}
+	}
+	return ""
+}
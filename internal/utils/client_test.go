@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetIP(t *testing.T) {
+	defer viper.Reset()
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	assert.Equal(t, "10.0.0.1", GetIP(req))
+
+	t.Run("Untrusted proxy", func(t *testing.T) {
+		req.Header.Set("X-Forwarded-For", "192.168.0.2")
+		req.Header.Set("X-Real-IP", "192.168.0.3")
+		assert.Equal(t, "10.0.0.1", GetIP(req), "the headers of the client itself must be ignored")
+		req.Header.Del("X-Forwarded-For")
+		req.Header.Del("X-Real-IP")
+	})
+
+	viper.Set(constants.ViperTrustedProxiesKey, []string{"10.0.0.0/24"})
+
+	t.Run("Forwarded by trusted proxies", func(t *testing.T) {
+		req.Header.Set("X-Forwarded-For", "1.2.3.4, 192.168.0.2, 10.0.0.2")
+		assert.Equal(t, "192.168.0.2", GetIP(req), "the addresses added before the trusted proxies must be ignored")
+	})
+
+	t.Run("Real ip of trusted proxy", func(t *testing.T) {
+		req.Header.Set("X-Real-IP", "192.168.0.3")
+		assert.Equal(t, "192.168.0.3", GetIP(req))
+	})
+}
+
+func TestGetDevice(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		device    string
+	}{
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:100.0) Gecko/20100101 Firefox/100.0", "Firefox on Linux"},
+		{"Mozilla/5.0 (Linux; Android 12; Pixel 6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/101.0.4951.41 Mobile Safari/537.36", "Chrome on Android"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 15_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.4 Mobile/15E148 Safari/604.1", "Safari on iPhone"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/101.0.4951.54 Safari/537.36 Edg/101.0.1210.39", "Edge on Windows"},
+		{"curl/7.79.1", "Unknown device"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.device, func(t *testing.T) {
+			assert.Equal(t, test.device, GetDevice(test.userAgent))
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// SecondsToDurations reads the durations set in the config as bare numbers, like "access_ttl: 900"
+// of the configs written before the durations like "15m", as seconds. viper takes them for nanoseconds otherwise.
+func SecondsToDurations(keys ...string) {
+	for _, key := range keys {
+		value := viper.Get(key)
+		if value == nil {
+			continue
+		}
+		seconds, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+		if err != nil {
+			continue // a duration like "15m"
+		}
+		viper.Set(key, time.Duration(seconds*float64(time.Second)))
+	}
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecondsToDurations(t *testing.T) {
+	defer viper.Reset()
+
+	viper.Set("test.int", 900)
+	viper.Set("test.string", "60")
+	viper.Set("test.duration", "15m")
+
+	SecondsToDurations("test.int", "test.string", "test.duration", "test.unset")
+
+	assert.Equal(t, 15*time.Minute, viper.GetDuration("test.int"))
+	assert.Equal(t, time.Minute, viper.GetDuration("test.string"))
+	assert.Equal(t, 15*time.Minute, viper.GetDuration("test.duration"))
+	assert.False(t, viper.IsSet("test.unset"))
+}
@@ -3,6 +3,9 @@ package utils
 import (
 	"net/http"
 	"time"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+	"github.com/spf13/viper"
 )
 
 func CreateCookie(name, value string, ttl int64) *http.Cookie {
@@ -13,6 +16,14 @@ func CreateHTTPOnlyCookie(name, value string, ttl int64) *http.Cookie {
 	return createCookie(name, value, ttl, true)
 }
 
+// CreateSessionCookies returns http-only cookies with access and refresh tokens of the session.
+func CreateSessionCookies(authToken, refreshToken string) []*http.Cookie {
+	return []*http.Cookie{
+		CreateHTTPOnlyCookie(constants.CookieKeyAuthToken, authToken, int64(viper.GetDuration(constants.ViperAccessTTLKey)/time.Second)),
+		CreateHTTPOnlyCookie(constants.CookieKeyRefreshToken, refreshToken, int64(viper.GetDuration(constants.ViperRefreshTTLKey)/time.Second)),
+	}
+}
+
 func createCookie(name, value string, ttl int64, httpOnly bool) *http.Cookie {
 	cookie := new(http.Cookie)
 	cookie.Name = name
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"testing"
@@ -50,3 +51,19 @@ func TestCreateHttpOnlyCookie(t *testing.T) {
 		}
 	})
 }
+
+func TestCreateSessionCookies(t *testing.T) {
+	cookies := CreateSessionCookies("access", "refresh")
+	t.Run("Check equals", func(t *testing.T) {
+		if !assert.Equal(t, 2, len(cookies)) {
+			t.Fatal("got : ", len(cookies), " expected :", 2)
+		}
+		assert.Equal(t, constants.CookieKeyAuthToken, cookies[0].Name)
+		assert.Equal(t, "access", cookies[0].Value)
+		assert.Equal(t, constants.CookieKeyRefreshToken, cookies[1].Name)
+		assert.Equal(t, "refresh", cookies[1].Value)
+		for _, cookie := range cookies {
+			assert.True(t, cookie.HttpOnly)
+		}
+	})
+}
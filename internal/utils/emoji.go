@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-park-mail-ru/2022_1_CJ/internal/constants"
+)
+
+const zeroWidthJoiner = '\u200d'
+
+// IsEmoji tells if s is a short string of emoji only, including the ones made of several code points
+// such as flags, keycaps and emoji with skin tones.
+func IsEmoji(s string) bool {
+	if len(s) == 0 || len(s) > constants.ReactionMaxLength || !utf8.ValidString(s) {
+		return false
+	}
+
+	hasSymbol := false
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.So, r), unicode.Is(unicode.Me, r):
+			hasSymbol = true
+		case unicode.Is(unicode.Sk, r), unicode.Is(unicode.Mn, r), r == zeroWidthJoiner:
+			// skin tones, variation selectors and joiners modify the symbols
+		case r == '#' || r == '*' || unicode.IsDigit(r):
+			// keycaps
+		default:
+			return false
+		}
+	}
+	return hasSymbol
+}
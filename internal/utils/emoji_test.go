@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsEmoji(t *testing.T) {
+	for _, emoji := range []string{"👍", "❤️", "👍🏽", "👨‍👩‍👧", "🇷🇺", "1️⃣"} {
+		assert.True(t, IsEmoji(emoji), emoji)
+	}
+	for _, text := range []string{"", "a", "1", "hi 👍", "<b>👍</b>", "👍👍👍👍👍👍👍👍👍"} {
+		assert.False(t, IsEmoji(text), text)
+	}
+}
@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -22,22 +21,6 @@ func TestGetLimitArray(t *testing.T) {
 	})
 }
 
-func TestGetLimitMessage(t *testing.T) {
-	arrMessage := []core.Message{{ID: "123"}, {ID: "234"}, {ID: "23123"}, {ID: "12312"}, {ID: "231"}}
-	res, total, page := GetLimitMessage(&arrMessage, 2, 1)
-	t.Run("Check pagination", func(t *testing.T) {
-		if !assert.Equal(t, res, []core.Message{{ID: "231"}, {ID: "12312"}}) {
-			t.Error("got : ", res, " expected :", []core.Message{{ID: "231"}, {ID: "12312"}})
-		}
-		if !assert.Equal(t, int(total), len(arrMessage)) {
-			t.Error("got : ", total, " expected :", len(arrMessage))
-		}
-		if !assert.Equal(t, int(page), 3) {
-			t.Error("got : ", total, " expected :", 3)
-		}
-	})
-}
-
 func TestIsLarge(t *testing.T) {
 	res := IsLarge(true)
 	t.Run("Check res", func(t *testing.T) {
@@ -56,13 +39,3 @@ func TestReverseString(t *testing.T) {
 		}
 	})
 }
-
-func TestReverseMessage(t *testing.T) {
-	arrMessage := []core.Message{{ID: "123"}, {ID: "234"}, {ID: "23123"}}
-	res := reverseMessage(arrMessage)
-	t.Run("Check res", func(t *testing.T) {
-		if !assert.Equal(t, res, []core.Message{{ID: "23123"}, {ID: "234"}, {ID: "123"}}) {
-			t.Error("got : ", res, " expected :", []core.Message{{ID: "23123"}, {ID: "234"}, {ID: "123"}})
-		}
-	})
-}
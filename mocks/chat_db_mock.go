@@ -35,6 +35,20 @@ func (m *MockChatRepository) EXPECT() *MockChatRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddParticipants mocks base method.
+func (m *MockChatRepository) AddParticipants(ctx context.Context, dialogID string, userIDs []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddParticipants", ctx, dialogID, userIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddParticipants indicates an expected call of AddParticipants.
+func (mr *MockChatRepositoryMockRecorder) AddParticipants(ctx, dialogID, userIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddParticipants", reflect.TypeOf((*MockChatRepository)(nil).AddParticipants), ctx, dialogID, userIDs)
+}
+
 // CreateDialog mocks base method.
 func (m *MockChatRepository) CreateDialog(ctx context.Context, userID, name string, authorIDs []string) (*core.Dialog, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +64,20 @@ func (mr *MockChatRepositoryMockRecorder) CreateDialog(ctx, userID, name, author
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDialog", reflect.TypeOf((*MockChatRepository)(nil).CreateDialog), ctx, userID, name, authorIDs)
 }
 
+// EditDialog mocks base method.
+func (m *MockChatRepository) EditDialog(ctx context.Context, dialogID, name, image string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EditDialog", ctx, dialogID, name, image)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EditDialog indicates an expected call of EditDialog.
+func (mr *MockChatRepositoryMockRecorder) EditDialog(ctx, dialogID, name, image interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditDialog", reflect.TypeOf((*MockChatRepository)(nil).EditDialog), ctx, dialogID, name, image)
+}
+
 // GetDialogByID mocks base method.
 func (m *MockChatRepository) GetDialogByID(ctx context.Context, dialogID string) (*core.Dialog, error) {
 	m.ctrl.T.Helper()
@@ -65,6 +93,21 @@ func (mr *MockChatRepositoryMockRecorder) GetDialogByID(ctx, dialogID interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDialogByID", reflect.TypeOf((*MockChatRepository)(nil).GetDialogByID), ctx, dialogID)
 }
 
+// GetDialogsOverview mocks base method.
+func (m *MockChatRepository) GetDialogsOverview(ctx context.Context, userID string, skip, limit int64) (*core.DialogsOverview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDialogsOverview", ctx, userID, skip, limit)
+	ret0, _ := ret[0].(*core.DialogsOverview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDialogsOverview indicates an expected call of GetDialogsOverview.
+func (mr *MockChatRepositoryMockRecorder) GetDialogsOverview(ctx, userID, skip, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDialogsOverview", reflect.TypeOf((*MockChatRepository)(nil).GetDialogsOverview), ctx, userID, skip, limit)
+}
+
 // IsChatExist mocks base method.
 func (m *MockChatRepository) IsChatExist(ctx context.Context, dialogID string) error {
 	m.ctrl.T.Helper()
@@ -108,30 +151,44 @@ func (mr *MockChatRepositoryMockRecorder) IsUniqDialog(ctx, userID1, userID2 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUniqDialog", reflect.TypeOf((*MockChatRepository)(nil).IsUniqDialog), ctx, userID1, userID2)
 }
 
-// ReadMessage mocks base method.
-func (m *MockChatRepository) ReadMessage(ctx context.Context, userID, messageID, dialogID string) error {
+// LeaveDialogs mocks base method.
+func (m *MockChatRepository) LeaveDialogs(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LeaveDialogs", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LeaveDialogs indicates an expected call of LeaveDialogs.
+func (mr *MockChatRepositoryMockRecorder) LeaveDialogs(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LeaveDialogs", reflect.TypeOf((*MockChatRepository)(nil).LeaveDialogs), ctx, userID)
+}
+
+// RemoveParticipant mocks base method.
+func (m *MockChatRepository) RemoveParticipant(ctx context.Context, dialogID, userID string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReadMessage", ctx, userID, messageID, dialogID)
+	ret := m.ctrl.Call(m, "RemoveParticipant", ctx, dialogID, userID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// ReadMessage indicates an expected call of ReadMessage.
-func (mr *MockChatRepositoryMockRecorder) ReadMessage(ctx, userID, messageID, dialogID interface{}) *gomock.Call {
+// RemoveParticipant indicates an expected call of RemoveParticipant.
+func (mr *MockChatRepositoryMockRecorder) RemoveParticipant(ctx, dialogID, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadMessage", reflect.TypeOf((*MockChatRepository)(nil).ReadMessage), ctx, userID, messageID, dialogID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveParticipant", reflect.TypeOf((*MockChatRepository)(nil).RemoveParticipant), ctx, dialogID, userID)
 }
 
-// SendMessage mocks base method.
-func (m *MockChatRepository) SendMessage(ctx context.Context, message core.Message, dialogID string) error {
+// SetAdmins mocks base method.
+func (m *MockChatRepository) SetAdmins(ctx context.Context, dialogID string, adminIDs []string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SendMessage", ctx, message, dialogID)
+	ret := m.ctrl.Call(m, "SetAdmins", ctx, dialogID, adminIDs)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SendMessage indicates an expected call of SendMessage.
-func (mr *MockChatRepositoryMockRecorder) SendMessage(ctx, message, dialogID interface{}) *gomock.Call {
+// SetAdmins indicates an expected call of SetAdmins.
+func (mr *MockChatRepositoryMockRecorder) SetAdmins(ctx, dialogID, adminIDs interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessage", reflect.TypeOf((*MockChatRepository)(nil).SendMessage), ctx, message, dialogID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAdmins", reflect.TypeOf((*MockChatRepository)(nil).SetAdmins), ctx, dialogID, adminIDs)
 }
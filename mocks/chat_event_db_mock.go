@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/db/chat_event.go
+
+// Package mock_db is a generated GoMock package.
+package mock_db
+
+import (
+	context "context"
+	reflect "reflect"
+
+	core "github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockChatEventRepository is a mock of ChatEventRepository interface.
+type MockChatEventRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockChatEventRepositoryMockRecorder
+}
+
+// MockChatEventRepositoryMockRecorder is the mock recorder for MockChatEventRepository.
+type MockChatEventRepositoryMockRecorder struct {
+	mock *MockChatEventRepository
+}
+
+// NewMockChatEventRepository creates a new mock instance.
+func NewMockChatEventRepository(ctrl *gomock.Controller) *MockChatEventRepository {
+	mock := &MockChatEventRepository{ctrl: ctrl}
+	mock.recorder = &MockChatEventRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChatEventRepository) EXPECT() *MockChatEventRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddEvents mocks base method.
+func (m *MockChatEventRepository) AddEvents(ctx context.Context, events []core.ChatEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddEvents", ctx, events)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddEvents indicates an expected call of AddEvents.
+func (mr *MockChatEventRepositoryMockRecorder) AddEvents(ctx, events interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddEvents", reflect.TypeOf((*MockChatEventRepository)(nil).AddEvents), ctx, events)
+}
+
+// DeleteUserEvents mocks base method.
+func (m *MockChatEventRepository) DeleteUserEvents(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserEvents", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserEvents indicates an expected call of DeleteUserEvents.
+func (mr *MockChatEventRepositoryMockRecorder) DeleteUserEvents(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserEvents", reflect.TypeOf((*MockChatEventRepository)(nil).DeleteUserEvents), ctx, userID)
+}
+
+// GetCounter mocks base method.
+func (m *MockChatEventRepository) GetCounter(ctx context.Context, userID string) (*core.ChatEventCounter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCounter", ctx, userID)
+	ret0, _ := ret[0].(*core.ChatEventCounter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCounter indicates an expected call of GetCounter.
+func (mr *MockChatEventRepositoryMockRecorder) GetCounter(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCounter", reflect.TypeOf((*MockChatEventRepository)(nil).GetCounter), ctx, userID)
+}
+
+// GetEvents mocks base method.
+func (m *MockChatEventRepository) GetEvents(ctx context.Context, userID string, since, limit int64) ([]core.ChatEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvents", ctx, userID, since, limit)
+	ret0, _ := ret[0].([]core.ChatEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEvents indicates an expected call of GetEvents.
+func (mr *MockChatEventRepositoryMockRecorder) GetEvents(ctx, userID, since, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvents", reflect.TypeOf((*MockChatEventRepository)(nil).GetEvents), ctx, userID, since, limit)
+}
+
+// NextSeqs mocks base method.
+func (m *MockChatEventRepository) NextSeqs(ctx context.Context, userIDs []string, at int64) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NextSeqs", ctx, userIDs, at)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NextSeqs indicates an expected call of NextSeqs.
+func (mr *MockChatEventRepositoryMockRecorder) NextSeqs(ctx, userIDs, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextSeqs", reflect.TypeOf((*MockChatEventRepository)(nil).NextSeqs), ctx, userIDs, at)
+}
@@ -64,6 +64,20 @@ func (mr *MockCommentRepositoryMockRecorder) DeleteComment(ctx, commentID interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteComment", reflect.TypeOf((*MockCommentRepository)(nil).DeleteComment), ctx, commentID)
 }
 
+// DeleteComments mocks base method.
+func (m *MockCommentRepository) DeleteComments(ctx context.Context, commentIDs []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteComments", ctx, commentIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteComments indicates an expected call of DeleteComments.
+func (mr *MockCommentRepositoryMockRecorder) DeleteComments(ctx, commentIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteComments", reflect.TypeOf((*MockCommentRepository)(nil).DeleteComments), ctx, commentIDs)
+}
+
 // EditComment mocks base method.
 func (m *MockCommentRepository) EditComment(ctx context.Context, comment *core.Comment) (*core.Comment, error) {
 	m.ctrl.T.Helper()
@@ -93,3 +107,18 @@ func (mr *MockCommentRepositoryMockRecorder) GetCommentByID(ctx, commentID inter
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentByID", reflect.TypeOf((*MockCommentRepository)(nil).GetCommentByID), ctx, commentID)
 }
+
+// GetUserCommentIDs mocks base method.
+func (m *MockCommentRepository) GetUserCommentIDs(ctx context.Context, userID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserCommentIDs", ctx, userID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserCommentIDs indicates an expected call of GetUserCommentIDs.
+func (mr *MockCommentRepositoryMockRecorder) GetUserCommentIDs(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserCommentIDs", reflect.TypeOf((*MockCommentRepository)(nil).GetUserCommentIDs), ctx, userID)
+}
@@ -36,6 +36,20 @@ func (m *MockCommunityRepository) EXPECT() *MockCommunityRepositoryMockRecorder
 	return m.recorder
 }
 
+// AddAdmin mocks base method.
+func (m *MockCommunityRepository) AddAdmin(ctx context.Context, communityID, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAdmin", ctx, communityID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAdmin indicates an expected call of AddAdmin.
+func (mr *MockCommunityRepositoryMockRecorder) AddAdmin(ctx, communityID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAdmin", reflect.TypeOf((*MockCommunityRepository)(nil).AddAdmin), ctx, communityID, userID)
+}
+
 // AddFollower mocks base method.
 func (m *MockCommunityRepository) AddFollower(ctx context.Context, communityID, userID string) error {
 	m.ctrl.T.Helper()
@@ -180,6 +194,20 @@ func (mr *MockCommunityRepositoryMockRecorder) GetCommunityByID(ctx, communityID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommunityByID", reflect.TypeOf((*MockCommunityRepository)(nil).GetCommunityByID), ctx, communityID)
 }
 
+// RemoveMember mocks base method.
+func (m *MockCommunityRepository) RemoveMember(ctx context.Context, communityID, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMember", ctx, communityID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveMember indicates an expected call of RemoveMember.
+func (mr *MockCommunityRepositoryMockRecorder) RemoveMember(ctx, communityID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMember", reflect.TypeOf((*MockCommunityRepository)(nil).RemoveMember), ctx, communityID, userID)
+}
+
 // SearchCommunities mocks base method.
 func (m *MockCommunityRepository) SearchCommunities(ctx context.Context, selector string, limit, pageNumber int64) ([]core.Community, *common.PageResponse, error) {
 	m.ctrl.T.Helper()
@@ -90,6 +90,20 @@ func (mr *MockFriendsRepositoryMockRecorder) DeleteRequest(ctx, from, to interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRequest", reflect.TypeOf((*MockFriendsRepository)(nil).DeleteRequest), ctx, from, to)
 }
 
+// DeleteUserFriends mocks base method.
+func (m *MockFriendsRepository) DeleteUserFriends(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserFriends", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserFriends indicates an expected call of DeleteUserFriends.
+func (mr *MockFriendsRepositoryMockRecorder) DeleteUserFriends(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserFriends", reflect.TypeOf((*MockFriendsRepository)(nil).DeleteUserFriends), ctx, userID)
+}
+
 // GetFriends mocks base method.
 func (m *MockFriendsRepository) GetFriends(ctx context.Context, userID string) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -64,6 +64,20 @@ func (mr *MockLikeRepositoryMockRecorder) DeleteLike(ctx, subjectID interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLike", reflect.TypeOf((*MockLikeRepository)(nil).DeleteLike), ctx, subjectID)
 }
 
+// DeleteUserLikes mocks base method.
+func (m *MockLikeRepository) DeleteUserLikes(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserLikes", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserLikes indicates an expected call of DeleteUserLikes.
+func (mr *MockLikeRepositoryMockRecorder) DeleteUserLikes(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserLikes", reflect.TypeOf((*MockLikeRepository)(nil).DeleteUserLikes), ctx, userID)
+}
+
 // GetLikeBySubjectID mocks base method.
 func (m *MockLikeRepository) GetLikeBySubjectID(ctx context.Context, subjectID string) (*core.Like, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,196 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/db/message.go
+
+// Package mock_db is a generated GoMock package.
+package mock_db
+
+import (
+	context "context"
+	reflect "reflect"
+
+	common "github.com/go-park-mail-ru/2022_1_CJ/internal/model/common"
+	core "github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMessageRepository is a mock of MessageRepository interface.
+type MockMessageRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageRepositoryMockRecorder
+}
+
+// MockMessageRepositoryMockRecorder is the mock recorder for MockMessageRepository.
+type MockMessageRepositoryMockRecorder struct {
+	mock *MockMessageRepository
+}
+
+// NewMockMessageRepository creates a new mock instance.
+func NewMockMessageRepository(ctrl *gomock.Controller) *MockMessageRepository {
+	mock := &MockMessageRepository{ctrl: ctrl}
+	mock.recorder = &MockMessageRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageRepository) EXPECT() *MockMessageRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddReaction mocks base method.
+func (m *MockMessageRepository) AddReaction(ctx context.Context, messageID string, reaction core.Reaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReaction", ctx, messageID, reaction)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddReaction indicates an expected call of AddReaction.
+func (mr *MockMessageRepositoryMockRecorder) AddReaction(ctx, messageID, reaction interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReaction", reflect.TypeOf((*MockMessageRepository)(nil).AddReaction), ctx, messageID, reaction)
+}
+
+// CreateMessage mocks base method.
+func (m *MockMessageRepository) CreateMessage(ctx context.Context, message *core.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMessage", ctx, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMessage indicates an expected call of CreateMessage.
+func (mr *MockMessageRepositoryMockRecorder) CreateMessage(ctx, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockMessageRepository)(nil).CreateMessage), ctx, message)
+}
+
+// DeleteMessage mocks base method.
+func (m *MockMessageRepository) DeleteMessage(ctx context.Context, messageID string, deletedAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMessage", ctx, messageID, deletedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMessage indicates an expected call of DeleteMessage.
+func (mr *MockMessageRepositoryMockRecorder) DeleteMessage(ctx, messageID, deletedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMessage", reflect.TypeOf((*MockMessageRepository)(nil).DeleteMessage), ctx, messageID, deletedAt)
+}
+
+// EditMessage mocks base method.
+func (m *MockMessageRepository) EditMessage(ctx context.Context, messageID, body string, editedAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EditMessage", ctx, messageID, body, editedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EditMessage indicates an expected call of EditMessage.
+func (mr *MockMessageRepositoryMockRecorder) EditMessage(ctx, messageID, body, editedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditMessage", reflect.TypeOf((*MockMessageRepository)(nil).EditMessage), ctx, messageID, body, editedAt)
+}
+
+// GetMessage mocks base method.
+func (m *MockMessageRepository) GetMessage(ctx context.Context, dialogID, messageID string) (*core.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessage", ctx, dialogID, messageID)
+	ret0, _ := ret[0].(*core.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessage indicates an expected call of GetMessage.
+func (mr *MockMessageRepositoryMockRecorder) GetMessage(ctx, dialogID, messageID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessage", reflect.TypeOf((*MockMessageRepository)(nil).GetMessage), ctx, dialogID, messageID)
+}
+
+// GetMessages mocks base method.
+func (m *MockMessageRepository) GetMessages(ctx context.Context, dialogID, userID, before string, limit int64) ([]core.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessages", ctx, dialogID, userID, before, limit)
+	ret0, _ := ret[0].([]core.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessages indicates an expected call of GetMessages.
+func (mr *MockMessageRepositoryMockRecorder) GetMessages(ctx, dialogID, userID, before, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessages", reflect.TypeOf((*MockMessageRepository)(nil).GetMessages), ctx, dialogID, userID, before, limit)
+}
+
+// GetMessagesByIDs mocks base method.
+func (m *MockMessageRepository) GetMessagesByIDs(ctx context.Context, dialogID string, messageIDs []string) ([]core.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessagesByIDs", ctx, dialogID, messageIDs)
+	ret0, _ := ret[0].([]core.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessagesByIDs indicates an expected call of GetMessagesByIDs.
+func (mr *MockMessageRepositoryMockRecorder) GetMessagesByIDs(ctx, dialogID, messageIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessagesByIDs", reflect.TypeOf((*MockMessageRepository)(nil).GetMessagesByIDs), ctx, dialogID, messageIDs)
+}
+
+// GetMessagesPage mocks base method.
+func (m *MockMessageRepository) GetMessagesPage(ctx context.Context, dialogID, userID string, limit, pageNumber int64) ([]core.Message, *common.PageResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessagesPage", ctx, dialogID, userID, limit, pageNumber)
+	ret0, _ := ret[0].([]core.Message)
+	ret1, _ := ret[1].(*common.PageResponse)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetMessagesPage indicates an expected call of GetMessagesPage.
+func (mr *MockMessageRepositoryMockRecorder) GetMessagesPage(ctx, dialogID, userID, limit, pageNumber interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessagesPage", reflect.TypeOf((*MockMessageRepository)(nil).GetMessagesPage), ctx, dialogID, userID, limit, pageNumber)
+}
+
+// HideMessage mocks base method.
+func (m *MockMessageRepository) HideMessage(ctx context.Context, messageID, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HideMessage", ctx, messageID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HideMessage indicates an expected call of HideMessage.
+func (mr *MockMessageRepositoryMockRecorder) HideMessage(ctx, messageID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HideMessage", reflect.TypeOf((*MockMessageRepository)(nil).HideMessage), ctx, messageID, userID)
+}
+
+// ReadMessage mocks base method.
+func (m *MockMessageRepository) ReadMessage(ctx context.Context, userID, messageID, dialogID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadMessage", ctx, userID, messageID, dialogID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReadMessage indicates an expected call of ReadMessage.
+func (mr *MockMessageRepositoryMockRecorder) ReadMessage(ctx, userID, messageID, dialogID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadMessage", reflect.TypeOf((*MockMessageRepository)(nil).ReadMessage), ctx, userID, messageID, dialogID)
+}
+
+// RemoveReaction mocks base method.
+func (m *MockMessageRepository) RemoveReaction(ctx context.Context, messageID string, reaction core.Reaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveReaction", ctx, messageID, reaction)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveReaction indicates an expected call of RemoveReaction.
+func (mr *MockMessageRepositoryMockRecorder) RemoveReaction(ctx, messageID, reaction interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveReaction", reflect.TypeOf((*MockMessageRepository)(nil).RemoveReaction), ctx, messageID, reaction)
+}
@@ -168,3 +168,17 @@ func (mr *MockPostRepositoryMockRecorder) PostDeleteComment(ctx, postID, comment
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostDeleteComment", reflect.TypeOf((*MockPostRepository)(nil).PostDeleteComment), ctx, postID, commentID)
 }
+
+// PostsDeleteComments mocks base method.
+func (m *MockPostRepository) PostsDeleteComments(ctx context.Context, commentIDs []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostsDeleteComments", ctx, commentIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PostsDeleteComments indicates an expected call of PostsDeleteComments.
+func (mr *MockPostRepositoryMockRecorder) PostsDeleteComments(ctx, commentIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostsDeleteComments", reflect.TypeOf((*MockPostRepository)(nil).PostsDeleteComments), ctx, commentIDs)
+}
@@ -0,0 +1,107 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/db/presence.go
+
+// Package mock_db is a generated GoMock package.
+package mock_db
+
+import (
+	context "context"
+	reflect "reflect"
+
+	core "github.com/go-park-mail-ru/2022_1_CJ/internal/model/core"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPresenceRepository is a mock of PresenceRepository interface.
+type MockPresenceRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPresenceRepositoryMockRecorder
+}
+
+// MockPresenceRepositoryMockRecorder is the mock recorder for MockPresenceRepository.
+type MockPresenceRepositoryMockRecorder struct {
+	mock *MockPresenceRepository
+}
+
+// NewMockPresenceRepository creates a new mock instance.
+func NewMockPresenceRepository(ctrl *gomock.Controller) *MockPresenceRepository {
+	mock := &MockPresenceRepository{ctrl: ctrl}
+	mock.recorder = &MockPresenceRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPresenceRepository) EXPECT() *MockPresenceRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Connect mocks base method.
+func (m *MockPresenceRepository) Connect(ctx context.Context, userID string, at int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Connect", ctx, userID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Connect indicates an expected call of Connect.
+func (mr *MockPresenceRepositoryMockRecorder) Connect(ctx, userID, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*MockPresenceRepository)(nil).Connect), ctx, userID, at)
+}
+
+// DeletePresence mocks base method.
+func (m *MockPresenceRepository) DeletePresence(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePresence", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePresence indicates an expected call of DeletePresence.
+func (mr *MockPresenceRepositoryMockRecorder) DeletePresence(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePresence", reflect.TypeOf((*MockPresenceRepository)(nil).DeletePresence), ctx, userID)
+}
+
+// Disconnect mocks base method.
+func (m *MockPresenceRepository) Disconnect(ctx context.Context, userID string, at int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Disconnect", ctx, userID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Disconnect indicates an expected call of Disconnect.
+func (mr *MockPresenceRepositoryMockRecorder) Disconnect(ctx, userID, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disconnect", reflect.TypeOf((*MockPresenceRepository)(nil).Disconnect), ctx, userID, at)
+}
+
+// GetPresence mocks base method.
+func (m *MockPresenceRepository) GetPresence(ctx context.Context, userIDs []string) ([]core.Presence, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPresence", ctx, userIDs)
+	ret0, _ := ret[0].([]core.Presence)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPresence indicates an expected call of GetPresence.
+func (mr *MockPresenceRepositoryMockRecorder) GetPresence(ctx, userIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPresence", reflect.TypeOf((*MockPresenceRepository)(nil).GetPresence), ctx, userIDs)
+}
+
+// Touch mocks base method.
+func (m *MockPresenceRepository) Touch(ctx context.Context, userID string, at int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Touch", ctx, userID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Touch indicates an expected call of Touch.
+func (mr *MockPresenceRepositoryMockRecorder) Touch(ctx, userID, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Touch", reflect.TypeOf((*MockPresenceRepository)(nil).Touch), ctx, userID, at)
+}
@@ -182,6 +182,20 @@ func (mr *MockUserRepositoryMockRecorder) IsUserInDialog(ctx, userID, dialogID i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUserInDialog", reflect.TypeOf((*MockUserRepository)(nil).IsUserInDialog), ctx, userID, dialogID)
 }
 
+// RemoveDialog mocks base method.
+func (m *MockUserRepository) RemoveDialog(ctx context.Context, dialogID, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveDialog", ctx, dialogID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveDialog indicates an expected call of RemoveDialog.
+func (mr *MockUserRepositoryMockRecorder) RemoveDialog(ctx, dialogID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveDialog", reflect.TypeOf((*MockUserRepository)(nil).RemoveDialog), ctx, dialogID, userID)
+}
+
 // SelectUsers mocks base method.
 func (m *MockUserRepository) SelectUsers(ctx context.Context, selector string, pageNumber, limit int64) ([]*core.User, *common.PageResponse, error) {
 	m.ctrl.T.Helper()